@@ -0,0 +1,185 @@
+package blockrun
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Variant is one arm of an A/B test.
+type Variant struct {
+	// Name identifies the variant in ResultCollector summaries.
+	Name string
+	// Model overrides the model passed to Chat; empty keeps it.
+	Model string
+	// Weight is the variant's relative share of traffic.
+	Weight float64
+	// PromptTransformer, if set, rewrites the messages sent for this variant
+	// (e.g. a different system prompt).
+	PromptTransformer func(messages []ChatMessage) []ChatMessage
+}
+
+// ABConfig configures an ABTestingClient.
+type ABConfig struct {
+	Variants []Variant
+	// Collector receives per-call results; a new one is created if nil.
+	Collector *ResultCollector
+}
+
+// VariantStats aggregates the results of one variant.
+type VariantStats struct {
+	Calls            int
+	Errors           int
+	TotalLatency     time.Duration
+	MeanLatency      time.Duration
+	TotalCostUSD     float64
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// ResultCollector accumulates A/B results per variant. It is safe for
+// concurrent use.
+type ResultCollector struct {
+	mu    sync.Mutex
+	stats map[string]*VariantStats
+}
+
+// NewResultCollector returns an empty collector.
+func NewResultCollector() *ResultCollector {
+	return &ResultCollector{stats: make(map[string]*VariantStats)}
+}
+
+// Record adds one call's outcome for variant.
+func (rc *ResultCollector) Record(variant string, latency time.Duration, costUSD float64, usage Usage, err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	s, ok := rc.stats[variant]
+	if !ok {
+		s = &VariantStats{}
+		rc.stats[variant] = s
+	}
+	s.Calls++
+	if err != nil {
+		s.Errors++
+	}
+	s.TotalLatency += latency
+	s.MeanLatency = s.TotalLatency / time.Duration(s.Calls)
+	s.TotalCostUSD += costUSD
+	s.PromptTokens += usage.PromptTokens
+	s.CompletionTokens += usage.CompletionTokens
+	s.TotalTokens += usage.TotalTokens
+}
+
+// Summary returns a snapshot of the stats keyed by variant name.
+func (rc *ResultCollector) Summary() map[string]VariantStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	out := make(map[string]VariantStats, len(rc.stats))
+	for name, s := range rc.stats {
+		out[name] = *s
+	}
+	return out
+}
+
+// ABOption configures an ABTestingClient.
+type ABOption func(*ABTestingClient)
+
+// WithSeed makes variant selection deterministic.
+func WithSeed(seed int64) ABOption {
+	return func(c *ABTestingClient) {
+		c.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// ABTestingClient splits chat traffic between variants by weight and
+// records how each performs.
+type ABTestingClient struct {
+	client    *LLMClient
+	variants  []Variant
+	total     float64
+	collector *ResultCollector
+
+	mu  sync.Mutex // guards rng
+	rng *rand.Rand
+}
+
+// NewABTestingClient wraps client to run the A/B test described by config.
+func NewABTestingClient(client *LLMClient, config ABConfig, opts ...ABOption) *ABTestingClient {
+	c := &ABTestingClient{
+		client:    client,
+		collector: config.Collector,
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, v := range config.Variants {
+		if v.Weight > 0 {
+			c.variants = append(c.variants, v)
+			c.total += v.Weight
+		}
+	}
+	if c.collector == nil {
+		c.collector = NewResultCollector()
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Collector returns the collector receiving this test's results.
+func (c *ABTestingClient) Collector() *ResultCollector {
+	return c.collector
+}
+
+// Chat sends prompt through a randomly chosen variant and returns the reply.
+func (c *ABTestingClient) Chat(ctx context.Context, model, prompt string) (string, error) {
+	resp, _, err := c.ChatCompletion(ctx, model, []ChatMessage{{Role: "user", Content: prompt}}, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", &APIError{Message: "No choices in response"}
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// ChatCompletion sends messages through a randomly chosen variant and
+// returns the response with the variant's name.
+func (c *ABTestingClient) ChatCompletion(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions) (*ChatResponse, string, error) {
+	if len(c.variants) == 0 {
+		return nil, "", &ValidationError{Field: "variants", Message: "At least one variant with a positive weight is required"}
+	}
+	v := c.pick()
+	if v.Model != "" {
+		model = v.Model
+	}
+	if v.PromptTransformer != nil {
+		messages = v.PromptTransformer(messages)
+	}
+
+	var cost float64
+	ctx = WithRequestOptions(ctx, withCostObserver(func(usd float64) { cost += usd }))
+	start := time.Now()
+	resp, err := c.client.ChatCompletion(ctx, model, messages, opts)
+	var usage Usage
+	if resp != nil {
+		usage = resp.Usage
+	}
+	c.collector.Record(v.Name, time.Since(start), cost, usage, err)
+	return resp, v.Name, err
+}
+
+// pick chooses a variant with probability proportional to its weight.
+func (c *ABTestingClient) pick() Variant {
+	c.mu.Lock()
+	r := c.rng.Float64() * c.total
+	c.mu.Unlock()
+	for _, v := range c.variants {
+		if r < v.Weight {
+			return v
+		}
+		r -= v.Weight
+	}
+	return c.variants[len(c.variants)-1]
+}
@@ -0,0 +1,117 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newEchoModelServer answers chat calls with the requested model as content.
+func newEchoModelServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Model:   body.Model,
+			Choices: []Choice{{Message: ChatMessage{Role: "assistant", Content: body.Model}}},
+			Usage:   Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestABTestingDistribution(t *testing.T) {
+	server := newEchoModelServer(t)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	ab := NewABTestingClient(client, ABConfig{Variants: []Variant{
+		{Name: "control", Weight: 70},
+		{Name: "candidate", Model: "anthropic/claude-sonnet-4", Weight: 20},
+		{Name: "long-prompt", Weight: 10, PromptTransformer: func(m []ChatMessage) []ChatMessage {
+			return append([]ChatMessage{{Role: "system", Content: "Be thorough."}}, m...)
+		}},
+	}}, WithSeed(42))
+
+	const calls = 10000
+	for i := 0; i < calls; i++ {
+		reply, err := ab.Chat(context.Background(), "openai/gpt-4o", "hi")
+		if err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+		if reply != "openai/gpt-4o" && reply != "anthropic/claude-sonnet-4" {
+			t.Fatalf("unexpected model %q", reply)
+		}
+	}
+
+	summary := ab.Collector().Summary()
+	for name, want := range map[string]float64{"control": 0.7, "candidate": 0.2, "long-prompt": 0.1} {
+		got := float64(summary[name].Calls) / calls
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("%s got %.3f of traffic, want %.2f ± 0.01", name, got, want)
+		}
+		if summary[name].TotalTokens != 5*summary[name].Calls {
+			t.Errorf("%s: expected token counts to accumulate, got %+v", name, summary[name])
+		}
+	}
+}
+
+func TestABTestingSeedIsDeterministic(t *testing.T) {
+	server := newEchoModelServer(t)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	config := ABConfig{Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}}
+
+	sequence := func() []string {
+		ab := NewABTestingClient(client, config, WithSeed(7))
+		var names []string
+		for i := 0; i < 20; i++ {
+			_, name, err := ab.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "x"}}, nil)
+			if err != nil {
+				t.Fatalf("ChatCompletion: %v", err)
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+	first, second := sequence(), sequence()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("same seed produced different selections: %v vs %v", first, second)
+		}
+	}
+}
+
+func TestABTestingRecordsCost(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "2500")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+	ab := NewABTestingClient(client, ABConfig{Variants: []Variant{{Name: "only", Weight: 1}}})
+	for i := 0; i < 4; i++ {
+		if _, err := ab.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+	}
+	if got := ab.Collector().Summary()["only"].TotalCostUSD; math.Abs(got-0.01) > 1e-9 {
+		t.Errorf("expected $0.01 over 4 calls, got %v", got)
+	}
+
+	empty := NewABTestingClient(client, ABConfig{})
+	if _, err := empty.Chat(context.Background(), "gpt-4o", "hi"); err == nil {
+		t.Error("expected an error without variants")
+	}
+}
@@ -0,0 +1,154 @@
+// Package agent runs ReAct-style tool-using agents on an LLMClient. The model
+// is offered a set of tools; each tool call it makes is executed and the
+// result fed back, until it answers without calling a tool:
+//
+//	client, _ := blockrun.NewLLMClient("")
+//	a := agent.NewReActAgent(client, "openai/gpt-4o", []agent.ToolDefinition{calculator}, 10)
+//	result, err := a.Run(ctx, "What is (2 + 3) * 4?")
+//	fmt.Println(result.FinalAnswer)
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// DefaultMaxIterations is the number of model calls Run makes when
+// NewReActAgent is given a maxIterations that is not positive.
+const DefaultMaxIterations = 10
+
+// ErrMaxIterations is returned by Run when the model is still calling tools
+// after the agent's maximum number of iterations.
+var ErrMaxIterations = errors.New("agent: maximum iterations reached without a final answer")
+
+// ToolDefinition is a tool the agent offers the model, and the function that
+// executes it.
+type ToolDefinition struct {
+	Name        string
+	Description string
+	// Parameters is the JSON Schema of the tool's arguments.
+	Parameters map[string]any
+	// Execute runs the tool with the model's JSON-encoded arguments. Its
+	// result, or its error as "Error: ...", is the step's observation.
+	Execute func(ctx context.Context, arguments string) (string, error)
+}
+
+// AgentStep is one tool call made by the model and its outcome.
+type AgentStep struct {
+	// Thought is the text the model sent alongside the tool call, if any.
+	Thought     string
+	Action      string
+	ActionInput string
+	Observation string
+}
+
+// AgentResult is the outcome of Run.
+type AgentResult struct {
+	FinalAnswer string
+	Steps       []AgentStep
+	TotalTokens int
+	// TotalCostUSD is the client's spending during the run, which includes
+	// any other calls made on the same client concurrently.
+	TotalCostUSD float64
+}
+
+// ReActAgent runs the reason-act loop for one model and set of tools.
+type ReActAgent struct {
+	client        *blockrun.LLMClient
+	model         string
+	tools         map[string]ToolDefinition
+	schemas       []blockrun.Tool
+	maxIterations int
+}
+
+// NewReActAgent creates an agent that lets model call tools, making at most
+// maxIterations model calls per Run.
+func NewReActAgent(client *blockrun.LLMClient, model string, tools []ToolDefinition, maxIterations int) *ReActAgent {
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	a := &ReActAgent{
+		client:        client,
+		model:         model,
+		tools:         make(map[string]ToolDefinition, len(tools)),
+		maxIterations: maxIterations,
+	}
+	for _, t := range tools {
+		a.tools[t.Name] = t
+		a.schemas = append(a.schemas, blockrun.Tool{
+			Type: "function",
+			Function: blockrun.ToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return a
+}
+
+// Run gives the model task and executes the tools it calls, feeding the
+// results back, until it replies without a tool call. That reply is the
+// FinalAnswer. If the model is still calling tools after maxIterations model
+// calls, Run returns the result so far and ErrMaxIterations. Tool calls in
+// one reply run in parallel.
+func (a *ReActAgent) Run(ctx context.Context, task string) (*AgentResult, error) {
+	session := blockrun.NewConversationSession(a.client, a.model,
+		blockrun.WithConversationOptions(&blockrun.ChatCompletionOptions{Tools: a.schemas}))
+	startUSD := a.client.GetSpending().TotalUSD
+	result := &AgentResult{}
+	defer func() {
+		result.TotalCostUSD = a.client.GetSpending().TotalUSD - startUSD
+	}()
+
+	reply, err := session.Send(ctx, task)
+	for iteration := 1; ; iteration++ {
+		if err != nil {
+			return result, err
+		}
+		resp := session.LastResponse()
+		result.TotalTokens += resp.Usage.TotalTokens
+		calls := resp.Choices[0].Message.ToolCalls
+		if len(calls) == 0 {
+			result.FinalAnswer = reply
+			return result, nil
+		}
+		if iteration >= a.maxIterations {
+			return result, ErrMaxIterations
+		}
+
+		outcomes, err := blockrun.ExecuteToolsParallel(ctx, calls, a.execute, 0)
+		if err != nil {
+			return result, err
+		}
+		thought := reply
+		for i, call := range calls {
+			observation := outcomes[i].Content
+			if outcomes[i].Err != nil {
+				observation = "Error: " + outcomes[i].Err.Error()
+			}
+			result.Steps = append(result.Steps, AgentStep{
+				Thought:     thought,
+				Action:      call.Function.Name,
+				ActionInput: call.Function.Arguments,
+				Observation: observation,
+			})
+			reply, err = session.AddToolResult(ctx, call.ID, call.Function.Name, observation)
+			if err != nil {
+				break
+			}
+		}
+	}
+}
+
+// execute runs the tool named by call.
+func (a *ReActAgent) execute(ctx context.Context, call blockrun.ToolCall) (string, error) {
+	tool, ok := a.tools[call.Function.Name]
+	if !ok || tool.Execute == nil {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+	return tool.Execute(ctx, call.Function.Arguments)
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// calculator applies op to a and b.
+var calculator = ToolDefinition{
+	Name:        "calculator",
+	Description: "Applies op (add, sub, mul) to a and b.",
+	Parameters: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"op": map[string]any{"type": "string"},
+			"a":  map[string]any{"type": "number"},
+			"b":  map[string]any{"type": "number"},
+		},
+	},
+	Execute: func(_ context.Context, arguments string) (string, error) {
+		var args struct {
+			Op string  `json:"op"`
+			A  float64 `json:"a"`
+			B  float64 `json:"b"`
+		}
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", err
+		}
+		switch args.Op {
+		case "add":
+			return strconv.FormatFloat(args.A+args.B, 'f', -1, 64), nil
+		case "sub":
+			return strconv.FormatFloat(args.A-args.B, 'f', -1, 64), nil
+		case "mul":
+			return strconv.FormatFloat(args.A*args.B, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("unknown op %q", args.Op)
+	},
+}
+
+// newArithmeticModel scripts a model solving (2 + 3) * 4 - 6 one operation
+// at a time, using each tool result as the next operand.
+func newArithmeticModel(t *testing.T, calls *int) *httptest.Server {
+	t.Helper()
+	plan := []struct {
+		op string
+		b  float64
+	}{{"add", 3}, {"mul", 4}, {"sub", 6}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []blockrun.ChatMessage `json:"messages"`
+			Tools    []blockrun.Tool        `json:"tools"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Tools) != 1 || body.Tools[0].Function.Name != "calculator" {
+			t.Errorf("expected the calculator tool, got %+v", body.Tools)
+		}
+
+		step := *calls
+		*calls++
+		operand := "2"
+		if last := body.Messages[len(body.Messages)-1]; last.Role == "tool" {
+			operand = last.Content
+		}
+		msg := blockrun.ChatMessage{Role: "assistant"}
+		finish := "stop"
+		if step < len(plan) {
+			msg.Content = "Step " + strconv.Itoa(step+1)
+			msg.ToolCalls = []blockrun.ToolCall{{
+				ID:   "call_" + strconv.Itoa(step),
+				Type: "function",
+				Function: blockrun.ToolCallFunction{
+					Name:      "calculator",
+					Arguments: fmt.Sprintf(`{"op":%q,"a":%s,"b":%v}`, plan[step].op, operand, plan[step].b),
+				},
+			}}
+			finish = "tool_calls"
+		} else {
+			msg.Content = "The answer is " + operand
+		}
+		json.NewEncoder(w).Encode(blockrun.ChatResponse{
+			Choices: []blockrun.Choice{{Message: msg, FinishReason: finish}},
+			Usage:   blockrun.Usage{TotalTokens: 10},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReActAgentRun(t *testing.T) {
+	var calls int
+	server := newArithmeticModel(t, &calls)
+	client, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	a := NewReActAgent(client, "gpt-4o", []ToolDefinition{calculator}, 10)
+	result, err := a.Run(context.Background(), "What is (2 + 3) * 4 - 6?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.FinalAnswer != "The answer is 14" {
+		t.Errorf("unexpected final answer %q", result.FinalAnswer)
+	}
+	if len(result.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(result.Steps))
+	}
+	for i, want := range []string{"5", "20", "14"} {
+		step := result.Steps[i]
+		if step.Action != "calculator" || step.Observation != want {
+			t.Errorf("step %d: expected calculator → %s, got %+v", i, want, step)
+		}
+	}
+	if result.Steps[0].Thought != "Step 1" || result.Steps[0].ActionInput != `{"op":"add","a":2,"b":3}` {
+		t.Errorf("unexpected first step %+v", result.Steps[0])
+	}
+	if result.TotalTokens != 40 {
+		t.Errorf("expected 40 tokens over 4 calls, got %d", result.TotalTokens)
+	}
+}
+
+func TestReActAgentMaxIterations(t *testing.T) {
+	var calls int
+	server := newArithmeticModel(t, &calls)
+	client, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(server.URL))
+
+	a := NewReActAgent(client, "gpt-4o", []ToolDefinition{calculator}, 2)
+	result, err := a.Run(context.Background(), "What is (2 + 3) * 4 - 6?")
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+	if calls != 2 || len(result.Steps) != 1 {
+		t.Errorf("expected 2 model calls and 1 step, got %d and %d", calls, len(result.Steps))
+	}
+}
@@ -0,0 +1,133 @@
+package blockrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WellKnownModelAliases are short names for popular models. They are not
+// applied unless passed to WithModelAliases.
+var WellKnownModelAliases = map[string]string{
+	"gpt4":   "openai/gpt-4o",
+	"claude": "anthropic/claude-sonnet-4",
+}
+
+// remoteAliasTimeout bounds WithRemoteAliases' fetch.
+const remoteAliasTimeout = 10 * time.Second
+
+// AliasResolver maps logical model names to gateway model IDs, so code can
+// name "fast" or "gpt4" and follow the model behind it as it changes. It is
+// safe for concurrent use.
+type AliasResolver struct {
+	mu      sync.RWMutex
+	aliases map[string]string
+}
+
+// NewAliasResolver returns a resolver with aliases.
+func NewAliasResolver(aliases map[string]string) *AliasResolver {
+	r := &AliasResolver{}
+	r.Add(aliases)
+	return r
+}
+
+// Add registers aliases, replacing any with the same names.
+func (r *AliasResolver) Add(aliases map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.aliases == nil {
+		r.aliases = make(map[string]string, len(aliases))
+	}
+	for name, model := range aliases {
+		r.aliases[name] = model
+	}
+}
+
+// Resolve returns the model ID name is an alias for, or name itself.
+func (r *AliasResolver) Resolve(name string) string {
+	if r == nil {
+		return name
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if model, ok := r.aliases[name]; ok {
+		return model
+	}
+	return name
+}
+
+// WithModelAliases resolves model names passed to ChatCompletion and
+// ChatCompletionStream through aliases (e.g. WellKnownModelAliases) before
+// the request is built. Later alias options win over earlier ones.
+func WithModelAliases(aliases map[string]string) ClientOption {
+	return func(c *LLMClient) {
+		c.addAliases(aliases)
+	}
+}
+
+// WithRemoteAliases is WithModelAliases with the aliases fetched, once,
+// from endpoint as a JSON object of name → model ID. A failed fetch is
+// logged and the client starts without them.
+func WithRemoteAliases(endpoint string) ClientOption {
+	return func(c *LLMClient) {
+		aliases, err := fetchRemoteAliases(endpoint)
+		if err != nil {
+			c.warnf("WithRemoteAliases: %v; ignoring", err)
+			return
+		}
+		c.addAliases(aliases)
+	}
+}
+
+// WithImageModelAliases resolves ImageGenerateOptions.Model through aliases
+// in Generate and GenerateWithProgress.
+func WithImageModelAliases(aliases map[string]string) ImageClientOption {
+	return func(c *ImageClient) {
+		c.addAliases(aliases)
+	}
+}
+
+// ResolveModel returns the model ID model is an alias for, or model itself
+// if it is not a registered alias.
+func (bc *baseClient) ResolveModel(model string) string {
+	return bc.aliases.Resolve(model)
+}
+
+// addAliases registers aliases on the client, creating its resolver.
+func (bc *baseClient) addAliases(aliases map[string]string) {
+	if bc.aliases == nil {
+		bc.aliases = NewAliasResolver(nil)
+	}
+	bc.aliases.Add(aliases)
+}
+
+// fetchRemoteAliases reads a JSON alias map from endpoint.
+func fetchRemoteAliases(endpoint string) (map[string]string, error) {
+	client := &http.Client{Timeout: remoteAliasTimeout}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch aliases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch aliases: status %d", resp.StatusCode)
+	}
+	var aliases map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&aliases); err != nil {
+		return nil, fmt.Errorf("failed to decode aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// resolveImageOptions returns opts with Model resolved through the client's
+// aliases, copying rather than modifying the caller's options.
+func (c *ImageClient) resolveImageOptions(opts *ImageGenerateOptions) *ImageGenerateOptions {
+	if opts == nil || opts.Model == "" || c.aliases == nil {
+		return opts
+	}
+	o := *opts
+	o.Model = c.ResolveModel(o.Model)
+	return &o
+}
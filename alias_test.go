@@ -0,0 +1,85 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestModelAliases(t *testing.T) {
+	var models []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/aliases.json":
+			w.Write([]byte(`{"fast":"openai/gpt-4o-mini","claude":"anthropic/claude-opus-4"}`))
+		case "/v1/chat/completions":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			models = append(models, body["model"])
+			w.Write([]byte(testChatResponseJSON))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithModelAliases(WellKnownModelAliases),
+		WithRemoteAliases(server.URL+"/aliases.json"))
+	ctx := context.Background()
+
+	for _, name := range []string{"gpt4", "fast", "claude", "openai/o3"} {
+		if _, err := client.Chat(ctx, name, "hi"); err != nil {
+			t.Fatalf("Chat(%s) failed: %v", name, err)
+		}
+	}
+	stream, err := client.ChatCompletionStream(ctx, "gpt4", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	stream.Close()
+
+	// The remote "claude" alias was registered last, so it wins.
+	want := []any{"openai/gpt-4o", "openai/gpt-4o-mini", "anthropic/claude-opus-4", "openai/o3", "openai/gpt-4o"}
+	if len(models) != len(want) {
+		t.Fatalf("expected %d requests, got %v", len(want), models)
+	}
+	for i := range want {
+		if models[i] != want[i] {
+			t.Errorf("request %d: expected model %v, got %v", i, want[i], models[i])
+		}
+	}
+
+	plain, _ := NewLLMClient(testPrivateKey)
+	if got := plain.ResolveModel("gpt4"); got != "gpt4" {
+		t.Errorf("expected well-known aliases to be off by default, got %q", got)
+	}
+}
+
+func TestImageModelAliases(t *testing.T) {
+	var model any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/images/generations" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		model = body["model"]
+		json.NewEncoder(w).Encode(ImageResponse{Created: 1, Data: []ImageData{{URL: "https://example.com/a.png"}}})
+	}))
+	defer server.Close()
+
+	client, _ := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL),
+		WithImageModelAliases(map[string]string{"best": "openai/gpt-image-2"}))
+	opts := &ImageGenerateOptions{Model: "best"}
+	if _, err := client.Generate(context.Background(), "a cat", opts); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if model != "openai/gpt-image-2" {
+		t.Errorf("expected the alias to be resolved, got %v", model)
+	}
+	if opts.Model != "best" {
+		t.Error("expected the caller's options to be left alone")
+	}
+}
@@ -0,0 +1,223 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Anomaly types reported in AnomalyWarning.Type by DefaultAnomalyDetector.
+const (
+	AnomalyPromptLength   = "prompt_length"
+	AnomalyTemperature    = "high_temperature"
+	AnomalyModelSwitching = "model_switching"
+	AnomalyLongHistory    = "long_history"
+)
+
+// Anomaly severities reported in AnomalyWarning.Severity.
+const (
+	AnomalySeverityLow    = "low"
+	AnomalySeverityMedium = "medium"
+	AnomalySeverityHigh   = "high"
+)
+
+// Actions accepted by WithAnomalyDetection.
+const (
+	// AnomalyActionWarn logs anomalies at warn level (see WithLogLevel).
+	AnomalyActionWarn = "warn"
+	// AnomalyActionLog logs anomalies at info level.
+	AnomalyActionLog = "log"
+	// AnomalyActionBlock fails the call with an *AnomalyError before
+	// anything is sent.
+	AnomalyActionBlock = "block"
+)
+
+// AnomalyWarning is one unusual property of a chat request.
+type AnomalyWarning struct {
+	Type     string
+	Severity string
+	Detail   string
+}
+
+// RequestAnomalyDetector inspects each chat request before it is sent.
+// Detect is called once per call, so it may keep per-session state; it must
+// be safe for concurrent use.
+type RequestAnomalyDetector interface {
+	Detect(model string, messages []ChatMessage, opts *ChatCompletionOptions) []AnomalyWarning
+}
+
+// DefaultAnomalyDetector flags prompts much longer than the session's
+// average, high temperatures, frequent model switching and long histories,
+// any of which may point to a bug or an injected prompt. Zero fields take
+// their defaults; a detector must not be copied after first use.
+type DefaultAnomalyDetector struct {
+	// PromptLengthFactor flags prompts longer than this multiple of the
+	// average prompt length of earlier calls; it defaults to 3.
+	PromptLengthFactor float64
+	// MaxTemperature defaults to 1.5.
+	MaxTemperature float64
+	// MaxModelSwitches flags more than this many model changes within the
+	// last ModelWindow calls; they default to 5 and 10.
+	MaxModelSwitches int
+	ModelWindow      int
+	// MaxMessages defaults to 50.
+	MaxMessages int
+
+	once        sync.Once
+	mu          sync.Mutex
+	promptChars int
+	calls       int
+	models      []string
+}
+
+// Detect checks the request against the session so far, then records it.
+func (d *DefaultAnomalyDetector) Detect(model string, messages []ChatMessage, opts *ChatCompletionOptions) []AnomalyWarning {
+	d.once.Do(func() {
+		if d.PromptLengthFactor <= 0 {
+			d.PromptLengthFactor = 3
+		}
+		if d.MaxTemperature <= 0 {
+			d.MaxTemperature = 1.5
+		}
+		if d.MaxModelSwitches <= 0 {
+			d.MaxModelSwitches = 5
+		}
+		if d.ModelWindow <= 0 {
+			d.ModelWindow = 10
+		}
+		if d.MaxMessages <= 0 {
+			d.MaxMessages = 50
+		}
+	})
+
+	chars := 0
+	for _, m := range messages {
+		chars += utf8.RuneCountInString(m.Content)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var warnings []AnomalyWarning
+	if d.calls > 0 {
+		avg := float64(d.promptChars) / float64(d.calls)
+		if float64(chars) > d.PromptLengthFactor*avg {
+			warnings = append(warnings, AnomalyWarning{
+				Type:     AnomalyPromptLength,
+				Severity: AnomalySeverityMedium,
+				Detail:   fmt.Sprintf("prompt is %d characters, over %.3gx the session average of %.0f", chars, d.PromptLengthFactor, avg),
+			})
+		}
+	}
+	if opts != nil && opts.Temperature > d.MaxTemperature {
+		warnings = append(warnings, AnomalyWarning{
+			Type:     AnomalyTemperature,
+			Severity: AnomalySeverityLow,
+			Detail:   fmt.Sprintf("temperature %.2f is above %.2f", opts.Temperature, d.MaxTemperature),
+		})
+	}
+	d.models = append(d.models, model)
+	if len(d.models) > d.ModelWindow {
+		d.models = d.models[len(d.models)-d.ModelWindow:]
+	}
+	switches := 0
+	for i := 1; i < len(d.models); i++ {
+		if d.models[i] != d.models[i-1] {
+			switches++
+		}
+	}
+	if switches > d.MaxModelSwitches {
+		warnings = append(warnings, AnomalyWarning{
+			Type:     AnomalyModelSwitching,
+			Severity: AnomalySeverityMedium,
+			Detail:   fmt.Sprintf("model changed %d times in the last %d calls", switches, len(d.models)),
+		})
+	}
+	if len(messages) > d.MaxMessages {
+		warnings = append(warnings, AnomalyWarning{
+			Type:     AnomalyLongHistory,
+			Severity: AnomalySeverityLow,
+			Detail:   fmt.Sprintf("history has %d messages, over %d", len(messages), d.MaxMessages),
+		})
+	}
+
+	d.promptChars += chars
+	d.calls++
+	return warnings
+}
+
+// AnomalyError is returned for a call blocked by WithAnomalyDetection.
+type AnomalyError struct {
+	Warnings []AnomalyWarning
+}
+
+func (e *AnomalyError) Error() string {
+	return "request blocked by anomaly detection: " + describeAnomalies(e.Warnings)
+}
+
+// AnomalyEvent is emitted for each chat call with anomalies, whatever the
+// WithAnomalyDetection action.
+type AnomalyEvent struct {
+	Model    string
+	Warnings []AnomalyWarning
+	// Blocked is true when the call was refused.
+	Blocked bool
+}
+
+// EventName implements Event.
+func (AnomalyEvent) EventName() string { return "anomaly" }
+
+// WithAnomalyDetection runs detector (a DefaultAnomalyDetector if nil) on
+// every chat call and handles what it finds per action: AnomalyActionWarn,
+// AnomalyActionLog or AnomalyActionBlock. Unknown actions are ignored with
+// a warning.
+func WithAnomalyDetection(detector RequestAnomalyDetector, action string) ClientOption {
+	return func(c *LLMClient) {
+		switch action {
+		case AnomalyActionWarn, AnomalyActionLog, AnomalyActionBlock:
+		default:
+			c.warnf("WithAnomalyDetection: unknown action %q; ignoring", action)
+			return
+		}
+		if detector == nil {
+			detector = &DefaultAnomalyDetector{}
+		}
+		c.anomalyDetector = detector
+		c.anomalyAction = action
+	}
+}
+
+// checkAnomalies runs the client's anomaly detector on a chat request,
+// returning an *AnomalyError if the request is to be blocked.
+func (c *LLMClient) checkAnomalies(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions) error {
+	if c.anomalyDetector == nil {
+		return nil
+	}
+	warnings := c.anomalyDetector.Detect(model, messages, opts)
+	if len(warnings) == 0 {
+		return nil
+	}
+	blocked := c.anomalyAction == AnomalyActionBlock
+	c.emit(ctx, AnomalyEvent{Model: model, Warnings: warnings, Blocked: blocked})
+	switch c.anomalyAction {
+	case AnomalyActionWarn:
+		c.warnf("unusual request to %s: %s", model, describeAnomalies(warnings))
+	case AnomalyActionLog:
+		c.infof("unusual request to %s: %s", model, describeAnomalies(warnings))
+	}
+	if blocked {
+		return &AnomalyError{Warnings: warnings}
+	}
+	return nil
+}
+
+// describeAnomalies joins warnings as "type: detail; ...".
+func describeAnomalies(warnings []AnomalyWarning) string {
+	parts := make([]string, len(warnings))
+	for i, w := range warnings {
+		parts[i] = w.Type + ": " + w.Detail
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,150 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func anomalyTypes(warnings []AnomalyWarning) []string {
+	var types []string
+	for _, w := range warnings {
+		types = append(types, w.Type)
+	}
+	return types
+}
+
+func TestDefaultAnomalyDetector(t *testing.T) {
+	msgs := func(content string) []ChatMessage { return []ChatMessage{{Role: "user", Content: content}} }
+
+	t.Run("prompt length", func(t *testing.T) {
+		d := &DefaultAnomalyDetector{}
+		if w := d.Detect("m", msgs(strings.Repeat("x", 1000)), nil); len(w) != 0 {
+			t.Errorf("first call has no average to compare with, got %v", w)
+		}
+		d.Detect("m", msgs(strings.Repeat("x", 1000)), nil)
+		if w := d.Detect("m", msgs(strings.Repeat("x", 3000)), nil); len(w) != 0 {
+			t.Errorf("3x the average is allowed, got %v", w)
+		}
+		w := d.Detect("m", msgs(strings.Repeat("x", 6000)), nil)
+		if len(w) != 1 || w[0].Type != AnomalyPromptLength || w[0].Severity != AnomalySeverityMedium {
+			t.Errorf("expected a prompt_length warning, got %v", w)
+		}
+	})
+
+	t.Run("temperature", func(t *testing.T) {
+		d := &DefaultAnomalyDetector{}
+		if w := d.Detect("m", msgs("hi"), &ChatCompletionOptions{Temperature: 1.5}); len(w) != 0 {
+			t.Errorf("1.5 is allowed, got %v", w)
+		}
+		if w := d.Detect("m", msgs("hi"), &ChatCompletionOptions{Temperature: 1.9}); len(w) != 1 || w[0].Type != AnomalyTemperature {
+			t.Errorf("expected a high_temperature warning, got %v", w)
+		}
+	})
+
+	t.Run("model switching", func(t *testing.T) {
+		d := &DefaultAnomalyDetector{}
+		models := []string{"a", "b", "a", "b", "a", "b"} // 5 switches
+		for _, m := range models {
+			if w := d.Detect(m, msgs("hi"), nil); len(w) != 0 {
+				t.Fatalf("5 switches are allowed, got %v", w)
+			}
+		}
+		w := d.Detect("c", msgs("hi"), nil)
+		if len(w) != 1 || w[0].Type != AnomalyModelSwitching {
+			t.Errorf("expected a model_switching warning, got %v", w)
+		}
+		for i := 0; i < 10; i++ {
+			w = d.Detect("c", msgs("hi"), nil)
+		}
+		if len(w) != 0 {
+			t.Errorf("expected switches to age out of the window, got %v", w)
+		}
+	})
+
+	t.Run("long history", func(t *testing.T) {
+		d := &DefaultAnomalyDetector{}
+		history := make([]ChatMessage, 51)
+		for i := range history {
+			history[i] = ChatMessage{Role: "user", Content: "hi"}
+		}
+		if w := d.Detect("m", history[:50], nil); len(w) != 0 {
+			t.Errorf("50 messages are allowed, got %v", w)
+		}
+		if w := d.Detect("m", history, nil); len(w) != 1 || w[0].Type != AnomalyLongHistory {
+			t.Errorf("expected a long_history warning, got %v", w)
+		}
+	})
+
+	t.Run("several", func(t *testing.T) {
+		d := &DefaultAnomalyDetector{MaxMessages: 1}
+		got := anomalyTypes(d.Detect("m", append(msgs("a"), msgs("b")...), &ChatCompletionOptions{Temperature: 2}))
+		if strings.Join(got, ",") != AnomalyTemperature+","+AnomalyLongHistory {
+			t.Errorf("unexpected warnings %v", got)
+		}
+	})
+}
+
+func TestWithAnomalyDetection(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+	hot := &ChatCompletionOptions{Temperature: 1.8}
+
+	var events []AnomalyEvent
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithAnomalyDetection(nil, AnomalyActionBlock),
+		WithMiddleware(MiddlewareFunc(func(ctx context.Context, ev Event) {
+			if e, ok := ev.(AnomalyEvent); ok {
+				events = append(events, e)
+			}
+		})))
+	_, err := client.ChatCompletion(context.Background(), "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, hot)
+	var anomalyErr *AnomalyError
+	if !errors.As(err, &anomalyErr) || anomalyErr.Warnings[0].Type != AnomalyTemperature {
+		t.Fatalf("expected an AnomalyError, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected a blocked call not to be sent, got %d requests", calls)
+	}
+	if len(events) != 1 || !events[0].Blocked || events[0].Model != "openai/gpt-4o" {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if _, err := client.ChatCompletionStream(context.Background(), "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, hot); !errors.As(err, &anomalyErr) {
+		t.Errorf("expected streams to be checked too, got %v", err)
+	}
+
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(prev)
+	warned, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithAnomalyDetection(nil, AnomalyActionWarn))
+	if _, err := warned.ChatCompletion(context.Background(), "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, hot); err != nil {
+		t.Fatalf("expected a warned call to go through, got %v", err)
+	}
+	if calls != 1 || !strings.Contains(logs.String(), "high_temperature") {
+		t.Errorf("expected the call to be sent and warned about; calls %d, logs %q", calls, logs.String())
+	}
+
+	logs.Reset()
+	quiet, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithAnomalyDetection(nil, AnomalyActionLog))
+	if _, err := quiet.ChatCompletion(context.Background(), "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, hot); err != nil {
+		t.Fatal(err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected the log action to stay quiet at the default level, got %q", logs.String())
+	}
+
+	ignored, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithAnomalyDetection(nil, "panic"))
+	if ignored.anomalyDetector != nil {
+		t.Error("expected an unknown action to be ignored")
+	}
+}
@@ -95,6 +95,8 @@ type AnthropicContentBlock struct {
 	Input     map[string]any        `json:"input,omitempty"`       // for type "tool_use"
 	ToolUseID string                `json:"tool_use_id,omitempty"` // for type "tool_result"
 	Source    *AnthropicImageSource `json:"source,omitempty"`      // for type "image"
+	// CacheControl caches the prompt up to and including this block.
+	CacheControl *CacheControlBlock `json:"cache_control,omitempty"`
 }
 
 // AnthropicImageSource describes an image source (base64 or URL).
@@ -136,6 +138,10 @@ type AnthropicCreateParams struct {
 type AnthropicUsage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+	// Prompt caching: tokens written to and read from the cache, on top of
+	// InputTokens.
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // AnthropicResponse is the response from Messages.Create.
@@ -0,0 +1,76 @@
+package blockrun
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// AudioFormatFLAC is an audio output format (see AudioOutputOptions); WAV
+// and MP3 use AudioFormatWAV and AudioFormatMP3.
+const AudioFormatFLAC = "flac"
+
+// AudioOutputOptions asks an audio-capable model such as
+// openai/gpt-4o-audio-preview to answer with speech as well as text (see
+// ChatCompletionOptions.AudioOutput).
+type AudioOutputOptions struct {
+	// Voice is the model's voice, e.g. "alloy".
+	Voice string
+	// Format is AudioFormatWAV, AudioFormatMP3 or AudioFormatFLAC.
+	Format string
+}
+
+// AudioMessageContent is the spoken form of an assistant message, in
+// ChatMessage.Audio. To continue the conversation, send the message back
+// unchanged; the model refers to the audio by ID until ExpiresAt.
+type AudioMessageContent struct {
+	ID string `json:"id"`
+	// ExpiresAt is when the gateway forgets the audio, in Unix seconds.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+	// Data is the audio, base64-encoded, in the requested format.
+	Data       string `json:"data,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// SaveAudio decodes the message's Audio.Data and writes it to path.
+func (m *ChatMessage) SaveAudio(path string) error {
+	if m.Audio == nil || m.Audio.Data == "" {
+		return fmt.Errorf("message has no audio")
+	}
+	data, err := base64.StdEncoding.DecodeString(m.Audio.Data)
+	if err != nil {
+		return fmt.Errorf("failed to decode audio: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// applyAudioOutput adds the audio output request for opts.AudioOutput to a
+// chat completion body.
+func applyAudioOutput(body map[string]any, opts *ChatCompletionOptions) error {
+	if opts == nil || opts.AudioOutput == nil {
+		return nil
+	}
+	audio := opts.AudioOutput
+	if audio.Voice == "" {
+		return &ValidationError{
+			Field:      "Voice",
+			Message:    "audio output needs a voice",
+			Suggestion: `Set Voice, e.g. "alloy"`,
+			FieldPath:  []string{"Options", "AudioOutput", "Voice"},
+		}
+	}
+	switch audio.Format {
+	case AudioFormatWAV, AudioFormatMP3, AudioFormatFLAC:
+	default:
+		return &ValidationError{
+			Field:      "Format",
+			Message:    fmt.Sprintf("unsupported audio output format %q", audio.Format),
+			Suggestion: "Use wav, mp3 or flac",
+			Value:      audio.Format,
+			FieldPath:  []string{"Options", "AudioOutput", "Format"},
+		}
+	}
+	body["modalities"] = []string{"text", "audio"}
+	body["audio"] = map[string]string{"voice": audio.Voice, "format": audio.Format}
+	return nil
+}
@@ -0,0 +1,104 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAudioOutput(t *testing.T) {
+	wav := []byte("RIFF\x24\x00\x00\x00WAVEfmt ")
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":    "c1",
+			"model": "openai/gpt-4o-audio-preview",
+			"choices": []any{map[string]any{
+				"index": 0,
+				"message": map[string]any{
+					"role":    "assistant",
+					"content": nil,
+					"audio": map[string]any{
+						"id":         "audio_abc",
+						"expires_at": 1760000000,
+						"data":       base64.StdEncoding.EncodeToString(wav),
+						"transcript": "Hello there",
+					},
+				},
+				"finish_reason": "stop",
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	ctx := context.Background()
+	opts := &ChatCompletionOptions{AudioOutput: &AudioOutputOptions{Voice: "alloy", Format: AudioFormatWAV}}
+	resp, err := client.ChatCompletion(ctx, "openai/gpt-4o-audio-preview", []ChatMessage{{Role: "user", Content: "Say hello"}}, opts)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if !reflect.DeepEqual(body["modalities"], []any{"text", "audio"}) {
+		t.Errorf("unexpected modalities %v", body["modalities"])
+	}
+	if !reflect.DeepEqual(body["audio"], map[string]any{"voice": "alloy", "format": "wav"}) {
+		t.Errorf("unexpected audio %v", body["audio"])
+	}
+
+	msg := resp.Choices[0].Message
+	want := &AudioMessageContent{ID: "audio_abc", ExpiresAt: 1760000000, Data: base64.StdEncoding.EncodeToString(wav), Transcript: "Hello there"}
+	if !reflect.DeepEqual(msg.Audio, want) {
+		t.Fatalf("expected audio %+v, got %+v", want, msg.Audio)
+	}
+	path := filepath.Join(t.TempDir(), "reply.wav")
+	if err := msg.SaveAudio(path); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != string(wav) {
+		t.Errorf("saved audio differs: %q", saved)
+	}
+	if err := (&ChatMessage{Content: "text only"}).SaveAudio(path); err == nil {
+		t.Error("expected an error saving a message without audio")
+	}
+
+	// The assistant message goes back with its audio in the next turn.
+	_, err = client.ChatCompletion(ctx, "openai/gpt-4o-audio-preview", []ChatMessage{{Role: "user", Content: "Say hello"}, msg, {Role: "user", Content: "Again"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := body["messages"].([]any)[1].(map[string]any)["audio"].(map[string]any)
+	if sent["id"] != "audio_abc" {
+		t.Errorf("expected the audio ID sent back, got %v", sent)
+	}
+	if _, ok := body["modalities"]; ok {
+		t.Error("modalities sent without AudioOutput")
+	}
+}
+
+func TestAudioOutputValidation(t *testing.T) {
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL("http://127.0.0.1:1"))
+	ctx := context.Background()
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	for _, audio := range []*AudioOutputOptions{{Format: AudioFormatMP3}, {Voice: "alloy", Format: "ogg"}, {Voice: "alloy"}} {
+		_, err := client.ChatCompletion(ctx, "openai/gpt-4o-audio-preview", messages, &ChatCompletionOptions{AudioOutput: audio})
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%+v: expected a ValidationError, got %v", audio, err)
+		}
+	}
+
+	opts := &ChatCompletionOptions{AudioOutput: &AudioOutputOptions{Voice: "alloy", Format: AudioFormatFLAC}, BufferedStream: true}
+	var ve *ValidationError
+	if _, err := client.ChatCompletion(ctx, "openai/gpt-4o-audio-preview", messages, opts); !errors.As(err, &ve) || ve.Field != "AudioOutput" {
+		t.Errorf("expected streams to reject AudioOutput, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package blockrun
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Headers sent by WithAuthenticator.
+const (
+	signatureHeader = "X-Blockrun-Signature"
+	apiKeyHeader    = "X-Blockrun-Api-Key"
+)
+
+// Authenticator signs requests for gateway deployments that authenticate
+// callers by API key rather than (or as well as) x402 payment.
+type Authenticator interface {
+	// Sign returns the X-Blockrun-Signature value for a request with body,
+	// sent at timestamp (Unix seconds).
+	Sign(body []byte, timestamp int64) (string, error)
+}
+
+// HMACAuthenticator is an Authenticator using a shared API key and secret.
+type HMACAuthenticator struct {
+	apiKey string
+	secret []byte
+}
+
+// NewHMACAuthenticator returns an authenticator for apiKey, signing with
+// secret.
+func NewHMACAuthenticator(apiKey, secret string) *HMACAuthenticator {
+	return &HMACAuthenticator{apiKey: apiKey, secret: []byte(secret)}
+}
+
+// APIKey returns the key sent as X-Blockrun-Api-Key.
+func (a *HMACAuthenticator) APIKey() string {
+	return a.apiKey
+}
+
+// Sign implements Authenticator. The signature is the hex
+// HMAC-SHA256(timestamp + "." + hex(sha256(body))) under the secret,
+// formatted as "t=<timestamp>,v1=<signature>".
+func (a *HMACAuthenticator) Sign(body []byte, timestamp int64) (string, error) {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + hex.EncodeToString(bodyHash[:])))
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil))), nil
+}
+
+// WithAuthenticator signs every request with a, sending X-Blockrun-Signature
+// (and X-Blockrun-Api-Key when a has an APIKey method). Endpoints that
+// accept the signature answer without asking for payment; those that still
+// answer 402 are paid with x402 as usual, so API-key and x402 endpoints can
+// be used from one client.
+func WithAuthenticator(a Authenticator) ClientOption {
+	return func(c *LLMClient) {
+		c.authenticator = a
+	}
+}
+
+// authenticate signs req's uncompressed body when an Authenticator is set.
+func (bc *baseClient) authenticate(req *http.Request) error {
+	if bc.authenticator == nil {
+		return nil
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	signature, err := bc.authenticator.Sign(body, currentClock().Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	req.Header.Set(signatureHeader, signature)
+	if keyed, ok := bc.authenticator.(interface{ APIKey() string }); ok {
+		req.Header.Set(apiKeyHeader, keyed.APIKey())
+	}
+	return nil
+}
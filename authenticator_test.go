@@ -0,0 +1,67 @@
+package blockrun
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestHMACAuthenticatorSign(t *testing.T) {
+	a := NewHMACAuthenticator("key-1", "s3cret")
+	body := []byte(`{"model":"gpt-4o"}`)
+	sig, err := a.Sign(body, 1700000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^t=1700000000,v1=[0-9a-f]{64}$`).MatchString(sig) {
+		t.Fatalf("unexpected signature format %q", sig)
+	}
+
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("1700000000." + hex.EncodeToString(bodyHash[:])))
+	if want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil)); sig != want {
+		t.Errorf("expected %s, got %s", want, sig)
+	}
+
+	tampered, _ := a.Sign([]byte(`{"model":"gpt-4o-mini"}`), 1700000000)
+	if tampered == sig {
+		t.Error("expected a tampered body to change the signature")
+	}
+	later, _ := a.Sign(body, 1700000001)
+	if later == sig {
+		t.Error("expected the timestamp to change the signature")
+	}
+}
+
+func TestWithAuthenticatorSignsRequests(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(NewMockClock(now))
+	defer SetClock(nil)
+
+	a := NewHMACAuthenticator("key-1", "s3cret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want, _ := a.Sign(body, now.Unix())
+		if got := r.Header.Get("X-Blockrun-Signature"); got != want {
+			t.Errorf("expected signature %s over the received body, got %s", want, got)
+		}
+		if got := r.Header.Get("X-Blockrun-Api-Key"); got != "key-1" {
+			t.Errorf("expected API key key-1, got %q", got)
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithAuthenticator(a))
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package blockrun
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// AuthorizationExtrasKey is the PaymentOption.Extra key under which a server
+// opts in to extra authorization fields: an object of string fields, such as
+// {"memo": "invoice-42"}, signed after the standard ones. No other Extra key
+// changes what is signed, so without it the payment is a plain EIP-3009
+// TransferWithAuthorization.
+const AuthorizationExtrasKey = "authorizationExtras"
+
+// standardAuthorizationKeys are the TransferWithAuthorization fields, which
+// an extra may not shadow.
+var standardAuthorizationKeys = map[string]bool{
+	"from":        true,
+	"to":          true,
+	"value":       true,
+	"validAfter":  true,
+	"validBefore": true,
+	"nonce":       true,
+}
+
+// AuthorizationExtras returns the string fields under a payment option's
+// Extra[AuthorizationExtrasKey], which CreatePaymentPayload adds to the
+// signed authorization. Fields that would shadow a standard one are dropped.
+// It returns nil if there are none.
+func AuthorizationExtras(extra map[string]any) map[string]string {
+	var fields map[string]any
+	switch v := extra[AuthorizationExtrasKey].(type) {
+	case map[string]any:
+		fields = v
+	case map[string]string:
+		fields = make(map[string]any, len(v))
+		for k, s := range v {
+			fields[k] = s
+		}
+	}
+	var out map[string]string
+	for k, v := range fields {
+		s, ok := v.(string)
+		if !ok || standardAuthorizationKeys[k] {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[k] = s
+	}
+	return out
+}
+
+// sortedExtraKeys returns the keys of extras in the order they are signed.
+func sortedExtraKeys(extras map[string]string) []string {
+	keys := make([]string, 0, len(extras))
+	for k := range extras {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// transferAuthorizationTypes returns the EIP-712 type list for auth: the
+// standard TransferWithAuthorization fields followed by any extras, as
+// strings in key order.
+func transferAuthorizationTypes(auth TransferAuthorization) []apitypes.Type {
+	types := []apitypes.Type{
+		{Name: "from", Type: "address"},
+		{Name: "to", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "validAfter", Type: "uint256"},
+		{Name: "validBefore", Type: "uint256"},
+		{Name: "nonce", Type: "bytes32"},
+	}
+	for _, k := range sortedExtraKeys(auth.Extras) {
+		types = append(types, apitypes.Type{Name: k, Type: "string"})
+	}
+	return types
+}
+
+// transferAuthorizationFields is TransferAuthorization without its methods,
+// so they can marshal it without recursing.
+type transferAuthorizationFields TransferAuthorization
+
+// MarshalJSON writes the standard fields followed by any extras inline.
+func (a TransferAuthorization) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(transferAuthorizationFields(a))
+	if err != nil || len(a.Extras) == 0 {
+		return data, err
+	}
+	m := make(map[string]any)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range a.Extras {
+		if !standardAuthorizationKeys[k] {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON reads the standard fields and collects any other string
+// fields into Extras.
+func (a *TransferAuthorization) UnmarshalJSON(data []byte) error {
+	var fields transferAuthorizationFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*a = TransferAuthorization(fields)
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok || standardAuthorizationKeys[k] {
+			continue
+		}
+		if a.Extras == nil {
+			a.Extras = make(map[string]string)
+		}
+		a.Extras[k] = s
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package blockrun
+
+import (
+	"context"
+	"strconv"
+)
+
+// autoExtendPrompt asks the model to carry on from a truncated reply.
+const autoExtendPrompt = "Please continue."
+
+// ExtendedChatResponse is a ChatResponse that may have been assembled from
+// several calls by WithAutoExtend.
+type ExtendedChatResponse struct {
+	ChatResponse
+	// Extensions is the number of continuation calls made; 0 if the first
+	// response was complete.
+	Extensions int
+}
+
+// WithAutoExtend continues ChatCompletion responses cut off at max_tokens
+// (finish reason "length"): the partial reply and a "Please continue." user
+// message are appended and the call repeated, up to maxExtensions times.
+// Each continuation is a separate paid call. 0 disables it (the default).
+func WithAutoExtend(maxExtensions int) ClientOption {
+	return func(c *LLMClient) {
+		c.autoExtend = maxExtensions
+	}
+}
+
+// ChatCompletionExtended is ChatCompletion reporting how many continuation
+// calls WithAutoExtend made. The returned response joins the content of
+// every part, sums their Usage and carries the last part's finish reason,
+// which is still "length" if the extensions ran out. A call with an
+// idempotency key gives each continuation its own key derived from it.
+func (c *LLMClient) ChatCompletionExtended(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions, reqOpts ...RequestOption) (*ExtendedChatResponse, error) {
+	ctx = WithRequestOptions(ctx, reqOpts...)
+	resp, err := c.chatCompletion(ctx, model, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	extended := &ExtendedChatResponse{ChatResponse: *resp}
+	if len(resp.Choices) == 0 {
+		return extended, nil
+	}
+	extended.Choices = append([]Choice(nil), resp.Choices[:1]...)
+
+	history := append([]ChatMessage(nil), messages...)
+	for extended.Extensions < c.autoExtend && resp.Choices[0].FinishReason == "length" {
+		history = append(history,
+			ChatMessage{Role: "assistant", Content: resp.Choices[0].Message.Content},
+			ChatMessage{Role: "user", Content: autoExtendPrompt},
+		)
+		extended.Extensions++
+		callCtx := ctx
+		if key := idempotencyKeyFrom(ctx); key != "" {
+			callCtx = WithRequestOptions(ctx, WithIdempotencyKey(key+"-ext-"+strconv.Itoa(extended.Extensions)))
+		}
+		if resp, err = c.chatCompletion(callCtx, model, history, opts); err != nil {
+			return nil, err
+		}
+		if len(resp.Choices) == 0 {
+			return nil, &APIError{Message: "No choices in continuation response"}
+		}
+
+		joined := &extended.Choices[0]
+		joined.Message.Content += resp.Choices[0].Message.Content
+		joined.Message.ThinkingContent += resp.Choices[0].Message.ThinkingContent
+		joined.FinishReason = resp.Choices[0].FinishReason
+		extended.Usage.add(resp.Usage)
+	}
+	return extended, nil
+}
+
+// add sums other's token counts into u.
+func (u *Usage) add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.NumSourcesUsed += other.NumSourcesUsed
+	u.CacheReadInputTokens += other.CacheReadInputTokens
+	u.CacheCreationInputTokens += other.CacheCreationInputTokens
+}
@@ -0,0 +1,74 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTruncatingServer answers in three parts, the first two cut off at
+// max_tokens, and records the messages of each request.
+func newTruncatingServer(t *testing.T, requests *[][]ChatMessage) *httptest.Server {
+	t.Helper()
+	parts := []string{"The quick brown ", "fox jumps over ", "the lazy dog."}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		*requests = append(*requests, body.Messages)
+		i := len(*requests) - 1
+		finish := "length"
+		if i == len(parts)-1 {
+			finish = "stop"
+		}
+		json.NewEncoder(w).Encode(ChatResponse{
+			Choices: []Choice{{Message: ChatMessage{Role: "assistant", Content: parts[i]}, FinishReason: finish}},
+			Usage:   Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAutoExtend(t *testing.T) {
+	var requests [][]ChatMessage
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(newTruncatingServer(t, &requests).URL), WithAutoExtend(3))
+
+	messages := []ChatMessage{{Role: "user", Content: "Say the pangram."}}
+	resp, err := client.ChatCompletionExtended(context.Background(), "gpt-4o", messages, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionExtended: %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "The quick brown fox jumps over the lazy dog." {
+		t.Errorf("unexpected joined content %q", got)
+	}
+	if resp.Extensions != 2 || resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("expected 2 extensions ending in stop, got %d / %q", resp.Extensions, resp.Choices[0].FinishReason)
+	}
+	if resp.Usage.TotalTokens != 45 || resp.Usage.CompletionTokens != 15 {
+		t.Errorf("expected summed usage, got %+v", resp.Usage)
+	}
+
+	// The last request carries both partial replies, each followed by a
+	// continuation prompt.
+	last := requests[2]
+	if len(last) != 5 || last[1].Content != "The quick brown " || last[2].Content != autoExtendPrompt || last[3].Content != "fox jumps over " {
+		t.Errorf("unexpected continuation history %+v", last)
+	}
+}
+
+func TestAutoExtendLimit(t *testing.T) {
+	var requests [][]ChatMessage
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(newTruncatingServer(t, &requests).URL), WithAutoExtend(1))
+
+	resp, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "Go"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if len(requests) != 2 || resp.Choices[0].Message.Content != "The quick brown fox jumps over " || resp.Choices[0].FinishReason != "length" {
+		t.Errorf("expected one extension still truncated, got %d calls and %+v", len(requests), resp.Choices[0])
+	}
+}
@@ -50,8 +50,13 @@ type rpcError struct {
 }
 
 // GetBalance queries the USDC balance on Base mainnet for the client's wallet address.
+// WithRPCURL replaces the default public endpoints.
 func (c *LLMClient) GetBalance(ctx context.Context) (float64, error) {
-	return getUSDCBalance(ctx, c.address, USDCBaseContract, baseMainnetRPCs)
+	rpcs := baseMainnetRPCs
+	if c.rpcURL != "" {
+		rpcs = []string{c.rpcURL}
+	}
+	return getUSDCBalance(ctx, c.address, USDCBaseContract, rpcs)
 }
 
 // GetBalanceTestnet queries the USDC balance on Base Sepolia testnet for the client's wallet address.
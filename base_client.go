@@ -23,7 +23,10 @@ import (
 // "solana" the client pays USDC on Solana instead: privateKey is nil, solanaKey
 // holds the bs58 signing key, and address is the bs58 public key.
 type baseClient struct {
-	privateKey      *ecdsa.PrivateKey
+	privateKey *ecdsa.PrivateKey
+	// signer, when set, signs payments in place of privateKey (see
+	// WithMPCSigner).
+	signer          MPCSigner
 	address         string
 	apiURL          string
 	httpClient      *http.Client
@@ -33,12 +36,90 @@ type baseClient struct {
 	sessionCalls    int
 	costLog         *CostLog
 
+	// basePath is the WithBasePath prefix, already part of apiURL.
+	basePath string
+
+	// receipts, when set, gets a Receipt for every settled payment.
+	receipts ReceiptStore
+	// maxPaymentRetries caps the signed payments per call; see
+	// WithMaxPaymentRetries.
+	maxPaymentRetries int
+	// userMetadata is the JSON X-User-Metadata header sent with every
+	// request; see WithUserMetadata.
+	userMetadata string
+	// maxClockDrift and autoClockDrift are set by WithMaxClockDrift;
+	// clockDrift holds the last measurement (created lazily, guarded by mu).
+	maxClockDrift  time.Duration
+	autoClockDrift bool
+	clockDrift     *ClockDriftChecker
+	// authenticator signs every request; see WithAuthenticator.
+	authenticator Authenticator
+	// w3cTracing sends traceparent headers; see WithW3CTracing.
+	w3cTracing bool
+	// protobuf negotiates protobuf chat completions; see WithProtobuf.
+	protobuf bool
+	// userAgent replaces DefaultUserAgent; see WithUserAgent.
+	userAgent string
+	// paymentMode is PaymentModeSync or PaymentModeDeferred ("" is sync);
+	// deferredPayments await FlushDeferredPayments (guarded by mu).
+	paymentMode      string
+	deferredPayments []DeferredPaymentRecord
+	// routingHeaders are sent with every request; see WithOpenRouterOptions.
+	routingHeaders http.Header
+	// aliases resolves model names; see WithModelAliases.
+	aliases *AliasResolver
+	// defaultTags are cost tags applied to every paid call (guarded by mu).
+	defaultTags map[string]string
+
+	// middleware observes client events (see WithMiddleware).
+	middleware []Middleware
+	// retryPolicy controls 429 back-off; the zero value uses DefaultRetryPolicy.
+	retryPolicy RetryPolicy
+	// transportTuned records that WithConnectionPool / WithHTTP2 configured
+	// the transport, so a later WithHTTPClient can warn that it overrides it.
+	transportTuned bool
+	// acceptEncodings are the response compressions to request (see
+	// WithCompression); requestEncoding compresses request bodies.
+	acceptEncodings []string
+	requestEncoding string
+
+	// pendingPayments holds signed payloads by idempotency key until the paid
+	// call is confirmed (see WithIdempotencyKey).
+	pendingPayments PendingPaymentStore
+
 	// chain is "base" (default) or "solana".
 	chain string
 	// solanaKey is the bs58 Solana signing key (only set when chain == "solana").
 	solanaKey string
 	// solanaRPCURL fetches blockhash + mint info while signing (chain == "solana").
 	solanaRPCURL string
+
+	// envPrefix is prepended to the environment variable names the client
+	// reads (see WithEnvPrefix).
+	envPrefix string
+	// logLevel filters the client's own log output ("" means warn).
+	logLevel string
+	// budgetUSD caps session spending; 0 means no cap (see WithBudget).
+	budgetUSD float64
+	// shadowBudgetUSD caps spending on shadow calls (see WithShadowBudget);
+	// shadowSpentUSD and shadowReservedUSD, the cost of shadow payments
+	// signed but not yet finished, are guarded by mu.
+	shadowBudgetUSD   float64
+	shadowSpentUSD    float64
+	shadowReservedUSD float64
+	// alerts are notified as session spending crosses their thresholds
+	// (see WithSpendingAlerts); their state is guarded by mu.
+	alerts []*spendingAlert
+	// balancer spreads requests over several endpoints (see WithEndpoints);
+	// nil sends everything to apiURL.
+	balancer *loadBalancer
+	// drain tracks in-flight calls for Shutdown.
+	drain drainState
+	// analytics keeps recent payments for SpendingWindow; nil disables (see
+	// WithRealTimeAnalytics).
+	analytics *spendingTracker
+	// fork links a client made by Fork to its parent; nil otherwise.
+	fork *forkState
 }
 
 // chainSolana identifies the Solana payment chain.
@@ -52,18 +133,42 @@ func (bc *baseClient) isSolana() bool { return bc.chain == chainSolana }
 // If privateKey is empty, it checks BLOCKRUN_WALLET_KEY then BASE_CHAIN_WALLET_KEY env vars.
 // If apiURL is empty, DefaultAPIURL is used; BLOCKRUN_API_URL env var can override.
 func newBaseClient(privateKey, apiURL string, timeout time.Duration) (*baseClient, error) {
-	// Get private key from param or environment
+	bc := newUnkeyedBaseClient(apiURL, timeout)
+	if err := bc.loadKey(privateKey); err != nil {
+		return nil, err
+	}
+	return bc, nil
+}
+
+// newUnkeyedBaseClient creates a Base baseClient without a signing key, for
+// constructors that apply options (such as WithEnvPrefix) before calling
+// loadKey.
+func newUnkeyedBaseClient(apiURL string, timeout time.Duration) *baseClient {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &baseClient{
+		apiURL:          apiURL,
+		httpClient:      &http.Client{Timeout: timeout, Transport: newDefaultTransport()},
+		costLog:         NewCostLog(),
+		pendingPayments: NewMemoryPendingPaymentStore(),
+	}
+}
+
+// loadKey sets the signing key from privateKey, or from the (prefixed)
+// BLOCKRUN_WALLET_KEY / BASE_CHAIN_WALLET_KEY env vars if it is empty.
+func (bc *baseClient) loadKey(privateKey string) error {
 	key := privateKey
 	if key == "" {
-		key = os.Getenv("BLOCKRUN_WALLET_KEY")
+		key = bc.getenv("BLOCKRUN_WALLET_KEY")
 	}
 	if key == "" {
-		key = os.Getenv("BASE_CHAIN_WALLET_KEY")
+		key = bc.getenv("BASE_CHAIN_WALLET_KEY")
 	}
 	if key == "" {
-		return nil, &ValidationError{
+		return &ValidationError{
 			Field:   "privateKey",
-			Message: "Private key required. Pass privateKey parameter or set BLOCKRUN_WALLET_KEY environment variable. NOTE: Your key never leaves your machine - only signatures are sent.",
+			Message: fmt.Sprintf("Private key required. Pass privateKey parameter or set %sBLOCKRUN_WALLET_KEY environment variable. NOTE: Your key never leaves your machine - only signatures are sent.", bc.envPrefix),
 		}
 	}
 
@@ -71,29 +176,20 @@ func newBaseClient(privateKey, apiURL string, timeout time.Duration) (*baseClien
 	key = strings.TrimPrefix(key, "0x")
 	ecdsaKey, err := crypto.HexToECDSA(key)
 	if err != nil {
-		return nil, &ValidationError{
+		return &ValidationError{
 			Field:   "privateKey",
 			Message: fmt.Sprintf("Invalid private key format: %v", err),
 		}
 	}
 
-	// Get wallet address
-	address := crypto.PubkeyToAddress(ecdsaKey.PublicKey).Hex()
-
-	// Determine API URL
-	if apiURL == "" {
-		apiURL = DefaultAPIURL
-	}
-
-	bc := &baseClient{
-		privateKey: ecdsaKey,
-		address:    address,
-		apiURL:     apiURL,
-		httpClient: &http.Client{Timeout: timeout},
-		costLog:    NewCostLog(),
-	}
+	bc.privateKey = ecdsaKey
+	bc.address = crypto.PubkeyToAddress(ecdsaKey.PublicKey).Hex()
+	return nil
+}
 
-	return bc, nil
+// getenv reads an environment variable under the client's env prefix.
+func (bc *baseClient) getenv(name string) string {
+	return os.Getenv(bc.envPrefix + name)
 }
 
 // newSolanaBaseClient creates a baseClient that pays USDC on Solana.
@@ -134,26 +230,29 @@ func newSolanaBaseClient(solanaKey, apiURL, rpcURL string, timeout time.Duration
 	}
 
 	return &baseClient{
-		address:      address,
-		apiURL:       strings.TrimSuffix(apiURL, "/"),
-		httpClient:   &http.Client{Timeout: timeout},
-		costLog:      NewCostLog(),
-		chain:        chainSolana,
-		solanaKey:    key,
-		solanaRPCURL: rpcURL,
+		address:         address,
+		apiURL:          strings.TrimSuffix(apiURL, "/"),
+		httpClient:      &http.Client{Timeout: timeout, Transport: newDefaultTransport()},
+		costLog:         NewCostLog(),
+		pendingPayments: NewMemoryPendingPaymentStore(),
+		chain:           chainSolana,
+		solanaKey:       key,
+		solanaRPCURL:    rpcURL,
 	}, nil
 }
 
 // checkEnvAPIURL overrides apiURL with the chain's API-URL env var if still at
-// the chain default. Called after options are applied so user-set URLs win.
+// the chain default, then applies any base path. Called after options are
+// applied so user-set URLs win.
 func (bc *baseClient) checkEnvAPIURL() {
+	defer bc.applyBasePath()
 	if bc.isSolana() {
-		if envURL := os.Getenv("BLOCKRUN_SOLANA_API_URL"); envURL != "" && bc.apiURL == DefaultSolanaAPIURL {
+		if envURL := bc.getenv("BLOCKRUN_SOLANA_API_URL"); envURL != "" && bc.apiURL == DefaultSolanaAPIURL {
 			bc.apiURL = strings.TrimSuffix(envURL, "/")
 		}
 		return
 	}
-	if envURL := os.Getenv("BLOCKRUN_API_URL"); envURL != "" && bc.apiURL == DefaultAPIURL {
+	if envURL := bc.getenv("BLOCKRUN_API_URL"); envURL != "" && bc.apiURL == DefaultAPIURL {
 		bc.apiURL = strings.TrimSuffix(envURL, "/")
 	}
 }
@@ -178,7 +277,7 @@ func (bc *baseClient) pollPaymentPayload(current string, lastSigned time.Time, o
 	if !bc.isSolana() || time.Since(lastSigned) < solanaPollResignInterval {
 		return current, lastSigned
 	}
-	fresh, err := bc.createPaymentPayload(option, resourceURL, description, extensions)
+	fresh, err := bc.createPaymentPayload(context.Background(), option, resourceURL, description, extensions)
 	if err != nil {
 		return current, lastSigned
 	}
@@ -187,13 +286,28 @@ func (bc *baseClient) pollPaymentPayload(current string, lastSigned time.Time, o
 
 // createPaymentPayload signs an x402 payment for the resolved chain. Base uses
 // EIP-712 (secp256k1); Solana uses the SVM exact scheme (ed25519). This is the
-// single signing entry point shared by every payment retry path.
-func (bc *baseClient) createPaymentPayload(option *PaymentOption, resourceURL, description string, extensions map[string]any) (string, error) {
+// single signing entry point shared by every payment retry path. On Base, an
+// option whose scheme is registered with RegisterPaymentScheme is signed by
+// that scheme; anything else is signed as "exact".
+func (bc *baseClient) createPaymentPayload(ctx context.Context, option *PaymentOption, resourceURL, description string, extensions map[string]any) (string, error) {
 	if bc.isSolana() {
 		return CreateSolanaPaymentPayload(bc.solanaKey, option, resourceURL, description, extensions, bc.solanaRPCURL)
 	}
-	return CreatePaymentPayload(
-		bc.privateKey,
+	if scheme := lookupPaymentScheme(option.Scheme); scheme != nil {
+		if _, exact := scheme.(ExactScheme); !exact {
+			if bc.privateKey == nil {
+				return "", fmt.Errorf("payment scheme %q needs a local private key", option.Scheme)
+			}
+			return scheme.CreatePayload(bc.privateKey, option, &ResourceInfo{
+				URL:         resourceURL,
+				Description: description,
+				MimeType:    "application/json",
+			})
+		}
+	}
+	return createPaymentPayloadAt(
+		currentClock().Now().Add(bc.paymentClockOffset(ctx)),
+		bc.paymentSigner(),
 		option.PayTo,
 		option.Amount,
 		option.Network,
@@ -205,6 +319,136 @@ func (bc *baseClient) createPaymentPayload(option *PaymentOption, resourceURL, d
 	)
 }
 
+// signPayment returns the PAYMENT-SIGNATURE for a paid retry. When the call
+// carries an idempotency key and a payload was already signed under it (an
+// earlier attempt never saw its response), that payload is re-submitted
+// instead of signing a second payment; otherwise a fresh payload is signed
+// and remembered under the key until clearPendingPayment. Calls made with
+// WithExternalPayment use the caller's signature, or fail with a
+// PaymentRequiredError when there is none. Errors are ready to return.
+func (bc *baseClient) signPayment(ctx context.Context, option *PaymentOption, resourceURL, description string, extensions map[string]any) (string, error) {
+	if cfg := requestConfigFrom(ctx); cfg != nil && cfg.externalPayment {
+		if cfg.paymentSignature != "" {
+			return cfg.paymentSignature, nil
+		}
+		return "", newPaymentRequiredError(option, resourceURL, description, extensions)
+	}
+
+	key := idempotencyKeyFrom(ctx)
+	if key != "" && bc.pendingPayments != nil {
+		if payload, ok := bc.pendingPayments.Get(key); ok {
+			return payload, nil
+		}
+	}
+	if err := bc.checkBudget(ctx, option); err != nil {
+		return "", err
+	}
+	payload, err := bc.createPaymentPayload(ctx, option, resourceURL, description, extensions)
+	if err != nil {
+		return "", &PaymentError{Message: fmt.Sprintf("Failed to create payment: %v", err)}
+	}
+	if key != "" && bc.pendingPayments != nil {
+		bc.pendingPayments.Put(key, payload)
+	}
+	return payload, nil
+}
+
+// clearPendingPayment forgets the payload signed for this call's idempotency
+// key. Called once the paid retry is answered with a definitive outcome (200,
+// or a 402 rejecting the signature); transport failures and 5xx keep the
+// payload so a retry re-submits it.
+func (bc *baseClient) clearPendingPayment(ctx context.Context) {
+	if key := idempotencyKeyFrom(ctx); key != "" && bc.pendingPayments != nil {
+		bc.pendingPayments.Delete(key)
+	}
+}
+
+// idempotencyKeyFrom returns the idempotency key attached to ctx, if any.
+func idempotencyKeyFrom(ctx context.Context) string {
+	if cfg := requestConfigFrom(ctx); cfg != nil {
+		return cfg.idempotencyKey
+	}
+	return ""
+}
+
+// do sends req through the client's HTTP client after stamping the per-call
+// headers carried on the request context (see RequestOption), backing off
+// and retrying on 429 per the client's RetryPolicy, and applying the
+// client's compression settings and any per-call deadline. Every gateway
+// request — probe, paid retry and poll — goes through here.
+func (bc *baseClient) do(req *http.Request) (*http.Response, error) {
+	if key := idempotencyKeyFrom(req.Context()); key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	if err := bc.setUserMetadata(req); err != nil {
+		return nil, err
+	}
+	if bc.userAgent != "" {
+		req.Header.Set("User-Agent", bc.userAgent)
+	} else {
+		req.Header.Set("User-Agent", DefaultUserAgent)
+	}
+	bc.setTraceHeaders(req)
+	for name, values := range bc.routingHeaders {
+		req.Header[name] = values
+	}
+	if err := bc.authenticate(req); err != nil {
+		return nil, err
+	}
+	if err := bc.compressRequest(req); err != nil {
+		return nil, err
+	}
+	if accept := bc.acceptEncodingHeader(); accept != "" {
+		req.Header.Set("Accept-Encoding", accept)
+	}
+	req, client, cancel := bc.withRequestDeadline(req)
+	start := time.Now()
+	resp, err := bc.sendWithThrottleRetry(client, req)
+	if bc.balancer != nil {
+		bc.balancer.record(req.URL.String(), time.Since(start), err != nil || resp.StatusCode >= 500)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	if len(bc.acceptEncodings) == 0 {
+		return resp, nil
+	}
+	if err := decompressResponse(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// newDefaultTransport returns the transport new clients start with: a clone
+// of http.DefaultTransport that takes its proxy from HTTP_PROXY / HTTPS_PROXY
+// / NO_PROXY (see WithProxyFromEnv, WithHTTPProxy).
+func newDefaultTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return transport
+}
+
+// withTransport applies fn to a clone of the client's *http.Transport and
+// installs it on a copy of the http.Client. Transport options therefore
+// compose with each other and with WithHTTPClient without mutating a client
+// the caller passed in. A non-*http.Transport RoundTripper is replaced by a
+// fresh default transport.
+func (bc *baseClient) withTransport(fn func(*http.Transport)) {
+	var transport *http.Transport
+	if base, ok := bc.httpClient.Transport.(*http.Transport); ok && base != nil {
+		transport = base.Clone()
+	} else {
+		transport = newDefaultTransport()
+	}
+	fn(transport)
+
+	client := *bc.httpClient
+	client.Transport = transport
+	bc.httpClient = &client
+}
+
 // GetWalletAddress returns the wallet address being used for payments.
 func (bc *baseClient) GetWalletAddress() string {
 	return bc.address
@@ -227,11 +471,24 @@ func (bc *baseClient) doRequest(ctx context.Context, endpoint string, body map[s
 	return data, err
 }
 
+// callContext prepares ctx for one API call: it attaches the call's trace
+// and pins its per-call deadline, so every request of the call shares both.
+func (bc *baseClient) callContext(ctx context.Context) context.Context {
+	return pinRequestDeadline(bc.withTrace(ctx), time.Now())
+}
+
 // doRequestHeaders is doRequest plus the final HTTP response headers, for
 // endpoints that surface gateway metadata in headers (e.g. /v1/rpc/{network}
 // returns X-Network / X-Cache / X-Payment-Receipt). Headers are nil when the
 // response was served from the local cache.
 func (bc *baseClient) doRequestHeaders(ctx context.Context, endpoint string, body map[string]any) ([]byte, http.Header, error) {
+	ctx = bc.callContext(ctx)
+	done, err := bc.drain.begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer done()
+
 	// Check cache before making request
 	if bc.cache != nil {
 		if cached, ok := bc.cache.Get(endpoint, body); ok {
@@ -239,22 +496,22 @@ func (bc *baseClient) doRequestHeaders(ctx context.Context, endpoint string, bod
 		}
 	}
 
-	url := bc.apiURL + endpoint
+	url := bc.baseURL() + endpoint
 
 	// Encode body
-	jsonBody, err := json.Marshal(body)
+	reqBody, contentType, err := bc.encodeRequestBody(endpoint, body)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to encode request body: %w", err)
+		return nil, nil, err
 	}
 
 	// First attempt (will likely return 402)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	bc.setBodyHeaders(req, contentType)
 
-	resp, err := bc.httpClient.Do(req)
+	resp, err := bc.do(req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -262,16 +519,13 @@ func (bc *baseClient) doRequestHeaders(ctx context.Context, endpoint string, bod
 
 	// Handle 402 Payment Required
 	if resp.StatusCode == http.StatusPaymentRequired {
-		return bc.handlePaymentAndRetryHeaders(ctx, url, jsonBody, resp)
+		return bc.handlePaymentAndRetryHeaders(ctx, url, reqBody, contentType, resp)
 	}
 
 	// Handle other errors
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("API error: %s", string(bodyBytes)),
-		}
+		return nil, nil, newAPIError(resp.StatusCode, "API error", bodyBytes)
 	}
 
 	// Read successful response
@@ -280,6 +534,13 @@ func (bc *baseClient) doRequestHeaders(ctx context.Context, endpoint string, bod
 		return nil, nil, err
 	}
 
+	// In deferred mode the gateway serves first and quotes the payment
+	if bc.paymentMode == PaymentModeDeferred {
+		if err := bc.deferPayment(ctx, url, modelFromRequest(reqBody, contentType), resp.Header, data); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Store in cache
 	if bc.cache != nil {
 		bc.cache.Set(endpoint, body, data)
@@ -290,6 +551,13 @@ func (bc *baseClient) doRequestHeaders(ctx context.Context, endpoint string, bod
 
 // doGet makes a GET request to the given endpoint and returns raw response bytes.
 func (bc *baseClient) doGet(ctx context.Context, endpoint string) ([]byte, error) {
+	ctx = bc.callContext(ctx)
+	done, err := bc.drain.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	// Check cache before making request
 	if bc.cache != nil {
 		if cached, ok := bc.cache.Get(endpoint, nil); ok {
@@ -297,14 +565,14 @@ func (bc *baseClient) doGet(ctx context.Context, endpoint string) ([]byte, error
 		}
 	}
 
-	url := bc.apiURL + endpoint
+	url := bc.baseURL() + endpoint
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := bc.httpClient.Do(req)
+	resp, err := bc.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -312,10 +580,7 @@ func (bc *baseClient) doGet(ctx context.Context, endpoint string) ([]byte, error
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("API error: %s", string(bodyBytes)),
-		}
+		return nil, newAPIError(resp.StatusCode, "API error", bodyBytes)
 	}
 
 	data, err := io.ReadAll(resp.Body)
@@ -335,7 +600,14 @@ func (bc *baseClient) doGet(ctx context.Context, endpoint string) ([]byte, error
 // and retries. This is used for Pyth-backed market-data endpoints where the
 // same path may be free (crypto/fx/commodity) or paid (stocks/usstock).
 func (bc *baseClient) doGetWithPayment(ctx context.Context, endpoint string, query map[string]string) ([]byte, error) {
-	url := bc.apiURL + endpoint
+	ctx = bc.callContext(ctx)
+	done, err := bc.drain.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	url := bc.baseURL() + endpoint
 	if len(query) > 0 {
 		sep := "?"
 		for k, v := range query {
@@ -349,14 +621,14 @@ func (bc *baseClient) doGetWithPayment(ctx context.Context, endpoint string, que
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := bc.httpClient.Do(req)
+	resp, err := bc.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusPaymentRequired {
-		if bc.privateKey == nil {
+		if bc.privateKey == nil && bc.signer == nil {
 			return nil, &PaymentError{Message: "endpoint returned 402 but no wallet is configured"}
 		}
 		return bc.handleGetPaymentAndRetry(ctx, url, resp)
@@ -364,10 +636,7 @@ func (bc *baseClient) doGetWithPayment(ctx context.Context, endpoint string, que
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("API error: %s", string(bodyBytes)),
-		}
+		return nil, newAPIError(resp.StatusCode, "API error", bodyBytes)
 	}
 
 	return io.ReadAll(resp.Body)
@@ -376,91 +645,60 @@ func (bc *baseClient) doGetWithPayment(ctx context.Context, endpoint string, que
 // handleGetPaymentAndRetry mirrors handlePaymentAndRetry for GET requests
 // (no body to re-send; PAYMENT-SIGNATURE rides on a second GET to the same URL).
 func (bc *baseClient) handleGetPaymentAndRetry(ctx context.Context, url string, resp *http.Response) ([]byte, error) {
-	paymentHeader := resp.Header.Get("payment-required")
-	if paymentHeader == "" {
-		var respBody map[string]any
-		if err := json.NewDecoder(resp.Body).Decode(&respBody); err == nil {
-			if _, ok := respBody["x402"]; ok {
-				jsonBytes, _ := json.Marshal(respBody["x402"])
-				paymentHeader = string(jsonBytes)
-			} else if _, ok := respBody["accepts"]; ok {
-				jsonBytes, _ := json.Marshal(respBody)
-				paymentHeader = string(jsonBytes)
-			}
-		}
-	}
-	if paymentHeader == "" {
-		return nil, &PaymentError{Message: "402 response but no payment requirements found"}
-	}
-
-	paymentReq, err := ParsePaymentRequired(paymentHeader)
+	respBody, _ := io.ReadAll(resp.Body)
+	paymentReq, err := paymentRequirementFromResponse(resp.Header, respBody)
 	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
-	}
-	paymentOption, err := ExtractPaymentDetails(paymentReq)
-	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
-	}
-
-	resourceURL := paymentReq.Resource.URL
-	if resourceURL == "" {
-		resourceURL = url
+		return nil, err
 	}
 
-	paymentPayload, err := bc.createPaymentPayload(paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
-	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to create payment: %v", err)}
-	}
+	for attempt := 1; ; attempt++ {
+		paymentOption, err := ExtractPaymentDetails(paymentReq)
+		if err != nil {
+			return nil, &PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
+		}
 
-	retryReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create retry request: %w", err)
-	}
-	retryReq.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
+		resourceURL := paymentReq.Resource.URL
+		if resourceURL == "" {
+			resourceURL = url
+		}
 
-	retryResp, err := bc.httpClient.Do(retryReq)
-	if err != nil {
-		return nil, fmt.Errorf("retry request failed: %w", err)
-	}
-	defer retryResp.Body.Close()
+		paymentPayload, err := bc.signPayment(ctx, paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
+		if err != nil {
+			return nil, err
+		}
 
-	if retryResp.StatusCode == http.StatusPaymentRequired {
-		return nil, &PaymentError{Message: "Payment was rejected. Check your wallet balance."}
-	}
-	if retryResp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(retryResp.Body)
-		return nil, &APIError{
-			StatusCode: retryResp.StatusCode,
-			Message:    fmt.Sprintf("API error after payment: %s", string(bodyBytes)),
+		retryReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create retry request: %w", err)
 		}
-	}
+		retryReq.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
 
-	respBytes, err := io.ReadAll(retryResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+		retryResp, err := bc.do(retryReq)
+		if err != nil {
+			return nil, fmt.Errorf("retry request failed: %w", err)
+		}
 
-	bc.mu.Lock()
-	bc.sessionCalls++
-	var costUSD float64
-	if amountStr := paymentOption.Amount; amountStr != "" {
-		var amountMicro float64
-		if _, err := fmt.Sscanf(amountStr, "%f", &amountMicro); err == nil {
-			costUSD = amountMicro / 1_000_000
-			bc.sessionTotalUSD += costUSD
+		if retryResp.StatusCode == http.StatusPaymentRequired {
+			if paymentReq, err = bc.rejectedPayment(ctx, retryResp, attempt); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		defer retryResp.Body.Close()
+		if retryResp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(retryResp.Body)
+			return nil, newAPIError(retryResp.StatusCode, "API error after payment", bodyBytes)
 		}
-	}
-	bc.mu.Unlock()
 
-	if bc.costLog != nil && costUSD > 0 {
-		endpoint := strings.TrimPrefix(url, bc.apiURL)
-		if idx := strings.Index(endpoint, "?"); idx != -1 {
-			endpoint = endpoint[:idx]
+		respBytes, err := io.ReadAll(retryResp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
 		}
-		bc.costLog.Append(endpoint, costUSD)
-	}
+		bc.clearPendingPayment(ctx)
+		bc.recordSettledCost(ctx, paymentOption, bc.endpointPath(url), "", paymentPayload)
 
-	return respBytes, nil
+		return respBytes, nil
+	}
 }
 
 // urlQueryEscape is a minimal query-string escaper used by doGetWithPayment.
@@ -485,146 +723,184 @@ func urlQueryEscape(s string) string {
 
 // handlePaymentAndRetry handles a 402 response by signing a payment and retrying.
 func (bc *baseClient) handlePaymentAndRetry(ctx context.Context, url string, body []byte, resp *http.Response) ([]byte, error) {
-	data, _, err := bc.handlePaymentAndRetryHeaders(ctx, url, body, resp)
+	data, _, err := bc.handlePaymentAndRetryHeaders(ctx, url, body, "application/json", resp)
 	return data, err
 }
 
 // handlePaymentAndRetryHeaders is handlePaymentAndRetry plus the retry
-// response headers (settlement receipt, gateway metadata).
-func (bc *baseClient) handlePaymentAndRetryHeaders(ctx context.Context, url string, body []byte, resp *http.Response) ([]byte, http.Header, error) {
-	// Get payment required header
-	paymentHeader := resp.Header.Get("payment-required")
-	if paymentHeader == "" {
-		// Try to get from response body
-		var respBody map[string]any
-		if err := json.NewDecoder(resp.Body).Decode(&respBody); err == nil {
-			if _, ok := respBody["x402"]; ok {
-				// Response body contains payment info - re-encode as header
-				jsonBytes, _ := json.Marshal(respBody)
-				paymentHeader = string(jsonBytes)
-			}
-		}
+// response headers (settlement receipt, gateway metadata), for a body of
+// the given Content-Type.
+func (bc *baseClient) handlePaymentAndRetryHeaders(ctx context.Context, url string, body []byte, contentType string, resp *http.Response) ([]byte, http.Header, error) {
+	// Payment requirements come in the payment-required header or the body
+	respBody, _ := io.ReadAll(resp.Body)
+	paymentReq, err := paymentRequirementFromResponse(resp.Header, respBody)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if paymentHeader == "" {
-		return nil, nil, &PaymentError{Message: "402 response but no payment requirements found"}
-	}
+	for attempt := 1; ; attempt++ {
+		// Extract payment details
+		paymentOption, err := ExtractPaymentDetails(paymentReq)
+		if err != nil {
+			return nil, nil, &PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
+		}
 
-	// Parse payment requirements
-	paymentReq, err := ParsePaymentRequired(paymentHeader)
-	if err != nil {
-		return nil, nil, &PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
-	}
+		// Determine resource URL
+		resourceURL := paymentReq.Resource.URL
+		if resourceURL == "" {
+			resourceURL = url
+		}
 
-	// Extract payment details
-	paymentOption, err := ExtractPaymentDetails(paymentReq)
-	if err != nil {
-		return nil, nil, &PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
-	}
+		// Create signed payment payload
+		paymentPayload, err := bc.signPayment(ctx, paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	// Determine resource URL
-	resourceURL := paymentReq.Resource.URL
-	if resourceURL == "" {
-		resourceURL = url
-	}
+		// Retry with payment signature
+		retryReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create retry request: %w", err)
+		}
+		bc.setBodyHeaders(retryReq, contentType)
+		retryReq.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
 
-	// Create signed payment payload
-	paymentPayload, err := bc.createPaymentPayload(paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
-	if err != nil {
-		return nil, nil, &PaymentError{Message: fmt.Sprintf("Failed to create payment: %v", err)}
-	}
+		retryResp, err := bc.do(retryReq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("retry request failed: %w", err)
+		}
 
-	// Retry with payment signature
-	retryReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create retry request: %w", err)
-	}
-	retryReq.Header.Set("Content-Type", "application/json")
-	retryReq.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
+		// A rejected payment is re-signed against the new requirements
+		// until the retry limit
+		if retryResp.StatusCode == http.StatusPaymentRequired {
+			if paymentReq, err = bc.rejectedPayment(ctx, retryResp, attempt); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		defer retryResp.Body.Close()
 
-	retryResp, err := bc.httpClient.Do(retryReq)
-	if err != nil {
-		return nil, nil, fmt.Errorf("retry request failed: %w", err)
-	}
-	defer retryResp.Body.Close()
+		// Handle other errors
+		if retryResp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(retryResp.Body)
+			return nil, nil, newAPIError(retryResp.StatusCode, "API error after payment", bodyBytes)
+		}
+
+		// Read successful response
+		respBytes, err := io.ReadAll(retryResp.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		bc.clearPendingPayment(ctx)
 
-	// Check for payment rejection
-	if retryResp.StatusCode == http.StatusPaymentRequired {
-		return nil, nil, &PaymentError{Message: "Payment was rejected. Check your wallet balance."}
+		// Track spending, cost log and receipt
+		bc.recordSettledCost(ctx, paymentOption, bc.endpointPath(url), modelFromRequest(body, contentType), paymentPayload)
+
+		return respBytes, retryResp.Header, nil
 	}
+}
 
-	// Handle other errors
-	if retryResp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(retryResp.Body)
-		return nil, nil, &APIError{
-			StatusCode: retryResp.StatusCode,
-			Message:    fmt.Sprintf("API error after payment: %s", string(bodyBytes)),
-		}
+// recordSettledCost tracks spending for a settled payment: the session
+// totals, the JSONL cost log and, when a ReceiptStore is configured, a
+// Receipt carrying the call's cost tags. Every paid path — synchronous,
+// streaming and the async submit→poll flows (video, slow-path images, which
+// charge only once a poll observes "completed") — funnels through here.
+// payload is the PAYMENT-SIGNATURE that settled, whose nonce and resource
+// URL the receipt records.
+func (bc *baseClient) recordSettledCost(ctx context.Context, option *PaymentOption, endpoint, model, payload string) {
+	cfg := requestConfigFrom(ctx)
+	if cfg != nil && cfg.externalPayment {
+		return // the caller's wallet paid, not ours
 	}
+	shadow := cfg != nil && cfg.shadow
 
-	// Read successful response
-	respBytes, err := io.ReadAll(retryResp.Body)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	costUSD := optionCostUSD(option)
+	if cfg != nil && cfg.onCost != nil {
+		cfg.onCost(costUSD)
 	}
 
-	// Track spending - convert amount from micro-USDC to USD
 	bc.mu.Lock()
 	bc.sessionCalls++
-	var costUSD float64
-	if amountStr := paymentOption.Amount; amountStr != "" {
-		var amountMicro float64
-		if _, err := fmt.Sscanf(amountStr, "%f", &amountMicro); err == nil {
-			costUSD = amountMicro / 1_000_000
-			bc.sessionTotalUSD += costUSD
+	if costUSD > 0 {
+		bc.sessionTotalUSD += costUSD
+		if shadow {
+			bc.shadowSpentUSD += costUSD
 		}
 	}
+	tags := bc.costTagsFor(cfg)
+	now := time.Now()
+	total := bc.sessionTotalUSD
+	due := bc.dueAlerts(total, now)
 	bc.mu.Unlock()
+	bc.sendAlerts(due, total, now)
+	setTagAttributes(ctx, tags)
+	if bc.analytics != nil {
+		bc.analytics.add(currentClock().Now(), costUSD)
+	}
 
-	// Log cost to persistent JSONL file
 	if bc.costLog != nil && costUSD > 0 {
-		endpoint := strings.TrimPrefix(url, bc.apiURL)
-		bc.costLog.Append(endpoint, costUSD)
+		bc.costLog.write(CostLogEntry{Endpoint: endpoint, CostUSD: costUSD, Shadow: shadow})
+	}
+	if bc.receipts != nil {
+		nonce, resourceURL := paymentPayloadDetails(payload)
+		bc.receipts.Save(Receipt{
+			Timestamp:   time.Now().UTC(),
+			Endpoint:    endpoint,
+			Model:       model,
+			Provider:    ModelProvider(model),
+			Amount:      option.Amount,
+			CostUSD:     costUSD,
+			Network:     option.Network,
+			PayTo:       option.PayTo,
+			Nonce:       nonce,
+			ResourceURL: resourceURL,
+			Tags:        tags,
+			Shadow:      shadow,
+		})
 	}
-
-	return respBytes, retryResp.Header, nil
 }
 
-// recordSettledCost tracks spending for a paid call whose settlement happens
-// outside handlePaymentAndRetryHeaders — the async submit→poll flows (video,
-// slow-path images), which charge only once a poll observes "completed". It
-// mirrors the session accounting and JSONL cost log of the synchronous path.
-func (bc *baseClient) recordSettledCost(amount, endpoint string) {
-	var costUSD float64
-	if amount != "" {
-		var amountMicro float64
-		if _, err := fmt.Sscanf(amount, "%f", &amountMicro); err == nil {
-			costUSD = amountMicro / 1_000_000
-		}
+// endpointPath strips the API base URL and any query string from url,
+// leaving the endpoint path used for cost accounting (e.g. "/v1/search").
+func (bc *baseClient) endpointPath(url string) string {
+	endpoint, ok := url, false
+	if bc.balancer != nil {
+		endpoint, ok = bc.balancer.trimEndpoint(url)
 	}
-
-	bc.mu.Lock()
-	bc.sessionCalls++
-	if costUSD > 0 {
-		bc.sessionTotalUSD += costUSD
+	if !ok {
+		endpoint = strings.TrimPrefix(url, bc.apiURL)
 	}
-	bc.mu.Unlock()
+	if idx := strings.Index(endpoint, "?"); idx != -1 {
+		endpoint = endpoint[:idx]
+	}
+	return endpoint
+}
 
-	if bc.costLog != nil && costUSD > 0 {
-		bc.costLog.Append(endpoint, costUSD)
+// modelFromBody returns the "model" field of a JSON request body, if any.
+func modelFromBody(body []byte) string {
+	var probe struct {
+		Model string `json:"model"`
 	}
+	if json.Unmarshal(body, &probe) != nil {
+		return ""
+	}
+	return probe.Model
 }
 
 // resolvePollURL resolves a server-supplied relative poll_url against the API
 // host. poll_url comes back as "/api/v1/...": apiURL already ends in "/api",
-// so strip that once to avoid "/api/api/...".
+// so strip that once to avoid "/api/api/...". A base path stays in front of
+// the endpoint, as for every other request.
 func (bc *baseClient) resolvePollURL(u string) string {
 	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
 		return u
 	}
-	base := bc.apiURL
+	base := strings.TrimSuffix(bc.apiURL, bc.basePath)
 	if strings.HasSuffix(base, "/api") {
+		if rest, ok := strings.CutPrefix(u, "/api/"); ok {
+			return bc.apiURL + "/" + rest
+		}
 		base = strings.TrimSuffix(base, "/api")
 	}
-	return base + u
+	return base + bc.basePath + u
 }
@@ -0,0 +1,45 @@
+package blockrun
+
+import "strings"
+
+// WithBasePath inserts path between the API URL and every endpoint, for
+// gateways that serve BlockRun under a prefix: with "/internal", chat
+// completions go to {apiURL}/internal/v1/chat/completions. Trailing slashes
+// are dropped; a path that fails ValidateBasePath is ignored with a warning.
+// The prefix also applies to every WithEndpoints endpoint.
+func WithBasePath(path string) ClientOption {
+	return func(c *LLMClient) {
+		c.setBasePath("WithBasePath", path)
+	}
+}
+
+// WithImageBasePath is WithBasePath for an ImageClient.
+func WithImageBasePath(path string) ImageClientOption {
+	return func(c *ImageClient) {
+		c.setBasePath("WithImageBasePath", path)
+	}
+}
+
+// setBasePath records the normalised base path for applyBasePath.
+func (bc *baseClient) setBasePath(option, path string) {
+	path = strings.TrimRight(path, "/")
+	if err := ValidateBasePath(path); err != nil {
+		bc.warnf("%s: %v; ignoring", option, err)
+		return
+	}
+	bc.basePath = path
+}
+
+// applyBasePath appends the base path to the API URL and to each load
+// balancer endpoint. It runs once, after options and environment overrides.
+func (bc *baseClient) applyBasePath() {
+	if bc.basePath == "" {
+		return
+	}
+	bc.apiURL += bc.basePath
+	if bc.balancer != nil {
+		for i := range bc.balancer.stats {
+			bc.balancer.stats[i].URL += bc.basePath
+		}
+	}
+}
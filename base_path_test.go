@@ -0,0 +1,83 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithBasePath(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/internal/v1/chat/completions":
+			w.Write([]byte(testChatResponseJSON))
+		case "/internal/v1/images/generations":
+			w.Write([]byte(`{"created":1700000000,"data":[{"url":"https://example.com/cat.png"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithBasePath("/internal/"))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	if _, err := client.Chat(context.Background(), "openai/gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/internal/v1/chat/completions" {
+		t.Errorf("expected /internal/v1/chat/completions, got %v", paths)
+	}
+	if got := client.endpointPath(client.apiURL + "/v1/chat/completions"); got != "/v1/chat/completions" {
+		t.Errorf("endpointPath = %q", got)
+	}
+
+	images, err := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL), WithImageBasePath("/internal"))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+	paths = nil
+	if _, err := images.Generate(context.Background(), "a cat", nil); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if paths[len(paths)-1] != "/internal/v1/images/generations" {
+		t.Errorf("expected /internal/v1/images/generations, got %v", paths)
+	}
+
+	ignored, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithBasePath("internal"))
+	if ignored.apiURL != server.URL {
+		t.Errorf("expected an invalid base path to be ignored, got API URL %q", ignored.apiURL)
+	}
+}
+
+func TestWithBasePathEndpointsAndPolls(t *testing.T) {
+	client, err := NewLLMClient(testPrivateKey,
+		WithEndpoints([]string{"https://a.example.com", "https://b.example.com/"}, nil),
+		WithBasePath("/gw/blockrun"))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	for _, e := range client.EndpointStats() {
+		if e.URL != "https://a.example.com/gw/blockrun" && e.URL != "https://b.example.com/gw/blockrun" {
+			t.Errorf("unexpected endpoint %q", e.URL)
+		}
+	}
+
+	tests := []struct {
+		apiURL, poll, want string
+	}{
+		{"https://blockrun.ai/api", "/api/v1/videos/job1", "https://blockrun.ai/api/gw/v1/videos/job1"},
+		{"http://127.0.0.1:8080", "/v1/videos/job1", "http://127.0.0.1:8080/gw/v1/videos/job1"},
+	}
+	for _, tt := range tests {
+		bc := &baseClient{apiURL: tt.apiURL, basePath: "/gw"}
+		bc.applyBasePath()
+		if got := bc.resolvePollURL(tt.poll); got != tt.want {
+			t.Errorf("resolvePollURL(%q) with API URL %q = %q, want %q", tt.poll, tt.apiURL, got, tt.want)
+		}
+	}
+}
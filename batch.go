@@ -0,0 +1,56 @@
+package blockrun
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultBatchConcurrency is the number of in-flight requests
+// BatchChatCompletion uses when concurrency is not positive.
+const DefaultBatchConcurrency = 10
+
+// BatchRequest is one chat completion in a BatchChatCompletion call.
+type BatchRequest struct {
+	// ID is copied to the matching BatchResult.
+	ID       string
+	Model    string
+	Messages []ChatMessage
+	Options  *ChatCompletionOptions
+}
+
+// BatchResult is the outcome of one BatchRequest.
+type BatchResult struct {
+	ID       string
+	Response *ChatResponse
+	Err      error
+	Latency  time.Duration
+}
+
+// BatchChatCompletion runs requests with at most concurrency in flight and
+// returns their results in input order. A failed request sets its result's
+// Err without stopping the others; the returned error is non-nil only if ctx
+// ends before the batch completes. Payments count toward the session total
+// like any other call.
+func (c *LLMClient) BatchChatCompletion(ctx context.Context, requests []BatchRequest, concurrency int) ([]BatchResult, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	results := make([]BatchResult, len(requests))
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, req := range requests {
+		i, req := i, req
+		g.Go(func() error {
+			start := time.Now()
+			resp, err := c.ChatCompletion(ctx, req.Model, req.Messages, req.Options)
+			results[i] = BatchResult{ID: req.ID, Response: resp, Err: err, Latency: time.Since(start)}
+			return nil
+		})
+	}
+	g.Wait()
+
+	return results, ctx.Err()
+}
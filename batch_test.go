@@ -0,0 +1,117 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchChatCompletionOrdering(t *testing.T) {
+	var inflight, maxInflight atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			m := maxInflight.Load()
+			if n <= m || maxInflight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		var body struct {
+			Messages []ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		i, _ := strconv.Atoi(strings.TrimPrefix(body.Messages[0].Content, "prompt "))
+		// Later prompts finish first.
+		time.Sleep(time.Duration(20-i) * time.Millisecond)
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":"answer %d"}}]}`, i)
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	requests := make([]BatchRequest, 20)
+	for i := range requests {
+		requests[i] = BatchRequest{
+			ID:       fmt.Sprintf("req-%d", i),
+			Model:    "gpt-4o",
+			Messages: []ChatMessage{{Role: "user", Content: fmt.Sprintf("prompt %d", i)}},
+		}
+	}
+
+	results, err := client.BatchChatCompletion(context.Background(), requests, 5)
+	if err != nil {
+		t.Fatalf("BatchChatCompletion: %v", err)
+	}
+	if len(results) != len(requests) {
+		t.Fatalf("expected %d results, got %d", len(requests), len(results))
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("result %d: %v", i, r.Err)
+		}
+		if r.ID != requests[i].ID || r.Response.Choices[0].Message.Content != fmt.Sprintf("answer %d", i) {
+			t.Errorf("result %d out of order: %s / %q", i, r.ID, r.Response.Choices[0].Message.Content)
+		}
+		if r.Latency <= 0 {
+			t.Errorf("result %d has no latency", i)
+		}
+	}
+	if got := maxInflight.Load(); got > 5 {
+		t.Errorf("expected at most 5 requests in flight, saw %d", got)
+	}
+}
+
+func TestBatchChatCompletionSpendingAndErrors(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000") // $0.001 per call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+
+	requests := make([]BatchRequest, 10)
+	for i := range requests {
+		requests[i] = BatchRequest{ID: strconv.Itoa(i), Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "hi"}}}
+	}
+	requests[3].Model = "" // fails validation without stopping the batch
+
+	results, err := client.BatchChatCompletion(context.Background(), requests, 0)
+	if err != nil {
+		t.Fatalf("BatchChatCompletion: %v", err)
+	}
+	for i, r := range results {
+		if (r.Err != nil) != (i == 3) {
+			t.Errorf("result %d: unexpected error state %v", i, r.Err)
+		}
+	}
+	spending := client.GetSpending()
+	if spending.Calls != 9 || math.Abs(spending.TotalUSD-0.009) > 1e-9 {
+		t.Errorf("expected 9 paid calls totalling $0.009, got %+v", spending)
+	}
+}
+
+func TestBatchChatCompletionCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL("http://127.0.0.1:1"))
+	results, err := client.BatchChatCompletion(ctx, []BatchRequest{{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "hi"}}}}, 1)
+	if err == nil || len(results) != 1 || results[0].Err == nil {
+		t.Errorf("expected a canceled batch, got %v, %+v", err, results)
+	}
+}
@@ -0,0 +1,227 @@
+// Package cdp signs BlockRun payments with a Coinbase Developer Platform
+// (CDP) MPC wallet, so the wallet's key never exists on this machine:
+//
+//	signer, _ := cdp.NewCDPMPCSigner(apiKeyName, apiKeySecret, walletID, address)
+//	client, _ := blockrun.NewLLMClientWithSigner(signer)
+//
+// Each payment hash is signed through the CDP payload signature API,
+// authenticated with a short-lived ES256 JWT made from the API key.
+package cdp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// DefaultBaseURL is the CDP platform API.
+const DefaultBaseURL = "https://api.cdp.coinbase.com/platform"
+
+const (
+	// jwtLifetime is how long a request's JWT is valid.
+	jwtLifetime = 2 * time.Minute
+	// defaultPollInterval and defaultSignTimeout bound the wait for a
+	// pending signature.
+	defaultPollInterval = 500 * time.Millisecond
+	defaultSignTimeout  = 30 * time.Second
+)
+
+// Signer is a blockrun.MPCSigner backed by a CDP wallet address. It is safe
+// for concurrent use.
+type Signer struct {
+	apiKey     string
+	key        *ecdsa.PrivateKey
+	walletID   string
+	addressID  string
+	baseURL    string
+	httpClient *http.Client
+
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+var _ blockrun.MPCSigner = (*Signer)(nil)
+
+// Option configures a Signer.
+type Option func(*Signer)
+
+// WithBaseURL sends requests to url instead of DefaultBaseURL.
+func WithBaseURL(url string) Option {
+	return func(s *Signer) {
+		s.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithHTTPClient sends requests with client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Signer) {
+		s.httpClient = client
+	}
+}
+
+// NewCDPMPCSigner returns a signer for the address addressID (the address
+// itself, as CDP uses) of wallet walletID. apiKey is the CDP API key name and
+// apiSecret its PEM EC private key; literal "\n" sequences, as found in
+// downloaded key files, are accepted.
+func NewCDPMPCSigner(apiKey, apiSecret, walletID, addressID string, opts ...Option) (blockrun.MPCSigner, error) {
+	if apiKey == "" || walletID == "" || addressID == "" {
+		return nil, &blockrun.ValidationError{Field: "cdp", Message: "apiKey, walletID and addressID are required"}
+	}
+	key, err := parseAPISecret(apiSecret)
+	if err != nil {
+		return nil, &blockrun.ValidationError{Field: "apiSecret", Message: err.Error()}
+	}
+	s := &Signer{
+		apiKey:       apiKey,
+		key:          key,
+		walletID:     walletID,
+		addressID:    addressID,
+		baseURL:      DefaultBaseURL,
+		httpClient:   &http.Client{Timeout: defaultSignTimeout},
+		pollInterval: defaultPollInterval,
+		timeout:      defaultSignTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// parseAPISecret parses a CDP API key secret.
+func parseAPISecret(secret string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.ReplaceAll(secret, `\n`, "\n")))
+	if block == nil {
+		return nil, errors.New("apiSecret is not a PEM key")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("apiSecret is not an EC private key: %v", err)
+		}
+		var ok bool
+		if key, ok = parsed.(*ecdsa.PrivateKey); !ok {
+			return nil, errors.New("apiSecret is not an EC private key")
+		}
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, errors.New("apiSecret must be a P-256 key")
+	}
+	return key, nil
+}
+
+// Address implements blockrun.MPCSigner.
+func (s *Signer) Address() string {
+	return s.addressID
+}
+
+// payloadSignature is a CDP payload signature resource.
+type payloadSignature struct {
+	ID        string `json:"payload_signature_id"`
+	Status    string `json:"status"` // "pending", "signed" or "failed"
+	Signature string `json:"signature"`
+}
+
+// Sign implements blockrun.MPCSigner. It asks CDP to sign hash and waits for
+// the signature while CDP reports it pending.
+func (s *Signer) Sign(hash []byte) ([]byte, error) {
+	path := "/v1/wallets/" + url.PathEscape(s.walletID) + "/addresses/" + url.PathEscape(s.addressID) + "/payload_signatures"
+	body, _ := json.Marshal(map[string]string{"unsigned_payload": hex.EncodeToString(hash)})
+	sig, err := s.call("POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	for sig.Status == "pending" {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("cdp: signature %s still pending after %s", sig.ID, s.timeout)
+		}
+		time.Sleep(s.pollInterval)
+		if sig, err = s.call("GET", path+"/"+url.PathEscape(sig.ID), nil); err != nil {
+			return nil, err
+		}
+	}
+	if sig.Status != "signed" {
+		return nil, fmt.Errorf("cdp: signature %s %s", sig.ID, sig.Status)
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(sig.Signature, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("cdp: invalid signature %q: %w", sig.Signature, err)
+	}
+	return signature, nil
+}
+
+// call sends an authenticated request to the CDP API.
+func (s *Signer) call(method, path string, body []byte) (*payloadSignature, error) {
+	endpoint := s.baseURL + path
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("cdp: failed to create request: %w", err)
+	}
+	token, err := s.jwt(method, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cdp: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("cdp: %s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var sig payloadSignature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("cdp: failed to decode response: %w", err)
+	}
+	return &sig, nil
+}
+
+// jwt returns the ES256 bearer token CDP requires for a request to u.
+func (s *Signer) jwt(method string, u *url.URL) (string, error) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	now := time.Now().Unix()
+	header, _ := json.Marshal(map[string]string{
+		"alg": "ES256", "typ": "JWT", "kid": s.apiKey, "nonce": hex.EncodeToString(nonce),
+	})
+	claims, _ := json.Marshal(map[string]any{
+		"sub": s.apiKey,
+		"iss": "cdp",
+		"nbf": now,
+		"exp": now + int64(jwtLifetime/time.Second),
+		"uri": method + " " + u.Host + u.Path,
+	})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, sigS, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("cdp: failed to sign JWT: %w", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	sigS.FillBytes(sig[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
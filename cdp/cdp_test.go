@@ -0,0 +1,171 @@
+package cdp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+	"github.com/BlockRunAI/blockrun-llm-go/testfacilitator"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// walletKey stands in for the key CDP holds in its MPC service.
+const walletKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// newAPIKey returns a CDP API key secret in PEM form and its public key.
+func newAPIKey(t *testing.T) (string, *ecdsa.PublicKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalECPrivateKey(key)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), &key.PublicKey
+}
+
+// verifyJWT checks a bearer token's ES256 signature and returns its claims.
+func verifyJWT(t *testing.T, pub *ecdsa.PublicKey, auth string) map[string]any {
+	parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+	if len(parts) != 3 {
+		t.Errorf("malformed JWT %q", auth)
+		return nil
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Error("JWT signature does not verify")
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]any
+	json.Unmarshal(payload, &claims)
+	return claims
+}
+
+// newMockCDP serves the payload signature API, answering each request
+// pending once before signing with walletKey. It returns the server and the
+// signatures it has issued.
+func newMockCDP(t *testing.T, pub *ecdsa.PublicKey, walletID, address string) (*httptest.Server, func() []string) {
+	key, _ := crypto.HexToECDSA(walletKey)
+	var mu sync.Mutex
+	pending := map[string][]byte{}
+	var issued []string
+	base := "/platform/v1/wallets/" + walletID + "/addresses/" + address + "/payload_signatures"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := verifyJWT(t, pub, r.Header.Get("Authorization"))
+		if claims["sub"] != "organizations/o/apiKeys/k" || claims["uri"] != r.Method+" "+r.Host+r.URL.Path {
+			t.Errorf("unexpected JWT claims %v", claims)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "POST" && r.URL.Path == base:
+			var body struct {
+				UnsignedPayload string `json:"unsigned_payload"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			hash, _ := hex.DecodeString(body.UnsignedPayload)
+			id := "sig-" + body.UnsignedPayload[:8]
+			pending[id] = hash
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"payload_signature_id": id, "status": "pending"})
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, base+"/"):
+			id := strings.TrimPrefix(r.URL.Path, base+"/")
+			sig, err := crypto.Sign(pending[id], key)
+			if err != nil {
+				t.Errorf("mock signing failed: %v", err)
+			}
+			sig[64] += 27
+			signature := "0x" + hex.EncodeToString(sig)
+			issued = append(issued, signature)
+			json.NewEncoder(w).Encode(map[string]string{"payload_signature_id": id, "status": "signed", "signature": signature})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), issued...)
+	}
+}
+
+func TestCDPSignerPaysThroughClient(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // keep the cost log out of the real home
+
+	key, _ := crypto.HexToECDSA(walletKey)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	secret, pub := newAPIKey(t)
+	cdpServer, issued := newMockCDP(t, pub, "wallet-1", address)
+
+	signer, err := NewCDPMPCSigner("organizations/o/apiKeys/k", strings.ReplaceAll(secret, "\n", `\n`), "wallet-1", address,
+		WithBaseURL(cdpServer.URL+"/platform"))
+	if err != nil {
+		t.Fatalf("NewCDPMPCSigner: %v", err)
+	}
+	signer.(*Signer).pollInterval = 0
+
+	f := testfacilitator.NewInProcessFacilitator()
+	f.SetBalance(address, 1_000_000)
+	var paid []string
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"paid"},"finish_reason":"stop"}]}`))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sig := r.Header.Get("PAYMENT-SIGNATURE"); sig != "" {
+			paid = append(paid, sig)
+		}
+		f.Middleware(api).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client, err := blockrun.NewLLMClientWithSigner(signer, blockrun.WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClientWithSigner: %v", err)
+	}
+	if client.GetWalletAddress() != address {
+		t.Errorf("expected the client to pay from %s, got %s", address, client.GetWalletAddress())
+	}
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if len(paid) != 1 || len(issued()) != 1 {
+		t.Fatalf("expected one CDP signature used for one payment, got %d and %d", len(issued()), len(paid))
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(paid[0])
+	var payload blockrun.PaymentPayload
+	json.Unmarshal(decoded, &payload)
+	if payload.Payload.Signature != issued()[0] {
+		t.Errorf("expected the CDP signature %s in the payload, got %s", issued()[0], payload.Payload.Signature)
+	}
+	if len(f.Transactions()) != 1 {
+		t.Error("expected the facilitator to settle the CDP-signed payment")
+	}
+}
+
+func TestNewCDPMPCSignerValidation(t *testing.T) {
+	secret, _ := newAPIKey(t)
+	if _, err := NewCDPMPCSigner("", secret, "w", "0x1"); err == nil {
+		t.Error("expected a missing API key to be rejected")
+	}
+	if _, err := NewCDPMPCSigner("k", "not a key", "w", "0x1"); err == nil {
+		t.Error("expected an invalid secret to be rejected")
+	}
+	if _, err := blockrun.NewLLMClientWithSigner(nil); err == nil {
+		t.Error("expected a nil signer to be rejected")
+	}
+}
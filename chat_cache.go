@@ -0,0 +1,61 @@
+package blockrun
+
+import (
+	"context"
+	"slices"
+)
+
+// ChatCache serves ChatCompletion responses without a paid gateway call.
+// Get is consulted before the request is sent; on a miss, a successful
+// response is handed to Put. body is the JSON request body with every
+// option applied (thinking, audio output, provider options, tools and so
+// on); a cache of exact repeats should key on it rather than on messages
+// alone. Put should store, and Get return, a Clone of the response, so a
+// caller editing its response cannot change it for later hits. Implementations must be
+// safe for concurrent use. See the semantic package for an embedding-based
+// implementation.
+type ChatCache interface {
+	Get(ctx context.Context, model string, messages []ChatMessage, body []byte) (*ChatResponse, bool)
+	Put(ctx context.Context, model string, messages []ChatMessage, body []byte, resp *ChatResponse)
+}
+
+// WithChatCache installs a ChatCache in front of ChatCompletion.
+func WithChatCache(cache ChatCache) ClientOption {
+	return func(c *LLMClient) {
+		c.chatCache = cache
+	}
+}
+
+// Clone returns a copy of r that shares no slices or pointers with it, so
+// either can be modified without affecting the other.
+func (r *ChatResponse) Clone() *ChatResponse {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Citations = slices.Clone(r.Citations)
+	if r.Choices != nil {
+		c.Choices = make([]Choice, len(r.Choices))
+		for i, choice := range r.Choices {
+			choice.Message = choice.Message.clone()
+			c.Choices[i] = choice
+		}
+	}
+	return &c
+}
+
+// clone returns a copy of m that shares no slices or pointers with it.
+// Content parts are values, so copying the slice is enough.
+func (m ChatMessage) clone() ChatMessage {
+	m.ToolCalls = slices.Clone(m.ToolCalls)
+	m.ContentParts = slices.Clone(m.ContentParts)
+	if m.Audio != nil {
+		audio := *m.Audio
+		m.Audio = &audio
+	}
+	if m.CacheControl != nil {
+		cc := *m.CacheControl
+		m.CacheControl = &cc
+	}
+	return m
+}
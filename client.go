@@ -12,6 +12,9 @@ import (
 )
 
 const (
+	// Version is the SDK release version, kept in sync with the VERSION file.
+	Version = "0.19.0"
+
 	// DefaultAPIURL is the default BlockRun API endpoint.
 	DefaultAPIURL = "https://blockrun.ai/api"
 
@@ -45,6 +48,49 @@ func defaultTimeout() time.Duration {
 // The key NEVER leaves your machine - only signatures are transmitted.
 type LLMClient struct {
 	*baseClient
+
+	// dedup collapses identical concurrent ChatCompletion calls; nil disables.
+	dedup *DeduplicationFilter
+	// chatCache answers ChatCompletion calls before payment; nil disables.
+	chatCache ChatCache
+	// health runs background health polling; nil disables.
+	health *healthChecker
+	// defaultModel and defaultMaxTokens fill in ChatCompletion calls that
+	// leave them unset (see WithDefaultModel, WithDefaultMaxTokens).
+	defaultModel     string
+	defaultMaxTokens int
+	// queue limits concurrent chat calls by priority; nil disables.
+	queue *PriorityQueue
+	// providerLimits caps concurrent chat calls per provider; nil disables
+	// (see WithProviderConcurrency).
+	providerLimits *providerLimiter
+	// shadow mirrors ChatCompletion calls to a second model; nil disables.
+	shadow *shadowConfig
+	// rpcURL overrides the public Base RPCs for balance reads, and
+	// balanceMonitor polls it (see WithRPCURL).
+	rpcURL         string
+	balanceMonitor *BalanceMonitor
+	// ensRPCURL is the Ethereum endpoint for ENS lookups (see WithENSRPCURL).
+	ensRPCURL string
+	// models caches ListModels results; nil disables.
+	models *modelCache[Model]
+	// maxRequestBodySize and maxPromptLength cap chat requests; 0 means no
+	// limit (see WithMaxRequestBodySize, WithMaxPromptLength).
+	maxRequestBodySize int64
+	maxPromptLength    int
+	// defaultTaskType picks a model for calls without one (see
+	// WithDefaultTaskType).
+	defaultTaskType TaskType
+	// autoExtend is how many times a truncated ChatCompletion is continued
+	// (see WithAutoExtend).
+	autoExtend int
+	// modelValidation checks models against the catalogue before a call;
+	// nil disables (see WithEagerModelValidation).
+	modelValidation *modelValidator
+	// anomalyDetector inspects chat requests, handled per anomalyAction;
+	// nil disables (see WithAnomalyDetection).
+	anomalyDetector RequestAnomalyDetector
+	anomalyAction   string
 }
 
 // Spending represents session spending information.
@@ -70,9 +116,14 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
-// WithHTTPClient sets a custom HTTP client.
+// WithHTTPClient sets a custom HTTP client. It replaces any earlier
+// WithConnectionPool / WithHTTP2 settings (a warning is logged).
 func WithHTTPClient(client *http.Client) ClientOption {
 	return func(c *LLMClient) {
+		if c.transportTuned {
+			c.warnf("WithHTTPClient overrides earlier connection pool settings")
+			c.transportTuned = false
+		}
 		c.httpClient = client
 	}
 }
@@ -93,26 +144,43 @@ func WithCache(enabled bool) ClientOption {
 // NewLLMClient creates a new BlockRun LLM client.
 //
 // If privateKey is empty, it will be read from the BLOCKRUN_WALLET_KEY or
-// BASE_CHAIN_WALLET_KEY environment variable.
+// BASE_CHAIN_WALLET_KEY environment variable (prefixed per WithEnvPrefix).
 //
 // SECURITY: Your private key is used ONLY for local EIP-712 signing.
 // The key NEVER leaves your machine - only signatures are transmitted.
 func NewLLMClient(privateKey string, opts ...ClientOption) (*LLMClient, error) {
-	bc, err := newBaseClient(privateKey, "", defaultTimeout())
-	if err != nil {
-		return nil, err
-	}
+	bc := newUnkeyedBaseClient("", defaultTimeout())
 
-	client := &LLMClient{baseClient: bc}
+	client := &LLMClient{baseClient: bc, models: &modelCache[Model]{ttl: DefaultModelCacheTTL}}
 
 	// Apply options
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	// Load the key after options so WithEnvPrefix applies
+	if bc.signer != nil {
+		if err := bc.useSigner(); err != nil {
+			return nil, err
+		}
+	} else if err := bc.loadKey(privateKey); err != nil {
+		return nil, err
+	}
+
 	// Check for custom API URL in environment (after options so user-set URLs win)
 	bc.checkEnvAPIURL()
 
+	if client.rpcURL != "" {
+		client.balanceMonitor = NewBalanceMonitor(client.rpcURL, bc.address, DefaultBalanceMonitorInterval)
+	}
+
+	if client.health != nil {
+		client.startHealthChecks()
+	}
+	if bc.analytics != nil {
+		bc.analytics.start()
+	}
+
 	return client, nil
 }
 
@@ -145,14 +213,63 @@ func (c *LLMClient) ChatWithSystem(ctx context.Context, model, prompt, system st
 }
 
 // ChatCompletion sends a full chat completion request (OpenAI-compatible).
-func (c *LLMClient) ChatCompletion(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions) (*ChatResponse, error) {
+// With WithAutoExtend, a response cut off at max_tokens is continued and
+// the parts joined (see ChatCompletionExtended).
+//
+// reqOpts apply to this call only (e.g. WithIdempotencyKey).
+func (c *LLMClient) ChatCompletion(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions, reqOpts ...RequestOption) (*ChatResponse, error) {
+	ctx = WithRequestOptions(ctx, reqOpts...)
+	ctx = pinRequestDeadline(ctx, time.Now())
+	if c.autoExtend <= 0 {
+		return c.chatCompletion(ctx, model, messages, opts)
+	}
+	resp, err := c.ChatCompletionExtended(ctx, model, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.ChatResponse, nil
+}
+
+// chatCompletion makes a single chat completion call.
+func (c *LLMClient) chatCompletion(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions) (*ChatResponse, error) {
+
 	// Validate inputs
+	if model == "" {
+		model = c.defaultModel
+	}
+	if model == "" && len(messages) > 0 {
+		selected, err := c.taskModel(ctx, messages, c.outputTokens(opts))
+		if err != nil {
+			return nil, err
+		}
+		model = selected
+	}
+	model = c.ResolveModel(model)
 	if model == "" {
 		return nil, &ValidationError{Field: "model", Message: "Model is required"}
 	}
 	if len(messages) == 0 {
 		return nil, &ValidationError{Field: "messages", Message: "At least one message is required"}
 	}
+	if err := c.checkPromptLength(messages); err != nil {
+		return nil, err
+	}
+	if err := c.validateModel(ctx, model); err != nil {
+		return nil, err
+	}
+
+	if opts != nil && opts.BufferedStream {
+		return c.withResponseSchema(ctx, opts, func(ctx context.Context) (*ChatResponse, error) {
+			return c.bufferedCompletion(ctx, model, messages, opts)
+		})
+	}
+	if opts != nil && opts.Stream {
+		return nil, &StreamingError{Message: "ChatCompletion cannot return a stream; use ChatCompletionStream, or set BufferedStream to receive the assembled response"}
+	}
+	if err := c.checkAnomalies(ctx, model, messages, opts); err != nil {
+		return nil, err
+	}
+	c.warnContextUsage(model, messages)
 
 	// Build request body
 	body := map[string]any{
@@ -162,6 +279,9 @@ func (c *LLMClient) ChatCompletion(ctx context.Context, model string, messages [
 
 	// Apply options
 	maxTokens := DefaultMaxTokens
+	if c.defaultMaxTokens > 0 {
+		maxTokens = c.defaultMaxTokens
+	}
 	if opts != nil {
 		if opts.MaxTokens > 0 {
 			maxTokens = opts.MaxTokens
@@ -194,20 +314,79 @@ func (c *LLMClient) ChatCompletion(ctx context.Context, model string, messages [
 			body["stop"] = opts.Stop
 		}
 	}
+	if err := applyThinking(body, model, opts, &maxTokens); err != nil {
+		return nil, err
+	}
+	if err := applyAudioOutput(body, opts); err != nil {
+		return nil, err
+	}
 	body["max_tokens"] = maxTokens
+	if err := applyProviderOptions(body, opts); err != nil {
+		return nil, err
+	}
+	if err := c.checkRequestBodySize(body); err != nil {
+		return nil, err
+	}
 
-	// Make request with payment handling
-	respBytes, err := c.doRequest(ctx, "/v1/chat/completions", body)
+	var cacheBody []byte
+	if c.chatCache != nil {
+		cacheBody, _ = json.Marshal(body)
+		if cached, ok := c.chatCache.Get(ctx, model, messages, cacheBody); ok {
+			return cached, nil
+		}
+	}
+
+	c.runShadow(ctx, body)
+
+	chatResp, err := c.withResponseSchema(ctx, opts, func(ctx context.Context) (*ChatResponse, error) {
+		return c.sendChatCompletion(ctx, body)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var chatResp ChatResponse
-	if err := json.Unmarshal(respBytes, &chatResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if c.chatCache != nil {
+		c.chatCache.Put(ctx, model, messages, cacheBody, chatResp)
 	}
 
-	return &chatResp, nil
+	return chatResp, nil
+}
+
+// sendChatCompletion posts a chat completion body, with payment handling,
+// through the client's provider limits, priority queue and deduplication
+// filter.
+func (c *LLMClient) sendChatCompletion(ctx context.Context, body map[string]any) (*ChatResponse, error) {
+	// header is set when this call made the request itself (not a cache
+	// hit or a deduplicated follower) so the response can be decoded by its
+	// Content-Type.
+	var header http.Header
+	model, _ := body["model"].(string)
+	fetch := func(ctx context.Context) ([]byte, error) {
+		releaseProvider, err := c.providerLimits.acquire(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+		defer releaseProvider()
+		release, err := c.queue.acquire(ctx, priorityFrom(ctx))
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		data, h, err := c.doRequestHeaders(ctx, "/v1/chat/completions", body)
+		header = h
+		return data, err
+	}
+	var respBytes []byte
+	var err error
+	if c.dedup != nil {
+		respBytes, err = c.dedup.do(ctx, dedupKey(ctx, "/v1/chat/completions", body), fetch)
+	} else {
+		respBytes, err = fetch(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeChatResponse(respBytes, header)
 }
 
 // GetCostSummary returns an aggregate summary of all costs logged to the persistent JSONL file.
@@ -215,8 +394,14 @@ func (c *LLMClient) GetCostSummary() (*CostSummary, error) {
 	return c.costLog.Summary()
 }
 
-// ListModels returns the list of available models with pricing.
+// ListModels returns the list of available models with pricing. The list is
+// cached for DefaultModelCacheTTL (see WithModelCacheTTL).
 func (c *LLMClient) ListModels(ctx context.Context) ([]Model, error) {
+	return c.listModelsWithCache(ctx)
+}
+
+// fetchModels reads the model catalogue from the gateway.
+func (c *LLMClient) fetchModels(ctx context.Context) ([]Model, error) {
 	respBytes, err := c.doGet(ctx, "/v1/models")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list models: %w", err)
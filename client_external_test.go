@@ -0,0 +1,275 @@
+package blockrun_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+	"github.com/BlockRunAI/blockrun-llm-go/testutil"
+)
+
+// Test wallet for testing purposes only - never use in production
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+const testWalletAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+
+func newFakeClient(t *testing.T) (*blockrun.LLMClient, *testutil.FakeBlockRunServer) {
+	t.Helper()
+	fake := testutil.NewFakeBlockRunServer(t)
+	fake.Models = []blockrun.Model{
+		{ID: "openai/gpt-4o", Name: "GPT-4o", Provider: "openai", Pricing: blockrun.ModelPricing{Input: 2.5, Output: 10.0}},
+		{ID: "anthropic/claude-sonnet-4", Name: "Claude Sonnet 4", Provider: "anthropic", Pricing: blockrun.ModelPricing{Input: 3.0, Output: 15.0}},
+	}
+	fake.ImageModels = []blockrun.ImageModel{
+		{ID: "google/nano-banana", Name: "Nano Banana", Provider: "google", PricePerImage: 0.01, Available: true},
+		{ID: "openai/dall-e-3", Name: "DALL-E 3", Provider: "openai", PricePerImage: 0.04, Available: true},
+	}
+	// Keep the cost log of paid calls out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+	client, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(fake.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, fake
+}
+
+func TestListModels(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list models: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Errorf("Expected 2 models, got %d", len(models))
+	}
+
+	if models[0].ID != "openai/gpt-4o" {
+		t.Errorf("Expected first model openai/gpt-4o, got %s", models[0].ID)
+	}
+	if models[0].InputPrice != 2.5 {
+		t.Errorf("Expected input price 2.5, got %v", models[0].InputPrice)
+	}
+}
+
+func TestListImageModels(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	models, err := client.ListImageModels(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list image models: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Errorf("Expected 2 image models, got %d", len(models))
+	}
+
+	if models[0].ID != "google/nano-banana" {
+		t.Errorf("Expected first model google/nano-banana, got %s", models[0].ID)
+	}
+}
+
+func TestListAllModels(t *testing.T) {
+	client, fake := newFakeClient(t)
+	fake.Models = fake.Models[:1]
+	fake.ImageModels = fake.ImageModels[:1]
+
+	models, err := client.ListAllModels(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list all models: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Errorf("Expected 2 total models, got %d", len(models))
+	}
+
+	// Check that we have both types
+	foundLLM := false
+	foundImage := false
+	for _, m := range models {
+		if m.Type == "llm" {
+			foundLLM = true
+			if m.ID != "openai/gpt-4o" {
+				t.Errorf("Expected LLM model openai/gpt-4o, got %s", m.ID)
+			}
+		}
+		if m.Type == "image" {
+			foundImage = true
+			if m.ID != "google/nano-banana" {
+				t.Errorf("Expected image model google/nano-banana, got %s", m.ID)
+			}
+		}
+	}
+
+	if !foundLLM {
+		t.Error("Expected to find LLM model")
+	}
+	if !foundImage {
+		t.Error("Expected to find image model")
+	}
+}
+
+func TestChatCompletionPaid(t *testing.T) {
+	client, fake := newFakeClient(t)
+	fake.ChatResponses = []blockrun.ChatResponse{
+		{Choices: []blockrun.Choice{{Message: blockrun.ChatMessage{Role: "assistant", Content: "first"}}}},
+		{Choices: []blockrun.Choice{{Message: blockrun.ChatMessage{Role: "assistant", Content: "second"}}}},
+	}
+
+	for _, want := range []string{"first", "second", "ok"} {
+		got, err := client.Chat(context.Background(), "openai/gpt-4o", "hi")
+		if err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+
+	payments := fake.RecordedPayments()
+	if len(payments) != 3 {
+		t.Fatalf("Expected 3 payments, got %d", len(payments))
+	}
+	auth := payments[0].Payload.Authorization
+	if !strings.EqualFold(auth.From, testWalletAddress) || auth.Value != "1000" {
+		t.Errorf("Unexpected authorization: %+v", auth)
+	}
+	if s := client.GetSpending(); s.Calls != 3 || s.TotalUSD != 0.003 {
+		t.Errorf("Expected 3 calls costing $0.003, got %+v", s)
+	}
+}
+
+func TestChatCompletionWithTools(t *testing.T) {
+	client, fake := newFakeClient(t)
+	fake.PaymentRequirement = nil
+	// Return a response with tool_calls
+	fake.ChatResponses = []blockrun.ChatResponse{{
+		ID:      "chatcmpl-123",
+		Object:  "chat.completion",
+		Created: 1700000000,
+		Model:   "openai/gpt-4o",
+		Choices: []blockrun.Choice{
+			{
+				Index: 0,
+				Message: blockrun.ChatMessage{
+					Role: "assistant",
+					ToolCalls: []blockrun.ToolCall{
+						{
+							ID:   "call_abc123",
+							Type: "function",
+							Function: blockrun.ToolCallFunction{
+								Name:      "get_weather",
+								Arguments: `{"location":"San Francisco","unit":"celsius"}`,
+							},
+						},
+					},
+				},
+				FinishReason: "tool_calls",
+			},
+		},
+		Usage: blockrun.Usage{
+			PromptTokens:     50,
+			CompletionTokens: 20,
+			TotalTokens:      70,
+		},
+	}}
+
+	messages := []blockrun.ChatMessage{
+		{Role: "user", Content: "What's the weather in San Francisco?"},
+	}
+
+	opts := &blockrun.ChatCompletionOptions{
+		Tools: []blockrun.Tool{
+			{
+				Type: "function",
+				Function: blockrun.ToolFunction{
+					Name:        "get_weather",
+					Description: "Get current weather for a location",
+					Parameters: map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"location": map[string]any{
+								"type":        "string",
+								"description": "City name",
+							},
+							"unit": map[string]any{
+								"type": "string",
+								"enum": []string{"celsius", "fahrenheit"},
+							},
+						},
+						"required": []string{"location"},
+					},
+				},
+			},
+		},
+		ToolChoice: "auto",
+	}
+
+	resp, err := client.ChatCompletion(context.Background(), "openai/gpt-4o", messages, opts)
+	if err != nil {
+		t.Fatalf("Failed to call ChatCompletion with tools: %v", err)
+	}
+
+	// Verify tools/tool_choice were sent
+	reqBody := fake.ChatRequests()[0]
+	toolsList, ok := reqBody["tools"].([]any)
+	if !ok || len(toolsList) != 1 {
+		t.Fatalf("Expected 1 tool, got %v", reqBody["tools"])
+	}
+	if reqBody["tool_choice"] != "auto" {
+		t.Errorf("Expected tool_choice 'auto', got %v", reqBody["tool_choice"])
+	}
+
+	if len(resp.Choices) != 1 {
+		t.Fatalf("Expected 1 choice, got %d", len(resp.Choices))
+	}
+
+	choice := resp.Choices[0]
+
+	// Verify tool_calls are deserialized
+	if len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(choice.Message.ToolCalls))
+	}
+
+	tc := choice.Message.ToolCalls[0]
+	if tc.ID != "call_abc123" {
+		t.Errorf("Expected tool call ID 'call_abc123', got '%s'", tc.ID)
+	}
+	if tc.Type != "function" {
+		t.Errorf("Expected tool call type 'function', got '%s'", tc.Type)
+	}
+	if tc.Function.Name != "get_weather" {
+		t.Errorf("Expected function name 'get_weather', got '%s'", tc.Function.Name)
+	}
+	if tc.Function.Arguments != `{"location":"San Francisco","unit":"celsius"}` {
+		t.Errorf("Unexpected arguments: %s", tc.Function.Arguments)
+	}
+
+	if choice.FinishReason != "tool_calls" {
+		t.Errorf("Expected finish_reason 'tool_calls', got '%s'", choice.FinishReason)
+	}
+	if len(fake.RecordedPayments()) != 0 {
+		t.Error("Expected no payment when the server is free")
+	}
+}
+
+func TestImageGeneratePaid(t *testing.T) {
+	_, fake := newFakeClient(t)
+	fake.ImageResponses = []blockrun.ImageResponse{{Created: 1, Data: []blockrun.ImageData{{URL: "https://example.com/cat.png"}}}}
+
+	client, err := blockrun.NewImageClient(testPrivateKey, blockrun.WithImageAPIURL(fake.URL))
+	if err != nil {
+		t.Fatalf("Failed to create image client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), "a cat", nil)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].URL != "https://example.com/cat.png" {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if len(fake.RecordedPayments()) != 1 {
+		t.Errorf("Expected 1 payment, got %d", len(fake.RecordedPayments()))
+	}
+}
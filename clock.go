@@ -0,0 +1,112 @@
+package blockrun
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for payment validity windows and retry
+// back-off. The SDK uses RealClock unless SetClock installs another, which
+// lets tests drive time-dependent logic without sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is the Clock backed by the time package.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time { return time.Now() }
+
+// After returns time.After(d).
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+var (
+	clockMu sync.RWMutex
+	clock   Clock = RealClock{}
+)
+
+// SetClock replaces the package clock; nil restores RealClock. It affects
+// every client in the process and is intended for tests.
+func SetClock(c Clock) {
+	if c == nil {
+		c = RealClock{}
+	}
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	clock = c
+}
+
+// currentClock returns the package clock.
+func currentClock() Clock {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock
+}
+
+// MockClock is a Clock that only moves when Advance is called. It is safe
+// for concurrent use.
+type MockClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []mockWaiter
+	afters  []time.Duration
+}
+
+// mockWaiter is a pending After channel and the time it fires.
+type mockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewMockClock returns a MockClock reading now.
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+// Now returns the mock time.
+func (m *MockClock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// After returns a channel that receives the mock time once Advance has moved
+// it d past the current time. A non-positive d fires immediately.
+func (m *MockClock) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.afters = append(m.afters, d)
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- m.now
+		return ch
+	}
+	m.waiters = append(m.waiters, mockWaiter{at: m.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the mock time forward by d, firing any After channels that
+// are due.
+func (m *MockClock) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+	pending := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.at.After(m.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- m.now
+	}
+	m.waiters = pending
+}
+
+// AfterCalls returns the durations passed to After, in call order.
+func (m *MockClock) AfterCalls() []time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]time.Duration(nil), m.afters...)
+}
@@ -0,0 +1,139 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultMaxClockDrift is the clock drift beyond which payments are signed
+// against the gateway's clock instead of the local one.
+const DefaultMaxClockDrift = 5 * time.Minute
+
+// clockDriftCheckInterval is how often WithMaxClockDrift re-measures drift.
+const clockDriftCheckInterval = time.Hour
+
+// ClockDriftChecker measures how far the local clock is from the gateway's,
+// using the Date header of a HEAD request. A wrong local clock puts payment
+// validity windows in the past (or future), and the gateway rejects them.
+// It is safe for concurrent use.
+type ClockDriftChecker struct {
+	apiURL string
+	do     func(*http.Request) (*http.Response, error)
+
+	mu        sync.Mutex
+	drift     time.Duration
+	checkedAt time.Time
+	warned    bool
+}
+
+// NewClockDriftChecker returns a checker that sends its HEAD requests to
+// apiURL with httpClient (nil uses http.DefaultClient).
+func NewClockDriftChecker(apiURL string, httpClient *http.Client) *ClockDriftChecker {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &ClockDriftChecker{apiURL: apiURL, do: httpClient.Do}
+}
+
+// CheckClockDrift measures the drift now. A positive drift means the local
+// clock is behind the gateway's. The measurement is corrected for half the
+// round trip and has the Date header's one-second resolution.
+func (d *ClockDriftChecker) CheckClockDrift(ctx context.Context) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", d.apiURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	start := currentClock().Now()
+	resp, err := d.do(req)
+	if err != nil {
+		return 0, fmt.Errorf("clock drift check failed: %w", err)
+	}
+	resp.Body.Close()
+	end := currentClock().Now()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("clock drift check failed: no valid Date header in response")
+	}
+	drift := serverTime.Sub(start.Add(end.Sub(start) / 2)).Round(time.Second)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drift, d.checkedAt, d.warned = drift, end, false
+	return drift, nil
+}
+
+// Drift returns the last measured drift and when it was measured; checkedAt
+// is zero before the first check.
+func (d *ClockDriftChecker) Drift() (drift time.Duration, checkedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.drift, d.checkedAt
+}
+
+// WithMaxClockDrift sets the drift beyond which payments are signed against
+// the gateway's clock (default DefaultMaxClockDrift), and has the client
+// measure drift before signing a payment, at most once an hour. Without it,
+// drift is only measured by CheckClockDrift.
+func WithMaxClockDrift(d time.Duration) ClientOption {
+	return func(c *LLMClient) {
+		c.maxClockDrift = d
+		c.autoClockDrift = true
+	}
+}
+
+// CheckClockDrift measures how far the local clock is from the gateway's
+// (positive when it is behind) and logs a warning if the drift exceeds the
+// maximum. Later payments are signed against the gateway's clock while it
+// does.
+func (bc *baseClient) CheckClockDrift(ctx context.Context) (time.Duration, error) {
+	drift, err := bc.driftChecker().CheckClockDrift(ctx)
+	if err != nil {
+		return 0, err
+	}
+	bc.paymentClockOffset(ctx)
+	return drift, nil
+}
+
+// driftChecker returns the client's ClockDriftChecker, creating it on first
+// use. Its requests go through do, so they carry the client's headers.
+func (bc *baseClient) driftChecker() *ClockDriftChecker {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if bc.clockDrift == nil {
+		bc.clockDrift = &ClockDriftChecker{apiURL: bc.apiURL, do: bc.do}
+	}
+	return bc.clockDrift
+}
+
+// paymentClockOffset returns how far to move a payment's validity window
+// from the local clock: the measured drift if it exceeds the maximum, else
+// zero. With WithMaxClockDrift a stale measurement is refreshed first. The
+// first use of each measurement that exceeds the maximum logs a warning.
+func (bc *baseClient) paymentClockOffset(ctx context.Context) time.Duration {
+	checker := bc.driftChecker()
+	_, checkedAt := checker.Drift()
+	if bc.autoClockDrift && (checkedAt.IsZero() || currentClock().Now().Sub(checkedAt) >= clockDriftCheckInterval) {
+		if _, err := checker.CheckClockDrift(ctx); err != nil {
+			bc.warnf("%v", err)
+		}
+	}
+
+	maxDrift := bc.maxClockDrift
+	if maxDrift <= 0 {
+		maxDrift = DefaultMaxClockDrift
+	}
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+	if checker.drift <= maxDrift && checker.drift >= -maxDrift {
+		return 0
+	}
+	if !checker.warned {
+		checker.warned = true
+		bc.warnf("local clock is %s off the gateway's; signing payments against the gateway's clock", checker.drift)
+	}
+	return checker.drift
+}
@@ -0,0 +1,112 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newDriftServer returns a paid chat server whose Date header runs ahead of
+// the mock clock by ahead, and which records the authorizations it is paid
+// with.
+func newDriftServer(t *testing.T, mock *MockClock, ahead time.Duration) (*httptest.Server, func() []TransferAuthorization) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var mu sync.Mutex
+	var auths []TransferAuthorization
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", mock.Now().Add(ahead).UTC().Format(http.TimeFormat))
+		if r.Method == "HEAD" {
+			return
+		}
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(sig)
+		var p PaymentPayload
+		json.Unmarshal(decoded, &p)
+		mu.Lock()
+		auths = append(auths, p.Payload.Authorization)
+		mu.Unlock()
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []TransferAuthorization {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]TransferAuthorization(nil), auths...)
+	}
+}
+
+func TestClockDriftCompensatesValidAfter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock := NewMockClock(start)
+	SetClock(mock)
+	defer SetClock(nil)
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(prev)
+
+	server, paid := newDriftServer(t, mock, 10*time.Minute)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+
+	drift, err := client.CheckClockDrift(context.Background())
+	if err != nil {
+		t.Fatalf("CheckClockDrift failed: %v", err)
+	}
+	if drift != 10*time.Minute {
+		t.Fatalf("expected a drift of 10m, got %s", drift)
+	}
+	if !strings.Contains(logs.String(), "off the gateway's") {
+		t.Errorf("expected a drift warning, got %q", logs.String())
+	}
+
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	auths := paid()
+	if len(auths) != 1 {
+		t.Fatalf("expected one paid request, got %d", len(auths))
+	}
+	serverNow := start.Add(10 * time.Minute).Unix()
+	if want := strconv.FormatInt(serverNow-600, 10); auths[0].ValidAfter != want {
+		t.Errorf("ValidAfter = %s, want %s", auths[0].ValidAfter, want)
+	}
+	if want := strconv.FormatInt(serverNow+300, 10); auths[0].ValidBefore != want {
+		t.Errorf("ValidBefore = %s, want %s", auths[0].ValidBefore, want)
+	}
+}
+
+func TestClockDriftWithinLimitIgnored(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock := NewMockClock(start)
+	SetClock(mock)
+	defer SetClock(nil)
+
+	// WithMaxClockDrift checks drift before the first payment on its own.
+	server, paid := newDriftServer(t, mock, 2*time.Minute)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMaxClockDrift(3*time.Minute))
+	client.costLog = nil
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if drift, checkedAt := client.driftChecker().Drift(); checkedAt.IsZero() || drift != 2*time.Minute {
+		t.Errorf("expected an automatic check measuring 2m, got %s at %v", drift, checkedAt)
+	}
+	if want := strconv.FormatInt(start.Unix()-600, 10); paid()[0].ValidAfter != want {
+		t.Errorf("expected drift under the limit to be ignored: ValidAfter = %s, want %s", paid()[0].ValidAfter, want)
+	}
+}
@@ -0,0 +1,112 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPaymentValidityUsesClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock := NewMockClock(start)
+	SetClock(mock)
+	defer SetClock(nil)
+
+	client, _ := NewLLMClient(testPrivateKey)
+	authAt := func() TransferAuthorization {
+		payload, err := CreatePaymentPayload(client.privateKey, "0x1234567890123456789012345678901234567890", "1000",
+			"eip155:8453", "https://blockrun.ai/api/v1/chat/completions", "", 300, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(payload)
+		var p PaymentPayload
+		json.Unmarshal(decoded, &p)
+		return p.Payload.Authorization
+	}
+
+	auth := authAt()
+	if want := strconv.FormatInt(start.Unix()+300, 10); auth.ValidBefore != want {
+		t.Errorf("ValidBefore = %s, want %s", auth.ValidBefore, want)
+	}
+	if want := strconv.FormatInt(start.Unix()-600, 10); auth.ValidAfter != want {
+		t.Errorf("ValidAfter = %s, want %s", auth.ValidAfter, want)
+	}
+
+	mock.Advance(300 * time.Second)
+	if want := strconv.FormatInt(start.Unix()+600, 10); authAt().ValidBefore != want {
+		t.Errorf("after Advance, ValidBefore = %s, want %s", authAt().ValidBefore, want)
+	}
+}
+
+func TestThrottleRetryUsesClock(t *testing.T) {
+	mock := NewMockClock(time.Now())
+	SetClock(mock)
+	defer SetClock(nil)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Chat(context.Background(), "gpt-4o", "hi")
+		done <- err
+	}()
+
+	waitFor(t, func() bool { return len(mock.AfterCalls()) == 1 })
+	if got := mock.AfterCalls()[0]; got != 5*time.Second {
+		t.Errorf("expected a 5s back-off, got %v", got)
+	}
+	select {
+	case <-done:
+		t.Fatal("retry did not wait for the clock")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	mock.Advance(5 * time.Second)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("retry did not resume after Advance")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+}
+
+func TestMockClockAfter(t *testing.T) {
+	mock := NewMockClock(time.Unix(0, 0))
+	short, long := mock.After(time.Second), mock.After(time.Minute)
+	mock.Advance(2 * time.Second)
+	select {
+	case at := <-short:
+		if !at.Equal(time.Unix(2, 0)) {
+			t.Errorf("fired at %v", at)
+		}
+	default:
+		t.Error("expected the 1s channel to fire")
+	}
+	select {
+	case <-long:
+		t.Error("the 1m channel fired early")
+	default:
+	}
+}
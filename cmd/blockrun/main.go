@@ -0,0 +1,485 @@
+// Command blockrun is a command-line client for the BlockRun gateway.
+//
+// Usage:
+//
+//	blockrun [flags] chat <model> <prompt>
+//	blockrun [flags] chat-stream <model> <prompt>
+//	blockrun [flags] image <prompt>
+//	blockrun [flags] models
+//	blockrun [flags] wallet create|info|balance
+//	blockrun [flags] wallet fund [--poll 5s] [--no-browser]
+//	blockrun [flags] wallet export-keystore --password <pass>
+//	blockrun [flags] wallet receipts [--format csv|json] [--since DATE] [--until DATE] [--file PATH]
+//
+// Flags:
+//
+//	--output text|json  output format (default text)
+//	--budget USD        refuse payments above this total for the invocation
+//	--rpc-url URL       Base JSON-RPC endpoint for "wallet balance"
+//
+// The wallet key is read from BLOCKRUN_WALLET_KEY (or BASE_CHAIN_WALLET_KEY)
+// and otherwise from ~/.blockrun/.session. BLOCKRUN_API_URL overrides the
+// gateway URL.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const usage = `usage: blockrun [--output text|json] [--budget USD] [--rpc-url URL] <command> [args]
+
+commands:
+  chat <model> <prompt>         send a chat message
+  chat-stream <model> <prompt>  stream a chat response
+  image <prompt>                generate an image
+  models                        list available models
+  wallet create                 create a wallet (or show the existing one)
+  wallet info                   show the wallet address and funding links
+  wallet balance                show the wallet's USDC balance on Base
+  wallet fund [--poll 5s] [--no-browser]
+                                show a funding QR code, open the funding page
+                                and wait for the balance to increase
+  wallet export-keystore --password <pass>
+                                print the wallet as an encrypted keystore
+  wallet receipts [--format csv|json] [--since 2024-01-01] [--until DATE] [--file PATH]
+                                export payment receipts (default file
+                                ~/.blockrun/receipts.jsonl)
+`
+
+// errUsage marks command-line mistakes, which exit with status 2.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// cli holds the parsed global flags.
+type cli struct {
+	json   bool
+	budget float64
+	rpcURL string
+	stdout io.Writer
+}
+
+// run executes the command line args and returns the exit status.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("blockrun", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	output := fs.String("output", "text", "output format: text or json")
+	budget := fs.Float64("budget", 0, "spending cap in USD for this invocation (0 = none)")
+	rpcURL := fs.String("rpc-url", os.Getenv("BLOCKRUN_RPC_URL"), "Base JSON-RPC endpoint for wallet balance")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(stderr, "blockrun: unknown output format %q\n", *output)
+		return 2
+	}
+
+	c := &cli{json: *output == "json", budget: *budget, rpcURL: *rpcURL, stdout: stdout}
+	err := c.dispatch(ctx, fs.Args())
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "blockrun: %v\n\n%s", err, usage)
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "blockrun: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// dispatch runs the subcommand named by args[0].
+func (c *cli) dispatch(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: no command", errUsage)
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "chat", "chat-stream":
+		if len(args) != 2 {
+			return fmt.Errorf("%w: %s needs <model> <prompt>", errUsage, cmd)
+		}
+		if cmd == "chat" {
+			return c.chat(ctx, args[0], args[1])
+		}
+		return c.chatStream(ctx, args[0], args[1])
+	case "image":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: image needs <prompt>", errUsage)
+		}
+		return c.image(ctx, args[0])
+	case "models":
+		return c.models(ctx)
+	case "wallet":
+		if len(args) == 0 {
+			return fmt.Errorf("%w: wallet needs create, info, balance, fund, export-keystore or receipts", errUsage)
+		}
+		switch sub, rest := args[0], args[1:]; sub {
+		case "create", "info", "balance":
+			if len(rest) != 0 {
+				return fmt.Errorf("%w: wallet %s takes no arguments", errUsage, sub)
+			}
+			switch sub {
+			case "create":
+				return c.walletCreate()
+			case "info":
+				return c.walletInfo()
+			}
+			return c.walletBalance(ctx)
+		case "fund":
+			return c.walletFund(ctx, rest)
+		case "export-keystore":
+			return c.walletExportKeystore(rest)
+		case "receipts":
+			return c.walletReceipts(rest)
+		}
+		return fmt.Errorf("%w: unknown wallet command %q", errUsage, args[0])
+	}
+	return fmt.Errorf("%w: unknown command %q", errUsage, cmd)
+}
+
+// walletKey returns the key the clients should use: "" lets them read the
+// environment, otherwise the saved wallet file is used.
+func walletKey() (string, error) {
+	if os.Getenv("BLOCKRUN_WALLET_KEY") != "" || os.Getenv("BASE_CHAIN_WALLET_KEY") != "" {
+		return "", nil
+	}
+	key, err := blockrun.LoadWallet()
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		return "", errors.New("no wallet found; set BLOCKRUN_WALLET_KEY or run \"blockrun wallet create\"")
+	}
+	return key, nil
+}
+
+func (c *cli) llmClient() (*blockrun.LLMClient, error) {
+	key, err := walletKey()
+	if err != nil {
+		return nil, err
+	}
+	opts := []blockrun.ClientOption{blockrun.WithBudget(c.budget)}
+	if c.rpcURL != "" {
+		opts = append(opts, blockrun.WithRPCURL(c.rpcURL))
+	}
+	return blockrun.NewLLMClient(key, opts...)
+}
+
+func (c *cli) chat(ctx context.Context, model, prompt string) error {
+	client, err := c.llmClient()
+	if err != nil {
+		return err
+	}
+	reply, err := client.Chat(ctx, model, prompt)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(map[string]any{"model": model, "response": reply, "cost_usd": client.GetSpending().TotalUSD})
+	}
+	fmt.Fprintln(c.stdout, reply)
+	return nil
+}
+
+func (c *cli) chatStream(ctx context.Context, model, prompt string) error {
+	client, err := c.llmClient()
+	if err != nil {
+		return err
+	}
+	stream, err := client.ChatCompletionStream(ctx, model, []blockrun.ChatMessage{{Role: "user", Content: prompt}}, nil)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	enc := json.NewEncoder(c.stdout)
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			return err
+		}
+		if chunk == nil {
+			break
+		}
+		if c.json {
+			// One chunk per line (NDJSON).
+			if err := enc.Encode(chunk); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, choice := range chunk.Choices {
+			fmt.Fprint(c.stdout, choice.Delta.Content)
+		}
+	}
+	if !c.json {
+		fmt.Fprintln(c.stdout)
+	}
+	return nil
+}
+
+func (c *cli) image(ctx context.Context, prompt string) error {
+	key, err := walletKey()
+	if err != nil {
+		return err
+	}
+	client, err := blockrun.NewImageClient(key, blockrun.WithImageBudget(c.budget))
+	if err != nil {
+		return err
+	}
+	resp, err := client.Generate(ctx, prompt, nil)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(resp)
+	}
+	for _, img := range resp.Data {
+		fmt.Fprintln(c.stdout, img.URL)
+	}
+	return nil
+}
+
+func (c *cli) models(ctx context.Context) error {
+	client, err := c.llmClient()
+	if err != nil {
+		return err
+	}
+	models, err := client.ListAllModels(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(models)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tPROVIDER\tPRICE")
+	for _, m := range models {
+		price := fmt.Sprintf("$%.2f / $%.2f per 1M tokens", m.InputPrice, m.OutputPrice)
+		if m.Type == "image" {
+			price = fmt.Sprintf("$%.3f per image", m.PricePerImage)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m.ID, m.Type, m.Provider, price)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) walletCreate() error {
+	info, err := blockrun.GetOrCreateWallet()
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(map[string]any{"address": info.Address, "new": info.IsNew})
+	}
+	if info.IsNew {
+		fmt.Fprintf(c.stdout, "Created wallet %s (saved to %s)\n", info.Address, blockrun.WalletFile)
+		fmt.Fprintf(c.stdout, "Fund it with USDC on Base: %s\n", blockrun.GetPaymentLinks(info.Address).Basescan)
+		return nil
+	}
+	fmt.Fprintf(c.stdout, "Wallet already exists: %s\n", info.Address)
+	return nil
+}
+
+func (c *cli) walletInfo() error {
+	address, err := blockrun.GetWalletAddressFromEnvOrFile()
+	if err != nil {
+		return err
+	}
+	if address == "" {
+		return errors.New("no wallet found; set BLOCKRUN_WALLET_KEY or run \"blockrun wallet create\"")
+	}
+	links := blockrun.GetPaymentLinks(address)
+	if c.json {
+		return c.writeJSON(map[string]any{
+			"address":     address,
+			"basescan":    links.Basescan,
+			"wallet_link": links.WalletLink,
+		})
+	}
+	fmt.Fprintf(c.stdout, "Address:  %s\nBasescan: %s\nPay link: %s\n", address, links.Basescan, links.WalletLink)
+	return nil
+}
+
+func (c *cli) walletBalance(ctx context.Context) error {
+	client, err := c.llmClient()
+	if err != nil {
+		return err
+	}
+	balance, err := client.GetBalance(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(map[string]any{"address": client.GetWalletAddress(), "balance_usdc": balance})
+	}
+	fmt.Fprintf(c.stdout, "%s USDC\n", formatUSDC(balance))
+	return nil
+}
+
+// openBrowser opens url in the default browser without waiting for it.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// walletFund shows how to fund the wallet, then polls the balance until it
+// increases.
+func (c *cli) walletFund(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("wallet fund", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	poll := fs.Duration("poll", 5*time.Second, "balance polling interval")
+	noBrowser := fs.Bool("no-browser", false, "do not open the funding page")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *poll <= 0 {
+		return fmt.Errorf("%w: wallet fund takes [--poll duration] [--no-browser]", errUsage)
+	}
+
+	client, err := c.llmClient()
+	if err != nil {
+		return err
+	}
+	address := client.GetWalletAddress()
+	start, err := client.GetBalance(ctx)
+	if err != nil {
+		return err
+	}
+	links := blockrun.GetPaymentLinks(address)
+	if !c.json {
+		fmt.Fprintf(c.stdout, "Address: %s\nBalance: %s USDC\n\n%s\n", address, formatUSDC(start), links.QRCodeTerminal())
+		fmt.Fprintf(c.stdout, "Send USDC on Base to the address above, or fund it at:\n%s\n", links.Blockrun)
+	}
+	if !*noBrowser {
+		if err := openBrowser(links.Blockrun); err != nil && !c.json {
+			fmt.Fprintf(c.stdout, "(could not open a browser: %v)\n", err)
+		}
+	}
+	if !c.json {
+		fmt.Fprintln(c.stdout, "Waiting for funds (Ctrl-C to stop)...")
+	}
+
+	ticker := time.NewTicker(*poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		balance, err := client.GetBalance(ctx)
+		if err != nil || balance <= start {
+			continue // RPC errors are retried on the next tick
+		}
+		if c.json {
+			return c.writeJSON(map[string]any{"address": address, "previous_balance_usdc": start, "balance_usdc": balance})
+		}
+		fmt.Fprintf(c.stdout, "Received %s USDC; balance is now %s USDC\n", formatUSDC(balance-start), formatUSDC(balance))
+		return nil
+	}
+}
+
+// walletExportKeystore prints the wallet's key as a Web3 Secret Storage
+// keystore encrypted under --password.
+func (c *cli) walletExportKeystore(args []string) error {
+	fs := flag.NewFlagSet("wallet export-keystore", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	password := fs.String("password", "", "keystore password")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *password == "" {
+		return fmt.Errorf("%w: wallet export-keystore needs --password <pass>", errUsage)
+	}
+
+	key := os.Getenv("BLOCKRUN_WALLET_KEY")
+	if key == "" {
+		key = os.Getenv("BASE_CHAIN_WALLET_KEY")
+	}
+	if key == "" {
+		var err error
+		if key, err = walletKey(); err != nil {
+			return err
+		}
+	}
+	data, err := blockrun.ExportKeystore(key, *password)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.stdout, "%s\n", data)
+	return err
+}
+
+// walletReceipts exports the receipts in --file (the default receipt store
+// file) between --since and --until, as CSV or JSON.
+func (c *cli) walletReceipts(args []string) error {
+	fs := flag.NewFlagSet("wallet receipts", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", "csv", "csv, json or parquet")
+	sinceFlag := fs.String("since", "", "first date to include, YYYY-MM-DD")
+	untilFlag := fs.String("until", "", "first date to exclude, YYYY-MM-DD")
+	file := fs.String("file", "", "receipt file (default ~/.blockrun/receipts.jsonl)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return fmt.Errorf("%w: wallet receipts takes [--format csv|json] [--since DATE] [--until DATE] [--file PATH]", errUsage)
+	}
+	var since, until time.Time
+	for _, d := range []struct {
+		flag  string
+		value string
+		dst   *time.Time
+	}{{"since", *sinceFlag, &since}, {"until", *untilFlag, &until}} {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.DateOnly, d.value)
+		if err != nil {
+			return fmt.Errorf("%w: --%s wants a YYYY-MM-DD date, got %q", errUsage, d.flag, d.value)
+		}
+		*d.dst = t
+	}
+
+	store := blockrun.NewFileReceiptStore(*file)
+	switch *format {
+	case "csv":
+		return blockrun.ExportReceiptsCSV(store, c.stdout, since, until)
+	case "json":
+		return blockrun.ExportReceiptsJSON(store, c.stdout, since, until)
+	case "parquet":
+		return blockrun.ExportReceiptsParquet(store, c.stdout, since, until)
+	}
+	return fmt.Errorf("%w: unknown receipts format %q", errUsage, *format)
+}
+
+// formatUSDC formats an amount of USDC without trailing zeros.
+func formatUSDC(amount float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.6f", amount), "0"), ".")
+}
+
+// writeJSON prints v as indented JSON.
+func (c *cli) writeJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+const testAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "blockrun-cli")
+	if err != nil {
+		panic(err)
+	}
+	binary = filepath.Join(dir, "blockrun")
+	if out, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		panic(fmt.Sprintf("build failed: %v\n%s", err, out))
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newGateway mocks the gateway endpoints and a Base RPC at /rpc.
+func newGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			var body struct {
+				Stream bool `json:"stream"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Stream {
+				for _, part := range []string{"Hel", "lo"} {
+					fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", part)
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+				return
+			}
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello there"}}]}`)
+		case "/v1/models":
+			fmt.Fprint(w, `{"data":[{"id":"openai/gpt-4o","name":"GPT-4o","provider":"openai","inputPrice":2.5,"outputPrice":10}]}`)
+		case "/v1/images/models":
+			fmt.Fprint(w, `{"data":[{"id":"openai/dall-e-3","name":"DALL-E 3","provider":"openai","pricePerImage":0.04}]}`)
+		case "/v1/images/generations":
+			fmt.Fprint(w, `{"created":1,"data":[{"url":"https://cdn.example.com/cat.png"}]}`)
+		case "/rpc":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"0x%064x"}`, 12_500_000)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runCLI runs the binary with a fresh HOME and returns stdout, stderr and
+// the exit code.
+func runCLI(t *testing.T, env []string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Env = append([]string{"HOME=" + t.TempDir(), "PATH=" + os.Getenv("PATH")}, env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	return stdout.String(), stderr.String(), code
+}
+
+func gatewayEnv(server *httptest.Server) []string {
+	return []string{"BLOCKRUN_WALLET_KEY=" + testPrivateKey, "BLOCKRUN_API_URL=" + server.URL}
+}
+
+func TestChat(t *testing.T) {
+	env := gatewayEnv(newGateway(t))
+
+	stdout, stderr, code := runCLI(t, env, "chat", "openai/gpt-4o", "hi")
+	if code != 0 || stdout != "Hello there\n" {
+		t.Errorf("chat: code %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+
+	stdout, _, _ = runCLI(t, env, "--output", "json", "chat", "openai/gpt-4o", "hi")
+	var out map[string]any
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil || out["response"] != "Hello there" || out["model"] != "openai/gpt-4o" {
+		t.Errorf("chat json: %q (%v)", stdout, err)
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	env := gatewayEnv(newGateway(t))
+
+	stdout, _, code := runCLI(t, env, "chat-stream", "openai/gpt-4o", "hi")
+	if code != 0 || stdout != "Hello\n" {
+		t.Errorf("chat-stream: code %d, stdout %q", code, stdout)
+	}
+
+	stdout, _, _ = runCLI(t, env, "--output", "json", "chat-stream", "openai/gpt-4o", "hi")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"content":"Hel"`) {
+		t.Errorf("chat-stream json: %q", stdout)
+	}
+}
+
+func TestImage(t *testing.T) {
+	env := gatewayEnv(newGateway(t))
+
+	stdout, _, code := runCLI(t, env, "image", "a cat")
+	if code != 0 || stdout != "https://cdn.example.com/cat.png\n" {
+		t.Errorf("image: code %d, stdout %q", code, stdout)
+	}
+
+	stdout, _, _ = runCLI(t, env, "--output", "json", "image", "a cat")
+	if !strings.Contains(stdout, `"url": "https://cdn.example.com/cat.png"`) {
+		t.Errorf("image json: %q", stdout)
+	}
+}
+
+func TestModels(t *testing.T) {
+	env := gatewayEnv(newGateway(t))
+
+	stdout, _, code := runCLI(t, env, "models")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if code != 0 || len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") ||
+		!strings.Contains(lines[1], "openai/gpt-4o") || !strings.Contains(lines[2], "$0.040 per image") {
+		t.Errorf("models: code %d, stdout:\n%s", code, stdout)
+	}
+
+	stdout, _, _ = runCLI(t, env, "--output", "json", "models")
+	var models []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &models); err != nil || len(models) != 2 {
+		t.Errorf("models json: %q (%v)", stdout, err)
+	}
+}
+
+func TestWallet(t *testing.T) {
+	server := newGateway(t)
+	env := gatewayEnv(server)
+
+	stdout, _, code := runCLI(t, env, "wallet", "info")
+	if code != 0 || !strings.Contains(stdout, "Address:  "+testAddress) {
+		t.Errorf("wallet info: code %d, stdout %q", code, stdout)
+	}
+
+	stdout, _, code = runCLI(t, env, "--rpc-url", server.URL+"/rpc", "wallet", "balance")
+	if code != 0 || stdout != "12.5 USDC\n" {
+		t.Errorf("wallet balance: code %d, stdout %q", code, stdout)
+	}
+	stdout, _, _ = runCLI(t, env, "--output", "json", "--rpc-url", server.URL+"/rpc", "wallet", "balance")
+	if !strings.Contains(stdout, `"balance_usdc": 12.5`) {
+		t.Errorf("wallet balance json: %q", stdout)
+	}
+
+	// Without a key in the environment, create writes ~/.blockrun/.session.
+	stdout, _, code = runCLI(t, nil, "--output", "json", "wallet", "create")
+	var created map[string]any
+	if err := json.Unmarshal([]byte(stdout), &created); code != 0 || err != nil || created["new"] != true ||
+		!strings.HasPrefix(created["address"].(string), "0x") {
+		t.Errorf("wallet create: code %d, stdout %q", code, stdout)
+	}
+}
+
+func TestWalletFund(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		balance := 12_500_000
+		if calls > 2 {
+			balance = 15_000_000 // funds arrive on the second poll
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"0x%064x"}`, balance)
+	}))
+	defer rpc.Close()
+	env := gatewayEnv(newGateway(t))
+
+	stdout, stderr, code := runCLI(t, env, "--rpc-url", rpc.URL, "wallet", "fund", "--poll", "10ms", "--no-browser")
+	if code != 0 {
+		t.Fatalf("wallet fund: code %d, stderr %q", code, stderr)
+	}
+	for _, want := range []string{"Address: " + testAddress, "Balance: 12.5 USDC", "█", "https://blockrun.ai/fund?address=" + testAddress,
+		"Received 2.5 USDC; balance is now 15 USDC"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("wallet fund output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestWalletExportKeystore(t *testing.T) {
+	env := gatewayEnv(newGateway(t))
+
+	stdout, stderr, code := runCLI(t, env, "wallet", "export-keystore", "--password", "hunter2")
+	if code != 0 {
+		t.Fatalf("export-keystore: code %d, stderr %q", code, stderr)
+	}
+	key, err := blockrun.ImportKeystore([]byte(stdout), "hunter2")
+	if err != nil || key != testPrivateKey {
+		t.Errorf("keystore did not decrypt to the wallet key: %v", err)
+	}
+
+	if _, _, code := runCLI(t, env, "wallet", "export-keystore"); code != 2 {
+		t.Errorf("expected a usage error without --password, got code %d", code)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{{}, {"chat", "only-model"}, {"wallet", "nope"}, {"bogus"}, {"--output", "xml", "models"}} {
+		_, stderr, code := runCLI(t, nil, args...)
+		if code != 2 || stderr == "" {
+			t.Errorf("%v: expected usage error, got code %d, stderr %q", args, code, stderr)
+		}
+	}
+
+	_, stderr, code := runCLI(t, nil, "chat", "m", "p")
+	if code != 1 || !strings.Contains(stderr, "no wallet found") {
+		t.Errorf("missing wallet: code %d, stderr %q", code, stderr)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	pr := `{"x402Version":2,"accepts":[{"scheme":"exact","network":"eip155:8453","amount":"2500","asset":"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913","payTo":"0x1234567890123456789012345678901234567890","maxTimeoutSeconds":300}],"resource":{"url":"https://blockrun.ai/api/v1/chat/completions"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			fmt.Fprintf(w, `{"x402":%s}`, pr)
+			return
+		}
+		if r.URL.Path == "/v1/images/generations" {
+			fmt.Fprint(w, `{"created":1,"data":[{"url":"https://cdn.example.com/paid.png"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"paid"}}]}`)
+	}))
+	defer server.Close()
+	env := gatewayEnv(server)
+
+	_, stderr, code := runCLI(t, env, "--budget", "0.001", "chat", "openai/gpt-4o", "hi")
+	if code != 1 || !strings.Contains(stderr, "budget") {
+		t.Errorf("over budget: code %d, stderr %q", code, stderr)
+	}
+	stdout, _, code := runCLI(t, env, "--budget", "0.01", "chat", "openai/gpt-4o", "hi")
+	if code != 0 || stdout != "paid\n" {
+		t.Errorf("within budget: code %d, stdout %q", code, stdout)
+	}
+
+	_, stderr, code = runCLI(t, env, "--budget", "0.001", "image", "a cat")
+	if code != 1 || !strings.Contains(stderr, "budget") {
+		t.Errorf("image over budget: code %d, stderr %q", code, stderr)
+	}
+	stdout, _, code = runCLI(t, env, "--budget", "0.01", "image", "a cat")
+	if code != 0 || stdout != "https://cdn.example.com/paid.png\n" {
+		t.Errorf("image within budget: code %d, stdout %q", code, stdout)
+	}
+}
+
+func TestWalletReceipts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "receipts.jsonl")
+	store := blockrun.NewFileReceiptStore(file)
+	for day := 1; day <= 5; day++ {
+		store.Save(blockrun.Receipt{
+			Timestamp: time.Date(2023, 12, 30+day, 12, 0, 0, 0, time.UTC),
+			Model:     "openai/gpt-4o",
+			Amount:    "1000",
+			CostUSD:   0.001,
+		})
+	}
+
+	stdout, stderr, code := runCLI(t, nil, "wallet", "receipts", "--format", "csv", "--since", "2024-01-01", "--file", file)
+	if code != 0 {
+		t.Fatalf("wallet receipts: code %d, stderr %q", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if lines[0] != "timestamp,model,provider,amount_usdc,cost_usd,pay_to,network,nonce,resource_url" || len(lines) != 5 {
+		t.Errorf("expected a header and 4 rows since 2024-01-01, got:\n%s", stdout)
+	}
+
+	stdout, _, code = runCLI(t, nil, "wallet", "receipts", "--format", "json", "--file", file)
+	var receipts []blockrun.Receipt
+	if err := json.Unmarshal([]byte(stdout), &receipts); code != 0 || err != nil || len(receipts) != 5 {
+		t.Errorf("json: code %d, %d receipts, %v", code, len(receipts), err)
+	}
+
+	for _, args := range [][]string{{"--format", "xml"}, {"--since", "yesterday"}} {
+		if _, _, code := runCLI(t, nil, append([]string{"wallet", "receipts", "--file", file}, args...)...); code != 2 {
+			t.Errorf("%v: expected a usage error, got code %d", args, code)
+		}
+	}
+	if _, stderr, code := runCLI(t, nil, "wallet", "receipts", "--format", "parquet", "--file", file); code != 1 || !strings.Contains(stderr, "not supported") {
+		t.Errorf("parquet: code %d, stderr %q", code, stderr)
+	}
+}
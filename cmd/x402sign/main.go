@@ -0,0 +1,168 @@
+// Command x402sign creates and verifies signed x402 payment payloads, for
+// integrating BlockRun payments from languages without an SDK.
+//
+// Usage:
+//
+//	x402sign --pay-to ADDRESS --amount UNITS [flags]
+//	x402sign --verify < payload
+//
+// Sign flags:
+//
+//	--private-key KEY    hex wallet key; read from stdin when omitted
+//	--pay-to ADDRESS     recipient address (required)
+//	--amount UNITS       amount in USDC base units, 1000000 = $1 (required)
+//	--network NETWORK    CAIP-2 network (default eip155:8453, Base mainnet)
+//	--resource-url URL   resource being paid for
+//	--description TEXT   resource description
+//	--timeout SECONDS    validity window of the authorization (default 300)
+//
+// The base64 PAYMENT-SIGNATURE value is written to stdout. Piping the key in
+// (x402sign --pay-to ... < keyfile) keeps it out of shell history.
+//
+// With --verify, a payload is read from stdin and the address that signed it
+// is printed; the command fails if the signature does not match the
+// authorization's from address.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const usage = `usage: x402sign --pay-to ADDRESS --amount UNITS [--private-key KEY] [--network NETWORK]
+                [--resource-url URL] [--description TEXT] [--timeout SECONDS]
+       x402sign --verify < payload
+
+The private key is read from stdin when --private-key is omitted.
+`
+
+// errUsage marks command-line mistakes, which exit with status 2.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("x402sign", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	privateKey := fs.String("private-key", "", "hex wallet key (default: read from stdin)")
+	payTo := fs.String("pay-to", "", "recipient address")
+	amount := fs.String("amount", "", "amount in USDC base units")
+	network := fs.String("network", "eip155:8453", "CAIP-2 network")
+	resourceURL := fs.String("resource-url", "", "resource being paid for")
+	description := fs.String("description", "", "resource description")
+	timeout := fs.Int("timeout", 300, "authorization validity in seconds")
+	verify := fs.Bool("verify", false, "verify a payload read from stdin")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	if *verify {
+		err = verifyPayload(stdin, stdout)
+	} else {
+		err = sign(stdin, stdout, signRequest{
+			privateKey:  *privateKey,
+			payTo:       *payTo,
+			amount:      *amount,
+			network:     *network,
+			resourceURL: *resourceURL,
+			description: *description,
+			timeout:     *timeout,
+		})
+	}
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "x402sign: %v\n\n%s", err, usage)
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "x402sign: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// signRequest holds the sign-mode flags.
+type signRequest struct {
+	privateKey  string
+	payTo       string
+	amount      string
+	network     string
+	resourceURL string
+	description string
+	timeout     int
+}
+
+func sign(stdin io.Reader, stdout io.Writer, req signRequest) error {
+	if req.payTo == "" || req.amount == "" {
+		return fmt.Errorf("%w: --pay-to and --amount are required", errUsage)
+	}
+	if amount, ok := new(big.Int).SetString(req.amount, 10); !ok || amount.Sign() <= 0 {
+		return fmt.Errorf("%w: --amount must be a positive integer number of base units", errUsage)
+	}
+	if req.timeout <= 0 {
+		return fmt.Errorf("%w: --timeout must be positive", errUsage)
+	}
+
+	key := req.privateKey
+	if key == "" {
+		var err error
+		if key, err = readLine(stdin); err != nil {
+			return fmt.Errorf("reading private key from stdin: %w", err)
+		}
+	}
+	if err := blockrun.ValidatePrivateKey(key); err != nil {
+		return err
+	}
+	ecdsaKey, err := blockrun.GetPrivateKeyFromHex(key)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+
+	payload, err := blockrun.CreatePaymentPayload(ecdsaKey, req.payTo, req.amount, req.network,
+		req.resourceURL, req.description, req.timeout, nil, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, payload)
+	return nil
+}
+
+func verifyPayload(stdin io.Reader, stdout io.Writer) error {
+	payload, err := readLine(stdin)
+	if err != nil {
+		return fmt.Errorf("reading payload from stdin: %w", err)
+	}
+	signer, err := blockrun.VerifyPaymentPayload(payload)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, signer)
+	return nil
+}
+
+// readLine returns the first non-blank line of r, trimmed.
+func readLine(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.ErrUnexpectedEOF
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+const testAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+const payTo = "0x1234567890123456789012345678901234567890"
+
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "x402sign")
+	if err != nil {
+		panic(err)
+	}
+	binary = filepath.Join(dir, "x402sign")
+	if out, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		panic(fmt.Sprintf("build failed: %v\n%s", err, out))
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// runBinary runs x402sign with stdin and returns stdout, stderr and the
+// exit code.
+func runBinary(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), code
+}
+
+func decodePayload(t *testing.T, out string) blockrun.PaymentPayload {
+	t.Helper()
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatalf("output is not base64: %v", err)
+	}
+	var p blockrun.PaymentPayload
+	if err := json.Unmarshal(decoded, &p); err != nil {
+		t.Fatalf("output is not a PaymentPayload: %v", err)
+	}
+	return p
+}
+
+func TestSign(t *testing.T) {
+	out, stderr, code := runBinary(t, "", "--private-key", testPrivateKey, "--pay-to", payTo, "--amount", "2500",
+		"--resource-url", "https://blockrun.ai/api/v1/chat/completions", "--description", "chat", "--timeout", "60")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	p := decodePayload(t, out)
+	auth := p.Payload.Authorization
+	if auth.From != testAddress || auth.To != payTo || auth.Value != "2500" {
+		t.Errorf("unexpected authorization %+v", auth)
+	}
+	if p.Accepted.Network != "eip155:8453" || p.Accepted.MaxTimeoutSeconds != 60 || p.Resource.Description != "chat" {
+		t.Errorf("unexpected payload %+v", p)
+	}
+}
+
+func TestSignKeyFromStdin(t *testing.T) {
+	out, stderr, code := runBinary(t, testPrivateKey+"\n", "--pay-to", payTo, "--amount", "1000")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if from := decodePayload(t, out).Payload.Authorization.From; from != testAddress {
+		t.Errorf("expected from %s, got %s", testAddress, from)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	payload, _, _ := runBinary(t, testPrivateKey, "--pay-to", payTo, "--amount", "1000")
+
+	out, stderr, code := runBinary(t, payload, "--verify")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(out) != testAddress {
+		t.Errorf("expected %s, got %q", testAddress, out)
+	}
+
+	if _, _, code := runBinary(t, "bm90IGEgcGF5bG9hZA==", "--verify"); code != 1 {
+		t.Errorf("expected exit 1 for a bad payload, got %d", code)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"--private-key", testPrivateKey},
+		{"--private-key", testPrivateKey, "--pay-to", payTo, "--amount", "1.5"},
+		{"--private-key", testPrivateKey, "--pay-to", payTo, "--amount", "1000", "--timeout", "0"},
+	} {
+		if _, _, code := runBinary(t, "", args...); code != 2 {
+			t.Errorf("%v: expected exit 2, got %d", args, code)
+		}
+	}
+	if _, _, code := runBinary(t, "", "--pay-to", payTo, "--amount", "1000"); code != 1 {
+		t.Errorf("expected exit 1 with no key on stdin, got %d", code)
+	}
+}
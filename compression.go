@@ -0,0 +1,110 @@
+package blockrun
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Supported compression algorithms.
+const (
+	CompressionGzip   = "gzip"
+	CompressionBrotli = "br"
+)
+
+// WithCompression advertises compressed responses (Accept-Encoding) and
+// decompresses them transparently, so callers still see plain JSON. With no
+// arguments both gzip and br are offered; unknown algorithms are ignored.
+func WithCompression(algorithms ...string) ClientOption {
+	return func(c *LLMClient) {
+		if len(algorithms) == 0 {
+			algorithms = []string{CompressionGzip, CompressionBrotli}
+		}
+		c.acceptEncodings = nil
+		for _, a := range algorithms {
+			if a == CompressionGzip || a == CompressionBrotli {
+				c.acceptEncodings = append(c.acceptEncodings, a)
+			}
+		}
+	}
+}
+
+// WithRequestCompression compresses request bodies with algorithm and sets
+// Content-Encoding. Only gzip is supported; any other value fails each
+// request with a descriptive error.
+func WithRequestCompression(algorithm string) ClientOption {
+	return func(c *LLMClient) {
+		c.requestEncoding = algorithm
+	}
+}
+
+// compressRequest replaces req's body with its compressed form.
+func (bc *baseClient) compressRequest(req *http.Request) error {
+	if bc.requestEncoding == "" || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if bc.requestEncoding != CompressionGzip {
+		return &ValidationError{Field: "requestCompression", Message: fmt.Sprintf("unsupported request compression %q (want gzip)", bc.requestEncoding)}
+	}
+	raw, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(raw)
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress request body: %w", err)
+	}
+	compressed := buf.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", CompressionGzip)
+	return nil
+}
+
+// decompressResponse swaps a compressed response body for a decoding reader.
+func decompressResponse(resp *http.Response) error {
+	var body io.ReadCloser
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return fmt.Errorf("failed to decompress response: %w", err)
+		}
+		body = &decodedBody{Reader: zr, raw: resp.Body}
+	case CompressionBrotli:
+		body = &decodedBody{Reader: brotli.NewReader(resp.Body), raw: resp.Body}
+	default:
+		return nil
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decodedBody reads decompressed data and closes the underlying body.
+type decodedBody struct {
+	io.Reader
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error { return b.raw.Close() }
+
+// acceptEncodingHeader returns the Accept-Encoding value for the client.
+func (bc *baseClient) acceptEncodingHeader() string {
+	return strings.Join(bc.acceptEncodings, ", ")
+}
@@ -0,0 +1,109 @@
+package blockrun
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// newCompressingServer serves testChatResponseJSON, compressed with the
+// first encoding the client accepts. It records each request's
+// Accept-Encoding.
+func newCompressingServer(t *testing.T, accepted *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept-Encoding")
+		*accepted = append(*accepted, accept)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(accept, "br"):
+			w.Header().Set("Content-Encoding", "br")
+			bw := brotli.NewWriter(w)
+			io.WriteString(bw, testChatResponseJSON)
+			bw.Close()
+		case strings.HasPrefix(accept, "gzip"):
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			io.WriteString(zw, testChatResponseJSON)
+			zw.Close()
+		default:
+			io.WriteString(w, testChatResponseJSON)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWithCompression(t *testing.T) {
+	var accepted []string
+	server := newCompressingServer(t, &accepted)
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	baseline, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	want, err := baseline.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+	if err != nil {
+		t.Fatalf("baseline ChatCompletion: %v", err)
+	}
+
+	for _, algorithms := range [][]string{{"gzip"}, {"br", "gzip"}, nil} {
+		client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithCompression(algorithms...))
+		got, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+		if err != nil {
+			t.Fatalf("ChatCompletion with %v: %v", algorithms, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("compressed response %v decoded to %+v, want %+v", algorithms, got, want)
+		}
+	}
+
+	// The baseline's "gzip" is added (and undone) by net/http itself.
+	wantAccept := []string{"gzip", "gzip", "br, gzip", "gzip, br"}
+	if !reflect.DeepEqual(accepted, wantAccept) {
+		t.Errorf("Accept-Encoding = %q, want %q", accepted, wantAccept)
+	}
+}
+
+func TestWithRequestCompression(t *testing.T) {
+	var model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("expected gzip Content-Encoding, got %q", r.Header.Get("Content-Encoding"))
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("request body is not gzip: %v", err)
+		}
+		var body map[string]any
+		json.NewDecoder(zr).Decode(&body)
+		model, _ = body["model"].(string)
+		io.WriteString(w, testChatResponseJSON)
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithRequestCompression("gzip"))
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if model != "gpt-4o" {
+		t.Errorf("server decoded model %q, want gpt-4o", model)
+	}
+
+	bad, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithRequestCompression("zstd"))
+	_, err := bad.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("expected ValidationError for unsupported algorithm, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package blockrun
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the on-disk LLMClient configuration read by
+// NewLLMClientFromConfig. Zero fields leave the client default in place.
+type Config struct {
+	PrivateKey   string             `yaml:"private_key,omitempty" toml:"private_key,omitempty"`
+	APIURL       string             `yaml:"api_url,omitempty" toml:"api_url,omitempty"`
+	BasePath     string             `yaml:"base_path,omitempty" toml:"base_path,omitempty"`
+	Timeout      ConfigDuration     `yaml:"timeout,omitempty" toml:"timeout,omitempty"`
+	MaxTokens    int                `yaml:"max_tokens,omitempty" toml:"max_tokens,omitempty"`
+	DefaultModel string             `yaml:"default_model,omitempty" toml:"default_model,omitempty"`
+	RetryPolicy  *RetryPolicyConfig `yaml:"retry_policy,omitempty" toml:"retry_policy,omitempty"`
+	BudgetUSD    float64            `yaml:"budget_usd,omitempty" toml:"budget_usd,omitempty"`
+	LogLevel     string             `yaml:"log_level,omitempty" toml:"log_level,omitempty"`
+}
+
+// RetryPolicyConfig is the config-file form of RetryPolicy.
+type RetryPolicyConfig struct {
+	MaxAttempts int            `yaml:"max_attempts,omitempty" toml:"max_attempts,omitempty"`
+	MaxWait     ConfigDuration `yaml:"max_wait,omitempty" toml:"max_wait,omitempty"`
+}
+
+// ConfigDuration is a time.Duration written as a Go duration string ("90s",
+// "2m") or a whole number of seconds.
+type ConfigDuration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *ConfigDuration) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if secs, err := strconv.Atoi(s); err == nil {
+		*d = ConfigDuration(time.Duration(secs) * time.Second)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = ConfigDuration(parsed)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d ConfigDuration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// LoadConfig reads a YAML (.yaml, .yml) or TOML (.toml) config file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, &cfg)
+	default:
+		return nil, &ValidationError{Field: "path", Message: fmt.Sprintf("unsupported config format %q (want .yaml, .yml or .toml)", filepath.Ext(path))}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Options returns the ClientOptions equivalent to cfg (all but the private
+// key, which is passed to NewLLMClient directly).
+func (cfg *Config) Options() []ClientOption {
+	var opts []ClientOption
+	if cfg.APIURL != "" {
+		opts = append(opts, WithAPIURL(cfg.APIURL))
+	}
+	if cfg.BasePath != "" {
+		opts = append(opts, WithBasePath(cfg.BasePath))
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, WithTimeout(time.Duration(cfg.Timeout)))
+	}
+	if cfg.MaxTokens > 0 {
+		opts = append(opts, WithDefaultMaxTokens(cfg.MaxTokens))
+	}
+	if cfg.DefaultModel != "" {
+		opts = append(opts, WithDefaultModel(cfg.DefaultModel))
+	}
+	if cfg.RetryPolicy != nil {
+		opts = append(opts, WithRetryPolicy(RetryPolicy{
+			MaxAttempts:     cfg.RetryPolicy.MaxAttempts,
+			MaxWaitDuration: time.Duration(cfg.RetryPolicy.MaxWait),
+		}))
+	}
+	if cfg.BudgetUSD > 0 {
+		opts = append(opts, WithBudget(cfg.BudgetUSD))
+	}
+	if cfg.LogLevel != "" {
+		opts = append(opts, WithLogLevel(cfg.LogLevel))
+	}
+	return opts
+}
+
+// NewLLMClientFromConfig creates an LLMClient from a YAML or TOML config
+// file (format chosen by extension). opts are applied after the file's
+// settings, so they override it. An empty private_key falls back to the
+// environment as in NewLLMClient.
+func NewLLMClientFromConfig(path string, opts ...ClientOption) (*LLMClient, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewLLMClient(cfg.PrivateKey, append(cfg.Options(), opts...)...)
+}
+
+// ExportConfig serialises client's current settings as "yaml" or "toml".
+// The private key is never written, so NewLLMClientFromConfig on the result
+// loads it from the environment.
+func ExportConfig(client *LLMClient, format string) ([]byte, error) {
+	cfg := Config{
+		APIURL:       strings.TrimSuffix(client.apiURL, client.basePath),
+		BasePath:     client.basePath,
+		Timeout:      ConfigDuration(client.httpClient.Timeout),
+		MaxTokens:    client.defaultMaxTokens,
+		DefaultModel: client.defaultModel,
+		BudgetUSD:    client.budgetUSD,
+		LogLevel:     client.logLevel,
+	}
+	if client.retryPolicy != (RetryPolicy{}) {
+		cfg.RetryPolicy = &RetryPolicyConfig{
+			MaxAttempts: client.retryPolicy.MaxAttempts,
+			MaxWait:     ConfigDuration(client.retryPolicy.MaxWaitDuration),
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		return yaml.Marshal(&cfg)
+	case "toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, &ValidationError{Field: "format", Message: fmt.Sprintf("unsupported config format %q (want yaml or toml)", format)}
+	}
+}
@@ -0,0 +1,169 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLLMClientFromConfigYAML(t *testing.T) {
+	t.Setenv("BLOCKRUN_API_URL", "")
+	client, err := NewLLMClientFromConfig(filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatalf("NewLLMClientFromConfig: %v", err)
+	}
+	client.costLog = nil
+
+	if client.httpClient.Timeout != 90*time.Second {
+		t.Errorf("expected 90s timeout, got %v", client.httpClient.Timeout)
+	}
+	if client.apiURL != "https://gateway.example.com/api" || client.defaultModel != "openai/gpt-4o" ||
+		client.defaultMaxTokens != 2048 || client.budgetUSD != 2.5 || client.logLevel != LogLevelError {
+		t.Errorf("settings not applied: %+v", client.baseClient)
+	}
+	if client.retryPolicy != (RetryPolicy{MaxAttempts: 5, MaxWaitDuration: time.Minute}) {
+		t.Errorf("unexpected retry policy %+v", client.retryPolicy)
+	}
+	if addr, _ := GetAddressFromKey(testPrivateKey); client.GetWalletAddress() != addr {
+		t.Errorf("expected the config's private key to be used")
+	}
+}
+
+func TestNewLLMClientFromConfigSecondsAndTOML(t *testing.T) {
+	client, err := NewLLMClientFromConfig(filepath.Join("testdata", "config_seconds.yaml"))
+	if err != nil {
+		t.Fatalf("NewLLMClientFromConfig: %v", err)
+	}
+	if client.httpClient.Timeout != 45*time.Second {
+		t.Errorf("expected integer seconds to parse, got %v", client.httpClient.Timeout)
+	}
+
+	client, err = NewLLMClientFromConfig(filepath.Join("testdata", "config.toml"))
+	if err != nil {
+		t.Fatalf("NewLLMClientFromConfig(toml): %v", err)
+	}
+	if client.httpClient.Timeout != 2*time.Minute || client.defaultModel != "anthropic/claude-sonnet-4" || client.retryPolicy.MaxAttempts != 2 {
+		t.Errorf("TOML settings not applied: timeout=%v model=%q retry=%+v", client.httpClient.Timeout, client.defaultModel, client.retryPolicy)
+	}
+}
+
+func TestNewLLMClientFromConfigOptionsOverride(t *testing.T) {
+	client, err := NewLLMClientFromConfig(filepath.Join("testdata", "config.yaml"), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("NewLLMClientFromConfig: %v", err)
+	}
+	if client.httpClient.Timeout != 5*time.Second {
+		t.Errorf("expected the programmatic timeout to win, got %v", client.httpClient.Timeout)
+	}
+
+	if _, err := NewLLMClientFromConfig("config.json"); err == nil {
+		t.Error("expected an error for an unsupported extension")
+	}
+}
+
+func TestExportConfigOmitsKey(t *testing.T) {
+	client, err := NewLLMClientFromConfig(filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatalf("NewLLMClientFromConfig: %v", err)
+	}
+	for _, format := range []string{"yaml", "toml"} {
+		data, err := ExportConfig(client, format)
+		if err != nil {
+			t.Fatalf("ExportConfig(%s): %v", format, err)
+		}
+		out := string(data)
+		if strings.Contains(out, strings.TrimPrefix(testPrivateKey, "0x")) || strings.Contains(out, "private_key") {
+			t.Errorf("%s export must omit the private key:\n%s", format, out)
+		}
+		if !strings.Contains(out, "1m30s") || !strings.Contains(out, "openai/gpt-4o") {
+			t.Errorf("%s export is missing settings:\n%s", format, out)
+		}
+	}
+	if _, err := ExportConfig(client, "ini"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestExportConfigRoundTrip(t *testing.T) {
+	t.Setenv("BASE_CHAIN_WALLET_KEY", testPrivateKey)
+	t.Setenv("BLOCKRUN_API_URL", "")
+	client, err := NewLLMClient(testPrivateKey,
+		WithAPIURL("https://gateway.example.com"),
+		WithBasePath("/internal"),
+		WithTimeout(90*time.Second),
+		WithDefaultModel("openai/gpt-4o"),
+		WithBudget(2.5),
+	)
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	for _, format := range []string{"yaml", "toml"} {
+		data, err := ExportConfig(client, format)
+		if err != nil {
+			t.Fatalf("ExportConfig(%s): %v", format, err)
+		}
+		path := filepath.Join(t.TempDir(), "config."+format)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := NewLLMClientFromConfig(path)
+		if err != nil {
+			t.Fatalf("NewLLMClientFromConfig(%s): %v\n%s", format, err, data)
+		}
+		if loaded.apiURL != "https://gateway.example.com/internal" || loaded.httpClient.Timeout != 90*time.Second ||
+			loaded.defaultModel != "openai/gpt-4o" || loaded.budgetUSD != 2.5 {
+			t.Errorf("%s round trip changed the settings: url=%q timeout=%v model=%q budget=%v",
+				format, loaded.apiURL, loaded.httpClient.Timeout, loaded.defaultModel, loaded.budgetUSD)
+		}
+		if loaded.GetWalletAddress() != client.GetWalletAddress() {
+			t.Errorf("%s round trip should load the key from the environment", format)
+		}
+	}
+}
+
+func TestDefaultModelAndBudget(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "600000") // $0.60
+	var gotModel string
+	var gotMaxTokens float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel, _ = body["model"].(string)
+		gotMaxTokens, _ = body["max_tokens"].(float64)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithDefaultModel("openai/gpt-4o-mini"), WithDefaultMaxTokens(77), WithBudget(1))
+	client.costLog = nil
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	if _, err := client.ChatCompletion(context.Background(), "", messages, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if gotModel != "openai/gpt-4o-mini" || gotMaxTokens != 77 {
+		t.Errorf("expected defaults to be sent, got model %q max_tokens %v", gotModel, gotMaxTokens)
+	}
+
+	_, err := client.ChatCompletion(context.Background(), "", messages, nil)
+	var payErr *PaymentError
+	if !errors.As(err, &payErr) || !strings.Contains(payErr.Message, "budget") {
+		t.Errorf("expected the second $0.60 call to exceed the $1 budget, got %v", err)
+	}
+	if spent := client.GetSpending(); spent.Calls != 1 {
+		t.Errorf("expected only the first call to be paid, got %+v", spent)
+	}
+}
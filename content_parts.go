@@ -0,0 +1,173 @@
+package blockrun
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Audio formats accepted in an AudioContentPart.
+const (
+	AudioFormatPCM16    = "pcm16"
+	AudioFormatG711ULaw = "g711_ulaw"
+	AudioFormatG711ALaw = "g711_alaw"
+	AudioFormatWAV      = "wav"
+	AudioFormatMP3      = "mp3"
+)
+
+// ContentPart is one part of a multi-part message, sent in
+// ChatMessage.ContentParts: a TextContentPart, ImageContentPart,
+// AudioContentPart or FileContentPart.
+type ContentPart interface {
+	contentPart()
+}
+
+// TextContentPart is a "text" content part.
+type TextContentPart struct {
+	Text string
+}
+
+// ImageContentPart is an "image_url" content part. URL may be an http(s)
+// URL or a data: URL.
+type ImageContentPart struct {
+	URL    string
+	Detail string // "auto", "low" or "high"; empty lets the model decide
+}
+
+// AudioContentPart is an "input_audio" content part for models that accept
+// audio directly in the chat messages.
+type AudioContentPart struct {
+	Type      string // always "input_audio"; set when empty
+	AudioData string // base64-encoded audio
+	Format    string // AudioFormatPCM16, AudioFormatG711ULaw, AudioFormatWAV, ...
+}
+
+// FileContentPart is a "file" content part referring to a file uploaded
+// with UploadFile.
+type FileContentPart struct {
+	FileID string
+}
+
+func (TextContentPart) contentPart()  {}
+func (ImageContentPart) contentPart() {}
+func (AudioContentPart) contentPart() {}
+func (FileContentPart) contentPart()  {}
+
+// MarshalJSON writes the part in the OpenAI content part format.
+func (p TextContentPart) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"type": "text", "text": p.Text})
+}
+
+// MarshalJSON writes the part in the OpenAI content part format.
+func (p ImageContentPart) MarshalJSON() ([]byte, error) {
+	image := map[string]any{"url": p.URL}
+	if p.Detail != "" {
+		image["detail"] = p.Detail
+	}
+	return json.Marshal(map[string]any{"type": "image_url", "image_url": image})
+}
+
+// MarshalJSON writes the part in the OpenAI content part format.
+func (p AudioContentPart) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"type":        "input_audio",
+		"input_audio": map[string]any{"data": p.AudioData, "format": p.Format},
+	})
+}
+
+// MarshalJSON writes the part in the OpenAI content part format.
+func (p FileContentPart) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"type": "file",
+		"file": map[string]any{"file_id": p.FileID},
+	})
+}
+
+// contentBlock is one block of a message's content array, as received.
+type contentBlock struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Thinking string `json:"thinking"`
+	ImageURL *struct {
+		URL    string `json:"url"`
+		Detail string `json:"detail"`
+	} `json:"image_url"`
+	InputAudio *struct {
+		Data   string `json:"data"`
+		Format string `json:"format"`
+	} `json:"input_audio"`
+	File *struct {
+		FileID string `json:"file_id"`
+	} `json:"file"`
+}
+
+// part returns the image, audio or file ContentPart b holds, or nil for any
+// other block.
+func (b contentBlock) part() ContentPart {
+	switch {
+	case b.Type == "image_url" && b.ImageURL != nil:
+		return ImageContentPart{URL: b.ImageURL.URL, Detail: b.ImageURL.Detail}
+	case b.Type == "input_audio" && b.InputAudio != nil:
+		return AudioContentPart{Type: "input_audio", AudioData: b.InputAudio.Data, Format: b.InputAudio.Format}
+	case b.Type == "file" && b.File != nil:
+		return FileContentPart{FileID: b.File.FileID}
+	}
+	return nil
+}
+
+// NewAudioMessage returns a message with role whose content is audioBytes,
+// base64-encoded, in format (see the AudioFormat constants).
+func NewAudioMessage(role string, audioBytes []byte, format string) ChatMessage {
+	return ChatMessage{
+		Role: role,
+		ContentParts: []ContentPart{AudioContentPart{
+			Type:      "input_audio",
+			AudioData: base64.StdEncoding.EncodeToString(audioBytes),
+			Format:    format,
+		}},
+	}
+}
+
+// LoadAudioFile reads the WAV or MP3 file at path and returns its bytes and
+// format (AudioFormatWAV or AudioFormatMP3), detected from the file's
+// header rather than its extension.
+func LoadAudioFile(path string) ([]byte, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read audio file: %w", err)
+	}
+	format := detectAudioFormat(data)
+	if format == "" {
+		return nil, "", fmt.Errorf("unsupported audio file %s: expected WAV or MP3", path)
+	}
+	return data, format, nil
+}
+
+// detectAudioFormat returns AudioFormatWAV for a RIFF/WAVE header,
+// AudioFormatMP3 for an ID3 tag or MPEG audio frame sync, and "" otherwise.
+func detectAudioFormat(data []byte) string {
+	switch {
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE")):
+		return AudioFormatWAV
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return AudioFormatMP3
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return AudioFormatMP3
+	}
+	return ""
+}
+
+// contentJSON is the value sent as a message's content: Content alone, or
+// the content parts, led by Content as a text part if both are set.
+func (m ChatMessage) contentJSON() any {
+	if len(m.ContentParts) == 0 {
+		return m.Content
+	}
+	parts := m.ContentParts
+	if m.Content != "" {
+		parts = append([]ContentPart{TextContentPart{Text: m.Content}}, parts...)
+	}
+	return parts
+}
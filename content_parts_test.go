@@ -0,0 +1,128 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestWAV writes one second of 8 kHz mono 16-bit silence as a WAV file.
+func writeTestWAV(t *testing.T) string {
+	t.Helper()
+	const sampleRate, samples = 8000, 8000
+	data := make([]byte, 44+samples*2)
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	copy(data[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)
+	binary.LittleEndian.PutUint16(data[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(data[22:], 1) // mono
+	binary.LittleEndian.PutUint32(data[24:], sampleRate)
+	binary.LittleEndian.PutUint32(data[28:], sampleRate*2)
+	binary.LittleEndian.PutUint16(data[32:], 2)
+	binary.LittleEndian.PutUint16(data[34:], 16)
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], samples*2)
+
+	path := filepath.Join(t.TempDir(), "hello.wav")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAudioMessageSerialised(t *testing.T) {
+	audio, format, err := LoadAudioFile(writeTestWAV(t))
+	if err != nil {
+		t.Fatalf("LoadAudioFile failed: %v", err)
+	}
+	if format != AudioFormatWAV || len(audio) != 44+16000 {
+		t.Fatalf("expected a 16044-byte wav, got %d bytes of %q", len(audio), format)
+	}
+
+	var body struct {
+		Messages []struct {
+			Role    string            `json:"role"`
+			Content []json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	msg := NewAudioMessage("user", audio, format)
+	msg.Content = "Reply to this."
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o-audio-preview", []ChatMessage{msg}, nil); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if len(body.Messages) != 1 || len(body.Messages[0].Content) != 2 {
+		t.Fatalf("expected one message with two content parts, got %+v", body.Messages)
+	}
+	var text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	json.Unmarshal(body.Messages[0].Content[0], &text)
+	if text.Type != "text" || text.Text != "Reply to this." {
+		t.Errorf("unexpected text part %s", body.Messages[0].Content[0])
+	}
+	var part struct {
+		Type       string `json:"type"`
+		InputAudio struct {
+			Data   string `json:"data"`
+			Format string `json:"format"`
+		} `json:"input_audio"`
+	}
+	json.Unmarshal(body.Messages[0].Content[1], &part)
+	if part.Type != "input_audio" || part.InputAudio.Format != "wav" {
+		t.Errorf("unexpected audio part %s", body.Messages[0].Content[1])
+	}
+	if part.InputAudio.Data != base64.StdEncoding.EncodeToString(audio) {
+		t.Error("expected the audio base64-encoded in input_audio.data")
+	}
+}
+
+func TestContentPartsMarshal(t *testing.T) {
+	msg := ChatMessage{Role: "user", ContentParts: []ContentPart{
+		ImageContentPart{URL: "https://example.com/cat.png", Detail: "low"},
+		AudioContentPart{AudioData: "AAAA", Format: AudioFormatPCM16},
+	}}
+	got, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"user","content":[{"image_url":{"detail":"low","url":"https://example.com/cat.png"},"type":"image_url"},{"input_audio":{"data":"AAAA","format":"pcm16"},"type":"input_audio"}]}`
+	if string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	plain, _ := json.Marshal(ChatMessage{Role: "user", Content: "hi"})
+	if string(plain) != `{"role":"user","content":"hi"}` {
+		t.Errorf("expected plain string content, got %s", plain)
+	}
+}
+
+func TestLoadAudioFileDetectsFormat(t *testing.T) {
+	dir := t.TempDir()
+	mp3 := filepath.Join(dir, "a.bin")
+	os.WriteFile(mp3, []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), 0o600)
+	if _, format, err := LoadAudioFile(mp3); err != nil || format != AudioFormatMP3 {
+		t.Errorf("expected mp3, got %q, %v", format, err)
+	}
+
+	txt := filepath.Join(dir, "a.wav")
+	os.WriteFile(txt, []byte("not audio"), 0o600)
+	if _, _, err := LoadAudioFile(txt); err == nil {
+		t.Error("expected an error for a non-audio file")
+	}
+}
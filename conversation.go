@@ -0,0 +1,232 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ConversationSession keeps the message history of a multi-turn chat and
+// sends it with each new message. When WithMaxHistoryTokens is set, the
+// history is shortened by a TruncationStrategy before it outgrows the limit.
+// A session is safe for concurrent use; sends are serialised.
+type ConversationSession struct {
+	ID           string
+	Model        string
+	SystemPrompt string
+	CreatedAt    time.Time
+
+	client           *LLMClient
+	options          *ChatCompletionOptions
+	maxHistoryTokens int
+	truncation       TruncationStrategy
+	// tools runs the model's tool calls; see WithToolRegistry.
+	tools *ToolRegistry
+
+	mu           sync.Mutex
+	history      []ChatMessage
+	lastResponse *ChatResponse
+}
+
+// ConversationOption configures a ConversationSession.
+type ConversationOption func(*ConversationSession)
+
+// WithSystemPrompt sets the system prompt sent at the start of every request.
+// It is not part of History and is never truncated.
+func WithSystemPrompt(prompt string) ConversationOption {
+	return func(s *ConversationSession) {
+		s.SystemPrompt = prompt
+	}
+}
+
+// WithConversationOptions sets the ChatCompletionOptions used for each turn.
+func WithConversationOptions(opts *ChatCompletionOptions) ConversationOption {
+	return func(s *ConversationSession) {
+		s.options = opts
+	}
+}
+
+// WithMaxHistoryTokens truncates the history once its estimated size exceeds
+// n tokens. 0 (the default) keeps the full history.
+func WithMaxHistoryTokens(n int) ConversationOption {
+	return func(s *ConversationSession) {
+		s.maxHistoryTokens = n
+	}
+}
+
+// WithTruncation sets the strategy used to shorten the history
+// (DropOldestTruncation by default).
+func WithTruncation(strategy TruncationStrategy) ConversationOption {
+	return func(s *ConversationSession) {
+		s.truncation = strategy
+	}
+}
+
+// NewConversationSession starts an empty conversation with model.
+func NewConversationSession(client *LLMClient, model string, opts ...ConversationOption) *ConversationSession {
+	s := &ConversationSession{
+		ID:         NewIdempotencyKey(),
+		Model:      model,
+		CreatedAt:  time.Now().UTC(),
+		client:     client,
+		truncation: DropOldestTruncation{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Client returns the client the session sends with.
+func (s *ConversationSession) Client() *LLMClient {
+	return s.client
+}
+
+// LastResponse returns the full response to the most recent successful
+// send, with its finish reason and usage, or nil before the first.
+func (s *ConversationSession) LastResponse() *ChatResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastResponse
+}
+
+// History returns a copy of the conversation so far, excluding the system
+// prompt.
+func (s *ConversationSession) History() []ChatMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ChatMessage(nil), s.history...)
+}
+
+// Send adds a user message, truncating the history first if it is over the
+// limit, and returns the assistant's reply, which is added too. If the call
+// fails the user message is not kept.
+func (s *ConversationSession) Send(ctx context.Context, content string) (string, error) {
+	return s.SendWithRole(ctx, "user", content)
+}
+
+// SendWithRole is Send for a message with the given role.
+func (s *ConversationSession) SendWithRole(ctx context.Context, role, content string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.send(ctx, ChatMessage{Role: role, Content: content})
+}
+
+// AddToolResult adds the result of one of the latest assistant message's
+// tool calls. Once every tool call has a result, the conversation is sent
+// and the model's reply returned; until then it returns "" and a nil error.
+func (s *ConversationSession) AddToolResult(ctx context.Context, toolCallID, name, content string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := NewToolResultMessage(toolCallID, name, content)
+	if s.awaitingToolResults(toolCallID) {
+		s.history = append(s.history, msg)
+		return "", nil
+	}
+	return s.send(ctx, msg)
+}
+
+// awaitingToolResults reports whether tool calls of the latest assistant
+// message other than answering still lack a result.
+func (s *ConversationSession) awaitingToolResults(answering string) bool {
+	answered := map[string]bool{answering: true}
+	for i := len(s.history) - 1; i >= 0; i-- {
+		m := s.history[i]
+		switch m.Role {
+		case "tool":
+			answered[m.ToolCallID] = true
+		case "assistant":
+			for _, call := range m.ToolCalls {
+				if !answered[call.ID] {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// send adds msg and sends the conversation. With a ToolRegistry, tool calls
+// in the reply are run and their results sent until the model answers
+// without them; rounds already answered stay in the history if a later one
+// fails. s.mu must be held.
+func (s *ConversationSession) send(ctx context.Context, msg ChatMessage) (string, error) {
+	pending := []ChatMessage{msg}
+	for round := 0; ; round++ {
+		history := append(append([]ChatMessage(nil), s.history...), pending...)
+		if s.maxHistoryTokens > 0 && s.truncation != nil && estimateTokens(history) > s.maxHistoryTokens {
+			truncated, err := s.truncation.Truncate(ctx, s, history, s.maxHistoryTokens)
+			if err != nil {
+				return "", err
+			}
+			history = truncated
+		}
+
+		messages := history
+		if s.SystemPrompt != "" {
+			messages = append([]ChatMessage{{Role: "system", Content: s.SystemPrompt}}, history...)
+		}
+		resp, err := s.client.ChatCompletion(ctx, s.Model, messages, s.sendOptions())
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", &APIError{Message: "No choices in response"}
+		}
+
+		s.lastResponse = resp
+		reply := resp.Choices[0].Message
+		s.history = append(history, ChatMessage{Role: "assistant", Content: reply.Content, ToolCalls: reply.ToolCalls})
+		if s.tools == nil || len(reply.ToolCalls) == 0 {
+			return reply.Content, nil
+		}
+		if round+1 >= maxToolRounds {
+			return "", fmt.Errorf("%w after %d rounds", ErrToolRounds, maxToolRounds)
+		}
+		results, err := ExecuteToolsParallel(ctx, reply.ToolCalls, s.tools.executeCall, 0)
+		if err != nil {
+			return "", err
+		}
+		pending = ToolResultsToMessages(results)
+	}
+}
+
+// TruncationStrategy shortens a conversation history that has grown past
+// maxTokens. history ends with the message about to be sent, which must be
+// kept.
+type TruncationStrategy interface {
+	Truncate(ctx context.Context, s *ConversationSession, history []ChatMessage, maxTokens int) ([]ChatMessage, error)
+}
+
+// DropOldestTruncation discards the oldest non-system messages until the
+// history fits.
+type DropOldestTruncation struct{}
+
+// Truncate implements TruncationStrategy.
+func (DropOldestTruncation) Truncate(_ context.Context, _ *ConversationSession, history []ChatMessage, maxTokens int) ([]ChatMessage, error) {
+	out := append([]ChatMessage(nil), history...)
+	for estimateTokens(out) > maxTokens {
+		i := 0
+		for i < len(out)-1 && out[i].Role == "system" {
+			i++
+		}
+		if i >= len(out)-1 {
+			break // only system messages and the new message remain
+		}
+		out = append(out[:i], out[i+1:]...)
+	}
+	return out, nil
+}
+
+// estimateTokens roughly sizes messages at four characters per token plus
+// a small per-message overhead.
+func estimateTokens(messages []ChatMessage) int {
+	n := 0
+	for _, m := range messages {
+		n += len(m.Content)/4 + 4
+	}
+	return n
+}
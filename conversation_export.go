@@ -0,0 +1,80 @@
+package blockrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// conversationExportVersion is the Export format version. Bump it when the
+// format changes and teach ImportConversation to read the older versions.
+const conversationExportVersion = 1
+
+// conversationExport is the JSON form of a ConversationSession.
+type conversationExport struct {
+	Version      int           `json:"version"`
+	ID           string        `json:"id"`
+	Model        string        `json:"model"`
+	SystemPrompt string        `json:"system_prompt,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	History      []ChatMessage `json:"history"`
+}
+
+// Export returns the session's history and metadata (ID, model, system
+// prompt and creation time) as JSON, for ImportConversation. Options set
+// with ConversationOptions other than WithSystemPrompt are not included.
+func (s *ConversationSession) Export() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Marshal(conversationExport{
+		Version:      conversationExportVersion,
+		ID:           s.ID,
+		Model:        s.Model,
+		SystemPrompt: s.SystemPrompt,
+		CreatedAt:    s.CreatedAt,
+		History:      s.history,
+	})
+}
+
+// ImportConversation restores a session from Export's output, sending with
+// client. opts are applied afterwards, to restore settings Export does not
+// keep, such as WithMaxHistoryTokens.
+func ImportConversation(client *LLMClient, data []byte, opts ...ConversationOption) (*ConversationSession, error) {
+	var e conversationExport
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation: %w", err)
+	}
+	if e.Version < 1 || e.Version > conversationExportVersion {
+		return nil, fmt.Errorf("unsupported conversation export version %d", e.Version)
+	}
+
+	s := NewConversationSession(client, e.Model, WithSystemPrompt(e.SystemPrompt))
+	s.ID, s.CreatedAt, s.history = e.ID, e.CreatedAt, e.History
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// SaveConversation writes s.Export() to path, readable only by its owner.
+func SaveConversation(s *ConversationSession, path string) error {
+	data, err := s.Export()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file.
+	return os.Chmod(path, 0600)
+}
+
+// LoadConversation restores a session saved with SaveConversation.
+func LoadConversation(client *LLMClient, path string, opts ...ConversationOption) (*ConversationSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ImportConversation(client, data, opts...)
+}
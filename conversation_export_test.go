@@ -0,0 +1,67 @@
+package blockrun
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConversationExportImport(t *testing.T) {
+	server, _ := newConversationServer(t, "unused marker")
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+
+	s := NewConversationSession(client, "gpt-4o", WithSystemPrompt("You are terse."))
+	s.history = []ChatMessage{
+		{Role: "user", Content: "What's the weather in Paris?"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: ToolCallFunction{Name: "weather", Arguments: `{"city":"Paris"}`}}}},
+		NewToolResultMessage("call_1", "weather", "18C, cloudy"),
+		{Role: "assistant", Content: "18C and cloudy."},
+		{Role: "user", Content: "Thanks"},
+		{Role: "user", Content: "What's in these?", ContentParts: []ContentPart{
+			ImageContentPart{URL: "https://example.com/cat.png", Detail: "low"},
+			NewAudioMessage("user", []byte("RIFF"), AudioFormatWAV).ContentParts[0],
+			FileContentPart{FileID: "file-abc"},
+			TextContentPart{Text: "Compare them."},
+		}},
+		{Role: "user", ContentParts: []ContentPart{ImageContentPart{URL: "data:image/png;base64,AAAA"}}},
+	}
+
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := SaveConversation(s, path); err != nil {
+		t.Fatalf("SaveConversation failed: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"version":1`) {
+		t.Errorf("expected a version field, got %s", data)
+	}
+
+	restored, err := LoadConversation(client, path)
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if restored.ID != s.ID || restored.Model != s.Model || restored.SystemPrompt != s.SystemPrompt || !restored.CreatedAt.Equal(s.CreatedAt) {
+		t.Errorf("metadata not restored: %+v", restored)
+	}
+	if !reflect.DeepEqual(restored.History(), s.History()) {
+		t.Errorf("history not restored:\n got %+v\nwant %+v", restored.History(), s.History())
+	}
+
+	reply, err := restored.Send(context.Background(), "And tomorrow?")
+	if err != nil {
+		t.Fatalf("Send after import failed: %v", err)
+	}
+	if reply != "reply 1" || len(restored.History()) != 9 {
+		t.Errorf("expected a reply appended to the history, got %q and %d messages", reply, len(restored.History()))
+	}
+
+	if _, err := ImportConversation(client, []byte(`{"version":99}`)); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
@@ -0,0 +1,128 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newConversationServer answers summary requests (those containing marker)
+// with a fixed summary and everything else with a numbered reply. Requests
+// are paid, so spending can be checked.
+func newConversationServer(t *testing.T, marker string) (*httptest.Server, *[]string) {
+	t.Helper()
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var mu sync.Mutex
+	var prompts []string
+	replies := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		var body struct {
+			Messages []ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		last := body.Messages[len(body.Messages)-1].Content
+
+		mu.Lock()
+		defer mu.Unlock()
+		prompts = append(prompts, last)
+		reply := "The user and assistant exchanged greetings."
+		if !strings.Contains(last, marker) {
+			replies++
+			reply = fmt.Sprintf("reply %d", replies)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: ChatMessage{Role: "assistant", Content: reply}}}})
+	}))
+	t.Cleanup(server.Close)
+	return server, &prompts
+}
+
+func TestSummarisingTruncation(t *testing.T) {
+	server, prompts := newConversationServer(t, DefaultSummaryInstruction)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+
+	session := NewConversationSession(client, "gpt-4o",
+		WithSystemPrompt("You are terse."),
+		WithMaxHistoryTokens(40),
+		WithTruncation(NewSummarisingTruncation()))
+
+	const turns = 6
+	for i := 0; i < turns; i++ {
+		if _, err := session.Send(context.Background(), fmt.Sprintf("this is user message number %d, padded out a little", i)); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+
+	history := session.History()
+	if len(history) >= 2*turns {
+		t.Errorf("expected the history to shrink below %d messages, got %d", 2*turns, len(history))
+	}
+	if history[0].Role != "system" || !strings.HasPrefix(history[0].Content, "Earlier conversation summary: The user and assistant") {
+		t.Errorf("expected a summary system message first, got %+v", history[0])
+	}
+	if last := history[len(history)-1]; last.Role != "assistant" || last.Content != fmt.Sprintf("reply %d", turns) {
+		t.Errorf("expected the latest reply to be kept, got %+v", last)
+	}
+
+	summaries := 0
+	for _, p := range *prompts {
+		if strings.HasSuffix(p, DefaultSummaryInstruction) {
+			summaries++
+			if !strings.Contains(p, "user: this is user message number 0") && summaries == 1 {
+				t.Errorf("first summary should cover the oldest message, got %q", p)
+			}
+		}
+	}
+	if summaries == 0 {
+		t.Fatal("expected at least one summary call")
+	}
+	if got := client.GetSpending().Calls; got != turns+summaries {
+		t.Errorf("expected %d paid calls including summaries, got %d", turns+summaries, got)
+	}
+}
+
+func TestSummarySuffix(t *testing.T) {
+	const suffix = "Give a one-line recap."
+	server, prompts := newConversationServer(t, suffix)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+
+	session := NewConversationSession(client, "gpt-4o",
+		WithMaxHistoryTokens(20),
+		WithTruncation(NewSummarisingTruncation(WithSummarySuffix(suffix))))
+	for i := 0; i < 3; i++ {
+		if _, err := session.Send(context.Background(), "a message long enough to need truncating"); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	found := false
+	for _, p := range *prompts {
+		found = found || strings.HasSuffix(p, suffix)
+	}
+	if !found {
+		t.Errorf("expected a summary request ending with the custom suffix, got %q", *prompts)
+	}
+}
+
+func TestDropOldestTruncation(t *testing.T) {
+	history := []ChatMessage{
+		{Role: "system", Content: "keep me"},
+		{Role: "user", Content: strings.Repeat("a", 40)},
+		{Role: "assistant", Content: strings.Repeat("b", 40)},
+		{Role: "user", Content: "latest"},
+	}
+	out, _ := DropOldestTruncation{}.Truncate(context.Background(), nil, history, 20)
+	if len(out) != 2 || out[0].Content != "keep me" || out[1].Content != "latest" {
+		t.Errorf("unexpected truncation %+v", out)
+	}
+}
@@ -14,6 +14,8 @@ type CostLogEntry struct {
 	Timestamp float64 `json:"ts"`
 	Endpoint  string  `json:"endpoint"`
 	CostUSD   float64 `json:"cost_usd"`
+	// Shadow marks spending on a shadow call (see WithShadowModel).
+	Shadow bool `json:"shadow,omitempty"`
 }
 
 // CostSummary represents an aggregate summary of cost log entries.
@@ -43,14 +45,15 @@ func newCostLogWithPath(path string) *CostLog {
 
 // Append writes a cost log entry to the JSONL file.
 func (cl *CostLog) Append(endpoint string, costUSD float64) error {
+	return cl.write(CostLogEntry{Endpoint: endpoint, CostUSD: costUSD})
+}
+
+// write appends entry, stamping it with the current time.
+func (cl *CostLog) write(entry CostLogEntry) error {
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
 
-	entry := CostLogEntry{
-		Timestamp: float64(time.Now().UnixMilli()) / 1000.0,
-		Endpoint:  endpoint,
-		CostUSD:   costUSD,
-	}
+	entry.Timestamp = float64(time.Now().UnixMilli()) / 1000.0
 
 	f, err := os.OpenFile(cl.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -0,0 +1,81 @@
+package blockrun
+
+import (
+	"context"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// costTagAttributePrefix prefixes cost tag keys in span attributes.
+const costTagAttributePrefix = "blockrun.tag."
+
+// WithCostTag attributes a call's spending to a project or cost center. The
+// tag is recorded on the call's Receipt (see WithReceiptStore), where it
+// can be aggregated with SpendingByTag / ExportTaggedCSV, and as a
+// "blockrun.tag.<key>" attribute on the OpenTelemetry span in the call's
+// context. Pass it several times for several tags; a later value for the
+// same key wins.
+func WithCostTag(key, value string) RequestOption {
+	return func(cfg *requestConfig) {
+		// Copy rather than mutate: cfg may be a copy of a parent context's
+		// config that still shares the map.
+		tags := make(map[string]string, len(cfg.tags)+1)
+		for k, v := range cfg.tags {
+			tags[k] = v
+		}
+		tags[key] = value
+		cfg.tags = tags
+	}
+}
+
+// SetDefaultTags sets cost tags applied to every paid call made by this
+// client. Per-call WithCostTag values override defaults with the same key.
+// Passing nil clears the defaults.
+func (c *LLMClient) SetDefaultTags(tags map[string]string) {
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	c.mu.Lock()
+	c.defaultTags = copied
+	c.mu.Unlock()
+}
+
+// costTagsFor merges the client's default tags with the call's tags. It
+// returns nil when there are none. Callers must hold bc.mu.
+func (bc *baseClient) costTagsFor(cfg *requestConfig) map[string]string {
+	if len(bc.defaultTags) == 0 && (cfg == nil || len(cfg.tags) == 0) {
+		return nil
+	}
+	tags := make(map[string]string, len(bc.defaultTags))
+	for k, v := range bc.defaultTags {
+		tags[k] = v
+	}
+	if cfg != nil {
+		for k, v := range cfg.tags {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// setTagAttributes records tags on the OpenTelemetry span in ctx as
+// "blockrun.tag.<key>" attributes. Without a recording span it does nothing.
+func setTagAttributes(ctx context.Context, tags map[string]string) {
+	span := trace.SpanFromContext(ctx)
+	if len(tags) == 0 || !span.IsRecording() {
+		return
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute.KeyValue, len(keys))
+	for i, k := range keys {
+		attrs[i] = attribute.String(costTagAttributePrefix+k, tags[k])
+	}
+	span.SetAttributes(attrs...)
+}
@@ -0,0 +1,168 @@
+// Package dashboard serves a live spending dashboard for a blockrun client:
+//
+//	client, _ := blockrun.NewLLMClient("", blockrun.WithReceiptStore(blockrun.NewMemoryReceiptStore()))
+//	srv, err := dashboard.StartDashboard("localhost:8090", client)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer srv.Close()
+//
+// GET / is an HTML page, refreshed every few seconds, showing the session's
+// spending, spending by model and the latest receipts. GET /api/spending
+// returns the same totals as a JSON blockrun.SpendingTotal. Per-model
+// totals and receipts need a receipt store (blockrun.WithReceiptStore);
+// without one only the session totals are shown.
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// RefreshSeconds is how often the HTML page reloads itself.
+const RefreshSeconds = 5
+
+// recentReceipts is how many receipts the page lists.
+const recentReceipts = 10
+
+// handler serves the dashboard for one client.
+type handler struct {
+	client *blockrun.LLMClient
+}
+
+// NewDashboardHandler returns a handler serving GET / and /api/spending for
+// client.
+func NewDashboardHandler(client *blockrun.LLMClient) http.Handler {
+	return &handler{client: client}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		h.servePage(w, r)
+	case "/api/spending":
+		total, err := h.total(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(total)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// total is the client's SpendingTotal. Without a receipt store it carries
+// only the session totals, with TotalCalls counting the session's calls.
+func (h *handler) total(ctx context.Context) (*blockrun.SpendingTotal, error) {
+	total, err := h.client.GetTotalSpending(ctx)
+	if errors.Is(err, blockrun.ErrNoReceiptStore) {
+		session := h.client.GetSpending()
+		return &blockrun.SpendingTotal{
+			CurrentSessionUSD: session.TotalUSD,
+			TotalCalls:        session.Calls,
+			ByModel:           map[string]float64{},
+			ByDate:            map[string]float64{},
+		}, nil
+	}
+	return total, err
+}
+
+// modelRow is one row of the page's spending-by-model table.
+type modelRow struct {
+	Model string
+	blockrun.Spending
+}
+
+// pageData is what the page template renders.
+type pageData struct {
+	Refresh  int
+	Wallet   string
+	Session  blockrun.Spending
+	Models   []modelRow
+	Receipts []blockrun.Receipt
+}
+
+func (h *handler) servePage(w http.ResponseWriter, r *http.Request) {
+	data := pageData{
+		Refresh: RefreshSeconds,
+		Wallet:  h.client.GetWalletAddress(),
+		Session: h.client.GetSpending(),
+	}
+	for model, s := range h.client.SpendingByModel() {
+		data.Models = append(data.Models, modelRow{Model: model, Spending: s})
+	}
+	sort.Slice(data.Models, func(i, j int) bool {
+		return data.Models[i].TotalUSD > data.Models[j].TotalUSD
+	})
+	data.Receipts, _ = h.client.RecentReceipts(recentReceipts)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, data); err != nil {
+		log.Printf("blockrun/dashboard: render: %v", err)
+	}
+}
+
+var page = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>BlockRun spending</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>BlockRun spending</h1>
+<p>Wallet: <code>{{.Wallet}}</code></p>
+<p>Session: <strong>${{printf "%.6f" .Session.TotalUSD}}</strong> over {{.Session.Calls}} calls</p>
+<h2>By model</h2>
+<table>
+<tr><th>Model</th><th>Calls</th><th>USD</th></tr>
+{{range .Models}}<tr><td>{{.Model}}</td><td>{{.Calls}}</td><td>{{printf "%.6f" .TotalUSD}}</td></tr>
+{{else}}<tr><td colspan="3">No receipts</td></tr>
+{{end}}</table>
+<h2>Recent receipts</h2>
+<table>
+<tr><th>Time</th><th>Endpoint</th><th>Model</th><th>USD</th></tr>
+{{range .Receipts}}<tr><td>{{.Timestamp.Format "2006-01-02 15:04:05"}}</td><td>{{.Endpoint}}</td><td>{{.Model}}</td><td>{{printf "%.6f" .CostUSD}}</td></tr>
+{{else}}<tr><td colspan="4">No receipts</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// StartDashboard serves NewDashboardHandler(client) on addr in a background
+// goroutine and returns the server, to be closed on shutdown. It fails if
+// addr cannot be listened on; later serving errors are logged.
+func StartDashboard(addr string, client *blockrun.LLMClient) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Addr:              ln.Addr().String(),
+		Handler:           NewDashboardHandler(client),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("blockrun/dashboard: server: %v", err)
+		}
+	}()
+	return srv, nil
+}
@@ -0,0 +1,104 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// newClient returns a client whose receipt store holds two receipts.
+func newClient(t *testing.T) *blockrun.LLMClient {
+	t.Setenv("HOME", t.TempDir())
+	store := blockrun.NewMemoryReceiptStore()
+	now := time.Now()
+	store.Save(blockrun.Receipt{Timestamp: now.Add(-time.Minute), Endpoint: "/v1/chat/completions", Model: "openai/gpt-4o", CostUSD: 0.002})
+	store.Save(blockrun.Receipt{Timestamp: now, Endpoint: "/v1/chat/completions", Model: "deepseek/deepseek-chat", CostUSD: 0.0005})
+	client, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithReceiptStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	return rec
+}
+
+func TestDashboardPage(t *testing.T) {
+	client := newClient(t)
+	rec := get(NewDashboardHandler(client), "/")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		client.GetWalletAddress(),
+		`<meta http-equiv="refresh" content="5">`,
+		"openai/gpt-4o",
+		"deepseek/deepseek-chat",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if strings.Index(body, "0.000500") > strings.LastIndex(body, "0.002000") {
+		t.Error("expected receipts listed newest first")
+	}
+
+	if got := get(NewDashboardHandler(client), "/other").Code; got != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown path, got %d", got)
+	}
+}
+
+func TestDashboardAPI(t *testing.T) {
+	rec := get(NewDashboardHandler(newClient(t)), "/api/spending")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var total blockrun.SpendingTotal
+	if err := json.NewDecoder(rec.Body).Decode(&total); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if total.TotalCalls != 2 || total.ByModel["openai/gpt-4o"] != 0.002 {
+		t.Errorf("unexpected totals: %+v", total)
+	}
+
+	// Without a receipt store only the session totals are reported.
+	client, _ := blockrun.NewLLMClient(testPrivateKey)
+	rec = get(NewDashboardHandler(client), "/api/spending")
+	if err := json.NewDecoder(rec.Body).Decode(&total); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected session totals, got %d: %v", rec.Code, err)
+	}
+	if !strings.Contains(get(NewDashboardHandler(client), "/").Body.String(), client.GetWalletAddress()) {
+		t.Error("page without a receipt store missing the wallet address")
+	}
+}
+
+func TestStartDashboard(t *testing.T) {
+	srv, err := StartDashboard("127.0.0.1:0", newClient(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	resp, err := http.Get("http://" + srv.Addr + "/api/spending")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	if _, err := StartDashboard(srv.Addr, newClient(t)); err == nil {
+		t.Error("expected an error for an address in use")
+	}
+}
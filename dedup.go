@@ -0,0 +1,132 @@
+package blockrun
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DeduplicationFilter collapses identical requests made close together into a
+// single API call (and a single payment). While a request is in flight, any
+// identical request blocks and receives the same response; a successful
+// response is also reused for Window after it arrives. Failed calls are never
+// reused, so a retry after an error goes to the gateway as normal.
+//
+// Requests are identical when their full request body, idempotency key,
+// cost tags and user metadata match. Calls paid with WithExternalPayment are
+// never deduplicated, since each caller pays for its own request. Payment
+// handling (402 → sign → retry) happens inside the shared call, so
+// re-signing after a 402 is unaffected.
+//
+// A DeduplicationFilter is safe for concurrent use.
+type DeduplicationFilter struct {
+	// Window is how long a completed response keeps satisfying identical
+	// requests. Zero only collapses requests that overlap in flight.
+	Window time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*dedupCall
+}
+
+// dedupCall is one in-flight or recently completed request.
+type dedupCall struct {
+	done chan struct{}
+	resp []byte
+	err  error
+}
+
+// NewDeduplicationFilter creates a filter that reuses responses for window.
+func NewDeduplicationFilter(window time.Duration) *DeduplicationFilter {
+	return &DeduplicationFilter{Window: window, calls: make(map[string]*dedupCall)}
+}
+
+// WithDeduplication installs a DeduplicationFilter on the client so identical
+// ChatCompletion calls within window share one request and one payment.
+func WithDeduplication(window time.Duration) ClientOption {
+	return func(c *LLMClient) {
+		c.dedup = NewDeduplicationFilter(window)
+	}
+}
+
+// do runs fn once per key among concurrent or recent callers. A nil filter
+// or empty key always calls fn with ctx. Every caller, the first included,
+// waits on its own ctx. The shared call keeps the first caller's context
+// values but not its cancellation, so one caller giving up does not fail the
+// others; it is still bounded by the client's timeout and any per-call
+// deadline.
+func (f *DeduplicationFilter) do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	if f == nil || key == "" {
+		return fn(ctx)
+	}
+
+	f.mu.Lock()
+	call, ok := f.calls[key]
+	if !ok {
+		call = &dedupCall{done: make(chan struct{})}
+		f.calls[key] = call
+		go f.run(context.WithoutCancel(ctx), key, call, fn)
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.resp, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run makes the shared call for key and keeps its response for Window.
+func (f *DeduplicationFilter) run(ctx context.Context, key string, call *dedupCall, fn func(context.Context) ([]byte, error)) {
+	call.resp, call.err = fn(ctx)
+	close(call.done)
+
+	if call.err != nil || f.Window <= 0 {
+		f.forget(key, call)
+	} else {
+		time.AfterFunc(f.Window, func() { f.forget(key, call) })
+	}
+}
+
+// forget drops key if it still refers to call.
+func (f *DeduplicationFilter) forget(key string, call *dedupCall) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls[key] == call {
+		delete(f.calls, key)
+	}
+}
+
+// dedupKey hashes a request body together with the call's idempotency key,
+// cost tags and user metadata. Calls paid with WithExternalPayment and
+// bodies that cannot be marshalled get an empty key, which disables
+// deduplication for that call.
+func dedupKey(ctx context.Context, endpoint string, body map[string]any) string {
+	cfg := requestConfigFrom(ctx)
+	if cfg != nil && cfg.externalPayment {
+		return ""
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(endpoint))
+	h.Write([]byte{0})
+	h.Write(raw)
+	h.Write([]byte{0})
+	h.Write([]byte(idempotencyKeyFrom(ctx)))
+	if cfg != nil {
+		// json.Marshal sorts map keys, so equal maps hash the same.
+		tags, _ := json.Marshal(cfg.tags)
+		metadata, _ := json.Marshal(cfg.userMetadata)
+		h.Write([]byte{0})
+		h.Write(tags)
+		h.Write([]byte{0})
+		h.Write(metadata)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
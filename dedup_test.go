@@ -0,0 +1,176 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeduplicationCollapsesConcurrentRequests(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var probes, paid int32
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			atomic.AddInt32(&probes, 1)
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		atomic.AddInt32(&paid, 1)
+		<-release // hold the first call in flight while the others arrive
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithDeduplication(time.Second))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	client.costLog = nil
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+			if err == nil && resp.Choices[0].Message.Content != "ok" {
+				t.Errorf("unexpected response content %q", resp.Choices[0].Message.Content)
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+	}
+	if probes != 1 || paid != 1 {
+		t.Errorf("expected exactly one probe and one paid request, got %d and %d", probes, paid)
+	}
+	if calls := client.GetSpending().Calls; calls != 1 {
+		t.Errorf("expected one payment recorded, got %d", calls)
+	}
+}
+
+func TestDeduplicationDistinguishesRequests(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithDeduplication(time.Minute))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	ctx := context.Background()
+	calls := []struct {
+		messages []ChatMessage
+		reqOpts  []RequestOption
+	}{
+		{[]ChatMessage{{Role: "user", Content: "a"}}, nil},
+		{[]ChatMessage{{Role: "user", Content: "a"}}, nil}, // reused within window
+		{[]ChatMessage{{Role: "user", Content: "b"}}, nil},
+		{[]ChatMessage{{Role: "user", Content: "a"}}, []RequestOption{WithIdempotencyKey("k1")}},
+		{[]ChatMessage{{Role: "user", Content: "a"}}, []RequestOption{WithCostTag("team", "x")}},
+		{[]ChatMessage{{Role: "user", Content: "a"}}, []RequestOption{WithCostTag("team", "y")}},
+		{[]ChatMessage{{Role: "user", Content: "a"}}, []RequestOption{WithRequestUserMetadata(map[string]string{"user": "u1"})}},
+		// each external payer pays for its own request
+		{[]ChatMessage{{Role: "user", Content: "a"}}, []RequestOption{WithExternalPayment("sig-1")}},
+		{[]ChatMessage{{Role: "user", Content: "a"}}, []RequestOption{WithExternalPayment("sig-1")}},
+	}
+	for _, call := range calls {
+		if _, err := client.ChatCompletion(ctx, "gpt-4o", call.messages, nil, call.reqOpts...); err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+	}
+	if hits != 8 {
+		t.Errorf("expected 8 upstream requests, got %d", hits)
+	}
+}
+
+func TestDeduplicationDoesNotReuseErrors(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithDeduplication(time.Minute))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err == nil {
+		t.Fatal("expected first call to fail")
+	}
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err != nil {
+		t.Fatalf("expected retry after error to reach the server, got %v", err)
+	}
+}
+
+func TestDeduplicationSurvivesLeaderCancel(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithDeduplication(time.Second))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := client.ChatCompletion(leaderCtx, "gpt-4o", messages, nil)
+		leader <- err
+	}()
+	time.Sleep(50 * time.Millisecond) // let the leader start the shared call
+	follower := make(chan error, 1)
+	go func() {
+		resp, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+		if err == nil && resp.Choices[0].Message.Content != "ok" {
+			t.Errorf("unexpected response content %q", resp.Choices[0].Message.Content)
+		}
+		follower <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-leader; err != context.Canceled {
+		t.Errorf("expected the leader to stop waiting with context.Canceled, got %v", err)
+	}
+	close(release)
+	if err := <-follower; err != nil {
+		t.Errorf("expected the follower to get the shared response, got %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("expected one upstream request, got %d", hits)
+	}
+}
@@ -0,0 +1,216 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Payment modes for WithPaymentMode.
+const (
+	// PaymentModeSync pays before the response: the unsigned request is
+	// answered with a 402 and retried with a PAYMENT-SIGNATURE.
+	PaymentModeSync = "sync"
+	// PaymentModeDeferred pays after the response: a gateway supporting it
+	// serves the unsigned request, quoting the price in its payment-required
+	// header, and the client signs the payment and settles it later with
+	// FlushDeferredPayments.
+	PaymentModeDeferred = "deferred"
+)
+
+// deferredSettleEndpoint receives deferred payments.
+const deferredSettleEndpoint = "/v1/payment/settle"
+
+// ErrDeferredPaymentExpired is joined into FlushDeferredPayments' error for
+// each pending payment whose authorization expired before it settled. Such
+// payments are dropped rather than retried.
+var ErrDeferredPaymentExpired = errors.New("blockrun: deferred payment authorization expired")
+
+// DeferredPaymentRecord is a payment signed for a response already received
+// and waiting for FlushDeferredPayments.
+type DeferredPaymentRecord struct {
+	// RequestID is the response's X-Request-Id, or a random ID.
+	RequestID string
+	// PaymentPayload is the PAYMENT-SIGNATURE value.
+	PaymentPayload string
+	// ResponseHash is the hex SHA-256 of the response body.
+	ResponseHash string
+	// ValidBefore is when the signed authorization expires, on the local
+	// clock. It is zero if the payload does not say.
+	ValidBefore time.Time
+
+	option   *PaymentOption
+	endpoint string
+	model    string
+	cfg      *requestConfig
+}
+
+// WithPaymentMode sets when POST requests are paid: PaymentModeSync (the
+// default) or PaymentModeDeferred. In deferred mode a request the gateway
+// still answers with a 402 is paid synchronously, and spending is recorded
+// only when the payment settles. Other modes are logged and ignored.
+func WithPaymentMode(mode string) ClientOption {
+	return func(c *LLMClient) {
+		switch mode {
+		case PaymentModeSync, PaymentModeDeferred:
+			c.paymentMode = mode
+		default:
+			c.warnf("WithPaymentMode: unknown mode %q; ignoring", mode)
+		}
+	}
+}
+
+// PendingDeferredPayments returns the deferred payments not yet settled,
+// oldest first.
+func (bc *baseClient) PendingDeferredPayments() []DeferredPaymentRecord {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return append([]DeferredPaymentRecord(nil), bc.deferredPayments...)
+}
+
+// deferPayment signs the payment quoted in header for a response already
+// received and queues it for FlushDeferredPayments. It does nothing if the
+// response quotes no payment.
+func (bc *baseClient) deferPayment(ctx context.Context, url, model string, header http.Header, respBody []byte) error {
+	if header.Get("payment-required") == "" {
+		return nil
+	}
+	paymentReq, err := paymentRequirementFromResponse(header, nil)
+	if err != nil {
+		return err
+	}
+	option, err := ExtractPaymentDetails(paymentReq)
+	if err != nil {
+		return &PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
+	}
+	resourceURL := paymentReq.Resource.URL
+	if resourceURL == "" {
+		resourceURL = url
+	}
+	payload, err := bc.signPayment(ctx, option, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
+	if err != nil {
+		return err
+	}
+	bc.clearPendingPayment(ctx)
+	validBefore := payloadValidBefore(payload)
+	if !validBefore.IsZero() {
+		// The window was signed against the gateway's clock when the
+		// local one drifts; convert it back.
+		validBefore = validBefore.Add(-bc.paymentClockOffset(ctx))
+	}
+
+	requestID := header.Get("X-Request-Id")
+	if requestID == "" {
+		requestID = NewIdempotencyKey()
+	}
+	hash := sha256.Sum256(respBody)
+	bc.mu.Lock()
+	bc.deferredPayments = append(bc.deferredPayments, DeferredPaymentRecord{
+		RequestID:      requestID,
+		PaymentPayload: payload,
+		ResponseHash:   hex.EncodeToString(hash[:]),
+		ValidBefore:    validBefore,
+		option:         option,
+		endpoint:       bc.endpointPath(url),
+		model:          model,
+		cfg:            requestConfigFrom(ctx),
+	})
+	bc.mu.Unlock()
+	return nil
+}
+
+// FlushDeferredPayments posts every pending deferred payment to the
+// gateway's settlement endpoint, recording spending for each one accepted.
+// Payments that fail stay pending for the next flush; their errors are
+// joined in the returned error. Payments whose authorization has expired can
+// never settle, so they are dropped and reported with
+// ErrDeferredPaymentExpired instead of being posted.
+func (bc *baseClient) FlushDeferredPayments(ctx context.Context) error {
+	bc.mu.Lock()
+	pending := bc.deferredPayments
+	bc.deferredPayments = nil
+	bc.mu.Unlock()
+
+	var failed []DeferredPaymentRecord
+	var errs []error
+	for i, rec := range pending {
+		if err := ctx.Err(); err != nil {
+			failed = append(failed, pending[i:]...)
+			errs = append(errs, err)
+			break
+		}
+		if !rec.ValidBefore.IsZero() && !currentClock().Now().Before(rec.ValidBefore) {
+			errs = append(errs, fmt.Errorf("settle %s: %w", rec.RequestID, ErrDeferredPaymentExpired))
+			continue
+		}
+		if err := bc.settleDeferred(ctx, rec); err != nil {
+			failed = append(failed, rec)
+			errs = append(errs, fmt.Errorf("settle %s: %w", rec.RequestID, err))
+			continue
+		}
+		settleCtx := context.Background()
+		if rec.cfg != nil {
+			settleCtx = context.WithValue(settleCtx, requestConfigKey{}, rec.cfg)
+		}
+		bc.recordSettledCost(settleCtx, rec.option, rec.endpoint, rec.model, rec.PaymentPayload)
+	}
+
+	if len(failed) > 0 {
+		bc.mu.Lock()
+		bc.deferredPayments = append(failed, bc.deferredPayments...)
+		bc.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// payloadValidBefore returns the validBefore of a PAYMENT-SIGNATURE value's
+// authorization, or the zero time if it cannot be read.
+func payloadValidBefore(payload string) time.Time {
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return time.Time{}
+	}
+	var p PaymentPayload
+	if err := json.Unmarshal(decoded, &p); err != nil {
+		return time.Time{}
+	}
+	secs, err := strconv.ParseInt(p.Payload.Authorization.ValidBefore, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// settleDeferred posts one deferred payment.
+func (bc *baseClient) settleDeferred(ctx context.Context, rec DeferredPaymentRecord) error {
+	body, _ := json.Marshal(map[string]string{
+		"request_id":    rec.RequestID,
+		"response_hash": rec.ResponseHash,
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", bc.baseURL()+deferredSettleEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PAYMENT-SIGNATURE", rec.PaymentPayload)
+
+	resp, err := bc.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, "Deferred payment rejected", respBody)
+	}
+	return nil
+}
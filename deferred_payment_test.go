@@ -0,0 +1,147 @@
+package blockrun
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeferredPayment(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var mu sync.Mutex
+	var events []string
+	var settled map[string]string
+	rejectSettle := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			if r.Header.Get("PAYMENT-SIGNATURE") != "" {
+				t.Error("deferred request should be sent unsigned")
+			}
+			events = append(events, "response")
+			w.Header().Set("payment-required", prHeader)
+			w.Header().Set("X-Request-Id", "req-1")
+			w.Write([]byte(testChatResponseJSON))
+		case "/v1/payment/settle":
+			if rejectSettle {
+				rejectSettle = false
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if _, err := VerifyPaymentPayload(r.Header.Get("PAYMENT-SIGNATURE")); err != nil {
+				t.Errorf("invalid settlement signature: %v", err)
+			}
+			json.NewDecoder(r.Body).Decode(&settled)
+			events = append(events, "settle")
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithPaymentMode(PaymentModeDeferred))
+	client.costLog = nil
+	ctx := context.Background()
+	if _, err := client.Chat(ctx, "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	pending := client.PendingDeferredPayments()
+	if len(pending) != 1 || pending[0].RequestID != "req-1" {
+		t.Fatalf("expected one pending payment for req-1, got %+v", pending)
+	}
+	hash := sha256.Sum256([]byte(testChatResponseJSON))
+	if pending[0].ResponseHash != hex.EncodeToString(hash[:]) {
+		t.Errorf("unexpected response hash %s", pending[0].ResponseHash)
+	}
+	if got := client.GetSpending().Calls; got != 0 {
+		t.Errorf("expected no spending before settlement, got %d calls", got)
+	}
+
+	// A failed settlement stays pending.
+	if err := client.FlushDeferredPayments(ctx); err == nil {
+		t.Fatal("expected the rejected settlement to fail")
+	}
+	if len(client.PendingDeferredPayments()) != 1 {
+		t.Fatal("expected the payment to stay pending")
+	}
+
+	if err := client.FlushDeferredPayments(ctx); err != nil {
+		t.Fatalf("FlushDeferredPayments: %v", err)
+	}
+	if len(events) != 2 || events[0] != "response" || events[1] != "settle" {
+		t.Errorf("expected the response before the settlement, got %v", events)
+	}
+	if settled["request_id"] != "req-1" || settled["response_hash"] != pending[0].ResponseHash {
+		t.Errorf("unexpected settlement body %v", settled)
+	}
+	if len(client.PendingDeferredPayments()) != 0 {
+		t.Error("expected nothing pending after the flush")
+	}
+	if got := client.GetSpending(); got.Calls != 1 || got.TotalUSD != 0.001 {
+		t.Errorf("expected $0.001 over 1 call, got %+v", got)
+	}
+}
+
+func TestDeferredPaymentExpiredIsDropped(t *testing.T) {
+	mock := NewMockClock(time.Now())
+	SetClock(mock)
+	defer SetClock(nil)
+
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var settleCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			w.Header().Set("payment-required", prHeader)
+			w.Header().Set("X-Request-Id", "req-1")
+			w.Write([]byte(testChatResponseJSON))
+		case "/v1/payment/settle":
+			settleCalls++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithPaymentMode(PaymentModeDeferred))
+	client.costLog = nil
+	ctx := context.Background()
+	if _, err := client.Chat(ctx, "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	pending := client.PendingDeferredPayments()
+	if len(pending) != 1 || !pending[0].ValidBefore.Equal(mock.Now().Truncate(time.Second).Add(300*time.Second)) {
+		t.Fatalf("expected one pending payment valid for 300s, got %+v", pending)
+	}
+
+	// Still valid: a failed settlement is retried later.
+	if err := client.FlushDeferredPayments(ctx); err == nil || errors.Is(err, ErrDeferredPaymentExpired) {
+		t.Fatalf("expected a settlement failure, got %v", err)
+	}
+	if len(client.PendingDeferredPayments()) != 1 {
+		t.Fatal("expected the payment to stay pending")
+	}
+
+	// Once the authorization has expired it is dropped without posting.
+	mock.Advance(301 * time.Second)
+	err := client.FlushDeferredPayments(ctx)
+	if !errors.Is(err, ErrDeferredPaymentExpired) {
+		t.Fatalf("expected ErrDeferredPaymentExpired, got %v", err)
+	}
+	if settleCalls != 1 {
+		t.Errorf("expected the expired payment not to be posted, got %d settle calls", settleCalls)
+	}
+	if len(client.PendingDeferredPayments()) != 0 {
+		t.Error("expected the expired payment to be dropped")
+	}
+	if got := client.GetSpending().Calls; got != 0 {
+		t.Errorf("expected no spending for an expired payment, got %d calls", got)
+	}
+}
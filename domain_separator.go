@@ -0,0 +1,97 @@
+package blockrun
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// transferWithAuthorizationTypeHash is the EIP-712 type hash of the EIP-3009
+// TransferWithAuthorization struct, without authorization extras.
+var transferWithAuthorizationTypeHash = crypto.Keccak256Hash([]byte(
+	"TransferWithAuthorization(address from,address to,uint256 value,uint256 validAfter,uint256 validBefore,bytes32 nonce)"))
+
+// domainSeparatorKey identifies an EIP-712 domain with the name, version,
+// chainId and verifyingContract fields used for USDC authorizations.
+type domainSeparatorKey struct {
+	chainID, contract, name, version string
+}
+
+// domainSeparators caches domain separators by domainSeparatorKey. The
+// default Base/USDC domain hashes to the same value for every payment.
+var domainSeparators sync.Map // domainSeparatorKey → [32]byte
+
+// cachedDomainSeparator returns the EIP-712 domain separator of typedData,
+// hashing it only the first time its domain is seen.
+func cachedDomainSeparator(typedData apitypes.TypedData) ([32]byte, error) {
+	d := typedData.Domain
+	key := domainSeparatorKey{contract: d.VerifyingContract, name: d.Name, version: d.Version}
+	if d.ChainId != nil {
+		key.chainID = (*big.Int)(d.ChainId).String()
+	}
+	if cached, ok := domainSeparators.Load(key); ok {
+		return cached.([32]byte), nil
+	}
+
+	hash, err := typedData.HashStruct("EIP712Domain", d.Map())
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to hash domain: %w", err)
+	}
+	var separator [32]byte
+	copy(separator[:], hash)
+	domainSeparators.Store(key, separator)
+	return separator, nil
+}
+
+// usdcDomainSeparator returns the separator of the Base USDC domain with
+// name and version, looking the cache up without building typed data.
+func usdcDomainSeparator(name, version string) ([32]byte, error) {
+	key := domainSeparatorKey{chainID: BaseChainIDStr, contract: USDCBase, name: name, version: version}
+	if cached, ok := domainSeparators.Load(key); ok {
+		return cached.([32]byte), nil
+	}
+	return cachedDomainSeparator(transferAuthorizationTypedData(TransferAuthorization{}, name, version))
+}
+
+// transferAuthorizationMessageHash returns the EIP-712 struct hash of auth,
+// ignoring Extras, encoded directly rather than through apitypes. ok is
+// false if a field is not in the form CreatePaymentPayload writes (hex
+// addresses, decimal integers, a 32-byte hex nonce); the caller then falls
+// back to apitypes, which reports the error.
+func transferAuthorizationMessageHash(auth TransferAuthorization) (hash common.Hash, ok bool) {
+	if !common.IsHexAddress(auth.From) || !common.IsHexAddress(auth.To) {
+		return common.Hash{}, false
+	}
+	var buf [7 * 32]byte
+	copy(buf[0:32], transferWithAuthorizationTypeHash[:])
+	from, to := common.HexToAddress(auth.From), common.HexToAddress(auth.To)
+	copy(buf[44:64], from[:])
+	copy(buf[76:96], to[:])
+	for i, s := range []string{auth.Value, auth.ValidAfter, auth.ValidBefore} {
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+			return common.Hash{}, false
+		}
+		n.FillBytes(buf[96+32*i : 128+32*i])
+	}
+	nonce, err := hexutil.Decode(auth.Nonce)
+	if err != nil || len(nonce) != 32 {
+		return common.Hash{}, false
+	}
+	copy(buf[192:], nonce)
+	return crypto.Keccak256Hash(buf[:]), true
+}
+
+// ClearDomainSeparatorCache forgets every cached EIP-712 domain separator,
+// so the next payment for each domain hashes it again.
+func ClearDomainSeparatorCache() {
+	domainSeparators.Range(func(key, _ any) bool {
+		domainSeparators.Delete(key)
+		return true
+	})
+}
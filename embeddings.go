@@ -0,0 +1,65 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// EmbeddingOptions contains optional parameters for CreateEmbedding.
+type EmbeddingOptions struct {
+	// Dimensions truncates the output vectors, for models that support it.
+	Dimensions int `json:"dimensions,omitempty"`
+	// User is an opaque end-user identifier forwarded upstream.
+	User string `json:"user,omitempty"`
+}
+
+// Embedding is one input's vector.
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// EmbeddingResponse is the OpenAI-compatible /v1/embeddings response.
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Data   []Embedding `json:"data"`
+	Model  string      `json:"model"`
+	Usage  Usage       `json:"usage"`
+}
+
+// CreateEmbedding embeds each input string with model. Data[i] corresponds to
+// input[i].
+func (c *LLMClient) CreateEmbedding(ctx context.Context, model string, input []string, opts *EmbeddingOptions) (*EmbeddingResponse, error) {
+	if model == "" {
+		return nil, &ValidationError{Field: "model", Message: "Model is required"}
+	}
+	if len(input) == 0 {
+		return nil, &ValidationError{Field: "input", Message: "At least one input is required"}
+	}
+
+	body := map[string]any{
+		"model": model,
+		"input": input,
+	}
+	if opts != nil {
+		if opts.Dimensions > 0 {
+			body["dimensions"] = opts.Dimensions
+		}
+		if opts.User != "" {
+			body["user"] = opts.User
+		}
+	}
+
+	respBytes, err := c.doRequest(ctx, "/v1/embeddings", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.Unmarshal(respBytes, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &embResp, nil
+}
@@ -0,0 +1,40 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateEmbedding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] != "text-embedding-3-small" || body["dimensions"] != float64(2) {
+			t.Errorf("unexpected body %v", body)
+		}
+		w.Write([]byte(`{"object":"list","model":"text-embedding-3-small","data":[{"object":"embedding","index":0,"embedding":[0.5,-0.5]}],"usage":{"prompt_tokens":1,"total_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	resp, err := client.CreateEmbedding(context.Background(), "text-embedding-3-small", []string{"hello"}, &EmbeddingOptions{Dimensions: 2})
+	if err != nil {
+		t.Fatalf("CreateEmbedding: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Embedding[1] != -0.5 || resp.Usage.TotalTokens != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if _, err := client.CreateEmbedding(context.Background(), "text-embedding-3-small", nil, nil); err == nil {
+		t.Error("expected validation error for empty input")
+	}
+}
@@ -0,0 +1,212 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// ENSRegistry is the ENS registry contract, deployed at the same address
+	// on Ethereum mainnet and its testnets.
+	ENSRegistry = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+
+	// DefaultENSRPCURL is the Ethereum mainnet endpoint DisplayName uses
+	// unless WithENSRPCURL is set.
+	DefaultENSRPCURL = "https://ethereum.publicnode.com"
+
+	// ENS function selectors.
+	ensSelectorResolver = "0x0178b8bf" // resolver(bytes32)
+	ensSelectorAddr     = "0x3b3b57de" // addr(bytes32)
+	ensSelectorName     = "0x691f3431" // name(bytes32)
+)
+
+// ErrENSNotFound is returned when a name has no address, or an address has
+// no (verified) primary name.
+var ErrENSNotFound = errors.New("ens: not found")
+
+// ResolveENS returns the checksummed address that name resolves to, using
+// the ENS registry on the chain served by rpcURL. Names are lower-cased but
+// not otherwise normalised.
+func ResolveENS(ctx context.Context, rpcURL, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", &ValidationError{Field: "name", Message: "ENS name is required"}
+	}
+	node := ensNamehash(name)
+	resolver, err := ensResolver(ctx, rpcURL, node)
+	if err != nil {
+		return "", err
+	}
+	result, err := ethCall(ctx, rpcURL, resolver, ensSelectorAddr+hex.EncodeToString(node[:]))
+	if err != nil {
+		return "", fmt.Errorf("ens: addr lookup failed: %w", err)
+	}
+	addr, ok := abiAddress(result)
+	if !ok {
+		return "", ErrENSNotFound
+	}
+	return addr, nil
+}
+
+// ReverseResolveENS returns the primary ENS name of address. The name is
+// only returned if it resolves back to address, as ENS requires.
+func ReverseResolveENS(ctx context.Context, rpcURL, address string) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", &ValidationError{Field: "address", Message: "invalid address"}
+	}
+	node := ensNamehash(strings.TrimPrefix(strings.ToLower(address), "0x") + ".addr.reverse")
+	resolver, err := ensResolver(ctx, rpcURL, node)
+	if err != nil {
+		return "", err
+	}
+	result, err := ethCall(ctx, rpcURL, resolver, ensSelectorName+hex.EncodeToString(node[:]))
+	if err != nil {
+		return "", fmt.Errorf("ens: name lookup failed: %w", err)
+	}
+	name, err := abiString(result)
+	if err != nil {
+		return "", fmt.Errorf("ens: %w", err)
+	}
+	if name == "" {
+		return "", ErrENSNotFound
+	}
+
+	forward, err := ResolveENS(ctx, rpcURL, name)
+	if errors.Is(err, ErrENSNotFound) {
+		return "", ErrENSNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(forward, address) {
+		return "", ErrENSNotFound
+	}
+	return name, nil
+}
+
+// GetPaymentLinksWithENS is GetPaymentLinks with ENSName filled in from a
+// reverse lookup against rpcURL. An address without a name is not an error.
+func GetPaymentLinksWithENS(ctx context.Context, rpcURL, address string) (*PaymentLinksInfo, error) {
+	links := GetPaymentLinks(address)
+	name, err := ReverseResolveENS(ctx, rpcURL, address)
+	switch {
+	case errors.Is(err, ErrENSNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		links.ENSName = name
+	}
+	return links, nil
+}
+
+// WithENSRPCURL sets the Ethereum endpoint used for ENS lookups by
+// DisplayName (DefaultENSRPCURL by default).
+func WithENSRPCURL(url string) ClientOption {
+	return func(c *LLMClient) {
+		c.ensRPCURL = url
+	}
+}
+
+// DisplayName returns the wallet's primary ENS name, or its checksummed
+// address if it has none.
+func (c *LLMClient) DisplayName(ctx context.Context) (string, error) {
+	rpcURL := c.ensRPCURL
+	if rpcURL == "" {
+		rpcURL = DefaultENSRPCURL
+	}
+	name, err := ReverseResolveENS(ctx, rpcURL, c.address)
+	if errors.Is(err, ErrENSNotFound) {
+		return common.HexToAddress(c.address).Hex(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ensNamehash implements the ENS namehash algorithm (EIP-137).
+func ensNamehash(name string) [32]byte {
+	var node [32]byte
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256([]byte(labels[i]))
+		copy(node[:], crypto.Keccak256(node[:], label))
+	}
+	return node
+}
+
+// ensResolver returns the resolver contract for node from the registry.
+func ensResolver(ctx context.Context, rpcURL string, node [32]byte) (string, error) {
+	result, err := ethCall(ctx, rpcURL, ENSRegistry, ensSelectorResolver+hex.EncodeToString(node[:]))
+	if err != nil {
+		return "", fmt.Errorf("ens: resolver lookup failed: %w", err)
+	}
+	resolver, ok := abiAddress(result)
+	if !ok {
+		return "", ErrENSNotFound
+	}
+	return resolver, nil
+}
+
+// ethCall makes an eth_call of data against contract to at the latest block.
+func ethCall(ctx context.Context, rpcURL, to, data string) (string, error) {
+	payload, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		Method:  "eth_call",
+		Params:  []interface{}{map[string]string{"to": to, "data": data}, "latest"},
+		ID:      1,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal RPC request: %w", err)
+	}
+	return callRPC(ctx, rpcURL, payload)
+}
+
+// abiAddress decodes an ABI-encoded address, reporting false for the zero
+// address or a short result.
+func abiAddress(result string) (string, bool) {
+	b, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil || len(b) < 32 {
+		return "", false
+	}
+	addr := common.BytesToAddress(b[12:32])
+	if addr == (common.Address{}) {
+		return "", false
+	}
+	return addr.Hex(), true
+}
+
+// abiString decodes an ABI-encoded dynamic string return value.
+func abiString(result string) (string, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex result: %w", err)
+	}
+	if len(b) == 0 {
+		return "", nil
+	}
+	if len(b) < 64 {
+		return "", errors.New("string result too short")
+	}
+	offset := new(big.Int).SetBytes(b[:32])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(b)-32) {
+		return "", errors.New("invalid string offset")
+	}
+	start := offset.Uint64()
+	length := new(big.Int).SetBytes(b[start : start+32])
+	if !length.IsUint64() || length.Uint64() > uint64(len(b))-start-32 {
+		return "", errors.New("invalid string length")
+	}
+	return string(b[start+32 : start+32+length.Uint64()]), nil
+}
@@ -0,0 +1,152 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testENSResolver = "0x8FADE66B79cC9f707aB26799354482EB93a5B7dD"
+	testENSName     = "blockrun-test.eth"
+	testENSAddress  = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266" // testPrivateKey's address
+)
+
+// newENSServer mocks the ENS registry and a public resolver holding
+// forward and reverse records.
+func newENSServer(t *testing.T, forward map[string]string, reverse map[string]string) *httptest.Server {
+	t.Helper()
+	resolvers := map[[32]byte]bool{}
+	addrs := map[[32]byte]string{}
+	names := map[[32]byte]string{}
+	for name, addr := range forward {
+		node := ensNamehash(name)
+		resolvers[node], addrs[node] = true, addr
+	}
+	for addr, name := range reverse {
+		node := ensNamehash(strings.ToLower(strings.TrimPrefix(addr, "0x")) + ".addr.reverse")
+		resolvers[node], names[node] = true, name
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var call struct{ To, Data string }
+		json.Unmarshal(req.Params[0], &call)
+
+		var node [32]byte
+		raw, _ := hex.DecodeString(call.Data[10:])
+		copy(node[:], raw)
+
+		result := "0x"
+		switch {
+		case strings.EqualFold(call.To, ENSRegistry) && call.Data[:10] == ensSelectorResolver:
+			resolver := ""
+			if resolvers[node] {
+				resolver = strings.TrimPrefix(strings.ToLower(testENSResolver), "0x")
+			}
+			result += fmt.Sprintf("%064s", resolver)
+		case strings.EqualFold(call.To, testENSResolver) && call.Data[:10] == ensSelectorAddr:
+			result += fmt.Sprintf("%064s", strings.TrimPrefix(strings.ToLower(addrs[node]), "0x"))
+		case strings.EqualFold(call.To, testENSResolver) && call.Data[:10] == ensSelectorName:
+			name := names[node]
+			padded := make([]byte, (len(name)+31)/32*32)
+			copy(padded, name)
+			result += fmt.Sprintf("%064x", 32) + fmt.Sprintf("%064x", len(name)) + hex.EncodeToString(padded)
+		default:
+			t.Errorf("unexpected call to %s with %s", call.To, call.Data)
+		}
+		json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: 1, Result: result})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestENSNamehash(t *testing.T) {
+	// Test vectors from EIP-137.
+	for name, want := range map[string]string{
+		"":        "0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	} {
+		node := ensNamehash(name)
+		if got := hex.EncodeToString(node[:]); got != want {
+			t.Errorf("namehash(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestResolveENS(t *testing.T) {
+	server := newENSServer(t, map[string]string{testENSName: testENSAddress}, nil)
+
+	addr, err := ResolveENS(context.Background(), server.URL, "BlockRun-Test.eth")
+	if err != nil {
+		t.Fatalf("ResolveENS: %v", err)
+	}
+	if addr != testENSAddress {
+		t.Errorf("ResolveENS = %s, want %s", addr, testENSAddress)
+	}
+
+	if _, err := ResolveENS(context.Background(), server.URL, "unknown.eth"); !errors.Is(err, ErrENSNotFound) {
+		t.Errorf("expected ErrENSNotFound, got %v", err)
+	}
+}
+
+func TestReverseResolveENS(t *testing.T) {
+	other := "0x70997970C51812dc3A010C7d01b50e20d4dc79C8"
+	server := newENSServer(t,
+		map[string]string{testENSName: testENSAddress, "spoof.eth": testENSAddress},
+		map[string]string{testENSAddress: testENSName, other: "spoof.eth"})
+
+	name, err := ReverseResolveENS(context.Background(), server.URL, testENSAddress)
+	if err != nil || name != testENSName {
+		t.Fatalf("ReverseResolveENS = %q, %v", name, err)
+	}
+
+	// other claims spoof.eth, but spoof.eth resolves elsewhere.
+	if _, err := ReverseResolveENS(context.Background(), server.URL, other); !errors.Is(err, ErrENSNotFound) {
+		t.Errorf("expected an unverified reverse record to be ignored, got %v", err)
+	}
+}
+
+func TestGetPaymentLinksWithENS(t *testing.T) {
+	server := newENSServer(t, map[string]string{testENSName: testENSAddress},
+		map[string]string{testENSAddress: testENSName})
+
+	links, err := GetPaymentLinksWithENS(context.Background(), server.URL, testENSAddress)
+	if err != nil {
+		t.Fatalf("GetPaymentLinksWithENS: %v", err)
+	}
+	if links.ENSName != testENSName || links.Basescan != GetPaymentLinks(testENSAddress).Basescan {
+		t.Errorf("unexpected links %+v", links)
+	}
+
+	nameless := "0x70997970C51812dc3A010C7d01b50e20d4dc79C8"
+	links, err = GetPaymentLinksWithENS(context.Background(), server.URL, nameless)
+	if err != nil || links.ENSName != "" {
+		t.Errorf("expected plain links without a name, got %+v, %v", links, err)
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	named := newENSServer(t, map[string]string{testENSName: testENSAddress},
+		map[string]string{testENSAddress: testENSName})
+	client, _ := NewLLMClient(testPrivateKey, WithENSRPCURL(named.URL))
+	if name, err := client.DisplayName(context.Background()); err != nil || name != testENSName {
+		t.Errorf("DisplayName = %q, %v", name, err)
+	}
+
+	empty := newENSServer(t, nil, nil)
+	client, _ = NewLLMClient(testPrivateKey, WithENSRPCURL(empty.URL))
+	if name, err := client.DisplayName(context.Background()); err != nil || name != testENSAddress {
+		t.Errorf("DisplayName without ENS = %q, %v; want the checksum address", name, err)
+	}
+}
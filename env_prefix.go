@@ -0,0 +1,28 @@
+package blockrun
+
+// WithEnvPrefix prefixes every environment variable the client reads, so
+// several clients in one process can be configured independently. With
+// prefix "TENANT_A_" the client reads TENANT_A_BLOCKRUN_WALLET_KEY,
+// TENANT_A_BASE_CHAIN_WALLET_KEY and TENANT_A_BLOCKRUN_API_URL, and ignores
+// the unprefixed names.
+func WithEnvPrefix(prefix string) ClientOption {
+	return func(c *LLMClient) {
+		c.envPrefix = prefix
+	}
+}
+
+// WithImageEnvPrefix prefixes the environment variables the image client
+// reads (see WithEnvPrefix).
+func WithImageEnvPrefix(prefix string) ImageClientOption {
+	return func(c *ImageClient) {
+		c.envPrefix = prefix
+	}
+}
+
+// WithWalletEnvPrefix makes GetOrCreateWallet read the prefixed wallet key
+// variables (see WithEnvPrefix).
+func WithWalletEnvPrefix(prefix string) WalletOption {
+	return func(cfg *walletConfig) {
+		cfg.envPrefix = prefix
+	}
+}
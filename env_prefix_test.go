@@ -0,0 +1,55 @@
+package blockrun
+
+import "testing"
+
+const otherTestPrivateKey = "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+
+func TestWithEnvPrefix(t *testing.T) {
+	t.Setenv("BLOCKRUN_WALLET_KEY", "")
+	t.Setenv("BASE_CHAIN_WALLET_KEY", testPrivateKey)
+	t.Setenv("BLOCKRUN_API_URL", "https://unprefixed.example.com")
+	t.Setenv("TENANT_A_BASE_CHAIN_WALLET_KEY", otherTestPrivateKey)
+	t.Setenv("TENANT_A_BLOCKRUN_API_URL", "https://tenant-a.example.com/")
+
+	want, _ := GetAddressFromKey(otherTestPrivateKey)
+
+	client, err := NewLLMClient("", WithEnvPrefix("TENANT_A_"))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	if client.GetWalletAddress() != want {
+		t.Errorf("expected the prefixed key's address %s, got %s", want, client.GetWalletAddress())
+	}
+	if client.apiURL != "https://tenant-a.example.com" {
+		t.Errorf("expected the prefixed API URL, got %s", client.apiURL)
+	}
+
+	images, err := NewImageClient("", WithImageEnvPrefix("TENANT_A_"))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+	if images.GetWalletAddress() != want || images.apiURL != "https://tenant-a.example.com" {
+		t.Errorf("image client ignored the prefix: %s %s", images.GetWalletAddress(), images.apiURL)
+	}
+
+	wallet, err := GetOrCreateWallet(WithWalletEnvPrefix("TENANT_A_"))
+	if err != nil || wallet.Address != want {
+		t.Errorf("GetOrCreateWallet with prefix = %+v, %v", wallet, err)
+	}
+
+	// The default (no prefix) is unchanged.
+	plain, _ := NewLLMClient("")
+	if addr, _ := GetAddressFromKey(testPrivateKey); plain.GetWalletAddress() != addr {
+		t.Errorf("expected the unprefixed key without a prefix, got %s", plain.GetWalletAddress())
+	}
+	if plain.apiURL != "https://unprefixed.example.com" {
+		t.Errorf("expected the unprefixed API URL, got %s", plain.apiURL)
+	}
+}
+
+func TestWithEnvPrefixMissingKey(t *testing.T) {
+	t.Setenv("BLOCKRUN_WALLET_KEY", testPrivateKey)
+	if _, err := NewLLMClient("", WithEnvPrefix("TENANT_B_")); err == nil {
+		t.Error("expected an error when the prefixed key is unset, even if the unprefixed one is")
+	}
+}
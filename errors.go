@@ -0,0 +1,68 @@
+package blockrun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error codes reported in APIError.ErrorCode.
+const (
+	ErrCodeRateLimit         = "rate_limit_exceeded"
+	ErrCodeInvalidModel      = "invalid_model"
+	ErrCodeContextTooLong    = "context_length_exceeded"
+	ErrCodeInsufficientFunds = "insufficient_funds"
+	ErrCodeInvalidSignature  = "invalid_signature"
+)
+
+// newAPIError builds an APIError for a non-OK gateway response. Message
+// keeps the raw body after prefix; Body and ErrorCode are filled from a JSON
+// body of the form {"error": {"code": "...", "message": "..."}}.
+func newAPIError(statusCode int, prefix string, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: statusCode,
+		Message:    fmt.Sprintf("%s: %s", prefix, string(body)),
+	}
+	var parsed map[string]any
+	if json.Unmarshal(body, &parsed) == nil {
+		e.Body = parsed
+		if inner, ok := parsed["error"].(map[string]any); ok {
+			if code, ok := inner["code"].(string); ok {
+				e.ErrorCode = code
+			} else if typ, ok := inner["type"].(string); ok {
+				e.ErrorCode = typ
+			}
+		} else if code, ok := parsed["code"].(string); ok {
+			e.ErrorCode = code
+		}
+	}
+	if e.ErrorCode == "" && statusCode == http.StatusTooManyRequests {
+		e.ErrorCode = ErrCodeRateLimit
+	}
+	return e
+}
+
+// hasErrorCode reports whether err wraps an *APIError with code.
+func hasErrorCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == code
+}
+
+// IsRateLimit reports whether err is a rate-limit (429) API error.
+func IsRateLimit(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.ErrorCode == ErrCodeRateLimit || apiErr.StatusCode == http.StatusTooManyRequests)
+}
+
+// IsInsufficientFunds reports whether err says the wallet cannot cover the
+// call.
+func IsInsufficientFunds(err error) bool {
+	return hasErrorCode(err, ErrCodeInsufficientFunds)
+}
+
+// IsContextTooLong reports whether err says the prompt exceeds the model's
+// context window.
+func IsContextTooLong(err error) bool {
+	return hasErrorCode(err, ErrCodeContextTooLong)
+}
@@ -0,0 +1,67 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStructuredAPIErrors(t *testing.T) {
+	tests := []struct {
+		name              string
+		status            int
+		body              string
+		wantCode          string
+		rateLimit         bool
+		insufficientFunds bool
+		contextTooLong    bool
+	}{
+		{"rate limit", 429, `{"error":{"code":"rate_limit_exceeded","message":"slow down"}}`, ErrCodeRateLimit, true, false, false},
+		{"bare 429", 429, `Too Many Requests`, ErrCodeRateLimit, true, false, false},
+		{"context", 400, `{"error":{"code":"context_length_exceeded","message":"too long"}}`, ErrCodeContextTooLong, false, false, true},
+		{"funds", 400, `{"error":{"code":"insufficient_funds","message":"top up"}}`, ErrCodeInsufficientFunds, false, true, false},
+		{"model", 404, `{"error":{"code":"invalid_model","message":"no such model"}}`, ErrCodeInvalidModel, false, false, false},
+		{"signature", 400, `{"error":{"type":"invalid_signature","message":"bad sig"}}`, ErrCodeInvalidSignature, false, false, false},
+		{"unstructured", 500, `internal error`, "", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+			_, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *APIError, got %T: %v", err, err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.ErrorCode != tt.wantCode {
+				t.Errorf("got status %d code %q, want %d %q", apiErr.StatusCode, apiErr.ErrorCode, tt.status, tt.wantCode)
+			}
+			if apiErr.Message != "API error: "+tt.body {
+				t.Errorf("expected the raw body in Message, got %q", apiErr.Message)
+			}
+			wrapped := fmt.Errorf("calling model: %w", err)
+			if IsRateLimit(wrapped) != tt.rateLimit {
+				t.Errorf("IsRateLimit = %v, want %v", !tt.rateLimit, tt.rateLimit)
+			}
+			if IsInsufficientFunds(wrapped) != tt.insufficientFunds {
+				t.Errorf("IsInsufficientFunds = %v, want %v", !tt.insufficientFunds, tt.insufficientFunds)
+			}
+			if IsContextTooLong(wrapped) != tt.contextTooLong {
+				t.Errorf("IsContextTooLong = %v, want %v", !tt.contextTooLong, tt.contextTooLong)
+			}
+		})
+	}
+
+	if IsRateLimit(nil) || IsInsufficientFunds(errors.New("x")) {
+		t.Error("expected helpers to be false for non-API errors")
+	}
+}
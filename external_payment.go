@@ -0,0 +1,50 @@
+package blockrun
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// WithExternalPayment makes the caller, not the client's wallet, pay for a
+// call — for services that relay requests on behalf of a remote payer.
+//
+// With a non-empty paymentSignature, it is sent as the PAYMENT-SIGNATURE
+// after the gateway's 402. With an empty one, the 402 is returned to the
+// caller as a *PaymentRequiredError holding the requirements to sign; the
+// caller signs them (e.g. with CreatePaymentPayload) and repeats the call
+// with the signature. Externally paid calls are not added to this client's
+// spending, cost log or receipts.
+func WithExternalPayment(paymentSignature string) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.externalPayment = true
+		cfg.paymentSignature = paymentSignature
+	}
+}
+
+// PaymentRequiredError is returned for a WithExternalPayment call that has no
+// signature yet, carrying the gateway's payment requirements.
+type PaymentRequiredError struct {
+	// PaymentRequired is the base64 payment-required header value; decode it
+	// with ParsePaymentRequired.
+	PaymentRequired string
+}
+
+func (e *PaymentRequiredError) Error() string {
+	return "Payment error: payment required (sign PaymentRequired and retry with WithExternalPayment)"
+}
+
+// newPaymentRequiredError re-encodes the negotiated payment option as a
+// payment-required header value for an external payer.
+func newPaymentRequiredError(option *PaymentOption, resourceURL, description string, extensions map[string]any) error {
+	req := PaymentRequirement{
+		X402Version: 2,
+		Accepts:     []PaymentOption{*option},
+		Resource:    ResourceInfo{URL: resourceURL, Description: description},
+		Extensions:  extensions,
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return &PaymentError{Message: "failed to encode payment requirements"}
+	}
+	return &PaymentRequiredError{PaymentRequired: base64.StdEncoding.EncodeToString(data)}
+}
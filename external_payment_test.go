@@ -0,0 +1,59 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithExternalPayment(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		signatures = append(signatures, sig)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.costLog = nil
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	_, err = client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithExternalPayment(""))
+	var required *PaymentRequiredError
+	if !errors.As(err, &required) {
+		t.Fatalf("expected PaymentRequiredError, got %v", err)
+	}
+	req, err := ParsePaymentRequired(required.PaymentRequired)
+	if err != nil {
+		t.Fatalf("ParsePaymentRequired: %v", err)
+	}
+	if len(req.Accepts) != 1 || req.Accepts[0].Amount != "1000" {
+		t.Errorf("unexpected requirements: %+v", req.Accepts)
+	}
+	if len(signatures) != 0 {
+		t.Fatal("expected the client not to sign for an external payer")
+	}
+
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithExternalPayment("external-sig")); err != nil {
+		t.Fatalf("ChatCompletion with external signature: %v", err)
+	}
+	if len(signatures) != 1 || signatures[0] != "external-sig" {
+		t.Errorf("expected the external signature to be forwarded, got %v", signatures)
+	}
+	if spent := client.GetSpending(); spent.Calls != 0 {
+		t.Errorf("expected externally paid calls to be excluded from spending, got %+v", spent)
+	}
+}
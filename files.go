@@ -0,0 +1,144 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// filesPath is the gateway's file storage endpoint.
+const filesPath = "/v1/files"
+
+// FileObject is a file stored on the gateway, referenced by ID from
+// FileContentPart and ImageGenerateOptions.FileID.
+type FileObject struct {
+	ID        string `json:"id"`
+	Filename  string `json:"filename"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Purpose   string `json:"purpose"`
+}
+
+// UploadFile uploads the file at path for purpose (e.g. "assistants",
+// "vision", "user_data") as multipart/form-data to POST /v1/files, paying
+// for storage over x402 if the gateway asks.
+func (c *LLMClient) UploadFile(ctx context.Context, path, purpose string) (*FileObject, error) {
+	if purpose == "" {
+		return nil, &ValidationError{Field: "purpose", Message: "Purpose is required"}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("purpose", purpose); err != nil {
+		return nil, err
+	}
+	part, err := mw.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	respBytes, err := c.doMultipart(ctx, filesPath, body.Bytes(), mw.FormDataContentType())
+	if err != nil {
+		return nil, err
+	}
+	var file FileObject
+	if err := json.Unmarshal(respBytes, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &file, nil
+}
+
+// ListFiles returns the files uploaded by this wallet (GET /v1/files).
+func (c *LLMClient) ListFiles(ctx context.Context) ([]FileObject, error) {
+	respBytes, err := c.doGetWithPayment(ctx, filesPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Data []FileObject `json:"data"`
+	}
+	if err := json.Unmarshal(respBytes, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return list.Data, nil
+}
+
+// DeleteFile deletes the uploaded file id (DELETE /v1/files/{id}).
+func (c *LLMClient) DeleteFile(ctx context.Context, id string) error {
+	if id == "" {
+		return &ValidationError{Field: "id", Message: "File ID is required"}
+	}
+	ctx = c.callContext(ctx)
+	done, err := c.drain.begin()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL()+filesPath+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, "API error", bodyBytes)
+	}
+	return nil
+}
+
+// doMultipart POSTs an encoded multipart body to endpoint with x402
+// payment handling. Unlike doRequest it is never cached.
+func (bc *baseClient) doMultipart(ctx context.Context, endpoint string, body []byte, contentType string) ([]byte, error) {
+	ctx = bc.callContext(ctx)
+	done, err := bc.drain.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	url := bc.baseURL() + endpoint
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	bc.setBodyHeaders(req, contentType)
+
+	resp, err := bc.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPaymentRequired {
+		data, _, err := bc.handlePaymentAndRetryHeaders(ctx, url, body, contentType, resp)
+		return data, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp.StatusCode, "API error", bodyBytes)
+	}
+	return io.ReadAll(resp.Body)
+}
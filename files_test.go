@@ -0,0 +1,115 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var paidUploads, deleted int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/v1/files":
+			if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+				w.Header().Set("payment-required", prHeader)
+				w.WriteHeader(http.StatusPaymentRequired)
+				return
+			}
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatalf("ParseMultipartForm: %v", err)
+			}
+			if got := r.FormValue("purpose"); got != "vision" {
+				t.Errorf("purpose = %q", got)
+			}
+			f, fh, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("FormFile: %v", err)
+			}
+			data, _ := io.ReadAll(f)
+			if fh.Filename != "cat.png" || string(data) != "png bytes" {
+				t.Errorf("unexpected file %q: %q", fh.Filename, data)
+			}
+			paidUploads++
+			w.Write([]byte(`{"id":"file-abc","filename":"cat.png","bytes":9,"created_at":1700000000,"purpose":"vision"}`))
+		case r.Method == "GET" && r.URL.Path == "/v1/files":
+			w.Write([]byte(`{"data":[{"id":"file-abc","filename":"cat.png","bytes":9,"created_at":1700000000,"purpose":"vision"}]}`))
+		case r.Method == "DELETE" && r.URL.Path == "/v1/files/file-abc":
+			deleted++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"no such file"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	client.costLog = nil
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "cat.png")
+	os.WriteFile(path, []byte("png bytes"), 0o600)
+	file, err := client.UploadFile(ctx, path, "vision")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if paidUploads != 1 || *file != (FileObject{ID: "file-abc", Filename: "cat.png", Bytes: 9, CreatedAt: 1700000000, Purpose: "vision"}) {
+		t.Errorf("unexpected upload %+v after %d paid uploads", file, paidUploads)
+	}
+	if client.GetSpending().Calls != 1 {
+		t.Errorf("expected the upload to be recorded as a paid call, got %+v", client.GetSpending())
+	}
+
+	files, err := client.ListFiles(ctx)
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(files) != 1 || files[0].ID != "file-abc" {
+		t.Errorf("unexpected files %+v", files)
+	}
+
+	if err := client.DeleteFile(ctx, "file-abc"); err != nil || deleted != 1 {
+		t.Errorf("DeleteFile: %v (deleted %d)", err, deleted)
+	}
+	var apiErr *APIError
+	if err := client.DeleteFile(ctx, "file-missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 APIError, got %v", err)
+	}
+
+	if _, err := client.UploadFile(ctx, path, ""); err == nil {
+		t.Error("expected a validation error for an empty purpose")
+	}
+	if err := client.DeleteFile(ctx, ""); err == nil {
+		t.Error("expected a validation error for an empty ID")
+	}
+}
+
+func TestFileIDRequestFields(t *testing.T) {
+	data, err := json.Marshal(ChatMessage{Role: "user", ContentParts: []ContentPart{
+		TextContentPart{Text: "summarise this"},
+		FileContentPart{FileID: "file-abc"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `{"file":{"file_id":"file-abc"},"type":"file"}`) {
+		t.Errorf("unexpected message JSON %s", data)
+	}
+
+	body := imageGenerateBody("a cat", &ImageGenerateOptions{FileID: "file-abc"})
+	if body["file_id"] != "file-abc" {
+		t.Errorf("unexpected image body %v", body)
+	}
+}
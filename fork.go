@@ -0,0 +1,147 @@
+package blockrun
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"time"
+)
+
+// forkState links a forked client to its parent; see Fork.
+type forkState struct {
+	parent *baseClient
+	// receipts is the fork's own store when the parent has one.
+	receipts *MemoryReceiptStore
+	// Spending, receipts and analytics events already merged into the
+	// parent (guarded by the fork's mu).
+	mergedUSD, mergedShadowUSD                float64
+	mergedCalls, mergedReceipts, mergedEvents int
+}
+
+// Fork returns a client for one unit of work, such as an incoming HTTP
+// request, without the cost of NewLLMClient. It shares the parent's key,
+// HTTP client, model cache, cost log and configuration, but starts with
+// zeroed spending, so its GetSpending shows only its own calls, records
+// receipts in its own store, and has its own copy of the middleware chain.
+// Close merges its spending, receipts, WithRealTimeAnalytics events and
+// unflushed deferred payments into the parent; spending alerts fire on the
+// parent's total at that point, not in the fork.
+func (c *LLMClient) Fork() *LLMClient {
+	forked := *c
+	forked.health = nil // background polling belongs to the parent
+	forked.baseClient = c.baseClient.forkBase()
+	return &forked
+}
+
+// forkBase returns a baseClient sharing bc's configuration with fresh spending
+// state. Fields are copied one by one because baseClient holds locks; those
+// left out (mu, spending and shadow counters, receipts, deferredPayments,
+// alerts, drain, analytics) start fresh. TestForkBaseCoversEveryField fails
+// when a new field is neither copied nor listed as fresh.
+func (bc *baseClient) forkBase() *baseClient {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	f := &baseClient{
+		privateKey:        bc.privateKey,
+		signer:            bc.signer,
+		address:           bc.address,
+		apiURL:            bc.apiURL,
+		basePath:          bc.basePath,
+		httpClient:        bc.httpClient,
+		cache:             bc.cache,
+		costLog:           bc.costLog,
+		maxPaymentRetries: bc.maxPaymentRetries,
+		userMetadata:      bc.userMetadata,
+		maxClockDrift:     bc.maxClockDrift,
+		autoClockDrift:    bc.autoClockDrift,
+		clockDrift:        bc.clockDrift,
+		authenticator:     bc.authenticator,
+		w3cTracing:        bc.w3cTracing,
+		protobuf:          bc.protobuf,
+		userAgent:         bc.userAgent,
+		paymentMode:       bc.paymentMode,
+		routingHeaders:    bc.routingHeaders,
+		aliases:           bc.aliases,
+		defaultTags:       maps.Clone(bc.defaultTags),
+		middleware:        slices.Clone(bc.middleware),
+		retryPolicy:       bc.retryPolicy,
+		transportTuned:    bc.transportTuned,
+		acceptEncodings:   bc.acceptEncodings,
+		requestEncoding:   bc.requestEncoding,
+		pendingPayments:   bc.pendingPayments,
+		chain:             bc.chain,
+		solanaKey:         bc.solanaKey,
+		solanaRPCURL:      bc.solanaRPCURL,
+		envPrefix:         bc.envPrefix,
+		logLevel:          bc.logLevel,
+		budgetUSD:         bc.budgetUSD,
+		shadowBudgetUSD:   bc.shadowBudgetUSD,
+		balancer:          bc.balancer,
+		fork:              &forkState{parent: bc},
+	}
+	if bc.receipts != nil {
+		f.fork.receipts = NewMemoryReceiptStore()
+		f.receipts = f.fork.receipts
+	}
+	if bc.analytics != nil {
+		// Not started: the fork's events are merged, not pruned.
+		f.analytics = &spendingTracker{}
+	}
+	return f
+}
+
+// mergeFork adds the fork's spending, receipts and analytics events since
+// the last merge to its parent, and hands over its unflushed deferred
+// payments. It does nothing for a client that is not a fork.
+func (bc *baseClient) mergeFork() error {
+	fs := bc.fork
+	if fs == nil {
+		return nil
+	}
+	bc.mu.Lock()
+	usd, shadowUSD := bc.sessionTotalUSD-fs.mergedUSD, bc.shadowSpentUSD-fs.mergedShadowUSD
+	calls := bc.sessionCalls - fs.mergedCalls
+	fs.mergedUSD, fs.mergedShadowUSD, fs.mergedCalls = bc.sessionTotalUSD, bc.shadowSpentUSD, bc.sessionCalls
+	var receipts []Receipt
+	if fs.receipts != nil {
+		all, _ := fs.receipts.List(time.Time{}, time.Time{})
+		receipts = all[fs.mergedReceipts:]
+		fs.mergedReceipts = len(all)
+	}
+	var events []spendEvent
+	if bc.analytics != nil {
+		events, fs.mergedEvents = bc.analytics.since(fs.mergedEvents)
+	}
+	deferred := bc.deferredPayments
+	bc.deferredPayments = nil
+	bc.mu.Unlock()
+
+	parent := fs.parent
+	if calls > 0 {
+		parent.mu.Lock()
+		parent.sessionTotalUSD += usd
+		parent.shadowSpentUSD += shadowUSD
+		parent.sessionCalls += calls
+		now := time.Now()
+		total := parent.sessionTotalUSD
+		due := parent.dueAlerts(total, now)
+		parent.mu.Unlock()
+		parent.sendAlerts(due, total, now)
+	}
+	if len(deferred) > 0 {
+		parent.mu.Lock()
+		parent.deferredPayments = append(parent.deferredPayments, deferred...)
+		parent.mu.Unlock()
+	}
+	if parent.analytics != nil {
+		parent.analytics.merge(events)
+	}
+
+	var errs []error
+	for _, r := range receipts {
+		if err := parent.receipts.Save(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
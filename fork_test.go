@@ -0,0 +1,209 @@
+package blockrun
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestForkSpendingMergesOnClose(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	store := NewMemoryReceiptStore()
+	parent, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithReceiptStore(store))
+	parent.costLog = nil
+
+	var forks []*LLMClient
+	var sum float64
+	for i := 0; i < 3; i++ {
+		fork := parent.Fork()
+		forks = append(forks, fork)
+		if _, err := fork.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+			t.Fatalf("fork %d Chat failed: %v", i, err)
+		}
+		spending := fork.GetSpending()
+		if spending.Calls != 1 {
+			t.Errorf("fork %d: expected only its own call, got %+v", i, spending)
+		}
+		sum += spending.TotalUSD
+	}
+	if got := parent.GetSpending(); got.Calls != 0 {
+		t.Errorf("expected no parent spending before Close, got %+v", got)
+	}
+	if receipts, _ := store.List(time.Time{}, time.Time{}); len(receipts) != 0 {
+		t.Errorf("expected fork receipts kept apart before Close, got %d", len(receipts))
+	}
+
+	for _, fork := range forks {
+		if err := fork.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+	forks[0].Close() // a second Close merges nothing new
+
+	got := parent.GetSpending()
+	if got.Calls != 3 || math.Abs(got.TotalUSD-sum) > 1e-12 || sum == 0 {
+		t.Errorf("expected parent spending of 3 calls totalling %v, got %+v", sum, got)
+	}
+	if receipts, _ := store.List(time.Time{}, time.Time{}); len(receipts) != 3 {
+		t.Errorf("expected 3 receipts merged into the parent store, got %d", len(receipts))
+	}
+}
+
+func TestForkMiddlewareIsolated(t *testing.T) {
+	parent, _ := NewLLMClient(testPrivateKey)
+	fork := parent.Fork()
+	fork.middleware = append(fork.middleware, nil)
+	if len(parent.middleware) != 0 {
+		t.Errorf("expected the fork's middleware chain to be its own, parent has %d", len(parent.middleware))
+	}
+	if fork.privateKey != parent.privateKey || fork.httpClient != parent.httpClient || fork.models != parent.models {
+		t.Error("expected the fork to share the key, HTTP client and model cache")
+	}
+}
+
+// forkFreshFields are the baseClient fields forkBase deliberately does not
+// copy from the parent.
+var forkFreshFields = map[string]bool{
+	"mu":                true,
+	"sessionTotalUSD":   true,
+	"sessionCalls":      true,
+	"receipts":          true,
+	"deferredPayments":  true,
+	"shadowSpentUSD":    true,
+	"shadowReservedUSD": true,
+	"alerts":            true,
+	"drain":             true,
+	"analytics":         true,
+	"fork":              true,
+}
+
+func TestForkBaseCoversEveryField(t *testing.T) {
+	parent := &baseClient{}
+	pv := reflect.ValueOf(parent).Elem()
+	typ := pv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if !forkFreshFields[typ.Field(i).Name] {
+			fillNonZero(settable(pv.Field(i)))
+		}
+	}
+
+	fv := reflect.ValueOf(parent.forkBase()).Elem()
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		if forkFreshFields[name] {
+			continue
+		}
+		want, got := settable(pv.Field(i)).Interface(), settable(fv.Field(i)).Interface()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("forkBase does not copy baseClient.%s; copy it or add it to forkFreshFields", name)
+		}
+	}
+}
+
+// settable returns an addressable, settable view of an unexported field.
+func settable(v reflect.Value) reflect.Value {
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// fillNonZero sets v to a non-zero value where its kind allows it.
+// Interfaces and funcs are left nil; the field name check still covers them.
+func fillNonZero(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		m.SetMapIndex(reflect.Zero(v.Type().Key()), reflect.Zero(v.Type().Elem()))
+		v.Set(m)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			fillNonZero(settable(v.Field(i)))
+		}
+	}
+}
+
+func TestForkMergesAnalyticsAndDeferredPayments(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	parent, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithRealTimeAnalytics())
+	parent.costLog = nil
+	defer parent.Close()
+	fork := parent.Fork()
+	if _, err := fork.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("fork Chat failed: %v", err)
+	}
+	if got := parent.SpendingWindow(time.Hour); got.Calls != 0 {
+		t.Errorf("expected no parent analytics before Close, got %+v", got)
+	}
+	fork.Close()
+	fork.Close() // a second Close merges nothing new
+	if got := parent.SpendingWindow(time.Hour); got.Calls != 1 || got.TotalUSD != 0.001 {
+		t.Errorf("expected the fork's payment in the parent's analytics, got %+v", got)
+	}
+
+	var settled int
+	deferredServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			w.Header().Set("payment-required", prHeader)
+			w.Write([]byte(testChatResponseJSON))
+		case "/v1/payment/settle":
+			settled++
+		}
+	}))
+	defer deferredServer.Close()
+
+	parent, _ = NewLLMClient(testPrivateKey, WithAPIURL(deferredServer.URL), WithPaymentMode(PaymentModeDeferred))
+	parent.costLog = nil
+	fork = parent.Fork()
+	if _, err := fork.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("fork Chat failed: %v", err)
+	}
+	fork.Close()
+	if len(fork.PendingDeferredPayments()) != 0 || len(parent.PendingDeferredPayments()) != 1 {
+		t.Fatalf("expected the fork's deferred payment handed to the parent, got %d in the fork and %d in the parent",
+			len(fork.PendingDeferredPayments()), len(parent.PendingDeferredPayments()))
+	}
+	if err := parent.FlushDeferredPayments(context.Background()); err != nil {
+		t.Fatalf("FlushDeferredPayments: %v", err)
+	}
+	if got := parent.GetSpending(); settled != 1 || got.Calls != 1 {
+		t.Errorf("expected the parent to settle and record the payment, got %d settlements and %+v", settled, got)
+	}
+}
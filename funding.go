@@ -0,0 +1,96 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// fundingEstimateInputTokens is the prompt size FundingStatus assumes for
+// each call; the completion is assumed to use the full max_tokens.
+const fundingEstimateInputTokens = 1000
+
+// FundingStatusReport says whether the wallet can pay for a planned batch of
+// calls (see LLMClient.FundingStatus).
+type FundingStatusReport struct {
+	CurrentBalanceUSD     float64
+	EstimatedCostPerCall  float64
+	EstimatedTotalCostUSD float64
+	IsSufficient          bool
+	// ShortfallUSD is how much more the batch needs; 0 if IsSufficient.
+	ShortfallUSD float64
+	// RecommendedTopUpUSD is ShortfallUSD rounded up to the next $0.50.
+	RecommendedTopUpUSD float64
+	// PaymentLinks are where to send the top-up.
+	PaymentLinks *PaymentLinksInfo
+}
+
+// EstimateCost returns the USD cost of one call to model with the given
+// token counts, from the model catalogue's pricing (per million tokens, or
+// the flat per-call price for flat-billed models).
+func (c *LLMClient) EstimateCost(ctx context.Context, model string, inputTokens, outputTokens int) (float64, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range models {
+		if m.ID == model {
+			return modelCallCost(&m, inputTokens, outputTokens), nil
+		}
+	}
+	return 0, unknownModelError(model)
+}
+
+// modelCallCost is the USD cost of one call to m: its flat price if it has
+// one, otherwise its per-million-token prices applied to the token counts.
+func modelCallCost(m *Model, inputTokens, outputTokens int) float64 {
+	if m.Pricing.Flat > 0 {
+		return m.Pricing.Flat
+	}
+	return (float64(inputTokens)*m.Pricing.Input + float64(outputTokens)*m.Pricing.Output) / 1_000_000
+}
+
+// unknownModelError reports a model missing from the catalogue.
+func unknownModelError(model string) error {
+	return &ValidationError{
+		Field:      "model",
+		Message:    fmt.Sprintf("unknown model %q", model),
+		Suggestion: "Use ListModels to see available models",
+		Value:      model,
+	}
+}
+
+// FundingStatus checks whether the wallet's Base USDC balance (GetBalance)
+// covers expectedRequests calls to model. Each call is estimated with
+// EstimateCost at a 1,000-token prompt and the client's max_tokens, so the
+// estimate errs high.
+func (c *LLMClient) FundingStatus(ctx context.Context, expectedRequests int, model string) (*FundingStatusReport, error) {
+	if expectedRequests < 0 {
+		return nil, &ValidationError{Field: "expectedRequests", Message: "must not be negative", Value: expectedRequests}
+	}
+	outputTokens := DefaultMaxTokens
+	if c.defaultMaxTokens > 0 {
+		outputTokens = c.defaultMaxTokens
+	}
+	perCall, err := c.EstimateCost(ctx, model, fundingEstimateInputTokens, outputTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate cost: %w", err)
+	}
+	balance, err := c.GetBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	report := &FundingStatusReport{
+		CurrentBalanceUSD:     balance,
+		EstimatedCostPerCall:  perCall,
+		EstimatedTotalCostUSD: perCall * float64(expectedRequests),
+		PaymentLinks:          GetPaymentLinks(c.address),
+	}
+	report.IsSufficient = balance >= report.EstimatedTotalCostUSD
+	if !report.IsSufficient {
+		report.ShortfallUSD = report.EstimatedTotalCostUSD - balance
+		report.RecommendedTopUpUSD = math.Ceil(report.ShortfallUSD*2) / 2
+	}
+	return report, nil
+}
@@ -0,0 +1,56 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFundingStatus(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":"openai/gpt-4o","pricing":{"input":2.5,"output":10}},
+			{"id":"flat/model","pricing":{"flat":0.01}}
+		]}`)
+	}))
+	defer gateway.Close()
+	rpc := newBalanceRPCServer(t, 1.5)
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(gateway.URL), WithRPCURL(rpc.URL))
+	ctx := context.Background()
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	// 1000 input tokens at $2.50/M plus 1024 output tokens at $10/M.
+	const perCall = 0.0025 + 0.01024
+	report, err := client.FundingStatus(ctx, 200, "openai/gpt-4o")
+	if err != nil {
+		t.Fatalf("FundingStatus failed: %v", err)
+	}
+	if report.CurrentBalanceUSD != 1.5 || !near(report.EstimatedCostPerCall, perCall) || !near(report.EstimatedTotalCostUSD, 200*perCall) {
+		t.Errorf("unexpected estimate: %+v", report)
+	}
+	// The $1.048 shortfall rounds up to the next $0.50.
+	if report.IsSufficient || !near(report.ShortfallUSD, 200*perCall-1.5) || report.RecommendedTopUpUSD != 1.5 {
+		t.Errorf("expected an insufficient balance needing $1.50, got %+v", report)
+	}
+	if report.PaymentLinks == nil || report.PaymentLinks.Blockrun == "" {
+		t.Error("expected payment links")
+	}
+
+	report, err = client.FundingStatus(ctx, 150, "flat/model")
+	if err != nil {
+		t.Fatalf("FundingStatus failed: %v", err)
+	}
+	if !report.IsSufficient || report.ShortfallUSD != 0 || report.RecommendedTopUpUSD != 0 || !near(report.EstimatedTotalCostUSD, 1.5) {
+		t.Errorf("expected a sufficient balance, got %+v", report)
+	}
+
+	var ve *ValidationError
+	if _, err := client.FundingStatus(ctx, 1, "nope/model"); !errors.As(err, &ve) {
+		t.Errorf("expected a ValidationError for an unknown model, got %v", err)
+	}
+}
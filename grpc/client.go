@@ -0,0 +1,166 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// LLM is the call surface shared by *blockrun.LLMClient and *GRPCLLMClient,
+// so code can switch transports without changes.
+type LLM interface {
+	ChatCompletion(ctx context.Context, model string, messages []blockrun.ChatMessage, opts *blockrun.ChatCompletionOptions, reqOpts ...blockrun.RequestOption) (*blockrun.ChatResponse, error)
+	ListModels(ctx context.Context) ([]blockrun.Model, error)
+	CreateEmbedding(ctx context.Context, model string, input []string, opts *blockrun.EmbeddingOptions) (*blockrun.EmbeddingResponse, error)
+}
+
+var (
+	_ LLM = (*blockrun.LLMClient)(nil)
+	_ LLM = (*GRPCLLMClient)(nil)
+)
+
+// GRPCLLMClient calls a BlockRun gRPC server.
+//
+// SECURITY: as with LLMClient, the private key is used only for local
+// EIP-712 signing; only the signature is sent over the connection.
+type GRPCLLMClient struct {
+	conn       *ggrpc.ClientConn
+	rpc        BlockRunClient
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewGRPCClient dials target. With a privateKey (hex, Base), chat and
+// embedding calls are paid from that wallet via the payment-required /
+// payment-signature metadata exchange; with "" the server's wallet pays.
+// Pass transport credentials in opts.
+func NewGRPCClient(target string, privateKey string, opts ...ggrpc.DialOption) (*GRPCLLMClient, error) {
+	c := &GRPCLLMClient{}
+	if privateKey != "" {
+		key, err := blockrun.GetPrivateKeyFromHex(privateKey)
+		if err != nil {
+			return nil, &blockrun.ValidationError{Field: "privateKey", Message: fmt.Sprintf("Invalid private key format: %v", err)}
+		}
+		c.privateKey = key
+	}
+
+	conn, err := ggrpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", target, err)
+	}
+	c.conn = conn
+	c.rpc = NewBlockRunClient(conn)
+	return c, nil
+}
+
+// Close closes the underlying connection.
+func (c *GRPCLLMClient) Close() error {
+	return c.conn.Close()
+}
+
+// ChatCompletion sends a chat completion request. reqOpts are accepted for
+// interface compatibility with LLMClient but are not sent to the server.
+func (c *GRPCLLMClient) ChatCompletion(ctx context.Context, model string, messages []blockrun.ChatMessage, opts *blockrun.ChatCompletionOptions, reqOpts ...blockrun.RequestOption) (*blockrun.ChatResponse, error) {
+	req, err := chatRequestToProto(model, messages, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	resp, err := invokePaid(ctx, c, func(ctx context.Context, callOpts ...ggrpc.CallOption) (*ChatResponse, error) {
+		return c.rpc.ChatCompletion(ctx, req, callOpts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chatResponseFromProto(resp)
+}
+
+// ListModels returns the models available through the server.
+func (c *GRPCLLMClient) ListModels(ctx context.Context) ([]blockrun.Model, error) {
+	resp, err := c.rpc.ListModels(ctx, &ListModelsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	models := make([]blockrun.Model, len(resp.GetModels()))
+	for i, s := range resp.GetModels() {
+		if err := fromStruct(s, &models[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode model: %w", err)
+		}
+	}
+	return models, nil
+}
+
+// Generate creates an image. It is always paid by the server's wallet.
+func (c *GRPCLLMClient) Generate(ctx context.Context, prompt string, opts *blockrun.ImageGenerateOptions) (*blockrun.ImageResponse, error) {
+	resp, err := c.rpc.Generate(ctx, &GenerateRequest{Prompt: prompt, Options: imageOptionsToProto(opts)})
+	if err != nil {
+		return nil, err
+	}
+	return imageResponseFromProto(resp), nil
+}
+
+// CreateEmbedding embeds each input string with model.
+func (c *GRPCLLMClient) CreateEmbedding(ctx context.Context, model string, input []string, opts *blockrun.EmbeddingOptions) (*blockrun.EmbeddingResponse, error) {
+	req := &CreateEmbeddingRequest{Model: model, Input: input, Options: embeddingOptionsToProto(opts)}
+	resp, err := invokePaid(ctx, c, func(ctx context.Context, callOpts ...ggrpc.CallOption) (*EmbeddingResponse, error) {
+		return c.rpc.CreateEmbedding(ctx, req, callOpts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return embeddingResponseFromProto(resp), nil
+}
+
+// invokePaid runs call, running the x402 exchange over metadata when the
+// client has its own wallet: the first call announces a client payer, the
+// server replies with a payment-required trailer, and the call is repeated
+// with the signed payload.
+func invokePaid[Resp any](ctx context.Context, c *GRPCLLMClient, call func(context.Context, ...ggrpc.CallOption) (*Resp, error)) (*Resp, error) {
+	if c.privateKey == nil {
+		return call(ctx)
+	}
+
+	var trailer metadata.MD
+	probeCtx := metadata.AppendToOutgoingContext(ctx, MetadataPayer, "client")
+	resp, err := call(probeCtx, ggrpc.Trailer(&trailer))
+	required := trailer.Get(MetadataPaymentRequired)
+	if status.Code(err) != codes.FailedPrecondition || len(required) == 0 {
+		return resp, err
+	}
+
+	signature, err := c.sign(required[0])
+	if err != nil {
+		return nil, err
+	}
+	paidCtx := metadata.AppendToOutgoingContext(ctx, MetadataPaymentSignature, signature)
+	return call(paidCtx)
+}
+
+// sign signs base64 payment requirements with the client's key.
+func (c *GRPCLLMClient) sign(paymentRequired string) (string, error) {
+	req, err := blockrun.ParsePaymentRequired(paymentRequired)
+	if err != nil {
+		return "", &blockrun.PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
+	}
+	option, err := blockrun.ExtractPaymentDetails(req)
+	if err != nil {
+		return "", &blockrun.PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
+	}
+	if strings.HasPrefix(option.Network, "solana") {
+		return "", &blockrun.PaymentError{Message: "gRPC client payments support Base only"}
+	}
+	payload, err := blockrun.CreatePaymentPayload(
+		c.privateKey, option.PayTo, option.Amount, option.Network,
+		req.Resource.URL, req.Resource.Description, option.MaxTimeoutSeconds,
+		option.Extra, req.Extensions,
+	)
+	if err != nil {
+		return "", &blockrun.PaymentError{Message: fmt.Sprintf("Failed to create payment: %v", err)}
+	}
+	return payload, nil
+}
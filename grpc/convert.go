@@ -0,0 +1,269 @@
+package grpc
+
+import (
+	"encoding/json"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The proto messages carry open-ended JSON objects (tools, tool calls,
+// models, ...) as google.protobuf.Struct and Value. These helpers convert
+// them through the JSON encoding of the blockrun types, so the field names
+// match the HTTP API.
+
+// toStruct converts v, which must encode as a JSON object, to a Struct.
+func toStruct(v any) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+// fromStruct decodes s into out.
+func fromStruct(s *structpb.Struct, out any) error {
+	data, err := json.Marshal(s.AsMap())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// toValue converts v to a Value, or nil if v is nil.
+func toValue(v any) (*structpb.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var plain any
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(plain)
+}
+
+// fromValue returns v as plain Go values, or nil if v is nil.
+func fromValue(v *structpb.Value) any {
+	if v == nil {
+		return nil
+	}
+	return v.AsInterface()
+}
+
+func chatMessageToProto(m blockrun.ChatMessage) (*ChatMessage, error) {
+	pm := &ChatMessage{Role: m.Role, Content: m.Content, ToolCallId: m.ToolCallID, Name: m.Name}
+	for _, tc := range m.ToolCalls {
+		s, err := toStruct(tc)
+		if err != nil {
+			return nil, err
+		}
+		pm.ToolCalls = append(pm.ToolCalls, s)
+	}
+	return pm, nil
+}
+
+func chatMessageFromProto(pm *ChatMessage) (blockrun.ChatMessage, error) {
+	m := blockrun.ChatMessage{
+		Role:       pm.GetRole(),
+		Content:    pm.GetContent(),
+		ToolCallID: pm.GetToolCallId(),
+		Name:       pm.GetName(),
+	}
+	for _, s := range pm.GetToolCalls() {
+		var tc blockrun.ToolCall
+		if err := fromStruct(s, &tc); err != nil {
+			return m, err
+		}
+		m.ToolCalls = append(m.ToolCalls, tc)
+	}
+	return m, nil
+}
+
+func chatRequestToProto(model string, messages []blockrun.ChatMessage, opts *blockrun.ChatCompletionOptions) (*ChatCompletionRequest, error) {
+	req := &ChatCompletionRequest{Model: model}
+	for _, m := range messages {
+		pm, err := chatMessageToProto(m)
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages, pm)
+	}
+	if opts == nil {
+		return req, nil
+	}
+	po := &ChatCompletionOptions{
+		MaxTokens:   int32(opts.MaxTokens),
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+	}
+	for _, tool := range opts.Tools {
+		s, err := toStruct(tool)
+		if err != nil {
+			return nil, err
+		}
+		po.Tools = append(po.Tools, s)
+	}
+	var err error
+	if po.ToolChoice, err = toValue(opts.ToolChoice); err != nil {
+		return nil, err
+	}
+	if po.ResponseFormat, err = toValue(opts.ResponseFormat); err != nil {
+		return nil, err
+	}
+	if po.Stop, err = toValue(opts.Stop); err != nil {
+		return nil, err
+	}
+	req.Options = po
+	return req, nil
+}
+
+func chatRequestFromProto(req *ChatCompletionRequest) ([]blockrun.ChatMessage, *blockrun.ChatCompletionOptions, error) {
+	messages := make([]blockrun.ChatMessage, 0, len(req.GetMessages()))
+	for _, pm := range req.GetMessages() {
+		m, err := chatMessageFromProto(pm)
+		if err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, m)
+	}
+	po := req.GetOptions()
+	if po == nil {
+		return messages, nil, nil
+	}
+	opts := &blockrun.ChatCompletionOptions{
+		MaxTokens:      int(po.GetMaxTokens()),
+		Temperature:    po.GetTemperature(),
+		TopP:           po.GetTopP(),
+		ToolChoice:     fromValue(po.GetToolChoice()),
+		ResponseFormat: fromValue(po.GetResponseFormat()),
+		Stop:           fromValue(po.GetStop()),
+	}
+	for _, s := range po.GetTools() {
+		var tool blockrun.Tool
+		if err := fromStruct(s, &tool); err != nil {
+			return nil, nil, err
+		}
+		opts.Tools = append(opts.Tools, tool)
+	}
+	return messages, opts, nil
+}
+
+func usageToProto(u blockrun.Usage) *Usage {
+	return &Usage{
+		PromptTokens:     int32(u.PromptTokens),
+		CompletionTokens: int32(u.CompletionTokens),
+		TotalTokens:      int32(u.TotalTokens),
+	}
+}
+
+func usageFromProto(u *Usage) blockrun.Usage {
+	return blockrun.Usage{
+		PromptTokens:     int(u.GetPromptTokens()),
+		CompletionTokens: int(u.GetCompletionTokens()),
+		TotalTokens:      int(u.GetTotalTokens()),
+	}
+}
+
+func chatResponseToProto(resp *blockrun.ChatResponse) (*ChatResponse, error) {
+	pr := &ChatResponse{
+		Id:      resp.ID,
+		Object:  resp.Object,
+		Created: resp.Created,
+		Model:   resp.Model,
+		Usage:   usageToProto(resp.Usage),
+	}
+	for _, c := range resp.Choices {
+		pm, err := chatMessageToProto(c.Message)
+		if err != nil {
+			return nil, err
+		}
+		pr.Choices = append(pr.Choices, &Choice{Index: int32(c.Index), Message: pm, FinishReason: c.FinishReason})
+	}
+	return pr, nil
+}
+
+func chatResponseFromProto(pr *ChatResponse) (*blockrun.ChatResponse, error) {
+	resp := &blockrun.ChatResponse{
+		ID:      pr.GetId(),
+		Object:  pr.GetObject(),
+		Created: pr.GetCreated(),
+		Model:   pr.GetModel(),
+		Usage:   usageFromProto(pr.GetUsage()),
+	}
+	for _, c := range pr.GetChoices() {
+		m, err := chatMessageFromProto(c.GetMessage())
+		if err != nil {
+			return nil, err
+		}
+		resp.Choices = append(resp.Choices, blockrun.Choice{Index: int(c.GetIndex()), Message: m, FinishReason: c.GetFinishReason()})
+	}
+	return resp, nil
+}
+
+func imageOptionsToProto(opts *blockrun.ImageGenerateOptions) *ImageGenerateOptions {
+	if opts == nil {
+		return nil
+	}
+	return &ImageGenerateOptions{Model: opts.Model, Size: opts.Size, N: int32(opts.N), Quality: opts.Quality}
+}
+
+func imageOptionsFromProto(po *ImageGenerateOptions) *blockrun.ImageGenerateOptions {
+	if po == nil {
+		return nil
+	}
+	return &blockrun.ImageGenerateOptions{Model: po.GetModel(), Size: po.GetSize(), N: int(po.GetN()), Quality: po.GetQuality()}
+}
+
+func imageResponseToProto(resp *blockrun.ImageResponse) *ImageResponse {
+	pr := &ImageResponse{Created: resp.Created, TxHash: resp.TxHash}
+	for _, d := range resp.Data {
+		pr.Data = append(pr.Data, &ImageData{Url: d.URL, RevisedPrompt: d.RevisedPrompt, B64Json: d.B64JSON})
+	}
+	return pr
+}
+
+func imageResponseFromProto(pr *ImageResponse) *blockrun.ImageResponse {
+	resp := &blockrun.ImageResponse{Created: pr.GetCreated(), TxHash: pr.GetTxHash()}
+	for _, d := range pr.GetData() {
+		resp.Data = append(resp.Data, blockrun.ImageData{URL: d.GetUrl(), RevisedPrompt: d.GetRevisedPrompt(), B64JSON: d.GetB64Json()})
+	}
+	return resp
+}
+
+func embeddingOptionsToProto(opts *blockrun.EmbeddingOptions) *EmbeddingOptions {
+	if opts == nil {
+		return nil
+	}
+	return &EmbeddingOptions{Dimensions: int32(opts.Dimensions), User: opts.User}
+}
+
+func embeddingOptionsFromProto(po *EmbeddingOptions) *blockrun.EmbeddingOptions {
+	if po == nil {
+		return nil
+	}
+	return &blockrun.EmbeddingOptions{Dimensions: int(po.GetDimensions()), User: po.GetUser()}
+}
+
+func embeddingResponseToProto(resp *blockrun.EmbeddingResponse) *EmbeddingResponse {
+	pr := &EmbeddingResponse{Object: resp.Object, Model: resp.Model, Usage: usageToProto(resp.Usage)}
+	for _, e := range resp.Data {
+		pr.Data = append(pr.Data, &Embedding{Object: e.Object, Index: int32(e.Index), Embedding: e.Embedding})
+	}
+	return pr
+}
+
+func embeddingResponseFromProto(pr *EmbeddingResponse) *blockrun.EmbeddingResponse {
+	resp := &blockrun.EmbeddingResponse{Object: pr.GetObject(), Model: pr.GetModel(), Usage: usageFromProto(pr.GetUsage())}
+	for _, e := range pr.GetData() {
+		resp.Data = append(resp.Data, blockrun.Embedding{Object: e.GetObject(), Index: int(e.GetIndex()), Embedding: e.GetEmbedding()})
+	}
+	return resp
+}
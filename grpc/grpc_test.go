@@ -0,0 +1,225 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	serverKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	clientKey = "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+)
+
+// gateway mocks the BlockRun HTTP API: chat and embeddings cost 1000
+// micro-USDC via x402, models are free. It records who signed each payment.
+type gateway struct {
+	*httptest.Server
+	mu      sync.Mutex
+	signers []string
+}
+
+func newGateway(t *testing.T) *gateway {
+	t.Helper()
+	pr, _ := json.Marshal(blockrun.PaymentRequirement{
+		X402Version: 2,
+		Accepts: []blockrun.PaymentOption{{
+			Scheme: "exact", Network: "eip155:8453", Amount: "1000",
+			Asset: blockrun.USDCBase, PayTo: "0x1234567890123456789012345678901234567890",
+			MaxTimeoutSeconds: 300,
+		}},
+		Resource: blockrun.ResourceInfo{URL: "https://blockrun.ai/api/v1/chat/completions"},
+	})
+	prHeader := base64.StdEncoding.EncodeToString(pr)
+
+	gw := &gateway{}
+	gw.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			w.Write([]byte(`{"data":[{"id":"openai/gpt-4o","owned_by":"openai"}]}`))
+			return
+		}
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		gw.recordSigner(t, sig)
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}]}`))
+		case "/v1/embeddings":
+			w.Write([]byte(`{"object":"list","model":"text-embedding-3-small","data":[{"object":"embedding","index":0,"embedding":[0.5,0.25]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func (gw *gateway) recordSigner(t *testing.T, sig string) {
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		t.Errorf("decode payment signature: %v", err)
+		return
+	}
+	var payload struct {
+		Payload struct {
+			Authorization struct {
+				From string `json:"from"`
+			} `json:"authorization"`
+		} `json:"payload"`
+	}
+	json.Unmarshal(raw, &payload)
+	gw.mu.Lock()
+	gw.signers = append(gw.signers, payload.Payload.Authorization.From)
+	gw.mu.Unlock()
+}
+
+// startServer serves the BlockRun service over an in-memory listener and
+// returns the relay's LLMClient plus a dial function.
+func startServer(t *testing.T, gw *gateway) (*blockrun.LLMClient, func(key string) *GRPCLLMClient) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	llm, err := blockrun.NewLLMClient(serverKey, blockrun.WithAPIURL(gw.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(llm)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dial := func(key string) *GRPCLLMClient {
+		c, err := NewGRPCClient("passthrough:///bufnet", key,
+			ggrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			ggrpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			t.Fatalf("NewGRPCClient: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+	return llm, dial
+}
+
+func TestGRPCClientPaysWithOwnWallet(t *testing.T) {
+	gw := newGateway(t)
+	llm, dial := startServer(t, gw)
+	client := dial(clientKey)
+
+	resp, err := client.ChatCompletion(context.Background(), "openai/gpt-4o",
+		[]blockrun.ChatMessage{{Role: "user", Content: "ping"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "pong" {
+		t.Errorf("expected pong, got %q", got)
+	}
+
+	clientAddr, _ := blockrun.NewLLMClient(clientKey)
+	if len(gw.signers) != 1 || gw.signers[0] != clientAddr.GetWalletAddress() {
+		t.Errorf("expected the gRPC client's wallet to sign, got %v", gw.signers)
+	}
+	if spent := llm.GetSpending(); spent.Calls != 0 || spent.TotalUSD != 0 {
+		t.Errorf("expected server wallet spending to stay zero, got %+v", spent)
+	}
+}
+
+func TestGRPCServerPays(t *testing.T) {
+	gw := newGateway(t)
+	llm, dial := startServer(t, gw)
+	client := dial("")
+
+	emb, err := client.CreateEmbedding(context.Background(), "text-embedding-3-small", []string{"hi"}, nil)
+	if err != nil {
+		t.Fatalf("CreateEmbedding: %v", err)
+	}
+	if len(emb.Data) != 1 || emb.Data[0].Embedding[0] != 0.5 {
+		t.Errorf("unexpected embedding response: %+v", emb)
+	}
+	if len(gw.signers) != 1 || gw.signers[0] != llm.GetWalletAddress() {
+		t.Errorf("expected the server wallet to sign, got %v", gw.signers)
+	}
+	if spent := llm.GetSpending(); spent.Calls != 1 {
+		t.Errorf("expected one server-paid call, got %+v", spent)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if len(models) != 1 || models[0].ID != "openai/gpt-4o" {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	gw := newGateway(t)
+	_, dial := startServer(t, gw)
+	client := dial("")
+
+	_, err := client.ChatCompletion(context.Background(), "", []blockrun.ChatMessage{{Role: "user", Content: "x"}}, nil)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a missing model, got %v", err)
+	}
+	_, err = client.Generate(context.Background(), "a cat", nil)
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented without an image client, got %v", err)
+	}
+}
+
+func TestNoGlobalCodecRegistered(t *testing.T) {
+	if encoding.GetCodec("json") != nil {
+		t.Error("expected the package not to register a global json codec")
+	}
+}
+
+func TestChatRequestProtoRoundTrip(t *testing.T) {
+	messages := []blockrun.ChatMessage{
+		{Role: "assistant", ToolCalls: []blockrun.ToolCall{{ID: "call_1", Type: "function", Function: blockrun.ToolCallFunction{Name: "lookup", Arguments: `{"q":"x"}`}}}},
+		{Role: "tool", ToolCallID: "call_1", Name: "lookup", Content: "42"},
+	}
+	opts := &blockrun.ChatCompletionOptions{
+		MaxTokens:   100,
+		Temperature: 0.5,
+		Tools: []blockrun.Tool{{Type: "function", Function: blockrun.ToolFunction{
+			Name: "lookup", Parameters: map[string]any{"type": "object"},
+		}}},
+		ToolChoice:     "auto",
+		ResponseFormat: map[string]any{"type": "json_object"},
+		Stop:           []any{"END"},
+	}
+
+	req, err := chatRequestToProto("openai/gpt-4o", messages, opts)
+	if err != nil {
+		t.Fatalf("chatRequestToProto: %v", err)
+	}
+	gotMessages, gotOpts, err := chatRequestFromProto(req)
+	if err != nil {
+		t.Fatalf("chatRequestFromProto: %v", err)
+	}
+	if !reflect.DeepEqual(gotMessages, messages) {
+		t.Errorf("messages = %+v, want %+v", gotMessages, messages)
+	}
+	if !reflect.DeepEqual(gotOpts, opts) {
+		t.Errorf("options = %+v, want %+v", gotOpts, opts)
+	}
+}
@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ServerOption configures NewGRPCServer / RegisterServer.
+type ServerOption func(*server)
+
+// WithImageClient enables the Generate method, paid by imageClient's wallet.
+func WithImageClient(imageClient *blockrun.ImageClient) ServerOption {
+	return func(s *server) {
+		s.images = imageClient
+	}
+}
+
+// WithGRPCServerOptions passes options (TLS credentials, interceptors, ...)
+// to the grpc.Server built by NewGRPCServer.
+func WithGRPCServerOptions(opts ...ggrpc.ServerOption) ServerOption {
+	return func(s *server) {
+		s.grpcOpts = append(s.grpcOpts, opts...)
+	}
+}
+
+// server relays gRPC calls to the BlockRun HTTP API.
+type server struct {
+	UnimplementedBlockRunServer
+
+	client   *blockrun.LLMClient
+	images   *blockrun.ImageClient
+	grpcOpts []ggrpc.ServerOption
+}
+
+// NewGRPCServer returns a grpc.Server serving the BlockRun service backed by
+// client. Calls are paid by client's wallet unless the caller pays itself
+// (see the package documentation).
+func NewGRPCServer(client *blockrun.LLMClient, opts ...ServerOption) *ggrpc.Server {
+	s := newServer(client, opts)
+	gs := ggrpc.NewServer(s.grpcOpts...)
+	RegisterBlockRunServer(gs, s)
+	return gs
+}
+
+// RegisterServer registers the BlockRun service on an existing grpc.Server.
+func RegisterServer(gs *ggrpc.Server, client *blockrun.LLMClient, opts ...ServerOption) {
+	RegisterBlockRunServer(gs, newServer(client, opts))
+}
+
+func newServer(client *blockrun.LLMClient, opts []ServerOption) *server {
+	s := &server{client: client}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *server) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatResponse, error) {
+	messages, opts, err := chatRequestFromProto(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+	ctx = paymentContext(ctx)
+	resp, err := s.client.ChatCompletion(ctx, req.GetModel(), messages, opts)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	out, err := chatResponseToProto(resp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+	}
+	return out, nil
+}
+
+func (s *server) ListModels(ctx context.Context, _ *ListModelsRequest) (*ListModelsResponse, error) {
+	models, err := s.client.ListModels(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	out := &ListModelsResponse{Models: make([]*structpb.Struct, 0, len(models))}
+	for _, m := range models {
+		pm, err := toStruct(m)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+		}
+		out.Models = append(out.Models, pm)
+	}
+	return out, nil
+}
+
+func (s *server) Generate(ctx context.Context, req *GenerateRequest) (*ImageResponse, error) {
+	if s.images == nil {
+		return nil, status.Error(codes.Unimplemented, "image generation is not enabled on this server")
+	}
+	resp, err := s.images.Generate(ctx, req.GetPrompt(), imageOptionsFromProto(req.GetOptions()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return imageResponseToProto(resp), nil
+}
+
+func (s *server) CreateEmbedding(ctx context.Context, req *CreateEmbeddingRequest) (*EmbeddingResponse, error) {
+	ctx = paymentContext(ctx)
+	resp, err := s.client.CreateEmbedding(ctx, req.GetModel(), req.GetInput(), embeddingOptionsFromProto(req.GetOptions()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return embeddingResponseToProto(resp), nil
+}
+
+// paymentContext switches the call to caller-paid when the incoming metadata
+// carries a payment signature or asks for client payment.
+func paymentContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if sig := md.Get(MetadataPaymentSignature); len(sig) > 0 && sig[0] != "" {
+		return blockrun.WithRequestOptions(ctx, blockrun.WithExternalPayment(sig[0]))
+	}
+	if payer := md.Get(MetadataPayer); len(payer) > 0 && payer[0] == "client" {
+		return blockrun.WithRequestOptions(ctx, blockrun.WithExternalPayment(""))
+	}
+	return ctx
+}
+
+// toStatus converts a blockrun client error into a gRPC status error. A
+// PaymentRequiredError becomes FAILED_PRECONDITION with the requirements in
+// the payment-required trailer.
+func toStatus(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	var required *blockrun.PaymentRequiredError
+	var payment *blockrun.PaymentError
+	var validation *blockrun.ValidationError
+	var api *blockrun.APIError
+	switch {
+	case errors.As(err, &required):
+		ggrpc.SetTrailer(ctx, metadata.Pairs(MetadataPaymentRequired, required.PaymentRequired))
+		return status.Error(codes.FailedPrecondition, "payment required")
+	case errors.As(err, &payment):
+		return status.Error(codes.PermissionDenied, payment.Error())
+	case errors.As(err, &validation):
+		return status.Error(codes.InvalidArgument, validation.Error())
+	case errors.As(err, &api):
+		return status.Error(httpStatusCode(api.StatusCode), api.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+// httpStatusCode maps an HTTP status from the gateway to a gRPC code.
+func httpStatusCode(code int) codes.Code {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case code == http.StatusNotFound:
+		return codes.NotFound
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return codes.PermissionDenied
+	case code >= 400 && code < 500:
+		return codes.InvalidArgument
+	default:
+		return codes.Unavailable
+	}
+}
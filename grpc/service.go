@@ -0,0 +1,29 @@
+// Package grpc exposes BlockRun over gRPC for latency-critical internal
+// services.
+//
+// NewGRPCServer relays ChatCompletion, ListModels, Generate and
+// CreateEmbedding calls to the BlockRun HTTP API through a blockrun client;
+// NewGRPCClient dials it and offers the same methods as *blockrun.LLMClient.
+// The messages and service stubs are generated from blockrun.proto and use
+// the standard protobuf codec; this package converts them to and from the
+// blockrun Go types.
+//
+// The x402 flow maps onto gRPC metadata: a client that pays with its own
+// wallet receives the requirements in a "payment-required" trailer and
+// sends its signed payload as "payment-signature" metadata.
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative blockrun.proto
+
+// ServiceName is the fully qualified gRPC service name.
+const ServiceName = "blockrun.v1.BlockRun"
+
+// Metadata keys carrying the x402 exchange.
+const (
+	// MetadataPaymentSignature carries the caller's signed payment payload.
+	MetadataPaymentSignature = "payment-signature"
+	// MetadataPaymentRequired is the trailer with base64 payment requirements.
+	MetadataPaymentRequired = "payment-required"
+	// MetadataPayer set to "client" asks the server to let the caller pay.
+	MetadataPayer = "x402-payer"
+)
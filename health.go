@@ -0,0 +1,217 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the result of a HealthCheck.
+type HealthStatus struct {
+	// Reachable is true when the gateway answered and reports itself healthy.
+	Reachable bool
+	// Latency is the round-trip time of the check.
+	Latency time.Duration
+	// APIVersion is the gateway's reported version, if any.
+	APIVersion string
+	// Message is the gateway's status message or the failure reason.
+	Message string
+}
+
+// HealthEvent is emitted by background health polling (see
+// WithHealthCheckInterval), both on HealthEvents() and to middleware.
+type HealthEvent struct {
+	Time   time.Time
+	Status *HealthStatus
+	Err    error
+}
+
+// EventName implements Event.
+func (HealthEvent) EventName() string { return "health" }
+
+// healthEventBuffer is the HealthEvents channel capacity; when a consumer
+// falls behind, new events are dropped rather than blocking the poller.
+const healthEventBuffer = 16
+
+// defaultHealthRetryInterval is how often WaitUntilHealthy re-checks when no
+// health check interval is configured.
+const defaultHealthRetryInterval = time.Second
+
+// healthChecker holds the state of background health polling.
+type healthChecker struct {
+	interval time.Duration
+	events   chan HealthEvent
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// WithHealthCheckInterval polls the gateway's health every d in a background
+// goroutine, delivering a HealthEvent per check on HealthEvents() and to
+// middleware. Call Close to stop polling.
+func WithHealthCheckInterval(d time.Duration) ClientOption {
+	return func(c *LLMClient) {
+		if d <= 0 {
+			c.health = nil
+			return
+		}
+		c.health = &healthChecker{interval: d}
+	}
+}
+
+// HealthCheck checks that the gateway is reachable. It calls /v1/health and
+// falls back to ListModels when the gateway has no health endpoint. Network
+// failures return a non-nil status with Reachable false as well as the error.
+func (c *LLMClient) HealthCheck(ctx context.Context) (*HealthStatus, error) {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL()+"/v1/health", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return &HealthStatus{Latency: time.Since(start), Message: err.Error()}, fmt.Errorf("health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return c.healthFromModels(ctx)
+	}
+
+	status := &HealthStatus{
+		Latency:    time.Since(start),
+		Reachable:  resp.StatusCode == http.StatusOK,
+		APIVersion: resp.Header.Get("X-API-Version"),
+	}
+	var parsed struct {
+		Status  string `json:"status"`
+		Version string `json:"version"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		if parsed.Version != "" {
+			status.APIVersion = parsed.Version
+		}
+		status.Message = parsed.Message
+		if status.Message == "" {
+			status.Message = parsed.Status
+		}
+		switch parsed.Status {
+		case "", "ok", "healthy", "up":
+		default:
+			status.Reachable = false
+		}
+	}
+	if !status.Reachable && status.Message == "" {
+		status.Message = fmt.Sprintf("health endpoint returned status %d", resp.StatusCode)
+	}
+	return status, nil
+}
+
+// Ping sends a HEAD request to the gateway, the cheapest reachability
+// check. It fails on a network error or a 5xx response.
+func (c *LLMClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.baseURL(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("ping failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// healthFromModels checks health by listing models.
+func (c *LLMClient) healthFromModels(ctx context.Context) (*HealthStatus, error) {
+	start := time.Now()
+	_, err := c.fetchModels(ctx)
+	status := &HealthStatus{Latency: time.Since(start), Reachable: err == nil, Message: "ok"}
+	if err != nil {
+		status.Message = err.Error()
+	}
+	return status, nil
+}
+
+// HealthEvents returns the channel of background health results, or nil if
+// WithHealthCheckInterval was not used.
+func (c *LLMClient) HealthEvents() <-chan HealthEvent {
+	if c.health == nil {
+		return nil
+	}
+	return c.health.events
+}
+
+// WaitUntilHealthy blocks until HealthCheck reports the gateway reachable or
+// ctx is done, re-checking at the health check interval (1s by default).
+func (c *LLMClient) WaitUntilHealthy(ctx context.Context) error {
+	interval := defaultHealthRetryInterval
+	if c.health != nil {
+		interval = c.health.interval
+	}
+	for {
+		status, err := c.HealthCheck(ctx)
+		if err == nil && status.Reachable {
+			return nil
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Close stops background work started by the client, such as health
+// polling and WithRealTimeAnalytics pruning. On a client made by Fork, it
+// merges the fork's spending and receipts since the last Close into the
+// parent. The client remains usable for calls.
+func (c *LLMClient) Close() error {
+	if c.health != nil && c.health.stop != nil {
+		c.health.once.Do(func() { close(c.health.stop) })
+	}
+	c.analytics.close()
+	return c.mergeFork()
+}
+
+// startHealthChecks launches the polling goroutine configured by
+// WithHealthCheckInterval.
+func (c *LLMClient) startHealthChecks() {
+	h := c.health
+	h.events = make(chan HealthEvent, healthEventBuffer)
+	h.stop = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+			}
+
+			checkCtx, checkCancel := context.WithTimeout(ctx, h.interval)
+			status, err := c.HealthCheck(checkCtx)
+			checkCancel()
+			ev := HealthEvent{Time: time.Now(), Status: status, Err: err}
+			c.emit(ctx, ev)
+			select {
+			case h.events <- ev:
+			default:
+			}
+		}
+	}()
+}
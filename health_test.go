@@ -0,0 +1,146 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/health" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(`{"status":"ok","version":"2025-06-01","message":"all systems operational"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	status, err := client.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	if !status.Reachable || status.APIVersion != "2025-06-01" || status.Message != "all systems operational" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if status.Latency < 5*time.Millisecond {
+		t.Errorf("expected latency to include the server delay, got %v", status.Latency)
+	}
+}
+
+func TestHealthCheckUnhealthy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"degraded","message":"maintenance"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	status, err := client.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	if status.Reachable || status.Message != "maintenance" {
+		t.Errorf("expected an unhealthy status, got %+v", status)
+	}
+}
+
+func TestHealthCheckFallsBackToListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	status, err := client.HealthCheck(context.Background())
+	if err != nil || !status.Reachable {
+		t.Errorf("expected ListModels fallback to succeed, got %+v, %v", status, err)
+	}
+}
+
+func TestHealthEventsAndWaitUntilHealthy(t *testing.T) {
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checks.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithHealthCheckInterval(10*time.Millisecond))
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.WaitUntilHealthy(ctx); err != nil {
+		t.Fatalf("WaitUntilHealthy: %v", err)
+	}
+
+	select {
+	case ev := <-client.HealthEvents():
+		if ev.Status == nil || ev.Time.IsZero() {
+			t.Errorf("unexpected event: %+v", ev)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected a background health event")
+	}
+}
+
+func TestWaitUntilHealthyRespectsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.WaitUntilHealthy(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if client.HealthEvents() != nil {
+		t.Error("expected no health events without WithHealthCheckInterval")
+	}
+}
+
+func TestHealthCheckAndPingUseLoadBalancer(t *testing.T) {
+	var hits [2]atomic.Int32
+	newServer := func(i int) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[i].Add(1)
+			w.Write([]byte(`{"status":"ok"}`))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	first, second := newServer(0), newServer(1)
+
+	client, _ := NewLLMClient(testPrivateKey, WithEndpoints([]string{first.URL, second.URL}, &RoundRobinPolicy{}))
+	for i := 0; i < 2; i++ {
+		if _, err := client.HealthCheck(context.Background()); err != nil {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+	}
+	if hits[0].Load() != 1 || hits[1].Load() != 1 {
+		t.Errorf("expected health checks to be spread across endpoints, got %d and %d", hits[0].Load(), hits[1].Load())
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := client.Ping(context.Background()); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	}
+	if hits[0].Load() != 2 || hits[1].Load() != 2 {
+		t.Errorf("expected pings to be spread across endpoints, got %d and %d", hits[0].Load(), hits[1].Load())
+	}
+}
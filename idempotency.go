@@ -0,0 +1,103 @@
+package blockrun
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+)
+
+// idempotencyHeader is the request header carrying the caller's idempotency key.
+const idempotencyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey tags a call with an idempotency key. The key is sent as
+// the Idempotency-Key header on both the unpaid probe and the paid retry, and
+// the signed PAYMENT-SIGNATURE is remembered under it until the call succeeds.
+//
+// If the process crashes (or the connection drops) after signing but before
+// the 200 arrives, repeating the call with the same key re-submits the
+// original signature instead of signing a second payment, so the gateway can
+// recognise the retry and settle at most once.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.idempotencyKey = key
+	}
+}
+
+// NewIdempotencyKey returns a random RFC 4122 version 4 UUID suitable for
+// WithIdempotencyKey.
+func NewIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand only fails if the OS entropy source is broken.
+		panic(fmt.Sprintf("blockrun: failed to read random bytes: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// PendingPaymentStore remembers signed payment payloads by idempotency key
+// between signing and a confirmed response, so an interrupted call can be
+// re-submitted with the same signature instead of paying twice.
+//
+// Values are the base64 PAYMENT-SIGNATURE header values produced by
+// CreatePaymentPayload / CreateSolanaPaymentPayload. Implementations must be
+// safe for concurrent use.
+type PendingPaymentStore interface {
+	// Get returns the payload stored under key, if any.
+	Get(key string) (string, bool)
+	// Put stores payload under key, replacing any previous value.
+	Put(key, payload string)
+	// Delete forgets key. Deleting a missing key is a no-op.
+	Delete(key string)
+}
+
+// MemoryPendingPaymentStore is an in-process PendingPaymentStore. It does not
+// survive a restart; plug in a persistent implementation via
+// WithPendingPaymentStore to cover process crashes.
+type MemoryPendingPaymentStore struct {
+	mu       sync.Mutex
+	payloads map[string]string
+}
+
+// NewMemoryPendingPaymentStore creates an empty in-memory store.
+func NewMemoryPendingPaymentStore() *MemoryPendingPaymentStore {
+	return &MemoryPendingPaymentStore{payloads: make(map[string]string)}
+}
+
+// Get returns the payload stored under key, if any.
+func (s *MemoryPendingPaymentStore) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payload, ok := s.payloads[key]
+	return payload, ok
+}
+
+// Put stores payload under key.
+func (s *MemoryPendingPaymentStore) Put(key, payload string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads[key] = payload
+}
+
+// Delete forgets key.
+func (s *MemoryPendingPaymentStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.payloads, key)
+}
+
+// Len returns the number of pending payloads.
+func (s *MemoryPendingPaymentStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.payloads)
+}
+
+// WithPendingPaymentStore replaces the client's in-memory PendingPaymentStore,
+// e.g. with one backed by disk or a shared database.
+func WithPendingPaymentStore(store PendingPaymentStore) ClientOption {
+	return func(c *LLMClient) {
+		c.pendingPayments = store
+	}
+}
@@ -0,0 +1,172 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// testPaymentRequiredHeader builds a base64 payment-required header asking for
+// amount micro-USDC on Base, for mock servers that exercise the 402 flow.
+func testPaymentRequiredHeader(t *testing.T, amount string) string {
+	t.Helper()
+	pr := PaymentRequirement{
+		X402Version: 2,
+		Accepts: []PaymentOption{{
+			Scheme:            "exact",
+			Network:           "eip155:8453",
+			Amount:            amount,
+			Asset:             USDCBase,
+			PayTo:             "0x1234567890123456789012345678901234567890",
+			MaxTimeoutSeconds: 300,
+		}},
+		Resource: ResourceInfo{
+			URL:         "https://blockrun.ai/api/v1/chat/completions",
+			Description: "Chat",
+		},
+	}
+	prJSON, err := json.Marshal(pr)
+	if err != nil {
+		t.Fatalf("marshal payment requirement: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(prJSON)
+}
+
+const testChatResponseJSON = `{"id":"c1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`
+
+func TestNewIdempotencyKey(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		key := NewIdempotencyKey()
+		if !uuidV4.MatchString(key) {
+			t.Fatalf("key %q is not a UUID v4", key)
+		}
+		if seen[key] {
+			t.Fatalf("duplicate key %q", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestIdempotencyKeyReusesSignatureOnRetry(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+
+	var mu sync.Mutex
+	var probeKeys, paidKeys, signatures []string
+	paidAttempts := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			probeKeys = append(probeKeys, r.Header.Get("Idempotency-Key"))
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		paidKeys = append(paidKeys, r.Header.Get("Idempotency-Key"))
+		signatures = append(signatures, sig)
+		paidAttempts++
+		if paidAttempts == 1 {
+			// Simulate the response getting lost after the payment was sent.
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	store := NewMemoryPendingPaymentStore()
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithPendingPaymentStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.costLog = nil
+
+	key := NewIdempotencyKey()
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithIdempotencyKey(key)); err == nil {
+		t.Fatal("expected first attempt to fail with 502")
+	}
+	if store.Len() != 1 {
+		t.Fatalf("expected signed payload to stay pending after a 502, got %d entries", store.Len())
+	}
+
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithIdempotencyKey(key)); err != nil {
+		t.Fatalf("retry with same key failed: %v", err)
+	}
+
+	if len(signatures) != 2 {
+		t.Fatalf("expected 2 paid requests, got %d", len(signatures))
+	}
+	if signatures[0] != signatures[1] {
+		t.Error("expected the retry to re-submit the original PAYMENT-SIGNATURE")
+	}
+	for _, got := range append(probeKeys, paidKeys...) {
+		if got != key {
+			t.Errorf("expected Idempotency-Key %q on every request, got %q", key, got)
+		}
+	}
+	if store.Len() != 0 {
+		t.Errorf("expected pending payload to be cleared after success, got %d entries", store.Len())
+	}
+}
+
+func TestDistinctIdempotencyKeysSignSeparately(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		signatures = append(signatures, sig)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.costLog = nil
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	for i := 0; i < 2; i++ {
+		if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithIdempotencyKey(NewIdempotencyKey())); err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+	}
+	if len(signatures) != 2 || signatures[0] == signatures[1] {
+		t.Error("expected distinct keys to produce distinct payment signatures")
+	}
+}
+
+func TestWithRequestOptionsContext(t *testing.T) {
+	ctx := WithRequestOptions(context.Background(), WithIdempotencyKey("a"))
+	if got := idempotencyKeyFrom(ctx); got != "a" {
+		t.Errorf("expected key a, got %q", got)
+	}
+	ctx2 := WithRequestOptions(ctx, WithIdempotencyKey("b"))
+	if got := idempotencyKeyFrom(ctx2); got != "b" {
+		t.Errorf("expected later option to win, got %q", got)
+	}
+	if got := idempotencyKeyFrom(ctx); got != "a" {
+		t.Errorf("expected parent context to be unchanged, got %q", got)
+	}
+	if WithRequestOptions(ctx) != ctx {
+		t.Error("expected no-op when no options are given")
+	}
+}
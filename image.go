@@ -3,12 +3,15 @@ package blockrun
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -53,6 +56,12 @@ type ImageClient struct {
 	// pollInterval is the wait between poll attempts on the async path.
 	// Defaults to imagePollInterval; overridable (mainly for tests).
 	pollInterval time.Duration
+	// models caches ListImageModels; validateModels makes Generate check
+	// its requests against it (see WithImageModelValidation).
+	models         *modelCache[ImageModel]
+	validateModels bool
+	// generations collapses identical concurrent GenerateDeduped calls.
+	generations singleflight.Group
 }
 
 // ImageClientOption is a function that configures an ImageClient.
@@ -72,6 +81,27 @@ func WithImageTimeout(timeout time.Duration) ImageClientOption {
 	}
 }
 
+// WithImageModelValidation checks Generate and GenerateWithProgress calls
+// against the image model catalogue (see ListImageModels) before sending
+// them: an unknown model, an unsupported size or an over-long prompt fails
+// with a ValidationError instead of a paid request. The catalogue is fetched
+// once per WithImageModelCacheTTL; if it cannot be fetched, a warning is
+// logged and the request is sent unchecked.
+func WithImageModelValidation() ImageClientOption {
+	return func(c *ImageClient) {
+		c.validateModels = true
+	}
+}
+
+// WithImageModelCacheTTL sets how long ListImageModels reuses the image
+// model catalogue (DefaultModelCacheTTL by default). A non-positive d
+// disables the cache.
+func WithImageModelCacheTTL(d time.Duration) ImageClientOption {
+	return func(c *ImageClient) {
+		c.models = &modelCache[ImageModel]{ttl: d}
+	}
+}
+
 // WithImageHTTPClient sets a custom HTTP client for the image client.
 func WithImageHTTPClient(client *http.Client) ImageClientOption {
 	return func(c *ImageClient) {
@@ -84,18 +114,20 @@ func WithImageHTTPClient(client *http.Client) ImageClientOption {
 // If privateKey is empty, it will be read from the BLOCKRUN_WALLET_KEY
 // or BASE_CHAIN_WALLET_KEY environment variable.
 func NewImageClient(privateKey string, opts ...ImageClientOption) (*ImageClient, error) {
-	bc, err := newBaseClient(privateKey, "", DefaultImageTimeout)
-	if err != nil {
-		return nil, err
-	}
+	bc := newUnkeyedBaseClient("", DefaultImageTimeout)
 
-	client := &ImageClient{baseClient: bc, pollInterval: imagePollInterval}
+	client := &ImageClient{baseClient: bc, pollInterval: imagePollInterval, models: &modelCache[ImageModel]{ttl: DefaultModelCacheTTL}}
 
 	// Apply options
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	// Load the key after options so WithEnvPrefix applies
+	if err := bc.loadKey(privateKey); err != nil {
+		return nil, err
+	}
+
 	// Check for custom API URL in environment (after options so user-set URLs win)
 	bc.checkEnvAPIURL()
 
@@ -108,6 +140,22 @@ type ImageGenerateOptions struct {
 	Size    string `json:"size,omitempty"`
 	N       int    `json:"n,omitempty"`
 	Quality string `json:"quality,omitempty"`
+	// InputImage, for image-to-image models, is sent as a base64 data URI
+	// in the request's "image" field. It must be PNG, JPEG or WebP and under
+	// MaxInputImageSize bytes.
+	InputImage []byte `json:"-"`
+	// InputImageMimeType is InputImage's type; it is detected if empty.
+	InputImageMimeType string `json:"-"`
+	// NegativePrompt describes what the image should not contain, for
+	// diffusion models that support it (see PromptEngineer).
+	NegativePrompt string `json:"negative_prompt,omitempty"`
+	// FileID, as an alternative to InputImage, is the ID of an image
+	// uploaded with UploadFile, sent in the request's "file_id" field.
+	FileID string `json:"file_id,omitempty"`
+
+	// inputImageErr is the error from WithInputImagePath or
+	// WithInputImageURL, reported by ValidateImageOptions.
+	inputImageErr error
 }
 
 // ImageData represents a single generated image.
@@ -144,7 +192,18 @@ type ImageModel struct {
 
 // Generate generates an image from a text prompt.
 func (c *ImageClient) Generate(ctx context.Context, prompt string, opts *ImageGenerateOptions) (*ImageResponse, error) {
-	// Build request body
+	opts = c.resolveImageOptions(opts)
+	if err := ValidateImageOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := c.validateGenerate(ctx, prompt, opts); err != nil {
+		return nil, err
+	}
+	return c.submitImageAndMaybePoll(ctx, "/v1/images/generations", imageGenerateBody(prompt, opts), nil)
+}
+
+// imageGenerateBody builds the /v1/images/generations request body.
+func imageGenerateBody(prompt string, opts *ImageGenerateOptions) map[string]any {
 	body := map[string]any{
 		"prompt": prompt,
 		"model":  DefaultImageModel,
@@ -165,9 +224,18 @@ func (c *ImageClient) Generate(ctx context.Context, prompt string, opts *ImageGe
 		if opts.Quality != "" {
 			body["quality"] = opts.Quality
 		}
+		if opts.InputImage != nil {
+			body["image"] = "data:" + inputImageMimeType(opts) + ";base64," + base64.StdEncoding.EncodeToString(opts.InputImage)
+		}
+		if opts.NegativePrompt != "" {
+			body["negative_prompt"] = opts.NegativePrompt
+		}
+		if opts.FileID != "" {
+			body["file_id"] = opts.FileID
+		}
 	}
 
-	return c.submitImageAndMaybePoll(ctx, "/v1/images/generations", body)
+	return body
 }
 
 // DefaultImageEditModel is the default model for image editing / fusion.
@@ -236,7 +304,7 @@ func (c *ImageClient) Edit(ctx context.Context, prompt string, images []string,
 		}
 	}
 
-	return c.submitImageAndMaybePoll(ctx, "/v1/images/image2image", body)
+	return c.submitImageAndMaybePoll(ctx, "/v1/images/image2image", body, nil)
 }
 
 // submitImageAndMaybePoll runs the gateway's hybrid image pipeline shared by
@@ -248,7 +316,11 @@ func (c *ImageClient) Edit(ctx context.Context, prompt string, images []string,
 // same wallet's PAYMENT-SIGNATURE until the job reaches a terminal state,
 // then returns the same ImageResponse shape as the fast path — callers never
 // see the async envelope.
-func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint string, body map[string]any) (*ImageResponse, error) {
+//
+// onProgress, when non-nil, is called as the job moves through its stages
+// (see GenerateWithProgress); it is never called with a terminal event.
+func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint string, body map[string]any, onProgress func(ImageProgressEvent)) (*ImageResponse, error) {
+	ctx = c.callContext(ctx)
 	submitURL := c.apiURL + endpoint
 
 	jsonBody, err := json.Marshal(body)
@@ -262,11 +334,10 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req1.Header.Set("Content-Type", "application/json")
-	resp1, err := c.httpClient.Do(req1)
+	resp1, err := c.do(req1)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	paymentHeader := resp1.Header.Get("payment-required")
 	body1, _ := io.ReadAll(resp1.Body)
 	resp1.Body.Close()
 
@@ -275,20 +346,14 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 		return decodeImageResponse(body1, resp1.Header)
 	}
 	if resp1.StatusCode != http.StatusPaymentRequired {
-		return nil, &APIError{
-			StatusCode: resp1.StatusCode,
-			Message:    fmt.Sprintf("API error: %s", string(body1)),
-		}
-	}
-	if paymentHeader == "" {
-		return nil, &PaymentError{Message: "402 response but no payment requirements found"}
+		return nil, newAPIError(resp1.StatusCode, "API error", body1)
 	}
 
 	// Step 2: sign the payment authorization. Floor the validity window at
 	// imageMaxTimeoutSeconds so the same signature survives the poll window.
-	paymentReq, err := ParsePaymentRequired(paymentHeader)
+	paymentReq, err := paymentRequirementFromResponse(resp1.Header, body1)
 	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
+		return nil, err
 	}
 	paymentOption, err := ExtractPaymentDetails(paymentReq)
 	if err != nil {
@@ -303,9 +368,9 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 		// (Base/EIP-712 only; the Solana path re-derives validity from the blockhash).
 		paymentOption.MaxTimeoutSeconds = imageMaxTimeoutSeconds
 	}
-	paymentPayload, err := c.createPaymentPayload(paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
+	paymentPayload, err := c.signPayment(ctx, paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
 	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to create payment: %v", err)}
+		return nil, err
 	}
 
 	// Step 3: retry with payment → 200 image data (fast path) or
@@ -316,7 +381,7 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 	}
 	req2.Header.Set("Content-Type", "application/json")
 	req2.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
-	resp2, err := c.httpClient.Do(req2)
+	resp2, err := c.do(req2)
 	if err != nil {
 		return nil, fmt.Errorf("submit request failed: %w", err)
 	}
@@ -325,18 +390,17 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 
 	switch resp2.StatusCode {
 	case http.StatusPaymentRequired:
+		c.clearPendingPayment(ctx)
 		return nil, &PaymentError{Message: "Payment was rejected. Check your wallet balance."}
 	case http.StatusOK:
 		// Fast path: generated and settled inline.
-		c.recordSettledCost(paymentOption.Amount, endpoint)
+		c.clearPendingPayment(ctx)
+		c.recordSettledCost(ctx, paymentOption, endpoint, modelFromBody(jsonBody), paymentPayload)
 		return decodeImageResponse(body2, resp2.Header)
 	case http.StatusAccepted:
 		// Slow path: async envelope — fall through to the poll loop below.
 	default:
-		return nil, &APIError{
-			StatusCode: resp2.StatusCode,
-			Message:    fmt.Sprintf("API error after payment: %s", string(body2)),
-		}
+		return nil, newAPIError(resp2.StatusCode, "API error after payment", body2)
 	}
 
 	var submitData struct {
@@ -344,15 +408,18 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 		PollURL string `json:"poll_url"`
 		Status  string `json:"status"`
 	}
-	if err := json.Unmarshal(body2, &submitData); err != nil {
-		return nil, fmt.Errorf("failed to decode submit response: %w", err)
-	}
-	if submitData.ID == "" || submitData.PollURL == "" {
-		return nil, &APIError{
-			StatusCode: resp2.StatusCode,
-			Message:    fmt.Sprintf("submit response missing id/poll_url: %s", string(body2)),
+	if len(body2) > 0 {
+		if err := json.Unmarshal(body2, &submitData); err != nil {
+			return nil, fmt.Errorf("failed to decode submit response: %w", err)
 		}
 	}
+	// Older gateway builds only announce the job via a Location header.
+	if submitData.PollURL == "" {
+		submitData.PollURL = resp2.Header.Get("Location")
+	}
+	if submitData.PollURL == "" {
+		return nil, newAPIError(resp2.StatusCode, "submit response missing poll_url", body2)
+	}
 
 	pollURL := c.resolvePollURL(submitData.PollURL)
 
@@ -370,6 +437,10 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 	pollSig := paymentPayload
 	lastSigned := time.Now()
 
+	if onProgress != nil {
+		onProgress(imageProgressFor(lastStatus, nil))
+	}
+
 	for time.Now().Before(deadline) {
 		select {
 		case <-ctx.Done():
@@ -383,7 +454,7 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 		}
 		pollSig, lastSigned = c.pollPaymentPayload(pollSig, lastSigned, paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
 		pollReq.Header.Set("PAYMENT-SIGNATURE", pollSig)
-		pollResp, err := c.httpClient.Do(pollReq)
+		pollResp, err := c.do(pollReq)
 		if err != nil {
 			return nil, fmt.Errorf("poll request failed: %w", err)
 		}
@@ -396,21 +467,22 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 			lastStatus = s
 		}
 
-		if pollResp.StatusCode == http.StatusAccepted && (lastStatus == "queued" || lastStatus == "in_progress") {
+		if pollResp.StatusCode == http.StatusAccepted && isImageJobPending(lastStatus) {
+			if onProgress != nil {
+				onProgress(imageProgressFor(lastStatus, pollData))
+			}
 			continue
 		}
 		if lastStatus == "failed" {
-			return nil, &APIError{
-				StatusCode: pollResp.StatusCode,
-				Message:    fmt.Sprintf("Upstream generation failed (no payment was taken): %s", string(pollBytes)),
-			}
+			return nil, newAPIError(pollResp.StatusCode, "Upstream generation failed (no payment was taken)", pollBytes)
 		}
 		// Terminal success is keyed on status, NOT the HTTP code — the
 		// gateway settles on-chain the moment a poll reports "completed", so
 		// the charge is irreversible at that point. Record the cost as soon
 		// as completion is observed, then decode.
 		if lastStatus == "completed" {
-			c.recordSettledCost(paymentOption.Amount, endpoint)
+			c.clearPendingPayment(ctx)
+			c.recordSettledCost(ctx, paymentOption, endpoint, modelFromBody(jsonBody), pollSig)
 			return decodeImageResponse(pollBytes, pollResp.Header)
 		}
 		// 504 on a poll = transient upstream hiccup; keep polling. Any other
@@ -418,10 +490,7 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 		if pollResp.StatusCode != http.StatusOK &&
 			pollResp.StatusCode != http.StatusAccepted &&
 			pollResp.StatusCode != http.StatusGatewayTimeout {
-			return nil, &APIError{
-				StatusCode: pollResp.StatusCode,
-				Message:    fmt.Sprintf("Poll failed: %s", string(pollBytes)),
-			}
+			return nil, newAPIError(pollResp.StatusCode, "Poll failed", pollBytes)
 		}
 	}
 
@@ -434,6 +503,114 @@ func (c *ImageClient) submitImageAndMaybePoll(ctx context.Context, endpoint stri
 	}
 }
 
+// Image job stages reported by GenerateWithProgress.
+const (
+	ImageStageQueued     = "queued"
+	ImageStageGenerating = "generating"
+	ImageStageProcessing = "processing"
+	ImageStageComplete   = "complete"
+)
+
+// ImageProgressEvent reports the state of a GenerateWithProgress job.
+//
+// The last event on the channel is terminal: either Stage == "complete" with
+// a non-nil Result, or Err set.
+type ImageProgressEvent struct {
+	// Stage is one of "queued", "generating", "processing" or "complete".
+	Stage string
+	// ProgressPercent is the gateway-reported progress (0-100) when the poll
+	// response carries one, otherwise an estimate derived from Stage.
+	ProgressPercent int
+	// Result is the generated image, set only when Stage == "complete".
+	Result *ImageResponse
+	// Err is set on the terminal event when generation failed.
+	Err error
+}
+
+// WithImagePollInterval sets the wait between poll attempts while an async
+// image job is running (default 3s).
+func WithImagePollInterval(interval time.Duration) ImageClientOption {
+	return func(c *ImageClient) {
+		if interval > 0 {
+			c.pollInterval = interval
+		}
+	}
+}
+
+// GenerateWithProgress is Generate for callers that want to surface progress
+// while slow models run (5-30s is typical). It returns immediately; events are
+// delivered on the returned channel, which is closed after the terminal
+// event. Fast models that answer inline produce a single "complete" event.
+//
+// Payment semantics are identical to Generate: an async job is charged only
+// once a poll observes completion. Cancel ctx to stop polling.
+func (c *ImageClient) GenerateWithProgress(ctx context.Context, prompt string, opts *ImageGenerateOptions) (<-chan ImageProgressEvent, error) {
+	if prompt == "" {
+		return nil, &ValidationError{Field: "prompt", Message: "Prompt is required"}
+	}
+	opts = c.resolveImageOptions(opts)
+	if err := ValidateImageOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := c.validateGenerate(ctx, prompt, opts); err != nil {
+		return nil, err
+	}
+
+	events := make(chan ImageProgressEvent, 8)
+	send := func(ev ImageProgressEvent) {
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(events)
+		resp, err := c.submitImageAndMaybePoll(ctx, "/v1/images/generations", imageGenerateBody(prompt, opts), send)
+		final := ImageProgressEvent{Stage: ImageStageComplete, ProgressPercent: 100, Result: resp}
+		if err != nil {
+			final = ImageProgressEvent{Err: err}
+		}
+		// Prefer delivering the terminal event, but never block forever on a
+		// caller that cancelled and stopped reading.
+		select {
+		case events <- final:
+		case <-ctx.Done():
+			select {
+			case events <- final:
+			default:
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// isImageJobPending reports whether a gateway job status is non-terminal.
+func isImageJobPending(status string) bool {
+	switch status {
+	case "queued", "in_progress", "generating", "processing":
+		return true
+	}
+	return false
+}
+
+// imageProgressFor maps a gateway job status (and optional poll body carrying
+// "progress") to a progress event.
+func imageProgressFor(status string, pollData map[string]any) ImageProgressEvent {
+	ev := ImageProgressEvent{Stage: ImageStageGenerating, ProgressPercent: 50}
+	switch status {
+	case "queued":
+		ev = ImageProgressEvent{Stage: ImageStageQueued, ProgressPercent: 0}
+	case "processing":
+		ev = ImageProgressEvent{Stage: ImageStageProcessing, ProgressPercent: 90}
+	}
+	if p, ok := pollData["progress"].(float64); ok && p >= 0 && p <= 100 {
+		ev.ProgressPercent = int(p)
+	}
+	return ev
+}
+
 // decodeImageResponse unmarshals a gateway image payload (the synchronous
 // shape or a completed poll, both carry data: [...]) and attaches the
 // settlement receipt from the X-Payment-Receipt header when present.
@@ -451,7 +628,13 @@ func decodeImageResponse(body []byte, header http.Header) (*ImageResponse, error
 }
 
 // ListImageModels returns the list of available image models with pricing.
+// The list is cached (see WithImageModelCacheTTL).
 func (c *ImageClient) ListImageModels(ctx context.Context) ([]ImageModel, error) {
+	return c.models.get(ctx, c.fetchImageModels)
+}
+
+// fetchImageModels fetches the image model catalogue, bypassing the cache.
+func (c *ImageClient) fetchImageModels(ctx context.Context) ([]ImageModel, error) {
 	respBytes, err := c.doGet(ctx, "/v1/images/models")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list image models: %w", err)
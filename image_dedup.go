@@ -0,0 +1,72 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// GenerateDeduped is Generate, but identical concurrent calls (same prompt,
+// options and idempotency key) share one upstream request and one payment,
+// for bursts such as many visitors asking for the same image. The returned
+// *ImageResponse is shared between those callers and must not be modified.
+// Waiters honour their own ctx. The shared call keeps the first caller's
+// context values but not its cancellation, so one caller giving up does not
+// fail the others; it is bounded by the client's timeout instead. Unlike
+// WithDeduplication nothing is reused once the call completes.
+func (c *ImageClient) GenerateDeduped(ctx context.Context, prompt string, opts *ImageGenerateOptions) (*ImageResponse, error) {
+	key := dedupKey(ctx, "/v1/images/generations", imageGenerateBody(prompt, c.resolveImageOptions(opts)))
+	if key == "" {
+		return c.Generate(ctx, prompt, opts)
+	}
+	ch := c.generations.DoChan(key, func() (any, error) {
+		callCtx := context.WithoutCancel(ctx)
+		if timeout := c.httpClient.Timeout; timeout > 0 {
+			var cancel context.CancelFunc
+			callCtx, cancel = context.WithTimeout(callCtx, timeout)
+			defer cancel()
+		}
+		return c.Generate(callCtx, prompt, opts)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*ImageResponse), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GenerateBatch generates an image for each prompt with opts, at most
+// concurrency at a time (DefaultBatchConcurrency if not positive), and
+// returns the responses in prompt order. Failed prompts leave a nil response
+// and are reported together in the error. Repeated prompts share one call,
+// as with GenerateDeduped.
+func (c *ImageClient) GenerateBatch(ctx context.Context, prompts []string, opts *ImageGenerateOptions, concurrency int) ([]*ImageResponse, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	responses := make([]*ImageResponse, len(prompts))
+	errs := make([]error, len(prompts))
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, prompt := range prompts {
+		i, prompt := i, prompt
+		g.Go(func() error {
+			resp, err := c.GenerateDeduped(ctx, prompt, opts)
+			responses[i] = resp
+			if err != nil {
+				errs[i] = fmt.Errorf("prompt %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	return responses, errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newMockImageServer answers /v1/images/generations with the prompt in the
+// image URL after delay, failing prompts that contain "fail".
+func newMockImageServer(t *testing.T, calls *atomic.Int32, delay time.Duration) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/images/generations" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		prompt, _ := body["prompt"].(string)
+		time.Sleep(delay)
+		if strings.Contains(prompt, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"rejected"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"created":1700000000,"data":[{"url":"https://example.com/%s.png"}]}`, strings.ReplaceAll(prompt, " ", "-"))
+	}))
+}
+
+func TestGenerateDeduped(t *testing.T) {
+	var calls atomic.Int32
+	server := newMockImageServer(t, &calls, 100*time.Millisecond)
+	defer server.Close()
+	client, err := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	responses := make([]*ImageResponse, 10)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := client.GenerateDeduped(context.Background(), "a cat", &ImageGenerateOptions{Size: "512x512"})
+			if err != nil {
+				t.Errorf("GenerateDeduped: %v", err)
+			}
+			responses[i] = resp
+		}(i)
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 upstream call for 10 identical prompts, got %d", n)
+	}
+	for _, resp := range responses {
+		if resp != responses[0] || resp.Data[0].URL != "https://example.com/a-cat.png" {
+			t.Fatalf("expected every caller to share the response, got %+v", resp)
+		}
+	}
+
+	// Once the call completes, nothing is reused; other options are a
+	// separate call.
+	client.GenerateDeduped(context.Background(), "a cat", &ImageGenerateOptions{Size: "512x512"})
+	client.GenerateDeduped(context.Background(), "a cat", &ImageGenerateOptions{Size: "1024x1024"})
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected 3 upstream calls, got %d", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.GenerateDeduped(ctx, "a dog", nil); err != context.DeadlineExceeded {
+		t.Errorf("expected the caller's context to end the wait, got %v", err)
+	}
+}
+
+func TestGenerateDedupedOutlivesFirstCaller(t *testing.T) {
+	var calls atomic.Int32
+	server := newMockImageServer(t, &calls, 100*time.Millisecond)
+	defer server.Close()
+	client, err := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.GenerateDeduped(first, "a cat", nil)
+		firstErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond) // let the first caller start the shared call
+	second := make(chan error, 1)
+	go func() {
+		resp, err := client.GenerateDeduped(context.Background(), "a cat", nil)
+		if err == nil && resp.Data[0].URL != "https://example.com/a-cat.png" {
+			err = fmt.Errorf("unexpected response %+v", resp)
+		}
+		second <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-firstErr; err != context.Canceled {
+		t.Errorf("expected the first caller to stop waiting, got %v", err)
+	}
+	if err := <-second; err != nil {
+		t.Errorf("expected the second caller to get the shared response, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 upstream call, got %d", n)
+	}
+}
+
+func TestGenerateBatch(t *testing.T) {
+	var calls atomic.Int32
+	server := newMockImageServer(t, &calls, 20*time.Millisecond)
+	defer server.Close()
+	client, err := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+
+	prompts := []string{"a cat", "a dog", "please fail", "a fox", "a cat"}
+	responses, err := client.GenerateBatch(context.Background(), prompts, nil, 2)
+	if err == nil || !strings.Contains(err.Error(), "prompt 2:") {
+		t.Errorf("expected an error for prompt 2, got %v", err)
+	}
+	if len(responses) != len(prompts) || responses[2] != nil {
+		t.Fatalf("unexpected responses %v", responses)
+	}
+	for i, want := range []string{"a-cat", "a-dog", "", "a-fox", "a-cat"} {
+		if want != "" && responses[i].Data[0].URL != "https://example.com/"+want+".png" {
+			t.Errorf("response %d: got %s, want %s", i, responses[i].Data[0].URL, want)
+		}
+	}
+	if n := calls.Load(); n < 4 || n > 5 {
+		t.Errorf("expected 4 or 5 upstream calls, got %d", n)
+	}
+}
@@ -0,0 +1,96 @@
+package blockrun
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// MaxInputImageSize is the largest ImageGenerateOptions.InputImage accepted.
+const MaxInputImageSize = 20 << 20
+
+// inputImageTypes are the MIME types accepted for InputImage.
+var inputImageTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/webp": true}
+
+// inputImageDownloadTimeout bounds WithInputImageURL's download.
+const inputImageDownloadTimeout = 30 * time.Second
+
+// WithInputImagePath returns options for an image-to-image Generate using
+// the image in the file at path. A read error is reported by Generate (or
+// ValidateImageOptions).
+func WithInputImagePath(path string) *ImageGenerateOptions {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &ImageGenerateOptions{inputImageErr: fmt.Errorf("failed to read input image: %w", err)}
+	}
+	return &ImageGenerateOptions{InputImage: data}
+}
+
+// WithInputImageURL returns options for an image-to-image Generate using
+// the image downloaded from url; its Content-Type, if an image type, is kept
+// as InputImageMimeType. A download error is reported by Generate (or
+// ValidateImageOptions).
+func WithInputImageURL(url string) *ImageGenerateOptions {
+	client := &http.Client{Timeout: inputImageDownloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return &ImageGenerateOptions{inputImageErr: fmt.Errorf("failed to download input image: %w", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &ImageGenerateOptions{inputImageErr: fmt.Errorf("failed to download input image: status %d", resp.StatusCode)}
+	}
+	// Read one byte past the limit so an oversized image fails validation.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxInputImageSize+1))
+	if err != nil {
+		return &ImageGenerateOptions{inputImageErr: fmt.Errorf("failed to download input image: %w", err)}
+	}
+	opts := &ImageGenerateOptions{InputImage: data}
+	if ct := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]); strings.HasPrefix(ct, "image/") {
+		opts.InputImageMimeType = ct
+	}
+	return opts
+}
+
+// ValidateImageOptions checks opts' input image: that it loaded, is under
+// MaxInputImageSize, and is PNG, JPEG or WebP.
+func ValidateImageOptions(opts *ImageGenerateOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.inputImageErr != nil {
+		return opts.inputImageErr
+	}
+	if opts.InputImage == nil {
+		return nil
+	}
+	if len(opts.InputImage) >= MaxInputImageSize {
+		return &ValidationError{
+			Field:      "InputImage",
+			Message:    fmt.Sprintf("input image is %d bytes, limit is %d", len(opts.InputImage), MaxInputImageSize),
+			Suggestion: "Resize or recompress the image",
+			Value:      len(opts.InputImage),
+		}
+	}
+	if mime := inputImageMimeType(opts); !inputImageTypes[mime] {
+		return &ValidationError{
+			Field:      "InputImageMimeType",
+			Message:    fmt.Sprintf("unsupported input image type %q", mime),
+			Suggestion: "Use a PNG, JPEG or WebP image",
+			Value:      mime,
+		}
+	}
+	return nil
+}
+
+// inputImageMimeType returns opts.InputImageMimeType, or the type detected
+// from InputImage.
+func inputImageMimeType(opts *ImageGenerateOptions) string {
+	if opts.InputImageMimeType != "" {
+		return strings.ToLower(opts.InputImageMimeType)
+	}
+	return http.DetectContentType(opts.InputImage)
+}
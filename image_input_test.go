@@ -0,0 +1,86 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPNG is the 8-byte PNG signature, enough for content sniffing.
+var testPNG = []byte("\x89PNG\r\n\x1a\n")
+
+func TestImageGenerateInputImage(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/generations":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			bodies = append(bodies, body)
+			json.NewEncoder(w).Encode(ImageResponse{Created: 1, Data: []ImageData{{URL: "https://example.com/out.png"}}})
+		case "/input.webp":
+			w.Header().Set("Content-Type", "image/webp")
+			w.Write([]byte("RIFF....WEBPVP8 "))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	ctx := context.Background()
+
+	if _, err := client.Generate(ctx, "a cat", &ImageGenerateOptions{InputImage: testPNG}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG)
+	if bodies[0]["image"] != want {
+		t.Errorf("expected image %q, got %v", want, bodies[0]["image"])
+	}
+
+	path := filepath.Join(t.TempDir(), "in.jpg")
+	os.WriteFile(path, []byte("\xff\xd8\xff\xe0jpeg"), 0644)
+	if _, err := client.Generate(ctx, "a cat", WithInputImagePath(path)); err != nil {
+		t.Fatalf("Generate with path failed: %v", err)
+	}
+	if img, _ := bodies[1]["image"].(string); len(img) < 23 || img[:23] != "data:image/jpeg;base64," {
+		t.Errorf("expected a JPEG data URI, got %q", img)
+	}
+
+	if _, err := client.Generate(ctx, "a cat", WithInputImageURL(server.URL+"/input.webp")); err != nil {
+		t.Fatalf("Generate with URL failed: %v", err)
+	}
+	if img, _ := bodies[2]["image"].(string); len(img) < 23 || img[:23] != "data:image/webp;base64," {
+		t.Errorf("expected a WebP data URI, got %q", img)
+	}
+
+	if _, err := client.Generate(ctx, "a cat", nil); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, ok := bodies[3]["image"]; ok {
+		t.Error("expected no image field without InputImage")
+	}
+}
+
+func TestValidateImageInput(t *testing.T) {
+	var ve *ValidationError
+	if err := ValidateImageOptions(&ImageGenerateOptions{InputImage: []byte("GIF89a")}); !errors.As(err, &ve) || ve.Field != "InputImageMimeType" {
+		t.Errorf("expected a MIME type ValidationError, got %v", err)
+	}
+	big := make([]byte, MaxInputImageSize)
+	copy(big, testPNG)
+	if err := ValidateImageOptions(&ImageGenerateOptions{InputImage: big}); !errors.As(err, &ve) || ve.Field != "InputImage" {
+		t.Errorf("expected a size ValidationError, got %v", err)
+	}
+	if err := ValidateImageOptions(WithInputImagePath(filepath.Join(t.TempDir(), "missing.png"))); err == nil {
+		t.Error("expected the read error to be reported")
+	}
+	if err := ValidateImageOptions(&ImageGenerateOptions{InputImage: testPNG}); err != nil {
+		t.Errorf("valid image rejected: %v", err)
+	}
+}
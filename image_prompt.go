@@ -0,0 +1,154 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultPromptEngineerModel is the chat model PromptEngineer uses when its
+// Model is empty.
+const DefaultPromptEngineerModel = "openai/gpt-4o-mini"
+
+// promptPlaceholder marks where ApplyStyle puts the prompt in a template.
+const promptPlaceholder = "{prompt}"
+
+// Meta-prompts sent by PromptEngineer.
+const (
+	enhancePromptInstruction = "Rewrite this image generation prompt so a diffusion model produces a better image: " +
+		"add concrete detail about subject, composition, lighting and camera, and keep the original intent.%s\n\n" +
+		"Prompt: %s\n\nReply with the rewritten prompt only."
+	negativePromptInstruction = "List what an image generation model should avoid when drawing: %s. " +
+		"Include common defects (blur, artifacts, bad anatomy, text, watermarks) and anything off-subject. " +
+		"Reply with a comma-separated negative prompt only."
+)
+
+// builtinImageStyles are the templates every ImagePromptLibrary starts with.
+var builtinImageStyles = map[string]string{
+	"photorealistic": "{prompt}, photorealistic, highly detailed, natural lighting, 85mm lens, sharp focus",
+	"anime":          "{prompt}, anime style, cel shading, vibrant colors, clean line art",
+	"oil painting":   "{prompt}, oil painting, visible brush strokes, rich textures, classical composition",
+}
+
+// ImagePromptLibrary holds named style templates for image prompts. A
+// template contains "{prompt}" where the prompt goes, or is appended to the
+// prompt after a comma if it does not. It is safe for concurrent use.
+type ImagePromptLibrary struct {
+	mu     sync.RWMutex
+	styles map[string]string
+}
+
+// NewImagePromptLibrary returns a library with the built-in
+// "photorealistic", "anime" and "oil painting" styles.
+func NewImagePromptLibrary() *ImagePromptLibrary {
+	l := &ImagePromptLibrary{styles: make(map[string]string, len(builtinImageStyles))}
+	for name, template := range builtinImageStyles {
+		l.styles[name] = template
+	}
+	return l
+}
+
+// Add adds or replaces the style name.
+func (l *ImagePromptLibrary) Add(name, template string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.styles[name] = template
+}
+
+// Styles returns the style names, sorted.
+func (l *ImagePromptLibrary) Styles() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	names := make([]string, 0, len(l.styles))
+	for name := range l.styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyStyle returns prompt in style. Unknown styles, including "", leave
+// prompt unchanged.
+func (l *ImagePromptLibrary) ApplyStyle(prompt, style string) string {
+	template, ok := l.template(style)
+	if !ok {
+		return prompt
+	}
+	if strings.Contains(template, promptPlaceholder) {
+		return strings.ReplaceAll(template, promptPlaceholder, prompt)
+	}
+	return prompt + ", " + template
+}
+
+// template returns the template of style, if the library has it.
+func (l *ImagePromptLibrary) template(style string) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	template, ok := l.styles[style]
+	return template, ok
+}
+
+// PromptEngineer uses a chat model to improve image prompts. The zero value
+// uses DefaultPromptEngineerModel and the built-in styles. The calls it
+// makes are ordinary, paid ChatCompletion calls.
+type PromptEngineer struct {
+	// Model is the chat model; DefaultPromptEngineerModel if empty.
+	Model string
+	// Library resolves style names; NewImagePromptLibrary() if nil.
+	Library *ImagePromptLibrary
+}
+
+// EnhancePrompt asks the model to rewrite basePrompt for better image
+// quality. style, if not empty, is a Library style name, whose template is
+// applied to the result, or else a free-form style description passed to
+// the model.
+func (e *PromptEngineer) EnhancePrompt(ctx context.Context, basePrompt, style string, client *LLMClient) (string, error) {
+	if basePrompt == "" {
+		return "", &ValidationError{Field: "basePrompt", Message: "Prompt is required"}
+	}
+	library := e.library()
+	hint := ""
+	if _, ok := library.template(style); style != "" && !ok {
+		hint = fmt.Sprintf(" Make it in this style: %s.", style)
+	}
+	enhanced, err := e.ask(ctx, client, fmt.Sprintf(enhancePromptInstruction, hint, basePrompt))
+	if err != nil {
+		return "", err
+	}
+	return library.ApplyStyle(enhanced, style), nil
+}
+
+// GenerateNegativePrompt asks the model what an image of subject should
+// exclude, for ImageGenerateOptions.NegativePrompt.
+func (e *PromptEngineer) GenerateNegativePrompt(ctx context.Context, subject string, client *LLMClient) (string, error) {
+	if subject == "" {
+		return "", &ValidationError{Field: "subject", Message: "Subject is required"}
+	}
+	return e.ask(ctx, client, fmt.Sprintf(negativePromptInstruction, subject))
+}
+
+// ask sends prompt to the engineer's model and returns the trimmed reply.
+func (e *PromptEngineer) ask(ctx context.Context, client *LLMClient, prompt string) (string, error) {
+	model := e.Model
+	if model == "" {
+		model = DefaultPromptEngineerModel
+	}
+	resp, err := client.ChatCompletion(ctx, model, []ChatMessage{{Role: "user", Content: prompt}}, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", &APIError{Message: "No choices in response"}
+	}
+	return strings.Trim(strings.TrimSpace(resp.Choices[0].Message.Content), `"`), nil
+}
+
+// library returns e.Library or the built-in styles.
+func (e *PromptEngineer) library() *ImagePromptLibrary {
+	if e.Library != nil {
+		return e.Library
+	}
+	return NewImagePromptLibrary()
+}
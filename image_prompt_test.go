@@ -0,0 +1,121 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImageNegativePrompt(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/images/generations" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"created":1700000000,"data":[{"url":"https://example.com/cat.png"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), "a cat", &ImageGenerateOptions{NegativePrompt: "blurry, extra limbs"}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if body["negative_prompt"] != "blurry, extra limbs" {
+		t.Errorf("expected negative_prompt in body, got %v", body)
+	}
+	if _, ok := imageGenerateBody("a cat", &ImageGenerateOptions{})["negative_prompt"]; ok {
+		t.Error("expected no negative_prompt when unset")
+	}
+}
+
+func TestImagePromptLibrary(t *testing.T) {
+	l := NewImagePromptLibrary()
+	if got := strings.Join(l.Styles(), ","); got != "anime,oil painting,photorealistic" {
+		t.Errorf("unexpected built-in styles %q", got)
+	}
+	if got := l.ApplyStyle("a lighthouse", "anime"); !strings.HasPrefix(got, "a lighthouse, anime style") {
+		t.Errorf("unexpected anime prompt %q", got)
+	}
+	l.Add("noir", "black and white film noir still of {prompt}")
+	l.Add("sketch", "pencil sketch")
+	if got := l.ApplyStyle("a detective", "noir"); got != "black and white film noir still of a detective" {
+		t.Errorf("unexpected noir prompt %q", got)
+	}
+	if got := l.ApplyStyle("a detective", "sketch"); got != "a detective, pencil sketch" {
+		t.Errorf("unexpected sketch prompt %q", got)
+	}
+	if got := l.ApplyStyle("a detective", "cubist"); got != "a detective" {
+		t.Errorf("expected unknown styles to leave the prompt alone, got %q", got)
+	}
+}
+
+func TestPromptEngineer(t *testing.T) {
+	var prompts []string
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string        `json:"model"`
+			Messages []ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		models = append(models, req.Model)
+		prompt := req.Messages[0].Content
+		prompts = append(prompts, prompt)
+		reply := `"a red fox in fresh snow at dawn, low sun, shallow depth of field"`
+		if strings.Contains(prompt, "avoid") {
+			reply = "blur, watermark, extra legs"
+		}
+		fmt.Fprintf(w, `{"id":"c","object":"chat.completion","created":1,"model":%q,"choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, req.Model, reply)
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	ctx := context.Background()
+	var e PromptEngineer
+
+	got, err := e.EnhancePrompt(ctx, "a fox", "photorealistic", client)
+	if err != nil {
+		t.Fatalf("EnhancePrompt: %v", err)
+	}
+	if got != "a red fox in fresh snow at dawn, low sun, shallow depth of field, photorealistic, highly detailed, natural lighting, 85mm lens, sharp focus" {
+		t.Errorf("unexpected enhanced prompt %q", got)
+	}
+	if models[0] != DefaultPromptEngineerModel || !strings.Contains(prompts[0], "Prompt: a fox") || strings.Contains(prompts[0], "this style") {
+		t.Errorf("unexpected enhance request to %s: %q", models[0], prompts[0])
+	}
+
+	e.Model = "anthropic/claude-haiku-4.5"
+	if _, err := e.EnhancePrompt(ctx, "a fox", "ukiyo-e woodblock print", client); err != nil {
+		t.Fatal(err)
+	}
+	if models[1] != "anthropic/claude-haiku-4.5" || !strings.Contains(prompts[1], "Make it in this style: ukiyo-e woodblock print.") {
+		t.Errorf("expected a free-form style to be passed to %s: %q", models[1], prompts[1])
+	}
+
+	negative, err := e.GenerateNegativePrompt(ctx, "a red fox", client)
+	if err != nil {
+		t.Fatalf("GenerateNegativePrompt: %v", err)
+	}
+	if negative != "blur, watermark, extra legs" || !strings.Contains(prompts[2], "avoid when drawing: a red fox") {
+		t.Errorf("unexpected negative prompt %q for request %q", negative, prompts[2])
+	}
+
+	if _, err := e.EnhancePrompt(ctx, "", "", client); err == nil {
+		t.Error("expected a validation error for an empty prompt")
+	}
+	if _, err := e.GenerateNegativePrompt(ctx, "", client); err == nil {
+		t.Error("expected a validation error for an empty subject")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -328,3 +329,100 @@ func TestImageClientGenerateAsyncFailedNotCharged(t *testing.T) {
 		t.Errorf("failed job must not record spending, got %f", got)
 	}
 }
+
+func TestImageClientGenerateWithProgress(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "40000")
+	pollStates := []map[string]any{
+		{"status": "queued"},
+		{"status": "in_progress", "progress": 40},
+		{"status": "processing"},
+	}
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.URL.Path != "/v1/images/generations/job42" {
+				t.Errorf("unexpected poll path %s", r.URL.Path)
+			}
+			if polls < len(pollStates) {
+				w.WriteHeader(http.StatusAccepted)
+				_ = json.NewEncoder(w).Encode(pollStates[polls])
+				polls++
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"status": "completed",
+				"data":   []map[string]any{{"url": "https://cdn.example.com/p.png"}},
+			})
+			return
+		}
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		// Job announced only via Location, with an empty body.
+		w.Header().Set("Location", "/v1/images/generations/job42")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client, err := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL), WithImagePollInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+	client.costLog = nil
+
+	events, err := client.GenerateWithProgress(context.Background(), "a lighthouse", nil)
+	if err != nil {
+		t.Fatalf("GenerateWithProgress: %v", err)
+	}
+
+	var stages []string
+	var percents []int
+	var last ImageProgressEvent
+	for ev := range events {
+		stages = append(stages, ev.Stage)
+		percents = append(percents, ev.ProgressPercent)
+		last = ev
+	}
+
+	want := []string{"queued", "queued", "generating", "processing", "complete"}
+	if strings.Join(stages, ",") != strings.Join(want, ",") {
+		t.Fatalf("stages = %v, want %v", stages, want)
+	}
+	if percents[2] != 40 {
+		t.Errorf("expected gateway-reported progress 40, got %d", percents[2])
+	}
+	if last.Err != nil || last.Result == nil || last.Result.Data[0].URL != "https://cdn.example.com/p.png" {
+		t.Errorf("unexpected terminal event: %+v", last)
+	}
+	if got := client.GetSpending().TotalUSD; got != 0.04 {
+		t.Errorf("expected $0.04 recorded, got %f", got)
+	}
+}
+
+func TestImageClientGenerateWithProgressFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"bad size"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+
+	events, err := client.GenerateWithProgress(context.Background(), "a lighthouse", nil)
+	if err != nil {
+		t.Fatalf("GenerateWithProgress: %v", err)
+	}
+	var got []ImageProgressEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != 1 || got[0].Err == nil {
+		t.Fatalf("expected a single error event, got %+v", got)
+	}
+}
@@ -0,0 +1,128 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateImageModel checks that model is one of models, as returned by
+// ListImageModels.
+func ValidateImageModel(model string, models []ImageModel) error {
+	for _, m := range models {
+		if m.ID == model {
+			return nil
+		}
+	}
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	return &ValidationError{
+		Field:      "model",
+		Message:    fmt.Sprintf("unknown image model %q", model),
+		Suggestion: "Use one of: " + strings.Join(ids, ", "),
+		Value:      model,
+	}
+}
+
+// ValidateImageSize checks that size is one of model's SupportedSizes. Any
+// size is accepted if the model does not list them.
+func ValidateImageSize(size string, model *ImageModel) error {
+	if model == nil || len(model.SupportedSizes) == 0 {
+		return nil
+	}
+	for _, s := range model.SupportedSizes {
+		if s == size {
+			return nil
+		}
+	}
+	return &ValidationError{
+		Field:      "size",
+		Message:    fmt.Sprintf("size %q is not supported by %s", size, model.ID),
+		Suggestion: "Use one of: " + strings.Join(model.SupportedSizes, ", "),
+		Value:      size,
+	}
+}
+
+// ValidateImagePromptLength checks that prompt is at most model's
+// MaxPromptLength characters. Any length is accepted if the model does not
+// set one.
+func ValidateImagePromptLength(prompt string, model *ImageModel) error {
+	if model == nil || model.MaxPromptLength <= 0 {
+		return nil
+	}
+	if n := utf8.RuneCountInString(prompt); n > model.MaxPromptLength {
+		return &ValidationError{
+			Field:      "prompt",
+			Message:    fmt.Sprintf("prompt too long for %s: %d characters, limit is %d", model.ID, n, model.MaxPromptLength),
+			Suggestion: "Shorten the prompt",
+			Value:      n,
+		}
+	}
+	return nil
+}
+
+// Validate checks the options against model: that Model, if set, names it
+// and that Size, if set, is one it supports. The prompt is not part of the
+// options; check it with ValidateImagePromptLength.
+func (o *ImageGenerateOptions) Validate(model *ImageModel) error {
+	if o == nil || model == nil {
+		return nil
+	}
+	if o.Model != "" {
+		if err := ValidateImageModel(o.Model, []ImageModel{*model}); err != nil {
+			return err
+		}
+	}
+	if o.Size != "" {
+		return ValidateImageSize(o.Size, model)
+	}
+	return nil
+}
+
+// validateGenerate checks a Generate call against the cached image model
+// catalogue when WithImageModelValidation is set. Validation is best
+// effort: if the catalogue cannot be fetched (logged as a warning) or lists
+// no models, the request goes ahead and the gateway decides.
+func (c *ImageClient) validateGenerate(ctx context.Context, prompt string, opts *ImageGenerateOptions) error {
+	if !c.validateModels {
+		return nil
+	}
+	models, err := c.ListImageModels(ctx)
+	if err != nil {
+		c.warnf("image model validation skipped: %v", err)
+		return nil
+	}
+	listed := models[:0]
+	for _, m := range models {
+		if m.ID != "" {
+			listed = append(listed, m)
+		}
+	}
+	if len(listed) == 0 {
+		return nil
+	}
+
+	// The default model is not checked against the catalogue, only a model
+	// the caller asked for.
+	id := DefaultImageModel
+	if opts != nil && opts.Model != "" {
+		id = opts.Model
+		if err := ValidateImageModel(id, listed); err != nil {
+			return err
+		}
+	}
+	var model *ImageModel
+	for i := range listed {
+		if listed[i].ID == id {
+			model = &listed[i]
+			break
+		}
+	}
+	if err := opts.Validate(model); err != nil {
+		return err
+	}
+	return ValidateImagePromptLength(prompt, model)
+}
@@ -0,0 +1,132 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var testImageModels = []ImageModel{
+	{ID: "google/nano-banana", SupportedSizes: []string{"1024x1024"}, MaxPromptLength: 20},
+	{ID: "openai/dall-e-3", SupportedSizes: []string{"1024x1024", "1792x1024"}},
+}
+
+func TestValidateImageOptions(t *testing.T) {
+	dalle := &testImageModels[1]
+	var ve *ValidationError
+
+	if err := ValidateImageModel("openai/dall-e-3", testImageModels); err != nil {
+		t.Errorf("listed model rejected: %v", err)
+	}
+	if err := ValidateImageModel("openai/dall-e-2", testImageModels); !errors.As(err, &ve) || ve.Field != "model" {
+		t.Errorf("expected a model ValidationError, got %v", err)
+	}
+	if err := ValidateImageSize("256x256", dalle); !errors.As(err, &ve) || ve.Field != "size" {
+		t.Errorf("expected a size ValidationError, got %v", err)
+	}
+	if err := ValidateImageSize("256x256", &ImageModel{ID: "x"}); err != nil {
+		t.Errorf("model without listed sizes should accept any size: %v", err)
+	}
+	if err := ValidateImagePromptLength(strings.Repeat("é", 20), &testImageModels[0]); err != nil {
+		t.Errorf("prompt at the limit rejected: %v", err)
+	}
+	if err := ValidateImagePromptLength(strings.Repeat("é", 21), &testImageModels[0]); !errors.As(err, &ve) || ve.Field != "prompt" {
+		t.Errorf("expected a prompt ValidationError, got %v", err)
+	}
+
+	opts := &ImageGenerateOptions{Model: "openai/dall-e-3", Size: "1792x1024"}
+	if err := opts.Validate(dalle); err != nil {
+		t.Errorf("valid options rejected: %v", err)
+	}
+	opts.Size = "512x512"
+	if err := opts.Validate(dalle); !errors.As(err, &ve) || ve.Field != "size" {
+		t.Errorf("expected a size ValidationError, got %v", err)
+	}
+	opts = &ImageGenerateOptions{Model: "google/nano-banana"}
+	if err := opts.Validate(dalle); !errors.As(err, &ve) || ve.Field != "model" {
+		t.Errorf("expected a model ValidationError, got %v", err)
+	}
+}
+
+func TestImageGenerateValidates(t *testing.T) {
+	var catalogue, generations int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/models":
+			atomic.AddInt32(&catalogue, 1)
+			json.NewEncoder(w).Encode(map[string]any{"data": testImageModels})
+		case "/v1/images/generations":
+			atomic.AddInt32(&generations, 1)
+			json.NewEncoder(w).Encode(ImageResponse{Created: 1, Data: []ImageData{{URL: "https://example.com/cat.png"}}})
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL), WithImageModelValidation())
+	ctx := context.Background()
+	var ve *ValidationError
+
+	cases := []struct {
+		prompt string
+		opts   *ImageGenerateOptions
+		field  string
+	}{
+		{"a cat", &ImageGenerateOptions{Model: "openai/dall-e-2"}, "model"},
+		{"a cat", &ImageGenerateOptions{Model: "openai/dall-e-3", Size: "256x256"}, "size"},
+		{"a cat", &ImageGenerateOptions{Size: "1792x1024"}, "size"},
+		{strings.Repeat("cat ", 10), nil, "prompt"},
+	}
+	for _, tc := range cases {
+		if _, err := client.Generate(ctx, tc.prompt, tc.opts); !errors.As(err, &ve) || ve.Field != tc.field {
+			t.Errorf("Generate(%q, %+v): expected a %s ValidationError, got %v", tc.prompt, tc.opts, tc.field, err)
+		}
+	}
+	if generations != 0 {
+		t.Errorf("expected invalid requests not to be sent, got %d", generations)
+	}
+
+	if _, err := client.Generate(ctx, "a cat", &ImageGenerateOptions{Model: "openai/dall-e-3", Size: "1792x1024"}); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+	if catalogue != 1 {
+		t.Errorf("expected the catalogue to be fetched once, got %d", catalogue)
+	}
+}
+
+func TestImageModelValidationIsOptIn(t *testing.T) {
+	var catalogue, generations int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/models":
+			atomic.AddInt32(&catalogue, 1)
+			http.Error(w, `{"error":"unavailable"}`, http.StatusServiceUnavailable)
+		case "/v1/images/generations":
+			atomic.AddInt32(&generations, 1)
+			json.NewEncoder(w).Encode(ImageResponse{Created: 1, Data: []ImageData{{URL: "https://example.com/cat.png"}}})
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	client, _ := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	if _, err := client.Generate(ctx, "a cat", &ImageGenerateOptions{Model: "openai/dall-e-2"}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if catalogue != 0 {
+		t.Errorf("expected no catalogue fetch without WithImageModelValidation, got %d", catalogue)
+	}
+
+	validating, _ := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL), WithImageModelValidation())
+	validating.logLevel = LogLevelOff
+	if _, err := validating.Generate(ctx, "a cat", nil); err != nil {
+		t.Fatalf("expected the request to go ahead when the catalogue fails, got %v", err)
+	}
+	if catalogue != 1 || generations != 2 {
+		t.Errorf("expected 1 catalogue fetch and 2 generations, got %d and %d", catalogue, generations)
+	}
+}
@@ -0,0 +1,131 @@
+// Package k8s serves Kubernetes liveness and readiness probes for a service
+// built on a blockrun client:
+//
+//	client, _ := blockrun.NewLLMClient("")
+//	srv := k8s.ServeProbes(":8081", client)
+//	defer srv.Close()
+//
+// GET /healthz answers 200 while the process is up, without any network
+// call. GET /readyz answers 200 when the BlockRun gateway can be reached,
+// checked as deep as WithReadinessDepth says, and 503 otherwise.
+package k8s
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// Readiness depths for WithReadinessDepth.
+const (
+	// ReadinessNone is always ready.
+	ReadinessNone = "none"
+	// ReadinessPing is ready when a HEAD request to the gateway succeeds.
+	ReadinessPing = "ping"
+	// ReadinessHealth is ready when HealthCheck reports the gateway
+	// reachable. It is the default.
+	ReadinessHealth = "health"
+	// ReadinessModelList is ready when ListModels succeeds.
+	ReadinessModelList = "model-list"
+)
+
+// DefaultReadinessTimeout bounds a readiness check.
+const DefaultReadinessTimeout = 5 * time.Second
+
+// probeHandler serves /healthz and /readyz.
+type probeHandler struct {
+	client  *blockrun.LLMClient
+	depth   string
+	timeout time.Duration
+}
+
+// Option configures a probe handler.
+type Option func(*probeHandler)
+
+// WithReadinessDepth sets how /readyz checks the gateway: ReadinessNone,
+// ReadinessPing, ReadinessHealth (the default) or ReadinessModelList.
+func WithReadinessDepth(depth string) Option {
+	return func(h *probeHandler) {
+		h.depth = depth
+	}
+}
+
+// WithReadinessTimeout bounds each readiness check (DefaultReadinessTimeout
+// by default).
+func WithReadinessTimeout(d time.Duration) Option {
+	return func(h *probeHandler) {
+		h.timeout = d
+	}
+}
+
+// NewProbeHandler returns a handler serving GET /healthz and /readyz for
+// client.
+func NewProbeHandler(client *blockrun.LLMClient, opts ...Option) http.Handler {
+	h := &probeHandler{client: client, depth: ReadinessHealth, timeout: DefaultReadinessTimeout}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *probeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		w.Write([]byte("ok\n"))
+	case "/readyz":
+		ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+		defer cancel()
+		if err := h.ready(ctx); err != nil {
+			http.Error(w, "not ready: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// ready checks the gateway at the handler's depth.
+func (h *probeHandler) ready(ctx context.Context) error {
+	switch h.depth {
+	case ReadinessNone:
+		return nil
+	case ReadinessPing:
+		return h.client.Ping(ctx)
+	case ReadinessModelList:
+		_, err := h.client.ListModels(ctx)
+		return err
+	case ReadinessHealth:
+		status, err := h.client.HealthCheck(ctx)
+		if err != nil {
+			return err
+		}
+		if !status.Reachable {
+			return errors.New(status.Message)
+		}
+		return nil
+	}
+	return errors.New("unknown readiness depth " + h.depth)
+}
+
+// ServeProbes serves NewProbeHandler(client, opts...) on addr in a
+// background goroutine and returns the server, to be closed on shutdown.
+// Serving errors are logged.
+func ServeProbes(addr string, client *blockrun.LLMClient, opts ...Option) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           NewProbeHandler(client, opts...),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("blockrun/k8s: probe server: %v", err)
+		}
+	}()
+	return srv
+}
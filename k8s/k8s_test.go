@@ -0,0 +1,97 @@
+package k8s
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// newAPI returns a gateway whose health, models and root endpoints all
+// answer with status.
+func newAPI(t *testing.T, status int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		switch r.URL.Path {
+		case "/v1/health":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/v1/models":
+			w.Write([]byte(`{"data":[{"id":"openai/gpt-4o"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// probe returns the status of GET path on h.
+func probe(h http.Handler, path string) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	return rec.Code
+}
+
+func TestProbes(t *testing.T) {
+	healthy, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(newAPI(t, http.StatusOK).URL))
+	down := newAPI(t, http.StatusServiceUnavailable)
+	unhealthy, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(down.URL))
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	unreachable, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(closed.URL))
+
+	for _, depth := range []string{ReadinessHealth, ReadinessPing, ReadinessModelList} {
+		for _, tc := range []struct {
+			name   string
+			client *blockrun.LLMClient
+			want   int
+		}{
+			{"healthy", healthy, http.StatusOK},
+			{"unhealthy", unhealthy, http.StatusServiceUnavailable},
+			{"unreachable", unreachable, http.StatusServiceUnavailable},
+		} {
+			h := NewProbeHandler(tc.client, WithReadinessDepth(depth))
+			if got := probe(h, "/healthz"); got != http.StatusOK {
+				t.Errorf("%s/%s: expected liveness 200, got %d", depth, tc.name, got)
+			}
+			if got := probe(h, "/readyz"); got != tc.want {
+				t.Errorf("%s/%s: expected readiness %d, got %d", depth, tc.name, tc.want, got)
+			}
+		}
+	}
+
+	if got := probe(NewProbeHandler(unreachable, WithReadinessDepth(ReadinessNone)), "/readyz"); got != http.StatusOK {
+		t.Errorf("expected depth none to be always ready, got %d", got)
+	}
+}
+
+func TestServeProbes(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	client, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(newAPI(t, http.StatusOK).URL))
+	srv := ServeProbes(addr, client)
+	defer srv.Close()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + addr + "/readyz"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("probe server not serving: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected readiness 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,62 @@
+package blockrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// WithMaxRequestBodySize rejects chat calls whose JSON request body is
+// larger than bytes, before anything is sent or paid for. 0 means no limit.
+func WithMaxRequestBodySize(bytes int64) ClientOption {
+	return func(c *LLMClient) {
+		c.maxRequestBodySize = bytes
+	}
+}
+
+// WithMaxPromptLength rejects chat calls whose message contents total more
+// than chars characters. 0 means no limit.
+func WithMaxPromptLength(chars int) ClientOption {
+	return func(c *LLMClient) {
+		c.maxPromptLength = chars
+	}
+}
+
+// checkPromptLength enforces WithMaxPromptLength.
+func (c *LLMClient) checkPromptLength(messages []ChatMessage) error {
+	if c.maxPromptLength <= 0 {
+		return nil
+	}
+	total := 0
+	for _, m := range messages {
+		total += utf8.RuneCountInString(m.Content)
+	}
+	if total > c.maxPromptLength {
+		return &ValidationError{
+			Field:      "messages",
+			Message:    fmt.Sprintf("prompt too long: %d characters, limit is %d", total, c.maxPromptLength),
+			Suggestion: "Shorten or summarise the messages, or raise WithMaxPromptLength",
+		}
+	}
+	return nil
+}
+
+// checkRequestBodySize enforces WithMaxRequestBodySize.
+func (c *LLMClient) checkRequestBodySize(body map[string]any) error {
+	if c.maxRequestBodySize <= 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request body: %w", err)
+	}
+	if size := int64(len(encoded)); size > c.maxRequestBodySize {
+		return &ValidationError{
+			Field:      "requestBody",
+			Message:    "body too large",
+			Value:      size,
+			Suggestion: fmt.Sprintf("Keep the request under %d bytes or raise WithMaxRequestBodySize", c.maxRequestBodySize),
+		}
+	}
+	return nil
+}
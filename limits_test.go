@@ -0,0 +1,70 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newCountingChatServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	server, hits := newCountingChatServer(t)
+	const limit = 1 << 20
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMaxRequestBodySize(limit))
+	ctx := context.Background()
+
+	huge := strings.Repeat("a", limit)
+	_, err := client.Chat(ctx, "gpt-4o", huge)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "requestBody" || ve.Message != "body too large" {
+		t.Fatalf("expected a requestBody ValidationError, got %v", err)
+	}
+	_, err = client.ChatCompletionStream(ctx, "gpt-4o", []ChatMessage{{Role: "user", Content: huge}}, nil)
+	if !errors.As(err, &ve) || ve.Field != "requestBody" {
+		t.Errorf("expected streams to be limited too, got %v", err)
+	}
+	if *hits != 0 {
+		t.Errorf("expected no request to be sent, got %d", *hits)
+	}
+
+	client, _ = NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMaxRequestBodySize(limit+1))
+	if _, err := client.Chat(ctx, "gpt-4o", "What is x402?"); err != nil {
+		t.Fatalf("normal prompt rejected: %v", err)
+	}
+}
+
+func TestMaxPromptLength(t *testing.T) {
+	server, hits := newCountingChatServer(t)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMaxPromptLength(10))
+	ctx := context.Background()
+
+	// Ten characters across two messages, counted as runes, not bytes.
+	ok := []ChatMessage{{Role: "system", Content: "héllo"}, {Role: "user", Content: "wörld"}}
+	if _, err := client.ChatCompletion(ctx, "gpt-4o", ok, nil); err != nil {
+		t.Fatalf("prompt at the limit rejected: %v", err)
+	}
+
+	long := append(ok, ChatMessage{Role: "user", Content: "!"})
+	_, err := client.ChatCompletion(ctx, "gpt-4o", long, nil)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "messages" {
+		t.Fatalf("expected a messages ValidationError, got %v", err)
+	}
+	if *hits != 1 {
+		t.Errorf("expected only the first call to be sent, got %d", *hits)
+	}
+}
@@ -0,0 +1,225 @@
+package blockrun
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// endpointEWMAWeight is the weight of the newest sample in the rolling
+// latency and error-rate averages.
+const endpointEWMAWeight = 0.2
+
+// EndpointStat describes the traffic one gateway endpoint has served (see
+// WithEndpoints).
+type EndpointStat struct {
+	URL      string
+	Requests int
+	Errors   int
+	// ErrorRate is Errors / Requests over the client's lifetime.
+	ErrorRate float64
+	// RecentErrorRate and AvgLatency are rolling averages weighted towards
+	// recent requests.
+	RecentErrorRate float64
+	AvgLatency      time.Duration
+	LastError       time.Time
+}
+
+// LoadBalancePolicy picks the endpoint for each request. Select is given a
+// snapshot of every endpoint's stats, in WithEndpoints order, and returns an
+// index into it. Implementations must be safe for concurrent use.
+type LoadBalancePolicy interface {
+	Select(endpoints []EndpointStat) int
+}
+
+// RoundRobinPolicy cycles through the endpoints in order.
+type RoundRobinPolicy struct {
+	next atomic.Uint64
+}
+
+// Select returns the next endpoint in turn.
+func (p *RoundRobinPolicy) Select(endpoints []EndpointStat) int {
+	return int((p.next.Add(1) - 1) % uint64(len(endpoints)))
+}
+
+// LeastLatencyPolicy sends each request to the endpoint with the lowest
+// rolling average latency, trying every endpoint once first. An endpoint that
+// fails fast looks fast; use it as a HealthAwarePolicy's Next to avoid that.
+type LeastLatencyPolicy struct{}
+
+// Select returns the untried or fastest endpoint.
+func (LeastLatencyPolicy) Select(endpoints []EndpointStat) int {
+	best := 0
+	for i, e := range endpoints {
+		if e.Requests == 0 {
+			return i
+		}
+		if e.AvgLatency < endpoints[best].AvgLatency {
+			best = i
+		}
+	}
+	return best
+}
+
+// HealthAwarePolicy skips endpoints whose recent error rate is above
+// MaxErrorRate and that have failed within Cooldown, and lets Next choose
+// among the rest. If every endpoint is unhealthy, Next chooses among all of
+// them.
+type HealthAwarePolicy struct {
+	// MaxErrorRate defaults to 0.5.
+	MaxErrorRate float64
+	// Cooldown is how long an unhealthy endpoint is skipped after its last
+	// error before it is tried again; it defaults to 30 seconds.
+	Cooldown time.Duration
+	// Next defaults to round robin.
+	Next LoadBalancePolicy
+
+	once sync.Once
+}
+
+// Select returns a healthy endpoint chosen by Next.
+func (p *HealthAwarePolicy) Select(endpoints []EndpointStat) int {
+	p.once.Do(func() {
+		if p.MaxErrorRate <= 0 {
+			p.MaxErrorRate = 0.5
+		}
+		if p.Cooldown <= 0 {
+			p.Cooldown = 30 * time.Second
+		}
+		if p.Next == nil {
+			p.Next = &RoundRobinPolicy{}
+		}
+	})
+
+	var healthy []EndpointStat
+	var index []int
+	for i, e := range endpoints {
+		if e.RecentErrorRate > p.MaxErrorRate && time.Since(e.LastError) < p.Cooldown {
+			continue
+		}
+		healthy = append(healthy, e)
+		index = append(index, i)
+	}
+	if len(healthy) == 0 {
+		return p.Next.Select(endpoints)
+	}
+	return index[p.Next.Select(healthy)]
+}
+
+// WithEndpoints spreads LLMClient requests across several gateway
+// endpoints, chosen per request by policy (round robin if nil). The unpaid
+// probe and the paid retry of one call go to the same endpoint. The first
+// endpoint also serves as the client's API URL.
+func WithEndpoints(endpoints []string, policy LoadBalancePolicy) ClientOption {
+	return func(c *LLMClient) {
+		if len(endpoints) == 0 {
+			c.warnf("WithEndpoints called with no endpoints; ignoring")
+			return
+		}
+		if policy == nil {
+			policy = &RoundRobinPolicy{}
+		}
+		lb := &loadBalancer{policy: policy, stats: make([]EndpointStat, len(endpoints))}
+		for i, e := range endpoints {
+			lb.stats[i].URL = strings.TrimSuffix(e, "/")
+		}
+		c.balancer = lb
+		c.apiURL = lb.stats[0].URL
+	}
+}
+
+// EndpointStats returns per-endpoint traffic statistics, or nil if
+// WithEndpoints was not used.
+func (c *LLMClient) EndpointStats() []EndpointStat {
+	if c.balancer == nil {
+		return nil
+	}
+	return c.balancer.snapshot()
+}
+
+// loadBalancer holds the endpoints' stats and the policy choosing between
+// them.
+type loadBalancer struct {
+	policy LoadBalancePolicy
+
+	mu    sync.Mutex
+	stats []EndpointStat
+}
+
+// snapshot returns a copy of the stats.
+func (lb *loadBalancer) snapshot() []EndpointStat {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return append([]EndpointStat(nil), lb.stats...)
+}
+
+// pick returns the base URL for the next request.
+func (lb *loadBalancer) pick() string {
+	stats := lb.snapshot()
+	i := lb.policy.Select(stats)
+	if i < 0 || i >= len(stats) {
+		i = 0
+	}
+	return stats[i].URL
+}
+
+// endpointFor returns the index of the endpoint url belongs to, or -1.
+// lb.mu must be held.
+func (lb *loadBalancer) endpointFor(url string) int {
+	best := -1
+	for i, e := range lb.stats {
+		rest, ok := strings.CutPrefix(url, e.URL)
+		if !ok || (rest != "" && rest[0] != '/' && rest[0] != '?') {
+			continue
+		}
+		if best < 0 || len(e.URL) > len(lb.stats[best].URL) {
+			best = i
+		}
+	}
+	return best
+}
+
+// trimEndpoint strips the endpoint url belongs to from its front.
+func (lb *loadBalancer) trimEndpoint(url string) (string, bool) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if i := lb.endpointFor(url); i >= 0 {
+		return strings.TrimPrefix(url, lb.stats[i].URL), true
+	}
+	return url, false
+}
+
+// record adds a request to url's endpoint stats.
+func (lb *loadBalancer) record(url string, latency time.Duration, failed bool) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	i := lb.endpointFor(url)
+	if i < 0 {
+		return
+	}
+	e := &lb.stats[i]
+	sample := 0.0
+	if failed {
+		sample = 1
+		e.Errors++
+		e.LastError = time.Now()
+	}
+	if e.Requests == 0 {
+		e.AvgLatency, e.RecentErrorRate = latency, sample
+	} else {
+		e.AvgLatency = time.Duration(endpointEWMAWeight*float64(latency) + (1-endpointEWMAWeight)*float64(e.AvgLatency))
+		e.RecentErrorRate = endpointEWMAWeight*sample + (1-endpointEWMAWeight)*e.RecentErrorRate
+	}
+	e.Requests++
+	e.ErrorRate = float64(e.Errors) / float64(e.Requests)
+}
+
+// baseURL returns the API URL for a new request: the load balancer's pick
+// if WithEndpoints is in use, otherwise apiURL.
+func (bc *baseClient) baseURL() string {
+	if bc.balancer != nil {
+		return bc.balancer.pick()
+	}
+	return bc.apiURL
+}
@@ -0,0 +1,118 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newDelayedServer answers chat completions after delay with the given
+// status, counting requests.
+func newDelayedServer(t *testing.T, delay time.Duration, status int) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(delay)
+		w.WriteHeader(status)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestLeastLatencyPolicyConverges(t *testing.T) {
+	slow, slowHits := newDelayedServer(t, 60*time.Millisecond, http.StatusOK)
+	fast, fastHits := newDelayedServer(t, 2*time.Millisecond, http.StatusOK)
+	medium, mediumHits := newDelayedServer(t, 30*time.Millisecond, http.StatusOK)
+
+	client, _ := NewLLMClient(testPrivateKey, WithEndpoints([]string{slow.URL, fast.URL + "/", medium.URL}, LeastLatencyPolicy{}))
+
+	const calls = 15
+	for i := 0; i < calls; i++ {
+		if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *slowHits != 1 || *mediumHits != 1 || *fastHits != calls-2 {
+		t.Errorf("expected one probe each of the slow endpoints and the rest to the fastest, got slow=%d medium=%d fast=%d",
+			*slowHits, *mediumHits, *fastHits)
+	}
+
+	stats := client.EndpointStats()
+	if len(stats) != 3 || stats[1].URL != fast.URL || stats[1].Requests != calls-2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if stats[1].AvgLatency >= stats[2].AvgLatency || stats[2].AvgLatency >= stats[0].AvgLatency {
+		t.Errorf("expected latencies to order fast < medium < slow, got %+v", stats)
+	}
+}
+
+func TestRoundRobinPolicyKeepsPaymentOnOneEndpoint(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	newPaidServer := func() (*httptest.Server, *int32, *int32) {
+		var probes, paid int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+				atomic.AddInt32(&probes, 1)
+				w.Header().Set("payment-required", prHeader)
+				w.WriteHeader(http.StatusPaymentRequired)
+				return
+			}
+			atomic.AddInt32(&paid, 1)
+			w.Write([]byte(testChatResponseJSON))
+		}))
+		t.Cleanup(server.Close)
+		return server, &probes, &paid
+	}
+	a, aProbes, aPaid := newPaidServer()
+	b, bProbes, bPaid := newPaidServer()
+
+	client, _ := NewLLMClient(testPrivateKey, WithEndpoints([]string{a.URL, b.URL}, nil))
+	client.costLog = nil
+	for i := 0; i < 4; i++ {
+		if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *aProbes != 2 || *aPaid != 2 || *bProbes != 2 || *bPaid != 2 {
+		t.Errorf("expected each endpoint to serve two probe+paid pairs, got a=%d/%d b=%d/%d", *aProbes, *aPaid, *bProbes, *bPaid)
+	}
+	if got := client.GetSpending().Calls; got != 4 {
+		t.Errorf("expected 4 paid calls, got %d", got)
+	}
+}
+
+func TestHealthAwarePolicySkipsFailingEndpoint(t *testing.T) {
+	broken, brokenHits := newDelayedServer(t, 0, http.StatusInternalServerError)
+	healthy, healthyHits := newDelayedServer(t, 0, http.StatusOK)
+
+	client, _ := NewLLMClient(testPrivateKey, WithEndpoints([]string{broken.URL, healthy.URL}, &HealthAwarePolicy{}))
+
+	for i := 0; i < 6; i++ {
+		client.Chat(context.Background(), "gpt-4o", "hi")
+	}
+	if *brokenHits != 1 || *healthyHits != 5 {
+		t.Errorf("expected the failing endpoint to be skipped after one error, got broken=%d healthy=%d", *brokenHits, *healthyHits)
+	}
+	stats := client.EndpointStats()
+	if stats[0].Errors != 1 || stats[0].ErrorRate != 1 || stats[1].Errors != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestHealthAwarePolicyCooldown(t *testing.T) {
+	p := &HealthAwarePolicy{Cooldown: time.Minute}
+	stats := []EndpointStat{
+		{URL: "a", Requests: 4, RecentErrorRate: 0.9, LastError: time.Now().Add(-2 * time.Minute)},
+		{URL: "b", Requests: 4, RecentErrorRate: 0.9, LastError: time.Now()},
+	}
+	for i := 0; i < 3; i++ {
+		if got := p.Select(stats); got != 0 {
+			t.Errorf("expected the endpoint past its cooldown to be used, got %d", got)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+// Package mcp exposes BlockRun as a Model Context Protocol (MCP) tool server.
+//
+// NewMCPHandler returns an http.Handler speaking MCP's JSON-RPC 2.0 protocol
+// over HTTP POST. It offers two tools: "blockrun_chat" (chat completion via
+// an LLMClient) and "blockrun_image" (image generation via an ImageClient,
+// when one is configured). Every tool call is paid by the wrapped client's
+// wallet, exactly as if the client had been called directly.
+//
+//	client, _ := blockrun.NewLLMClient("")
+//	images, _ := blockrun.NewImageClient("")
+//	srv := mcp.NewMCPServer(":8080", mcp.NewMCPHandler(client, mcp.WithImageClient(images)))
+//	log.Fatal(srv.ListenAndServe())
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// ProtocolVersion is the MCP protocol revision this handler implements.
+const ProtocolVersion = "2025-03-26"
+
+// Tool names.
+const (
+	ToolChat  = "blockrun_chat"
+	ToolImage = "blockrun_image"
+)
+
+// ToolSet whitelists the tools a handler exposes. A nil ToolSet exposes
+// every available tool.
+type ToolSet []string
+
+// contains reports whether name is in the set (nil contains everything).
+func (ts ToolSet) contains(name string) bool {
+	if ts == nil {
+		return true
+	}
+	for _, t := range ts {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Option configures an MCP handler.
+type Option func(*handler)
+
+// WithToolSet restricts the handler to the named tools.
+func WithToolSet(tools ToolSet) Option {
+	return func(h *handler) {
+		h.tools = tools
+	}
+}
+
+// WithImageClient enables the "blockrun_image" tool.
+func WithImageClient(client *blockrun.ImageClient) Option {
+	return func(h *handler) {
+		h.images = client
+	}
+}
+
+// Tool describes one MCP tool as returned by tools/list.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// handler is the MCP JSON-RPC endpoint.
+type handler struct {
+	client *blockrun.LLMClient
+	images *blockrun.ImageClient
+	tools  ToolSet
+}
+
+// NewMCPHandler returns an http.Handler serving MCP over JSON-RPC for client.
+func NewMCPHandler(client *blockrun.LLMClient, opts ...Option) http.Handler {
+	h := &handler{client: client}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "MCP endpoint accepts POST only", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+	if err != nil {
+		writeRPC(w, rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "failed to read request"}})
+		return
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeRPC(w, rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "invalid JSON"}})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeRPC(w, rpcResponse{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &rpcError{codeInvalidRequest, "not a JSON-RPC 2.0 request"}})
+		return
+	}
+
+	// Notifications (no id) get no response body.
+	if len(req.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	result, rpcErr := h.dispatch(r.Context(), req)
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	writeRPC(w, resp)
+}
+
+// dispatch routes a request to its method implementation.
+func (h *handler) dispatch(ctx context.Context, req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "blockrun", "version": blockrun.Version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": h.listTools()}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &rpcError{codeInvalidParams, "tools/call requires a tool name"}
+		}
+		return h.callTool(ctx, params.Name, params.Arguments)
+	default:
+		return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// listTools returns the tools this handler exposes.
+func (h *handler) listTools() []Tool {
+	var tools []Tool
+	if h.client != nil && h.tools.contains(ToolChat) {
+		tools = append(tools, Tool{
+			Name:        ToolChat,
+			Description: "Chat completion through the BlockRun gateway (paid per call in USDC).",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"model": map[string]any{"type": "string", "description": "Model ID, e.g. openai/gpt-4o"},
+					"messages": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"role":    map[string]any{"type": "string", "enum": []string{"system", "user", "assistant"}},
+								"content": map[string]any{"type": "string"},
+							},
+							"required": []string{"role", "content"},
+						},
+					},
+					"max_tokens": map[string]any{"type": "integer"},
+				},
+				"required": []string{"model", "messages"},
+			},
+		})
+	}
+	if h.images != nil && h.tools.contains(ToolImage) {
+		tools = append(tools, Tool{
+			Name:        ToolImage,
+			Description: "Generate an image through the BlockRun gateway (paid per call in USDC). Returns image URLs.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"prompt": map[string]any{"type": "string"},
+					"model":  map[string]any{"type": "string"},
+					"size":   map[string]any{"type": "string", "description": "e.g. 1024x1024"},
+				},
+				"required": []string{"prompt"},
+			},
+		})
+	}
+	return tools
+}
+
+// callTool runs a tool. Tool failures are reported in-band as an isError
+// result, per MCP; only unknown tools and bad arguments are JSON-RPC errors.
+func (h *handler) callTool(ctx context.Context, name string, args json.RawMessage) (any, *rpcError) {
+	exposed := false
+	for _, t := range h.listTools() {
+		if t.Name == name {
+			exposed = true
+			break
+		}
+	}
+	if !exposed {
+		return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", name)}
+	}
+
+	switch name {
+	case ToolChat:
+		var in struct {
+			Model     string                 `json:"model"`
+			Messages  []blockrun.ChatMessage `json:"messages"`
+			MaxTokens int                    `json:"max_tokens"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		var opts *blockrun.ChatCompletionOptions
+		if in.MaxTokens > 0 {
+			opts = &blockrun.ChatCompletionOptions{MaxTokens: in.MaxTokens}
+		}
+		resp, err := h.client.ChatCompletion(ctx, in.Model, in.Messages, opts)
+		if err != nil {
+			return toolError(err), nil
+		}
+		if len(resp.Choices) == 0 {
+			return toolError(fmt.Errorf("no choices in response")), nil
+		}
+		return toolText(resp.Choices[0].Message.Content), nil
+
+	case ToolImage:
+		var in struct {
+			Prompt string `json:"prompt"`
+			Model  string `json:"model"`
+			Size   string `json:"size"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		resp, err := h.images.Generate(ctx, in.Prompt, &blockrun.ImageGenerateOptions{Model: in.Model, Size: in.Size})
+		if err != nil {
+			return toolError(err), nil
+		}
+		urls := make([]string, 0, len(resp.Data))
+		for _, d := range resp.Data {
+			urls = append(urls, d.URL)
+		}
+		return toolText(strings.Join(urls, "\n")), nil
+	}
+	return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", name)}
+}
+
+// toolText wraps text as a successful tools/call result.
+func toolText(text string) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": false,
+	}
+}
+
+// toolError wraps err as a failed tools/call result.
+func toolError(err error) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": err.Error()}},
+		"isError": true,
+	}
+}
+
+// idOrNull returns id, or JSON null if it is empty.
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// writeRPC writes a JSON-RPC response.
+func writeRPC(w http.ResponseWriter, resp rpcResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
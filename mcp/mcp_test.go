@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// newGateway mocks the BlockRun chat and image endpoints (free, no 402).
+func newGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"id":"c1","model":"` + body["model"].(string) + `","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}]}`))
+		case "/v1/images/generations":
+			w.Write([]byte(`{"created":1,"data":[{"url":"https://cdn.example.com/cat.png"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+// rpc posts a JSON-RPC request to h and decodes the response.
+func rpc(t *testing.T, h http.Handler, method string, params any) rpcResponseForTest {
+	t.Helper()
+	req := map[string]any{"jsonrpc": "2.0", "id": 1, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	raw, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(raw)))
+
+	var resp rpcResponseForTest
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s response %q: %v", method, rec.Body.String(), err)
+	}
+	return resp
+}
+
+type rpcResponseForTest struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func newTestHandler(t *testing.T, opts ...Option) http.Handler {
+	t.Helper()
+	gw := newGateway(t)
+	client, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(gw.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	images, err := blockrun.NewImageClient(testPrivateKey, blockrun.WithImageAPIURL(gw.URL))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+	return NewMCPHandler(client, append([]Option{WithImageClient(images)}, opts...)...)
+}
+
+func TestToolsList(t *testing.T) {
+	h := newTestHandler(t)
+
+	resp := rpc(t, h, "tools/list", nil)
+	if resp.Error != nil {
+		t.Fatalf("tools/list error: %+v", resp.Error)
+	}
+	var result struct {
+		Tools []Tool `json:"tools"`
+	}
+	json.Unmarshal(resp.Result, &result)
+	if len(result.Tools) != 2 || result.Tools[0].Name != ToolChat || result.Tools[1].Name != ToolImage {
+		t.Fatalf("unexpected tools: %+v", result.Tools)
+	}
+	required, _ := result.Tools[0].InputSchema["required"].([]any)
+	if len(required) != 2 || required[0] != "model" || required[1] != "messages" {
+		t.Errorf("unexpected chat schema required fields: %v", required)
+	}
+}
+
+func TestToolSetWhitelist(t *testing.T) {
+	h := newTestHandler(t, WithToolSet(ToolSet{ToolChat}))
+
+	var result struct {
+		Tools []Tool `json:"tools"`
+	}
+	json.Unmarshal(rpc(t, h, "tools/list", nil).Result, &result)
+	if len(result.Tools) != 1 || result.Tools[0].Name != ToolChat {
+		t.Fatalf("expected only %s, got %+v", ToolChat, result.Tools)
+	}
+
+	resp := rpc(t, h, "tools/call", map[string]any{"name": ToolImage, "arguments": map[string]any{"prompt": "cat"}})
+	if resp.Error == nil || resp.Error.Code != codeInvalidParams {
+		t.Errorf("expected hidden tool to be rejected, got %+v", resp)
+	}
+}
+
+func TestToolsCallRoundTrip(t *testing.T) {
+	h := newTestHandler(t)
+
+	calls := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{ToolChat, map[string]any{"model": "openai/gpt-4o", "messages": []map[string]string{{"role": "user", "content": "ping"}}}, "pong"},
+		{ToolImage, map[string]any{"prompt": "a cat"}, "https://cdn.example.com/cat.png"},
+	}
+	for _, c := range calls {
+		resp := rpc(t, h, "tools/call", map[string]any{"name": c.name, "arguments": c.args})
+		if resp.Error != nil {
+			t.Fatalf("%s: %+v", c.name, resp.Error)
+		}
+		var result struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		}
+		json.Unmarshal(resp.Result, &result)
+		if result.IsError || len(result.Content) != 1 || result.Content[0].Text != c.want {
+			t.Errorf("%s: unexpected result %s", c.name, resp.Result)
+		}
+	}
+}
+
+func TestInitializeAndUnknownMethod(t *testing.T) {
+	h := newTestHandler(t)
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(rpc(t, h, "initialize", map[string]any{}).Result, &init)
+	if init.ProtocolVersion != ProtocolVersion {
+		t.Errorf("unexpected protocol version %q", init.ProtocolVersion)
+	}
+
+	if resp := rpc(t, h, "resources/list", nil); resp.Error == nil || resp.Error.Code != codeMethodNotFound {
+		t.Errorf("expected method-not-found, got %+v", resp)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))))
+	if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Errorf("expected 202 with empty body for a notification, got %d %q", rec.Code, rec.Body.String())
+	}
+}
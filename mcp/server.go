@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultPath is the URL path MCPServer mounts the handler on.
+const DefaultPath = "/mcp"
+
+// MCPServer serves an MCP handler on a listen address, optionally over TLS.
+type MCPServer struct {
+	// Addr is the TCP listen address, e.g. ":8080".
+	Addr string
+	// Path is where the handler is mounted (default DefaultPath).
+	Path string
+	// Handler is the MCP handler, usually from NewMCPHandler.
+	Handler http.Handler
+	// TLSConfig, if set, serves HTTPS. Certificates come from TLSConfig or
+	// from CertFile/KeyFile.
+	TLSConfig *tls.Config
+	// CertFile and KeyFile serve HTTPS from PEM files.
+	CertFile, KeyFile string
+
+	mu  sync.Mutex
+	srv *http.Server
+}
+
+// NewMCPServer creates a server for handler listening on addr.
+func NewMCPServer(addr string, handler http.Handler) *MCPServer {
+	return &MCPServer{Addr: addr, Path: DefaultPath, Handler: handler}
+}
+
+// ListenAndServe serves until Shutdown is called. It serves HTTPS when
+// TLSConfig or CertFile/KeyFile are set, and plain HTTP otherwise.
+func (s *MCPServer) ListenAndServe() error {
+	path := s.Path
+	if path == "" {
+		path = DefaultPath
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, s.Handler)
+
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           mux,
+		TLSConfig:         s.TLSConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.mu.Lock()
+	s.srv = srv
+	s.mu.Unlock()
+
+	var err error
+	if s.TLSConfig != nil || s.CertFile != "" {
+		err = srv.ListenAndServeTLS(s.CertFile, s.KeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server.
+func (s *MCPServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.srv
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
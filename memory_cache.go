@@ -0,0 +1,134 @@
+package blockrun
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InMemoryCache is a ChatCache that serves exact repeats of a request from
+// memory until the entry's TTL passes. A repeat must match the whole request
+// body, so the same messages with different options miss. The TTL can be set per model, so
+// cheap models can be cached longer than expensive ones. Time comes from
+// the package Clock. It is safe for concurrent use.
+type InMemoryCache struct {
+	// DefaultTTL applies to models without a PerModelTTL entry.
+	DefaultTTL time.Duration
+	// PerModelTTL overrides DefaultTTL for a model, keyed by model ID
+	// ("openai/gpt-4o") or by the name after the provider ("gpt-4o"). A
+	// non-positive TTL disables caching for that model.
+	PerModelTTL map[string]time.Duration
+
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	stats   CacheStat
+}
+
+var _ ChatCache = (*InMemoryCache)(nil)
+
+// memoryCacheEntry is a cached response and when it expires.
+type memoryCacheEntry struct {
+	model   string
+	resp    *ChatResponse
+	expires time.Time
+}
+
+// CacheStat counts InMemoryCache activity. Evictions are entries dropped
+// because their TTL passed; EntriesByModel counts live entries.
+type CacheStat struct {
+	Hits           int
+	Misses         int
+	Evictions      int
+	EntriesByModel map[string]int
+}
+
+// NewInMemoryCache returns a cache that keeps every response for ttl.
+func NewInMemoryCache(ttl time.Duration) *InMemoryCache {
+	return NewInMemoryCacheWithModelTTLs(ttl, nil)
+}
+
+// NewInMemoryCacheWithModelTTLs returns a cache that keeps responses for
+// defaultTTL, or for modelTTLs[model] when set.
+func NewInMemoryCacheWithModelTTLs(defaultTTL time.Duration, modelTTLs map[string]time.Duration) *InMemoryCache {
+	return &InMemoryCache{
+		DefaultTTL:  defaultTTL,
+		PerModelTTL: modelTTLs,
+		entries:     make(map[string]memoryCacheEntry),
+	}
+}
+
+// ttl returns the TTL for model.
+func (c *InMemoryCache) ttl(model string) time.Duration {
+	if ttl, ok := c.PerModelTTL[model]; ok {
+		return ttl
+	}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		if ttl, ok := c.PerModelTTL[model[i+1:]]; ok {
+			return ttl
+		}
+	}
+	return c.DefaultTTL
+}
+
+// memoryCacheKey keys a request by model and request body, so each model's
+// entries are stored and expired separately.
+func memoryCacheKey(model string, body []byte) string {
+	h := sha256.Sum256(body)
+	return model + ":" + hex.EncodeToString(h[:])
+}
+
+// Get returns a copy of the unexpired response cached for model and body.
+func (c *InMemoryCache) Get(ctx context.Context, model string, messages []ChatMessage, body []byte) (*ChatResponse, bool) {
+	key := memoryCacheKey(model, body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && !currentClock().Now().Before(entry.expires) {
+		delete(c.entries, key)
+		c.stats.Evictions++
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	return entry.resp.Clone(), true
+}
+
+// Put caches a copy of resp for model and body, unless model's TTL is non-positive.
+func (c *InMemoryCache) Put(ctx context.Context, model string, messages []ChatMessage, body []byte, resp *ChatResponse) {
+	ttl := c.ttl(model)
+	if ttl <= 0 {
+		return
+	}
+	key := memoryCacheKey(model, body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]memoryCacheEntry)
+	}
+	c.entries[key] = memoryCacheEntry{model: model, resp: resp.Clone(), expires: currentClock().Now().Add(ttl)}
+}
+
+// CacheStats returns the cache's counters after evicting expired entries.
+func (c *InMemoryCache) CacheStats() CacheStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := currentClock().Now()
+	stats := c.stats
+	stats.EntriesByModel = make(map[string]int)
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			c.stats.Evictions++
+			stats.Evictions++
+			continue
+		}
+		stats.EntriesByModel[entry.model]++
+	}
+	return stats
+}
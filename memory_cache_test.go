@@ -0,0 +1,121 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInMemoryCachePerModelTTL(t *testing.T) {
+	mock := NewMockClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(mock)
+	defer SetClock(nil)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	cache := NewInMemoryCacheWithModelTTLs(time.Hour, map[string]time.Duration{
+		"gpt-4o":      time.Minute,
+		"deepseek-r1": 24 * time.Hour,
+	})
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithChatCache(cache))
+	ctx := context.Background()
+	for _, model := range []string{"openai/gpt-4o", "deepseek/deepseek-r1", "openai/gpt-4o", "deepseek/deepseek-r1"} {
+		if _, err := client.Chat(ctx, model, "hi"); err != nil {
+			t.Fatalf("Chat(%s) failed: %v", model, err)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 gateway calls before expiry, got %d", got)
+	}
+
+	mock.Advance(2 * time.Minute)
+	if _, err := client.Chat(ctx, "deepseek/deepseek-r1", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected the deepseek entry to still be cached, got %d calls", got)
+	}
+	stats := cache.CacheStats()
+	if stats.Evictions != 1 || stats.EntriesByModel["openai/gpt-4o"] != 0 || stats.EntriesByModel["deepseek/deepseek-r1"] != 1 {
+		t.Errorf("expected the gpt-4o entry evicted, got %+v", stats)
+	}
+	if _, err := client.Chat(ctx, "openai/gpt-4o", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected the expired gpt-4o entry to be refetched, got %d calls", got)
+	}
+
+	stats = cache.CacheStats()
+	if stats.Hits != 3 || stats.Misses != 3 {
+		t.Errorf("expected 3 hits and 3 misses, got %+v", stats)
+	}
+}
+
+func TestInMemoryCacheDisabledModel(t *testing.T) {
+	cache := NewInMemoryCacheWithModelTTLs(time.Hour, map[string]time.Duration{"gpt-4o": 0})
+	ctx := context.Background()
+	msgs := []ChatMessage{{Role: "user", Content: "hi"}}
+	body := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+	cache.Put(ctx, "gpt-4o", msgs, body, &ChatResponse{ID: "a"})
+	if _, ok := cache.Get(ctx, "gpt-4o", msgs, body); ok {
+		t.Error("expected a zero TTL to disable caching")
+	}
+	cache.Put(ctx, "claude", msgs, body, &ChatResponse{ID: "b"})
+	if resp, ok := cache.Get(ctx, "claude", msgs, body); !ok || resp.ID != "b" {
+		t.Error("expected the default TTL to cache other models")
+	}
+}
+
+func TestInMemoryCacheReturnsCopies(t *testing.T) {
+	cache := NewInMemoryCache(time.Hour)
+	ctx := context.Background()
+	msgs := []ChatMessage{{Role: "user", Content: "hi"}}
+	body := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+	stored := &ChatResponse{Choices: []Choice{{Message: ChatMessage{Role: "assistant", Content: "hello"}}}}
+	cache.Put(ctx, "gpt-4o", msgs, body, stored)
+	stored.Choices[0].Message.Content = "edited after Put"
+
+	for i := 0; i < 2; i++ {
+		resp, ok := cache.Get(ctx, "gpt-4o", msgs, body)
+		if !ok || resp.Choices[0].Message.Content != "hello" {
+			t.Fatalf("get %d: expected the original response, got %+v", i, resp)
+		}
+		resp.Choices[0].Message.Content = "edited after Get"
+	}
+}
+
+func TestInMemoryCacheKeysOnRequestBody(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithChatCache(NewInMemoryCache(time.Hour)))
+	ctx := context.Background()
+	msgs := []ChatMessage{{Role: "user", Content: "hi"}}
+	for i, opts := range []*ChatCompletionOptions{
+		nil,
+		{Temperature: 0.2},
+		{Tools: []Tool{{Type: "function", Function: ToolFunction{Name: "weather"}}}},
+		{ProviderOptions: map[string]any{"seed": 7}},
+		{Temperature: 0.2},
+	} {
+		if _, err := client.ChatCompletion(ctx, "gpt-4o", msgs, opts); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("expected the same messages with different options to miss, got %d gateway calls", got)
+	}
+}
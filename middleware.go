@@ -0,0 +1,39 @@
+package blockrun
+
+import "context"
+
+// Event is something that happened during a client call, delivered to every
+// installed Middleware. Switch on the concrete type (e.g. ThrottleEvent) to
+// handle the events you care about.
+type Event interface {
+	// EventName returns a short identifier such as "throttle".
+	EventName() string
+}
+
+// Middleware observes client events — for metrics, logging or tracing.
+// OnEvent is called synchronously on the goroutine making the call, so it
+// should return quickly; it must be safe for concurrent use.
+type Middleware interface {
+	OnEvent(ctx context.Context, ev Event)
+}
+
+// MiddlewareFunc adapts a plain function to the Middleware interface.
+type MiddlewareFunc func(ctx context.Context, ev Event)
+
+// OnEvent calls f(ctx, ev).
+func (f MiddlewareFunc) OnEvent(ctx context.Context, ev Event) { f(ctx, ev) }
+
+// WithMiddleware appends middleware to the client. Middleware runs in the
+// order it was added.
+func WithMiddleware(mw ...Middleware) ClientOption {
+	return func(c *LLMClient) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// emit delivers ev to every installed middleware.
+func (bc *baseClient) emit(ctx context.Context, ev Event) {
+	for _, mw := range bc.middleware {
+		mw.OnEvent(ctx, ev)
+	}
+}
@@ -0,0 +1,84 @@
+package blockrun
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultModelCacheTTL is how long ListModels and ListImageModels reuse a
+// model catalogue before fetching it again.
+const DefaultModelCacheTTL = 5 * time.Minute
+
+// modelCache holds the last catalogue response for ttl.
+type modelCache[T any] struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	models  []T
+	fetched time.Time
+}
+
+// WithModelCacheTTL sets how long ListModels reuses the model catalogue
+// (DefaultModelCacheTTL by default). A non-positive d disables the cache.
+func WithModelCacheTTL(d time.Duration) ClientOption {
+	return func(c *LLMClient) {
+		c.models = &modelCache[Model]{ttl: d}
+	}
+}
+
+// InvalidateModelCache makes the next ListModels call fetch the catalogue.
+func (c *LLMClient) InvalidateModelCache() {
+	c.models.invalidate()
+}
+
+// listModelsWithCache returns the cached catalogue, fetching it when it is
+// missing or older than the TTL. Callers get their own copy of the slice.
+func (c *LLMClient) listModelsWithCache(ctx context.Context) ([]Model, error) {
+	return c.models.get(ctx, c.fetchModels)
+}
+
+// get returns a copy of the cached models, calling fetch when they are
+// missing or stale. A nil cache or non-positive TTL always fetches.
+func (mc *modelCache[T]) get(ctx context.Context, fetch func(context.Context) ([]T, error)) ([]T, error) {
+	if mc == nil || mc.ttl <= 0 {
+		return fetch(ctx)
+	}
+
+	mc.mu.RLock()
+	if mc.models != nil && time.Since(mc.fetched) < mc.ttl {
+		models := append([]T(nil), mc.models...)
+		mc.mu.RUnlock()
+		return models, nil
+	}
+	mc.mu.RUnlock()
+
+	models, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mc.mu.Lock()
+	mc.models, mc.fetched = models, time.Now()
+	mc.mu.Unlock()
+	return append([]T(nil), models...), nil
+}
+
+// peek returns the cached models, stale or not, without fetching. It
+// returns nil if nothing has been fetched.
+func (mc *modelCache[T]) peek() []T {
+	if mc == nil {
+		return nil
+	}
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return append([]T(nil), mc.models...)
+}
+
+// invalidate drops the cached models.
+func (mc *modelCache[T]) invalidate() {
+	if mc == nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.models, mc.fetched = nil, time.Time{}
+}
@@ -0,0 +1,90 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingModelsServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		fmt.Fprint(w, `{"data":[{"id":"openai/gpt-4o","owned_by":"openai"}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestModelCache(t *testing.T) {
+	server, hits := newCountingModelsServer(t)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		models, err := client.ListModels(ctx)
+		if err != nil || len(models) != 1 {
+			t.Fatalf("ListModels: %v, %v", models, err)
+		}
+		models[0].ID = "mutated" // must not leak into the cache
+	}
+	if *hits != 1 {
+		t.Errorf("expected 1 request within the TTL, got %d", *hits)
+	}
+
+	client.InvalidateModelCache()
+	models, _ := client.ListModels(ctx)
+	if *hits != 2 {
+		t.Errorf("expected a refetch after invalidation, got %d requests", *hits)
+	}
+	if models[0].ID != "openai/gpt-4o" {
+		t.Errorf("cached models were modified by a caller: %+v", models)
+	}
+}
+
+func TestModelCacheTTL(t *testing.T) {
+	server, hits := newCountingModelsServer(t)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithModelCacheTTL(20*time.Millisecond))
+	ctx := context.Background()
+
+	client.ListModels(ctx)
+	client.ListModels(ctx)
+	time.Sleep(30 * time.Millisecond)
+	client.ListModels(ctx)
+	if *hits != 2 {
+		t.Errorf("expected a refetch once the TTL expired, got %d requests", *hits)
+	}
+
+	uncached, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithModelCacheTTL(0))
+	uncached.ListModels(ctx)
+	uncached.ListModels(ctx)
+	if *hits != 4 {
+		t.Errorf("expected no caching with a zero TTL, got %d requests", *hits)
+	}
+}
+
+func TestModelCacheConcurrent(t *testing.T) {
+	server, _ := newCountingModelsServer(t)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%5 == 0 {
+				client.InvalidateModelCache()
+			}
+			if _, err := client.ListModels(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,53 @@
+package blockrun
+
+// Context limit units for Model.ContextLimitUnit.
+const (
+	ContextUnitTokens     = "tokens"
+	ContextUnitCharacters = "characters"
+)
+
+// ContextWarningFraction is how full a model's context window the prompt of
+// a ChatCompletion may get before the client logs a warning.
+const ContextWarningFraction = 0.9
+
+// NominalContextTokens returns m's context window in tokens. A limit given
+// in characters is converted at four characters a token. Models reporting
+// no limit get a default for their provider, or 8192 tokens.
+func NominalContextTokens(m *Model) int {
+	if m.ContextLimit > 0 {
+		if m.ContextLimitUnit == ContextUnitCharacters {
+			return m.ContextLimit / 4
+		}
+		return m.ContextLimit
+	}
+	return defaultContextTokens(m.ID)
+}
+
+// ContextFraction returns how full m's context window is with usedTokens
+// tokens in it, 1 meaning exactly full. It can exceed 1.
+func ContextFraction(usedTokens int, m *Model) float64 {
+	limit := NominalContextTokens(m)
+	if limit <= 0 {
+		return 0
+	}
+	return float64(usedTokens) / float64(limit)
+}
+
+// warnContextUsage logs a warning if messages are estimated to fill more
+// than ContextWarningFraction of model's context window. The limit comes
+// from the model catalogue if ListModels has already fetched it, and from
+// the embedded defaults otherwise; no request is made.
+func (c *LLMClient) warnContextUsage(model string, messages []ChatMessage) {
+	m := &Model{ID: model}
+	for _, cached := range c.models.peek() {
+		if cached.ID == model {
+			m = &cached
+			break
+		}
+	}
+	used := estimateTokens(messages)
+	if fraction := ContextFraction(used, m); fraction > ContextWarningFraction {
+		c.warnf("prompt of about %d tokens fills %.0f%% of %s's %d-token context window",
+			used, fraction*100, model, NominalContextTokens(m))
+	}
+}
@@ -0,0 +1,78 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNominalContextTokens(t *testing.T) {
+	tests := []struct {
+		model Model
+		want  int
+	}{
+		{Model{ID: "openai/gpt-4o", ContextLimit: 64000, ContextLimitUnit: ContextUnitTokens}, 64000},
+		{Model{ID: "x/y", ContextLimit: 40000, ContextLimitUnit: ContextUnitCharacters}, 10000},
+		{Model{ID: "openai/gpt-4.1"}, 1047576},           // exact default
+		{Model{ID: "anthropic/claude-sonnet-4"}, 200000}, // prefix default
+		{Model{ID: "openai/gpt-5.2"}, 400000},            // longest prefix wins
+		{Model{ID: "unknown-model"}, 8192},               // fallback
+	}
+	for _, tt := range tests {
+		if got := NominalContextTokens(&tt.model); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.model.ID, tt.want, got)
+		}
+	}
+
+	if got := ContextFraction(4096, &Model{ID: "unknown-model"}); got != 0.5 {
+		t.Errorf("expected 0.5, got %v", got)
+	}
+}
+
+func TestModelContextLimitUnit(t *testing.T) {
+	var m Model
+	json.Unmarshal([]byte(`{"id":"a/b","context_window":1000}`), &m)
+	if m.ContextLimitUnit != ContextUnitTokens {
+		t.Errorf("expected tokens by default, got %q", m.ContextLimitUnit)
+	}
+	m = Model{}
+	json.Unmarshal([]byte(`{"id":"a/b"}`), &m)
+	if m.ContextLimitUnit != "" {
+		t.Errorf("expected no unit without a limit, got %q", m.ContextLimitUnit)
+	}
+}
+
+func TestChatCompletionWarnsNearContextLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(prev)
+
+	// unknown-model falls back to 8192 tokens; about 7000 is under 90%.
+	small := []ChatMessage{{Role: "user", Content: strings.Repeat("a", 28000)}}
+	if _, err := client.ChatCompletion(context.Background(), "unknown-model", small, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no warning, got %q", logs.String())
+	}
+
+	large := []ChatMessage{{Role: "user", Content: strings.Repeat("a", 30000)}}
+	if _, err := client.ChatCompletion(context.Background(), "unknown-model", large, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if !strings.Contains(logs.String(), "context window") {
+		t.Errorf("expected a context warning, got %q", logs.String())
+	}
+}
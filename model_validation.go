@@ -0,0 +1,105 @@
+package blockrun
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Model validation modes; see WithEagerModelValidation.
+const (
+	modelValidationNone = iota
+	modelValidationEager
+	modelValidationStrict
+)
+
+// modelValidator checks chat models against the gateway's catalogue.
+type modelValidator struct {
+	mode int
+
+	mu  sync.Mutex
+	ids map[string]bool // eager mode: the catalogue loaded by the first call
+}
+
+// WithEagerModelValidation has the first ChatCompletion (or
+// ChatCompletionStream) call load the model catalogue with ListModels, and
+// every call reject a model missing from it with a ValidationError before
+// any payment is made. The catalogue is loaded once; if loading fails, the
+// call goes ahead unvalidated and the next call tries again.
+func WithEagerModelValidation() ClientOption {
+	return func(c *LLMClient) {
+		c.modelValidation = &modelValidator{mode: modelValidationEager}
+	}
+}
+
+// WithStrictModelValidation is WithEagerModelValidation checking against
+// ListModels on every call, so the catalogue is refreshed whenever the
+// model cache (see WithModelCacheTTL) expires. A failure to load the
+// catalogue fails the call.
+func WithStrictModelValidation() ClientOption {
+	return func(c *LLMClient) {
+		c.modelValidation = &modelValidator{mode: modelValidationStrict}
+	}
+}
+
+// WithNoModelValidation sends any model name to the gateway unchecked. This
+// is the default.
+func WithNoModelValidation() ClientOption {
+	return func(c *LLMClient) {
+		c.modelValidation = nil
+	}
+}
+
+// validateModel returns a ValidationError if model validation is on and
+// model is not in the catalogue. A model matches a catalogue ID exactly or
+// by the name after its provider ("gpt-4o" for "openai/gpt-4o").
+func (c *LLMClient) validateModel(ctx context.Context, model string) error {
+	v := c.modelValidation
+	if v == nil || v.mode == modelValidationNone {
+		return nil
+	}
+
+	var ids map[string]bool
+	if v.mode == modelValidationEager {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if v.ids == nil {
+			models, err := c.ListModels(ctx)
+			if err != nil {
+				c.warnf("model validation skipped: %v", err)
+				return nil
+			}
+			v.ids = modelIDSet(models)
+		}
+		ids = v.ids
+	} else {
+		models, err := c.ListModels(ctx)
+		if err != nil {
+			return err
+		}
+		ids = modelIDSet(models)
+	}
+
+	if !ids[model] {
+		return &ValidationError{
+			Field:      "model",
+			Message:    "model not found",
+			Suggestion: "Run ListModels() to see available options",
+			Value:      model,
+		}
+	}
+	return nil
+}
+
+// modelIDSet returns the IDs of models, each also under the name after its
+// provider.
+func modelIDSet(models []Model) map[string]bool {
+	ids := make(map[string]bool, 2*len(models))
+	for _, m := range models {
+		ids[m.ID] = true
+		if i := strings.LastIndex(m.ID, "/"); i >= 0 {
+			ids[m.ID[i+1:]] = true
+		}
+	}
+	return ids
+}
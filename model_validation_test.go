@@ -0,0 +1,78 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newCatalogueServer serves a two-model catalogue and free chat responses,
+// counting catalogue fetches and chat calls.
+func newCatalogueServer(t *testing.T, listCalls, chatCalls *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			listCalls.Add(1)
+			w.Write([]byte(`{"data":[{"id":"openai/gpt-4o"},{"id":"deepseek/deepseek-chat"}]}`))
+			return
+		}
+		chatCalls.Add(1)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEagerModelValidation(t *testing.T) {
+	var listCalls, chatCalls atomic.Int32
+	server := newCatalogueServer(t, &listCalls, &chatCalls)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithEagerModelValidation())
+	ctx := context.Background()
+
+	if _, err := client.Chat(ctx, "openai/gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat with a listed model failed: %v", err)
+	}
+	if _, err := client.Chat(ctx, "deepseek-chat", "hi"); err != nil {
+		t.Fatalf("Chat with a listed model's short name failed: %v", err)
+	}
+	_, err := client.Chat(ctx, "openai/gpt-9", "hi")
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Field != "model" || vErr.Message != "model not found" ||
+		vErr.Suggestion != "Run ListModels() to see available options" {
+		t.Fatalf("expected a model not found ValidationError, got %v", err)
+	}
+	if got := chatCalls.Load(); got != 2 {
+		t.Errorf("expected the unknown model to be rejected before any request, got %d chat calls", got)
+	}
+
+	client.InvalidateModelCache()
+	client.Chat(ctx, "openai/gpt-4o", "hi")
+	if got := listCalls.Load(); got != 1 {
+		t.Errorf("expected the catalogue loaded once, got %d fetches", got)
+	}
+}
+
+func TestStrictModelValidation(t *testing.T) {
+	var listCalls, chatCalls atomic.Int32
+	server := newCatalogueServer(t, &listCalls, &chatCalls)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithStrictModelValidation())
+	ctx := context.Background()
+
+	if _, err := client.Chat(ctx, "openai/gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	client.InvalidateModelCache()
+	if _, err := client.Chat(ctx, "unknown", "hi"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if got := listCalls.Load(); got != 2 {
+		t.Errorf("expected the catalogue re-checked through the cache, got %d fetches", got)
+	}
+
+	client, _ = NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithStrictModelValidation(), WithNoModelValidation())
+	if _, err := client.Chat(ctx, "unknown", "hi"); err != nil {
+		t.Errorf("expected WithNoModelValidation to send any model, got %v", err)
+	}
+}
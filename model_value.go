@@ -0,0 +1,57 @@
+package blockrun
+
+import "sort"
+
+// EstimatedCallsPerDollar returns how many calls with the given average token
+// counts one USD buys at m's pricing (see EstimateCost). It is 0 if m has no
+// price.
+func (m *Model) EstimatedCallsPerDollar(avgInputTokens, avgOutputTokens int) float64 {
+	return perDollar(modelCallCost(m, avgInputTokens, avgOutputTokens))
+}
+
+// EstimatedCallsPerDollar returns how many calls with the given average token
+// counts one USD buys at m's per-million-token prices. It is 0 for image
+// models (see EstimatedImagesPerDollar) and unpriced models.
+func (m AllModel) EstimatedCallsPerDollar(avgInputTokens, avgOutputTokens int) float64 {
+	if m.Type == "image" {
+		return 0
+	}
+	return perDollar((float64(avgInputTokens)*m.InputPrice + float64(avgOutputTokens)*m.OutputPrice) / 1_000_000)
+}
+
+// EstimatedImagesPerDollar returns how many images one USD buys at m's
+// PricePerImage, or 0 if it has none.
+func (m AllModel) EstimatedImagesPerDollar() float64 {
+	return perDollar(m.PricePerImage)
+}
+
+// EstimatedImagesPerDollar returns how many images one USD buys at m's
+// PricePerImage, or 0 if it has none.
+func (m ImageModel) EstimatedImagesPerDollar() float64 {
+	return perDollar(m.PricePerImage)
+}
+
+// RankByValue returns a copy of models sorted by value, most calls per dollar
+// first: EstimatedCallsPerDollar for LLMs, EstimatedImagesPerDollar for image
+// models. Ties keep their original order.
+func RankByValue(models []AllModel, avgInputTokens, avgOutputTokens int) []AllModel {
+	value := func(m AllModel) float64 {
+		if m.Type == "image" {
+			return m.EstimatedImagesPerDollar()
+		}
+		return m.EstimatedCallsPerDollar(avgInputTokens, avgOutputTokens)
+	}
+	ranked := append([]AllModel(nil), models...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return value(ranked[i]) > value(ranked[j])
+	})
+	return ranked
+}
+
+// perDollar is 1/cost, or 0 when cost is not positive.
+func perDollar(cost float64) float64 {
+	if cost <= 0 {
+		return 0
+	}
+	return 1 / cost
+}
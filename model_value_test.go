@@ -0,0 +1,56 @@
+package blockrun
+
+import (
+	"math"
+	"testing"
+)
+
+func roundTo4(f float64) float64 {
+	return math.Round(f*1e4) / 1e4
+}
+
+func TestEstimatedCallsPerDollar(t *testing.T) {
+	// 500 in, 200 out at $0.15/$0.60 per 1M: $0.000195 per call.
+	all := AllModel{ID: "openai/gpt-4o-mini", Type: "llm", InputPrice: 0.15, OutputPrice: 0.60}
+	if got := roundTo4(all.EstimatedCallsPerDollar(500, 200)); got != 5128.2051 {
+		t.Errorf("AllModel: expected 5128.2051, got %v", got)
+	}
+	model := Model{ID: "openai/gpt-4o-mini", Pricing: ModelPricing{Input: 0.15, Output: 0.60}}
+	if got := roundTo4(model.EstimatedCallsPerDollar(500, 200)); got != 5128.2051 {
+		t.Errorf("Model: expected 5128.2051, got %v", got)
+	}
+	flat := Model{ID: "flat/model", Pricing: ModelPricing{Flat: 0.003}}
+	if got := roundTo4(flat.EstimatedCallsPerDollar(500, 200)); got != 333.3333 {
+		t.Errorf("flat: expected 333.3333, got %v", got)
+	}
+	if got := (AllModel{ID: "free"}).EstimatedCallsPerDollar(500, 200); got != 0 {
+		t.Errorf("unpriced: expected 0, got %v", got)
+	}
+
+	image := AllModel{ID: "openai/dall-e-3", Type: "image", PricePerImage: 0.04}
+	if got := roundTo4(image.EstimatedImagesPerDollar()); got != 25 {
+		t.Errorf("images: expected 25, got %v", got)
+	}
+	if got := image.EstimatedCallsPerDollar(500, 200); got != 0 {
+		t.Errorf("image calls: expected 0, got %v", got)
+	}
+}
+
+func TestRankByValue(t *testing.T) {
+	models := []AllModel{
+		{ID: "openai/gpt-4o", Type: "llm", InputPrice: 2.5, OutputPrice: 10},
+		{ID: "openai/dall-e-3", Type: "image", PricePerImage: 0.04},
+		{ID: "openai/gpt-4o-mini", Type: "llm", InputPrice: 0.15, OutputPrice: 0.60},
+		{ID: "unpriced", Type: "llm"},
+	}
+	ranked := RankByValue(models, 500, 200)
+	want := []string{"openai/gpt-4o-mini", "openai/gpt-4o", "openai/dall-e-3", "unpriced"}
+	for i, id := range want {
+		if ranked[i].ID != id {
+			t.Fatalf("rank %d: expected %s, got %s", i, id, ranked[i].ID)
+		}
+	}
+	if models[0].ID != "openai/gpt-4o" {
+		t.Error("RankByValue should not reorder its input")
+	}
+}
@@ -0,0 +1,48 @@
+package blockrun
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// modelDefaultsJSON holds per-provider context window defaults, in tokens,
+// for models the catalogue reports no limit for.
+//
+//go:embed model_defaults.json
+var modelDefaultsJSON []byte
+
+// modelDefaults is the parsed form of model_defaults.json.
+type modelDefaults struct {
+	// Fallback is used for models matching no other entry.
+	Fallback int `json:"fallback"`
+	// Exact is keyed by model ID.
+	Exact map[string]int `json:"exact"`
+	// Prefix is keyed by model ID prefix; the longest match wins.
+	Prefix map[string]int `json:"prefix"`
+}
+
+var (
+	loadModelDefaults sync.Once
+	contextDefaults   modelDefaults
+)
+
+// defaultContextTokens returns the embedded default context window for id.
+func defaultContextTokens(id string) int {
+	loadModelDefaults.Do(func() {
+		if err := json.Unmarshal(modelDefaultsJSON, &contextDefaults); err != nil {
+			panic("blockrun: invalid model_defaults.json: " + err.Error())
+		}
+	})
+	if n, ok := contextDefaults.Exact[id]; ok {
+		return n
+	}
+	best, n := "", contextDefaults.Fallback
+	for prefix, limit := range contextDefaults.Prefix {
+		if strings.HasPrefix(id, prefix) && len(prefix) > len(best) {
+			best, n = prefix, limit
+		}
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ModelStatus is a Model with its current availability.
+type ModelStatus struct {
+	Model
+	// Available is false while the gateway cannot serve the model.
+	Available bool `json:"available"`
+	// Degraded marks an available model with elevated errors or latency.
+	Degraded bool `json:"degraded,omitempty"`
+	// Latency is the gateway's recent latency for the model; 0 if unknown.
+	Latency time.Duration `json:"-"`
+}
+
+// UnmarshalJSON reads a model entry and its inline availability. Entries
+// without availability information are treated as available.
+func (s *ModelStatus) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &s.Model); err != nil {
+		return err
+	}
+	var f modelStatusFields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	s.Available, s.Degraded, s.Latency = true, false, 0
+	f.apply(s)
+	return nil
+}
+
+// modelStatusFields is the availability part of a /v1/models or
+// /v1/models/status entry. Status is "available", "degraded" or
+// "unavailable"; the booleans, when present, take precedence.
+type modelStatusFields struct {
+	ID        string  `json:"id"`
+	Status    string  `json:"status,omitempty"`
+	Available *bool   `json:"available,omitempty"`
+	Degraded  *bool   `json:"degraded,omitempty"`
+	LatencyMS float64 `json:"latency_ms,omitempty"`
+}
+
+// known reports whether the entry carries any availability information.
+func (f modelStatusFields) known() bool {
+	return f.Status != "" || f.Available != nil || f.Degraded != nil
+}
+
+// apply copies the availability in f onto s.
+func (f modelStatusFields) apply(s *ModelStatus) {
+	switch f.Status {
+	case "unavailable":
+		s.Available, s.Degraded = false, false
+	case "degraded":
+		s.Available, s.Degraded = true, true
+	case "available":
+		s.Available, s.Degraded = true, false
+	}
+	if f.Available != nil {
+		s.Available = *f.Available
+	}
+	if f.Degraded != nil {
+		s.Degraded = *f.Degraded
+	}
+	if f.LatencyMS > 0 {
+		s.Latency = time.Duration(f.LatencyMS * float64(time.Millisecond))
+	}
+}
+
+// ListModelsWithStatus returns the model catalogue with each model's
+// availability, read from /v1/models/status. If the gateway has no status
+// endpoint, availability reported inline by /v1/models is used; models with
+// no availability information are assumed available.
+func (c *LLMClient) ListModelsWithStatus(ctx context.Context) ([]ModelStatus, error) {
+	respBytes, err := c.doGet(ctx, "/v1/models")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	var catalogue struct {
+		Data []ModelStatus `json:"data"`
+	}
+	if err := json.Unmarshal(respBytes, &catalogue); err != nil {
+		return nil, fmt.Errorf("failed to decode models response: %w", err)
+	}
+
+	statuses := make(map[string]modelStatusFields)
+	statusBytes, err := c.doGet(ctx, "/v1/models/status")
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		var status struct {
+			Data []modelStatusFields `json:"data"`
+		}
+		if err := json.Unmarshal(statusBytes, &status); err != nil {
+			return nil, fmt.Errorf("failed to decode model status response: %w", err)
+		}
+		for _, s := range status.Data {
+			statuses[s.ID] = s
+		}
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		// No status endpoint; fall back to inline availability.
+	default:
+		return nil, fmt.Errorf("failed to get model status: %w", err)
+	}
+
+	models := catalogue.Data
+	for i := range models {
+		if s, ok := statuses[models[i].ID]; ok && s.known() {
+			s.apply(&models[i])
+		}
+	}
+	return models, nil
+}
+
+// FilterAvailable returns the models that are currently available,
+// including degraded ones.
+func FilterAvailable(models []ModelStatus) []ModelStatus {
+	var out []ModelStatus
+	for _, m := range models {
+		if m.Available {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// AvailableModels returns the models the gateway can currently serve.
+func (c *LLMClient) AvailableModels(ctx context.Context) ([]Model, error) {
+	statuses, err := c.ListModelsWithStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	available := FilterAvailable(statuses)
+	models := make([]Model, len(available))
+	for i, m := range available {
+		models[i] = m.Model
+	}
+	return models, nil
+}
@@ -0,0 +1,84 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const statusTestModels = `{"data":[
+	{"id":"openai/gpt-4o","owned_by":"openai","pricing":{"input":2.5,"output":10}},
+	{"id":"anthropic/claude-sonnet-4","owned_by":"anthropic"},
+	{"id":"nvidia/llama-4-maverick","owned_by":"nvidia","status":"unavailable"}
+]}`
+
+func newModelStatusServer(t *testing.T, status string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			fmt.Fprint(w, statusTestModels)
+		case "/v1/models/status":
+			if status == "" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, status)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListModelsWithStatus(t *testing.T) {
+	server := newModelStatusServer(t, `{"data":[
+		{"id":"openai/gpt-4o","available":true,"degraded":true,"latency_ms":850},
+		{"id":"anthropic/claude-sonnet-4","available":false},
+		{"id":"nvidia/llama-4-maverick","status":"available"}
+	]}`)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	models, err := client.ListModelsWithStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 3 {
+		t.Fatalf("expected 3 models, got %d", len(models))
+	}
+	gpt := models[0]
+	if !gpt.Available || !gpt.Degraded || gpt.Latency != 850*time.Millisecond || gpt.Pricing.Input != 2.5 {
+		t.Errorf("unexpected status %+v", gpt)
+	}
+	if models[1].Available {
+		t.Errorf("expected %s to be unavailable", models[1].ID)
+	}
+	if !models[2].Available {
+		t.Errorf("expected the status endpoint to override inline status for %s", models[2].ID)
+	}
+
+	available := FilterAvailable(models)
+	if len(available) != 2 || available[0].ID != "openai/gpt-4o" || available[1].ID != "nvidia/llama-4-maverick" {
+		t.Errorf("unexpected filtered models %+v", available)
+	}
+}
+
+func TestAvailableModelsInlineStatus(t *testing.T) {
+	server := newModelStatusServer(t, "")
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	models, err := client.AvailableModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("expected 2 available models, got %+v", models)
+	}
+	for _, m := range models {
+		if m.ID == "nvidia/llama-4-maverick" {
+			t.Errorf("expected the inline unavailable model to be filtered out")
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultBalanceMonitorInterval is the polling interval of the
+// BalanceMonitor created by WithRPCURL.
+const DefaultBalanceMonitorInterval = time.Minute
+
+// errBalanceUnknown is returned by CurrentBalance before the first poll.
+var errBalanceUnknown = errors.New("balance not yet known")
+
+// BalanceMonitor polls a wallet's USDC balance on Base and notifies
+// callbacks when it changes or falls below a threshold. It is safe for
+// concurrent use.
+type BalanceMonitor struct {
+	rpcURL   string
+	address  string
+	contract string
+	interval time.Duration
+
+	mu       sync.Mutex
+	balance  float64
+	known    bool
+	lastErr  error
+	started  bool
+	lows     []*lowBalanceAlert
+	onChange []func(oldBalance, newBalance float64)
+}
+
+// lowBalanceAlert is a callback registered with OnLowBalance. fired is set
+// while the balance stays below threshold, so cb runs once per crossing.
+type lowBalanceAlert struct {
+	threshold float64
+	cb        func(balance float64)
+	fired     bool
+}
+
+// NewBalanceMonitor returns a monitor for address's USDC balance, read via
+// eth_call against the JSON-RPC endpoint rpcURL every interval once Start
+// is called.
+func NewBalanceMonitor(rpcURL string, address string, interval time.Duration) *BalanceMonitor {
+	if interval <= 0 {
+		interval = DefaultBalanceMonitorInterval
+	}
+	return &BalanceMonitor{
+		rpcURL:   rpcURL,
+		address:  address,
+		contract: USDCBaseContract,
+		interval: interval,
+	}
+}
+
+// OnLowBalance registers cb to run when the balance drops below threshold.
+// It fires once per crossing: the balance must recover to at least
+// threshold before cb can fire again.
+func (m *BalanceMonitor) OnLowBalance(threshold float64, cb func(balance float64)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lows = append(m.lows, &lowBalanceAlert{threshold: threshold, cb: cb})
+}
+
+// OnBalanceChange registers cb to run whenever a poll observes a different
+// balance from the previous one.
+func (m *BalanceMonitor) OnBalanceChange(cb func(oldBalance, newBalance float64)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, cb)
+}
+
+// Start polls the balance immediately and then every interval in a
+// background goroutine until ctx is done. Calling Start again while it is
+// running has no effect.
+func (m *BalanceMonitor) Start(ctx context.Context) {
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return
+	}
+	m.started = true
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			m.started = false
+			m.mu.Unlock()
+		}()
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			m.Poll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Poll reads the balance once, runs any callbacks it triggers, and returns it.
+func (m *BalanceMonitor) Poll(ctx context.Context) (float64, error) {
+	balance, err := getUSDCBalance(ctx, m.address, m.contract, []string{m.rpcURL})
+
+	m.mu.Lock()
+	if err != nil {
+		m.lastErr = err
+		m.mu.Unlock()
+		return 0, err
+	}
+	old, wasKnown := m.balance, m.known
+	m.balance, m.known, m.lastErr = balance, true, nil
+
+	var changed []func(float64, float64)
+	if wasKnown && old != balance {
+		changed = append(changed, m.onChange...)
+	}
+	var low []func(float64)
+	for _, a := range m.lows {
+		switch {
+		case balance < a.threshold && !a.fired:
+			a.fired = true
+			low = append(low, a.cb)
+		case balance >= a.threshold:
+			a.fired = false
+		}
+	}
+	m.mu.Unlock()
+
+	for _, cb := range changed {
+		cb(old, balance)
+	}
+	for _, cb := range low {
+		cb(balance)
+	}
+	return balance, nil
+}
+
+// CurrentBalance returns the balance seen by the last successful poll. Before
+// any poll succeeds it returns the last poll's error, if any.
+func (m *BalanceMonitor) CurrentBalance() (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.known {
+		if m.lastErr != nil {
+			return 0, m.lastErr
+		}
+		return 0, errBalanceUnknown
+	}
+	return m.balance, nil
+}
+
+// WithRPCURL reads balances from the Base JSON-RPC endpoint url, both in
+// GetBalance and in the client's BalanceMonitor, which polls the wallet
+// every DefaultBalanceMonitorInterval once started.
+func WithRPCURL(url string) ClientOption {
+	return func(c *LLMClient) {
+		c.rpcURL = url
+	}
+}
+
+// BalanceMonitor returns the client's balance monitor, or nil if WithRPCURL
+// was not used. Register callbacks, then call Start.
+func (c *LLMClient) BalanceMonitor() *BalanceMonitor {
+	return c.balanceMonitor
+}
@@ -0,0 +1,128 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newBalanceRPCServer answers eth_call with each of balances (in USDC) in
+// turn, repeating the last one.
+func newBalanceRPCServer(t *testing.T, balances ...float64) *httptest.Server {
+	t.Helper()
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_call" {
+			t.Errorf("expected eth_call, got %s", req.Method)
+		}
+		i := int(calls.Add(1)) - 1
+		if i >= len(balances) {
+			i = len(balances) - 1
+		}
+		json.NewEncoder(w).Encode(rpcResponse{
+			JSONRPC: "2.0",
+			ID:      1,
+			Result:  fmt.Sprintf("0x%064x", int64(balances[i]*1_000_000)),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBalanceMonitorLowBalanceFiresOnce(t *testing.T) {
+	server := newBalanceRPCServer(t, 5, 4, 3, 2, 1, 0.5)
+	m := NewBalanceMonitor(server.URL, "0x1234567890abcdef1234567890abcdef12345678", time.Hour)
+
+	if _, err := m.CurrentBalance(); err == nil {
+		t.Error("expected an error before the first poll")
+	}
+
+	var lows []float64
+	var changes [][2]float64
+	m.OnLowBalance(2.5, func(b float64) { lows = append(lows, b) })
+	m.OnBalanceChange(func(old, new float64) { changes = append(changes, [2]float64{old, new}) })
+
+	for i := 0; i < 6; i++ {
+		if _, err := m.Poll(context.Background()); err != nil {
+			t.Fatalf("Poll: %v", err)
+		}
+	}
+
+	if len(lows) != 1 || lows[0] != 2 {
+		t.Errorf("expected one low-balance alert at 2, got %v", lows)
+	}
+	if len(changes) != 5 || changes[0] != [2]float64{5, 4} || changes[4] != [2]float64{1, 0.5} {
+		t.Errorf("unexpected change callbacks %v", changes)
+	}
+	if b, err := m.CurrentBalance(); err != nil || b != 0.5 {
+		t.Errorf("CurrentBalance = %v, %v", b, err)
+	}
+}
+
+func TestBalanceMonitorRearmsAfterRecovery(t *testing.T) {
+	server := newBalanceRPCServer(t, 1, 3, 1)
+	m := NewBalanceMonitor(server.URL, "0x1234567890abcdef1234567890abcdef12345678", time.Hour)
+	alerts := 0
+	m.OnLowBalance(2, func(float64) { alerts++ })
+	for i := 0; i < 3; i++ {
+		m.Poll(context.Background())
+	}
+	if alerts != 2 {
+		t.Errorf("expected an alert for each crossing, got %d", alerts)
+	}
+}
+
+func TestBalanceMonitorStart(t *testing.T) {
+	server := newBalanceRPCServer(t, 3, 2, 1)
+	client, err := NewLLMClient(testPrivateKey, WithRPCURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	m := client.BalanceMonitor()
+	if m == nil || m.address != client.GetWalletAddress() {
+		t.Fatal("expected WithRPCURL to create a monitor for the client's wallet")
+	}
+	m.interval = time.Millisecond
+
+	var mu sync.Mutex
+	var lows []float64
+	m.OnLowBalance(1.5, func(b float64) {
+		mu.Lock()
+		defer mu.Unlock()
+		lows = append(lows, b)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Start(ctx)
+	waitFor(t, func() bool {
+		b, err := m.CurrentBalance()
+		return err == nil && b == 1
+	})
+	time.Sleep(10 * time.Millisecond) // a few more polls at the same balance
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lows) != 1 || lows[0] != 1 {
+		t.Errorf("expected exactly one low-balance alert, got %v", lows)
+	}
+
+	if b, err := client.GetBalance(context.Background()); err != nil || b != 1 {
+		t.Errorf("GetBalance via WithRPCURL = %v, %v", b, err)
+	}
+}
+
+func TestNoBalanceMonitorWithoutRPCURL(t *testing.T) {
+	client, _ := NewLLMClient(testPrivateKey)
+	if client.BalanceMonitor() != nil {
+		t.Error("expected no monitor without WithRPCURL")
+	}
+}
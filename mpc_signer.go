@@ -0,0 +1,74 @@
+package blockrun
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MPCSigner signs payments with a key held elsewhere, such as a Coinbase
+// MPC wallet (see the cdp package), instead of a local private key.
+type MPCSigner interface {
+	// Sign returns the 65-byte secp256k1 signature (r, s, v) of the 32-byte
+	// hash. v may be 0/1 or 27/28.
+	Sign(hash []byte) ([]byte, error)
+	// Address returns the signer's 0x-prefixed Ethereum address.
+	Address() string
+}
+
+// localSigner is the MPCSigner for a local private key.
+type localSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// Sign implements MPCSigner.
+func (s localSigner) Sign(hash []byte) ([]byte, error) {
+	signature, err := crypto.Sign(hash, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return signature, nil
+}
+
+// Address implements MPCSigner.
+func (s localSigner) Address() string {
+	return crypto.PubkeyToAddress(s.key.PublicKey).Hex()
+}
+
+// WithMPCSigner signs payments with s instead of a local private key; the
+// client pays from s's address. A private key passed to NewLLMClient is not
+// used. Payment schemes other than "exact" still need a local key.
+func WithMPCSigner(s MPCSigner) ClientOption {
+	return func(c *LLMClient) {
+		c.signer = s
+	}
+}
+
+// NewLLMClientWithSigner creates an LLMClient that signs payments with
+// signer and needs no private key.
+func NewLLMClientWithSigner(signer MPCSigner, opts ...ClientOption) (*LLMClient, error) {
+	if signer == nil {
+		return nil, &ValidationError{Field: "signer", Message: "signer is required"}
+	}
+	return NewLLMClient("", append([]ClientOption{WithMPCSigner(signer)}, opts...)...)
+}
+
+// useSigner makes the client pay from its MPCSigner's address.
+func (bc *baseClient) useSigner() error {
+	address := bc.signer.Address()
+	if !common.IsHexAddress(address) {
+		return &ValidationError{Field: "signer", Message: fmt.Sprintf("signer address %q is not an Ethereum address", address), Value: address}
+	}
+	bc.address = common.HexToAddress(address).Hex()
+	return nil
+}
+
+// paymentSigner returns the MPCSigner payments are signed with.
+func (bc *baseClient) paymentSigner() MPCSigner {
+	if bc.signer != nil {
+		return bc.signer
+	}
+	return localSigner{key: bc.privateKey}
+}
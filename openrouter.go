@@ -0,0 +1,61 @@
+package blockrun
+
+import "net/http"
+
+// OpenRouter-style headers forwarded by WithOpenRouterOptions. Gateways that
+// do not support the convention ignore them.
+const (
+	// openRouterRefererHeader identifies the calling app by URL.
+	openRouterRefererHeader = "HTTP-Referer"
+	// openRouterTitleHeader identifies the calling app by name.
+	openRouterTitleHeader = "X-Title"
+	// openRouterSortHeader asks the gateway to order upstream providers,
+	// e.g. by "price", "throughput" or "latency".
+	openRouterSortHeader = "X-Provider-Sort"
+	// openRouterMaxPriceHeader caps the upstream price the gateway may route
+	// to, in USD per million tokens.
+	openRouterMaxPriceHeader = "X-Max-Price"
+)
+
+// OpenRouterOptions configures OpenRouter-style routing headers. Empty
+// fields are not sent.
+type OpenRouterOptions struct {
+	// AppName is sent as X-Title.
+	AppName string
+	// RefererURL is sent as HTTP-Referer.
+	RefererURL string
+	// SortBy is sent as X-Provider-Sort.
+	SortBy string
+	// MaxPrice is sent as X-Max-Price.
+	MaxPrice string
+}
+
+// WithOpenRouterHeaders sends the X-Title and HTTP-Referer headers that
+// OpenRouter, and BlockRun deployments following its convention, use to
+// attribute and route requests. It is WithOpenRouterOptions with only
+// AppName and RefererURL set.
+func WithOpenRouterHeaders(appName, refererURL string) ClientOption {
+	return WithOpenRouterOptions(OpenRouterOptions{AppName: appName, RefererURL: refererURL})
+}
+
+// WithOpenRouterOptions sends o's non-empty fields as headers on every
+// request, probe and paid retry alike: AppName as X-Title, RefererURL as
+// HTTP-Referer, SortBy as X-Provider-Sort and MaxPrice as X-Max-Price.
+// Headers set by an earlier call are replaced only where o sets them.
+func WithOpenRouterOptions(o OpenRouterOptions) ClientOption {
+	return func(c *LLMClient) {
+		if c.routingHeaders == nil {
+			c.routingHeaders = make(http.Header)
+		}
+		for name, value := range map[string]string{
+			openRouterTitleHeader:    o.AppName,
+			openRouterRefererHeader:  o.RefererURL,
+			openRouterSortHeader:     o.SortBy,
+			openRouterMaxPriceHeader: o.MaxPrice,
+		} {
+			if value != "" {
+				c.routingHeaders.Set(name, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWithOpenRouterOptions(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var mu sync.Mutex
+	var seen []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Clone())
+		mu.Unlock()
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithOpenRouterHeaders("my-app", "https://example.com"),
+		WithOpenRouterOptions(OpenRouterOptions{SortBy: "price", MaxPrice: "5"}))
+	client.costLog = nil
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("expected probe and paid retry, got %d requests", len(seen))
+	}
+	want := map[string]string{
+		"HTTP-Referer":    "https://example.com",
+		"X-Title":         "my-app",
+		"X-Provider-Sort": "price",
+		"X-Max-Price":     "5",
+	}
+	for i, h := range seen {
+		for name, value := range want {
+			if got := h.Get(name); got != value {
+				t.Errorf("request %d: expected %s %q, got %q", i, name, value, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package optimise improves system prompts by gradient-free search, using
+// the model itself to propose rewrites and a caller-supplied objective to
+// score them:
+//
+//	client, _ := blockrun.NewLLMClient("")
+//	opt := optimise.NewPromptOptimiser(client, "openai/gpt-4o", func(resp string) float64 {
+//		return -float64(len(resp)) // prefer short answers
+//	})
+//	best, score, err := opt.Optimise(ctx, "You are a helpful assistant.", inputs, 5)
+package optimise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// DefaultCandidates is the number of mutations proposed per iteration.
+const DefaultCandidates = 3
+
+// mutationPrompt asks the model for a rewrite of a system prompt.
+const mutationPrompt = "Improve this system prompt: %s\n\nReply with the improved system prompt only."
+
+// ScoredPrompt is a prompt and its mean objective score over the test inputs.
+type ScoredPrompt struct {
+	Prompt string
+	Score  float64
+}
+
+// OptimisationStep records one hill-climbing iteration.
+type OptimisationStep struct {
+	Iteration int
+	// Candidates are the mutations proposed this iteration, with scores.
+	Candidates []ScoredPrompt
+	// BestPrompt and BestScore are the incumbent after this iteration.
+	BestPrompt string
+	BestScore  float64
+	// Improved reports whether a candidate replaced the incumbent.
+	Improved bool
+}
+
+// PromptOptimiser hill-climbs a system prompt against an objective.
+type PromptOptimiser struct {
+	// Candidates is the number of mutations proposed per iteration.
+	Candidates int
+	// Temperature is used when proposing mutations, so candidates differ.
+	Temperature float64
+
+	client    *blockrun.LLMClient
+	model     string
+	objective func(response string) float64
+
+	mu      sync.Mutex
+	history []OptimisationStep
+}
+
+// NewPromptOptimiser returns an optimiser that uses model both to propose
+// prompt mutations and to answer the test inputs, and scores each answer
+// with objective (higher is better).
+func NewPromptOptimiser(client *blockrun.LLMClient, model string, objective func(response string) float64) *PromptOptimiser {
+	return &PromptOptimiser{
+		Candidates:  DefaultCandidates,
+		Temperature: 0.9,
+		client:      client,
+		model:       model,
+		objective:   objective,
+	}
+}
+
+// Optimise runs iterations rounds of hill climbing from initialPrompt. Each
+// round proposes Candidates mutations of the current best prompt and keeps
+// the highest-scoring one if it beats the incumbent. A prompt's score is the
+// mean objective over its responses to testInputs.
+//
+// On error the best prompt found so far is returned along with the error.
+func (o *PromptOptimiser) Optimise(ctx context.Context, initialPrompt string, testInputs []string, iterations int) (bestPrompt string, bestScore float64, err error) {
+	if len(testInputs) == 0 {
+		return "", 0, errors.New("optimise: at least one test input is required")
+	}
+	if o.objective == nil {
+		return "", 0, errors.New("optimise: objective is required")
+	}
+
+	o.mu.Lock()
+	o.history = nil
+	o.mu.Unlock()
+
+	bestPrompt = initialPrompt
+	bestScore, err = o.score(ctx, initialPrompt, testInputs)
+	if err != nil {
+		return initialPrompt, 0, err
+	}
+
+	n := o.Candidates
+	if n <= 0 {
+		n = DefaultCandidates
+	}
+	for i := 1; i <= iterations; i++ {
+		step := OptimisationStep{Iteration: i}
+		for j := 0; j < n; j++ {
+			candidate, err := o.mutate(ctx, bestPrompt)
+			if err != nil {
+				return bestPrompt, bestScore, err
+			}
+			if candidate == "" {
+				continue
+			}
+			score, err := o.score(ctx, candidate, testInputs)
+			if err != nil {
+				return bestPrompt, bestScore, err
+			}
+			step.Candidates = append(step.Candidates, ScoredPrompt{Prompt: candidate, Score: score})
+		}
+
+		for _, c := range step.Candidates {
+			if c.Score > bestScore {
+				bestPrompt, bestScore = c.Prompt, c.Score
+				step.Improved = true
+			}
+		}
+		step.BestPrompt, step.BestScore = bestPrompt, bestScore
+
+		o.mu.Lock()
+		o.history = append(o.history, step)
+		o.mu.Unlock()
+	}
+	return bestPrompt, bestScore, nil
+}
+
+// History returns the steps of the most recent Optimise call.
+func (o *PromptOptimiser) History() []OptimisationStep {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]OptimisationStep(nil), o.history...)
+}
+
+// mutate asks the model for a rewrite of prompt.
+func (o *PromptOptimiser) mutate(ctx context.Context, prompt string) (string, error) {
+	resp, err := o.client.ChatCompletion(ctx, o.model, []blockrun.ChatMessage{
+		{Role: "user", Content: fmt.Sprintf(mutationPrompt, prompt)},
+	}, &blockrun.ChatCompletionOptions{Temperature: o.Temperature})
+	if err != nil {
+		return "", fmt.Errorf("optimise: mutate prompt: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", nil
+	}
+	return strings.Trim(strings.TrimSpace(resp.Choices[0].Message.Content), `"`), nil
+}
+
+// score returns the mean objective of prompt's responses to inputs.
+func (o *PromptOptimiser) score(ctx context.Context, prompt string, inputs []string) (float64, error) {
+	var total float64
+	for _, input := range inputs {
+		resp, err := o.client.ChatCompletion(ctx, o.model, []blockrun.ChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: input},
+		}, nil)
+		if err != nil {
+			return 0, fmt.Errorf("optimise: evaluate prompt: %w", err)
+		}
+		if len(resp.Choices) > 0 {
+			total += o.objective(resp.Choices[0].Message.Content)
+		}
+	}
+	return total / float64(len(inputs)), nil
+}
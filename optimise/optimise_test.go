@@ -0,0 +1,105 @@
+package optimise
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// newMockModel answers mutation requests by appending a sentence to the
+// prompt (every third one asks for concision) and answers test inputs by
+// echoing the system prompt.
+func newMockModel(t *testing.T) *httptest.Server {
+	t.Helper()
+	mutations := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []blockrun.ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		var reply string
+		if prompt, ok := strings.CutPrefix(body.Messages[0].Content, "Improve this system prompt: "); ok {
+			prompt, _, _ = strings.Cut(prompt, "\n\n")
+			mutations++
+			if mutations%3 == 0 {
+				reply = prompt + " Be concise."
+			} else {
+				reply = prompt + " Be thorough."
+			}
+		} else {
+			reply = "Following: " + body.Messages[0].Content
+		}
+		json.NewEncoder(w).Encode(blockrun.ChatResponse{
+			Choices: []blockrun.Choice{{Message: blockrun.ChatMessage{Role: "assistant", Content: reply}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func countConcise(response string) float64 {
+	return float64(strings.Count(strings.ToLower(response), "concise"))
+}
+
+func TestOptimiseHillClimbs(t *testing.T) {
+	server := newMockModel(t)
+	client, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	opt := NewPromptOptimiser(client, "gpt-4o", countConcise)
+	best, score, err := opt.Optimise(context.Background(), "You are helpful.", []string{"hi", "explain DNS"}, 3)
+	if err != nil {
+		t.Fatalf("Optimise: %v", err)
+	}
+	if score != 3 {
+		t.Errorf("expected score 3 after 3 iterations, got %v", score)
+	}
+	if want := "You are helpful. Be concise. Be concise. Be concise."; best != want {
+		t.Errorf("best prompt = %q, want %q", best, want)
+	}
+
+	history := opt.History()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 history steps, got %d", len(history))
+	}
+	for i, step := range history {
+		if step.Iteration != i+1 || len(step.Candidates) != DefaultCandidates || !step.Improved {
+			t.Errorf("step %d: %+v", i, step)
+		}
+		if step.BestScore != float64(i+1) {
+			t.Errorf("step %d best score = %v", i, step.BestScore)
+		}
+	}
+}
+
+func TestOptimiseKeepsIncumbentWithoutImprovement(t *testing.T) {
+	server := newMockModel(t)
+	client, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(server.URL))
+
+	opt := NewPromptOptimiser(client, "gpt-4o", countConcise)
+	opt.Candidates = 2 // the third mutation, the concise one, is never reached
+	best, score, err := opt.Optimise(context.Background(), "Answer.", []string{"hi"}, 1)
+	if err != nil {
+		t.Fatalf("Optimise: %v", err)
+	}
+	if best != "Answer." || score != 0 {
+		t.Errorf("expected the initial prompt to survive, got %q (%v)", best, score)
+	}
+	if h := opt.History(); len(h) != 1 || h[0].Improved {
+		t.Errorf("unexpected history %+v", h)
+	}
+
+	if _, _, err := opt.Optimise(context.Background(), "Answer.", nil, 1); err == nil {
+		t.Error("expected an error without test inputs")
+	}
+}
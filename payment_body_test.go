@@ -0,0 +1,105 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testPaymentRequirementJSON(t *testing.T) []byte {
+	t.Helper()
+	headerValue, _ := base64.StdEncoding.DecodeString(testPaymentRequiredHeader(t, "2500"))
+	return headerValue
+}
+
+func TestParsePaymentRequiredFromBody(t *testing.T) {
+	prJSON := testPaymentRequirementJSON(t)
+	for name, body := range map[string]string{
+		"x402 object": `{"error":"payment required","x402":` + string(prJSON) + `}`,
+		"x402 base64": `{"x402":"` + base64.StdEncoding.EncodeToString(prJSON) + `"}`,
+		"bare":        string(prJSON),
+	} {
+		req, err := ParsePaymentRequiredFromBody([]byte(body))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(req.Accepts) != 1 || req.Accepts[0].Amount != "2500" || req.X402Version != 2 {
+			t.Errorf("%s: unexpected requirement %+v", name, req)
+		}
+	}
+
+	if _, err := ParsePaymentRequiredFromBody([]byte(`{"error":"nope"}`)); err == nil {
+		t.Error("expected an error for a body without payment requirements")
+	}
+	if _, err := ParsePaymentRequiredFromBody([]byte(`{"x402":{"accepts":"bad"}}`)); err == nil {
+		t.Error("expected an error for malformed requirements")
+	}
+}
+
+// newBodyOnly402Server answers the unpaid probe with 402 and the payment
+// requirements only in the JSON body, then serves paid requests with ok.
+func newBodyOnly402Server(t *testing.T, ok string) *httptest.Server {
+	t.Helper()
+	prJSON := testPaymentRequirementJSON(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(`{"error":"Payment Required","x402":` + string(prJSON) + `}`))
+			return
+		}
+		w.Write([]byte(ok))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChatCompletionPaymentRequiredInBody(t *testing.T) {
+	server := newBodyOnly402Server(t, testChatResponseJSON)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+
+	resp, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "ok" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if got := client.GetSpending(); got.Calls != 1 || got.TotalUSD != 0.0025 {
+		t.Errorf("expected one $0.0025 payment, got %+v", got)
+	}
+}
+
+func TestImageGeneratePaymentRequiredInBody(t *testing.T) {
+	server := newBodyOnly402Server(t, `{"created":1749000000,"data":[{"url":"https://cdn.example.com/img.png"}]}`)
+	client, _ := NewImageClient(testPrivateKey, WithImageAPIURL(server.URL))
+	client.costLog = nil
+
+	resp, err := client.Generate(context.Background(), "a cat", nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].URL != "https://cdn.example.com/img.png" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestPaymentRequiredMissingEverywhere(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(map[string]string{"error": "pay up"})
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	_, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "no payment requirements found") {
+		t.Errorf("expected a missing-requirements PaymentError, got %v", err)
+	}
+}
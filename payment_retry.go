@@ -0,0 +1,50 @@
+package blockrun
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxPaymentRetries is how many signed payments a call makes by
+// default before giving up on repeated 402s.
+const DefaultMaxPaymentRetries = 1
+
+// WithMaxPaymentRetries sets how many signed payments one call may make
+// (DefaultMaxPaymentRetries by default). When a paid request is answered with
+// another 402, the client reads the new payment requirements, which may
+// quote a different amount, and pays again until n payments have been
+// rejected. It is separate from WithRetryPolicy, which covers rate limits.
+func WithMaxPaymentRetries(n int) ClientOption {
+	return func(c *LLMClient) {
+		c.maxPaymentRetries = n
+	}
+}
+
+// paymentRetryLimit returns the configured payment retry limit, at least 1.
+func (bc *baseClient) paymentRetryLimit() int {
+	if bc.maxPaymentRetries < 1 {
+		return DefaultMaxPaymentRetries
+	}
+	return bc.maxPaymentRetries
+}
+
+// rejectedPayment handles a 402 answering the attempt'th paid request. It
+// consumes and closes resp and returns the new requirements to pay, or the
+// PaymentError ending the call once the retry limit is reached or the 402
+// carries no requirements.
+func (bc *baseClient) rejectedPayment(ctx context.Context, resp *http.Response, attempt int) (*PaymentRequirement, error) {
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	bc.clearPendingPayment(ctx)
+
+	rejected := &PaymentError{Message: "Payment was rejected. Check your wallet balance.", Attempts: attempt}
+	if attempt >= bc.paymentRetryLimit() {
+		return nil, rejected
+	}
+	req, err := paymentRequirementFromResponse(resp.Header, body)
+	if err != nil {
+		return nil, rejected
+	}
+	return req, nil
+}
@@ -0,0 +1,80 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// newRepricingServer answers the first rejections requests with a 402 whose
+// quote rises by 100 micro-USDC each time, then succeeds.
+func newRepricingServer(t *testing.T, rejections int) (*httptest.Server, *int) {
+	t.Helper()
+	var mu sync.Mutex
+	var paid int
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("PAYMENT-SIGNATURE") != "" {
+			paid++
+		}
+		requests++
+		if requests <= rejections {
+			w.Header().Set("payment-required", testPaymentRequiredHeader(t, fmt.Sprint(1000+100*(requests-1))))
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server, &paid
+}
+
+func TestMaxPaymentRetries(t *testing.T) {
+	// The probe and two paid attempts are answered with 402.
+	server, paid := newRepricingServer(t, 3)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMaxPaymentRetries(3))
+	client.costLog = nil
+
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if *paid != 3 {
+		t.Errorf("expected 3 signed attempts, got %d", *paid)
+	}
+	// Only the accepted payment, at the last quote, is recorded.
+	if s := client.GetSpending(); s.Calls != 1 || s.TotalUSD != 0.0012 {
+		t.Errorf("expected one $0.0012 payment, got %+v", s)
+	}
+}
+
+func TestMaxPaymentRetriesExhausted(t *testing.T) {
+	for _, tc := range []struct {
+		opts     []ClientOption
+		attempts int
+	}{
+		{nil, 1},
+		{[]ClientOption{WithMaxPaymentRetries(2)}, 2},
+	} {
+		server, paid := newRepricingServer(t, 3)
+		client, _ := NewLLMClient(testPrivateKey, append(tc.opts, WithAPIURL(server.URL))...)
+		client.costLog = nil
+
+		_, err := client.Chat(context.Background(), "gpt-4o", "hi")
+		var pe *PaymentError
+		if !errors.As(err, &pe) || pe.Attempts != tc.attempts {
+			t.Fatalf("expected a PaymentError after %d attempts, got %v", tc.attempts, err)
+		}
+		if *paid != tc.attempts {
+			t.Errorf("expected %d signed attempts, got %d", tc.attempts, *paid)
+		}
+		if s := client.GetSpending(); s.Calls != 0 {
+			t.Errorf("expected nothing recorded, got %+v", s)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package blockrun
+
+import (
+	"crypto/ecdsa"
+	"sync"
+)
+
+// PaymentScheme signs payments for one x402 scheme on EVM chains. The
+// built-in ExactScheme handles "exact"; RegisterPaymentScheme adds others.
+type PaymentScheme interface {
+	// Scheme returns the x402 scheme name, matched against
+	// PaymentOption.Scheme.
+	Scheme() string
+	// CreatePayload returns the PAYMENT-SIGNATURE value paying opt for
+	// resource.
+	CreatePayload(key *ecdsa.PrivateKey, opt *PaymentOption, resource *ResourceInfo) (string, error)
+}
+
+// ExactScheme is the x402 "exact" scheme: an EIP-3009 USDC transfer
+// authorization for the quoted amount (see CreatePaymentPayload).
+type ExactScheme struct{}
+
+// Scheme returns "exact".
+func (ExactScheme) Scheme() string { return "exact" }
+
+// CreatePayload signs opt with CreatePaymentPayload.
+func (ExactScheme) CreatePayload(key *ecdsa.PrivateKey, opt *PaymentOption, resource *ResourceInfo) (string, error) {
+	return CreatePaymentPayload(key, opt.PayTo, opt.Amount, opt.Network, resource.URL, resource.Description,
+		opt.MaxTimeoutSeconds, opt.Extra, nil)
+}
+
+var (
+	paymentSchemesMu sync.RWMutex
+	paymentSchemes   = map[string]PaymentScheme{"exact": ExactScheme{}}
+)
+
+// RegisterPaymentScheme makes s available to every client in the process,
+// replacing any scheme registered under the same name. ExtractPaymentDetails
+// prefers options with a registered scheme other than "exact", then "exact",
+// and EVM payments are signed by the scheme the chosen option names. Solana
+// payments are unaffected.
+func RegisterPaymentScheme(s PaymentScheme) {
+	paymentSchemesMu.Lock()
+	defer paymentSchemesMu.Unlock()
+	paymentSchemes[s.Scheme()] = s
+}
+
+// lookupPaymentScheme returns the scheme registered as name, or nil.
+func lookupPaymentScheme(name string) PaymentScheme {
+	paymentSchemesMu.RLock()
+	defer paymentSchemesMu.RUnlock()
+	return paymentSchemes[name]
+}
@@ -0,0 +1,109 @@
+package blockrun
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mockStreamScheme is a user-defined scheme that signs nothing.
+type mockStreamScheme struct {
+	calls int
+}
+
+func (s *mockStreamScheme) Scheme() string { return "stream" }
+
+func (s *mockStreamScheme) CreatePayload(key *ecdsa.PrivateKey, opt *PaymentOption, resource *ResourceInfo) (string, error) {
+	s.calls++
+	return "stream:" + opt.Amount + ":" + resource.URL, nil
+}
+
+func registerTestScheme(t *testing.T, s PaymentScheme) {
+	t.Helper()
+	RegisterPaymentScheme(s)
+	t.Cleanup(func() {
+		paymentSchemesMu.Lock()
+		delete(paymentSchemes, s.Scheme())
+		paymentSchemesMu.Unlock()
+	})
+}
+
+func testTwoSchemeRequirement() *PaymentRequirement {
+	return &PaymentRequirement{
+		X402Version: 2,
+		Accepts: []PaymentOption{
+			{Scheme: "exact", Network: "eip155:8453", Amount: "1000", Asset: USDCBase, PayTo: "0x1234567890123456789012345678901234567890", MaxTimeoutSeconds: 300},
+			{Scheme: "stream", Network: "eip155:8453", Amount: "500", Asset: USDCBase, PayTo: "0x1234567890123456789012345678901234567890", MaxTimeoutSeconds: 300},
+		},
+		Resource: ResourceInfo{URL: "https://blockrun.ai/api/v1/chat/completions", Description: "Chat"},
+	}
+}
+
+func TestExtractPaymentDetailsPrefersRegisteredScheme(t *testing.T) {
+	req := testTwoSchemeRequirement()
+	req.Accepts[0].Scheme = "subscription"
+
+	option, err := ExtractPaymentDetails(req)
+	if err != nil || option.Scheme != "subscription" {
+		t.Fatalf("expected the first option while neither is registered, got %+v, %v", option, err)
+	}
+
+	registerTestScheme(t, &mockStreamScheme{})
+	req = testTwoSchemeRequirement()
+	req.Accepts[0].Scheme = "subscription"
+	option, _ = ExtractPaymentDetails(req)
+	if option.Scheme != "stream" {
+		t.Errorf("expected the registered stream option, got %q", option.Scheme)
+	}
+}
+
+func TestRegisteredSchemeSignsPayment(t *testing.T) {
+	scheme := &mockStreamScheme{}
+	registerTestScheme(t, scheme)
+
+	prJSON, _ := json.Marshal(testTwoSchemeRequirement())
+	prHeader := base64.StdEncoding.EncodeToString(prJSON)
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		signature = sig
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+	// Both "exact" and "stream" are registered; the user-registered scheme
+	// wins wherever the server lists it.
+	for i := 1; i <= 2; i++ {
+		if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+		if scheme.calls != i || signature != "stream:500:https://blockrun.ai/api/v1/chat/completions" {
+			t.Errorf("expected the stream scheme to sign, got %d calls and signature %q", scheme.calls, signature)
+		}
+
+		pr := testTwoSchemeRequirement()
+		pr.Accepts[0], pr.Accepts[1] = pr.Accepts[1], pr.Accepts[0]
+		prJSON, _ = json.Marshal(pr)
+		prHeader = base64.StdEncoding.EncodeToString(prJSON)
+	}
+}
+
+func TestExtractPaymentDetailsFallsBackToExact(t *testing.T) {
+	req := testTwoSchemeRequirement()
+	req.Accepts[0], req.Accepts[1] = req.Accepts[1], req.Accepts[0]
+	option, err := ExtractPaymentDetails(req)
+	if err != nil || option.Scheme != "exact" {
+		t.Errorf("expected exact while stream is not registered, got %+v, %v", option, err)
+	}
+}
@@ -0,0 +1,62 @@
+package blockrun
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// WithConnectionPool sizes the client's connection pool for high-throughput
+// workloads: maxIdle idle connections in total, maxIdlePerHost per host,
+// maxConns connections per host (0 = unlimited), and idle connections are
+// closed after idleTimeout. The default transport keeps only 2 idle
+// connections per host, which causes connection churn under heavy
+// concurrency.
+//
+// WithConnectionPool and WithHTTPClient replace each other's transport; the
+// last one applied wins and a warning is logged.
+func WithConnectionPool(maxIdle, maxIdlePerHost, maxConns int, idleTimeout time.Duration) ClientOption {
+	return func(c *LLMClient) {
+		c.tuneTransport(func(t *http.Transport) {
+			t.MaxIdleConns = maxIdle
+			t.MaxIdleConnsPerHost = maxIdlePerHost
+			t.MaxConnsPerHost = maxConns
+			t.IdleConnTimeout = idleTimeout
+		})
+	}
+}
+
+// WithHTTP2 forces HTTP/2 (enabled) or restricts the client to HTTP/1.1
+// (disabled). By default HTTP/2 is negotiated when the server supports it.
+// Like WithConnectionPool, it yields to a later WithHTTPClient.
+func WithHTTP2(enabled bool) ClientOption {
+	return func(c *LLMClient) {
+		c.tuneTransport(func(t *http.Transport) {
+			t.ForceAttemptHTTP2 = enabled
+			if enabled {
+				t.TLSNextProto = nil
+			} else {
+				// A non-nil empty map disables the automatic HTTP/2 upgrade.
+				t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			}
+		})
+	}
+}
+
+// ConnectionPoolStats returns the client's current *http.Transport for
+// inspecting its pool settings, or nil if a custom RoundTripper is in use.
+// Treat the result as read-only.
+func (c *LLMClient) ConnectionPoolStats() *http.Transport {
+	transport, _ := c.httpClient.Transport.(*http.Transport)
+	return transport
+}
+
+// tuneTransport applies a pool option via withTransport, warning when it
+// discards a custom RoundTripper installed by WithHTTPClient.
+func (bc *baseClient) tuneTransport(fn func(*http.Transport)) {
+	if _, ok := bc.httpClient.Transport.(*http.Transport); !ok && bc.httpClient.Transport != nil {
+		bc.warnf("connection pool option replaces the custom RoundTripper set by WithHTTPClient")
+	}
+	bc.withTransport(fn)
+	bc.transportTuned = true
+}
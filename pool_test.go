@@ -0,0 +1,73 @@
+package blockrun
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithConnectionPool(t *testing.T) {
+	client, err := NewLLMClient(testPrivateKey, WithConnectionPool(200, 50, 100, 90*time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	transport := client.ConnectionPoolStats()
+	if transport == nil {
+		t.Fatal("expected an *http.Transport")
+	}
+	if transport.MaxIdleConns != 200 || transport.MaxIdleConnsPerHost != 50 ||
+		transport.MaxConnsPerHost != 100 || transport.IdleConnTimeout != 90*time.Second {
+		t.Errorf("pool settings not applied: idle=%d perHost=%d conns=%d timeout=%v",
+			transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport == http.DefaultTransport {
+		t.Error("expected a dedicated transport, not http.DefaultTransport")
+	}
+	if transport.Proxy == nil {
+		t.Error("expected the environment proxy setting to be kept")
+	}
+}
+
+func TestWithHTTP2(t *testing.T) {
+	on, _ := NewLLMClient(testPrivateKey, WithHTTP2(true))
+	if tr := on.ConnectionPoolStats(); !tr.ForceAttemptHTTP2 || tr.TLSNextProto != nil {
+		t.Error("expected HTTP/2 to be forced")
+	}
+
+	off, _ := NewLLMClient(testPrivateKey, WithConnectionPool(10, 10, 0, time.Minute), WithHTTP2(false))
+	tr := off.ConnectionPoolStats()
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil || len(tr.TLSNextProto) != 0 {
+		t.Error("expected HTTP/2 to be disabled")
+	}
+	if tr.MaxIdleConns != 10 {
+		t.Error("expected WithHTTP2 to keep earlier pool settings")
+	}
+}
+
+func TestConnectionPoolAndHTTPClientLastWins(t *testing.T) {
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(prev)
+
+	custom := &http.Client{Transport: http.NewFileTransport(http.Dir("."))}
+	client, _ := NewLLMClient(testPrivateKey, WithConnectionPool(10, 10, 0, time.Minute), WithHTTPClient(custom))
+	if client.httpClient != custom || client.ConnectionPoolStats() != nil {
+		t.Error("expected the later WithHTTPClient to win")
+	}
+	if !strings.Contains(logs.String(), "overrides earlier connection pool settings") {
+		t.Errorf("expected a warning, got %q", logs.String())
+	}
+
+	logs.Reset()
+	client, _ = NewLLMClient(testPrivateKey, WithHTTPClient(custom), WithConnectionPool(10, 5, 0, time.Minute))
+	if tr := client.ConnectionPoolStats(); tr == nil || tr.MaxIdleConnsPerHost != 5 {
+		t.Error("expected the later WithConnectionPool to win")
+	}
+	if !strings.Contains(logs.String(), "replaces the custom RoundTripper") {
+		t.Errorf("expected a warning, got %q", logs.String())
+	}
+}
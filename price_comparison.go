@@ -0,0 +1,113 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+)
+
+// ModelPricePoint is one model's pricing, as returned by PriceComparison.
+type ModelPricePoint struct {
+	Model            string
+	Provider         string
+	InputPricePer1M  float64
+	OutputPricePer1M float64
+	// FlatPrice is the per-call price of flat-billed models, else 0.
+	FlatPrice float64
+	// TotalPriceForNTokens returns the USD cost of one call with the given
+	// token counts.
+	TotalPriceForNTokens func(in, out int) float64
+}
+
+// PriceComparison returns the catalogue pricing of models, in the order
+// given; aliases are resolved. A nil or empty models compares every listed
+// model. A model missing from the catalogue is a ValidationError.
+func (c *LLMClient) PriceComparison(ctx context.Context, models []string) ([]ModelPricePoint, error) {
+	catalogue, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Model, len(catalogue))
+	for i := range catalogue {
+		byID[catalogue[i].ID] = &catalogue[i]
+	}
+
+	var selected []*Model
+	if len(models) == 0 {
+		for i := range catalogue {
+			selected = append(selected, &catalogue[i])
+		}
+	}
+	for _, id := range models {
+		m, ok := byID[c.ResolveModel(id)]
+		if !ok {
+			return nil, unknownModelError(id)
+		}
+		selected = append(selected, m)
+	}
+
+	points := make([]ModelPricePoint, len(selected))
+	for i, m := range selected {
+		m := m
+		points[i] = ModelPricePoint{
+			Model:            m.ID,
+			Provider:         m.Provider,
+			InputPricePer1M:  m.Pricing.Input,
+			OutputPricePer1M: m.Pricing.Output,
+			FlatPrice:        m.Pricing.Flat,
+			TotalPriceForNTokens: func(in, out int) float64 {
+				return modelCallCost(m, in, out)
+			},
+		}
+	}
+	return points, nil
+}
+
+// CheapestFor returns the listed model with the lowest cost for one call of
+// inputTokens and outputTokens (see EstimateCost). Ties go to the model
+// listed first. Hidden models are skipped.
+func (c *LLMClient) CheapestFor(ctx context.Context, inputTokens, outputTokens int) (*Model, error) {
+	return c.cheapest(ctx, "", inputTokens, outputTokens)
+}
+
+// CheapestByCapability is CheapestFor among the models whose Categories
+// include capability, e.g. "vision" or "reasoning".
+func (c *LLMClient) CheapestByCapability(ctx context.Context, capability string, inputTokens, outputTokens int) (*Model, error) {
+	return c.cheapest(ctx, capability, inputTokens, outputTokens)
+}
+
+// cheapest implements CheapestFor and, when capability is set,
+// CheapestByCapability.
+func (c *LLMClient) cheapest(ctx context.Context, capability string, inputTokens, outputTokens int) (*Model, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var best *Model
+	bestCost := 0.0
+	for i := range models {
+		m := &models[i]
+		if m.Hidden || (capability != "" && !hasCategory(m, capability)) {
+			continue
+		}
+		if cost := modelCallCost(m, inputTokens, outputTokens); best == nil || cost < bestCost {
+			best, bestCost = m, cost
+		}
+	}
+	if best == nil {
+		if capability != "" {
+			return nil, fmt.Errorf("no model with capability %q", capability)
+		}
+		return nil, fmt.Errorf("no models available")
+	}
+	return best, nil
+}
+
+// hasCategory reports whether m lists category.
+func hasCategory(m *Model, category string) bool {
+	for _, c := range m.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
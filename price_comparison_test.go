@@ -0,0 +1,77 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newPricedModelsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":"openai/gpt-4o","owned_by":"openai","pricing":{"input":2.5,"output":10},"categories":["chat","vision"]},
+			{"id":"deepseek/deepseek-chat","owned_by":"deepseek","pricing":{"input":0.27,"output":1.1},"categories":["chat"]},
+			{"id":"google/gemini-2.5-flash","owned_by":"google","pricing":{"input":0.3,"output":2.5},"categories":["chat","vision"]},
+			{"id":"flat/model","owned_by":"flat","pricing":{"flat":0.002},"categories":["chat"]}
+		]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPriceComparison(t *testing.T) {
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(newPricedModelsServer(t).URL))
+	ctx := context.Background()
+
+	points, err := client.PriceComparison(ctx, []string{"deepseek/deepseek-chat", "openai/gpt-4o"})
+	if err != nil {
+		t.Fatalf("PriceComparison: %v", err)
+	}
+	if len(points) != 2 || points[0].Model != "deepseek/deepseek-chat" || points[1].Provider != "openai" {
+		t.Fatalf("unexpected points %+v", points)
+	}
+	if points[1].InputPricePer1M != 2.5 || points[1].OutputPricePer1M != 10 {
+		t.Errorf("unexpected gpt-4o prices %+v", points[1])
+	}
+	if got := points[1].TotalPriceForNTokens(1_000_000, 100_000); math.Abs(got-3.5) > 1e-9 {
+		t.Errorf("expected $3.50, got %v", got)
+	}
+
+	all, _ := client.PriceComparison(ctx, nil)
+	if len(all) != 4 {
+		t.Errorf("expected every model, got %d", len(all))
+	}
+	var ve *ValidationError
+	if _, err := client.PriceComparison(ctx, []string{"nope"}); !errors.As(err, &ve) {
+		t.Errorf("expected a ValidationError, got %v", err)
+	}
+}
+
+func TestCheapestFor(t *testing.T) {
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(newPricedModelsServer(t).URL))
+	ctx := context.Background()
+
+	// A short call: deepseek costs $0.00038, under the $0.002 flat price.
+	m, err := client.CheapestFor(ctx, 1000, 100)
+	if err != nil || m.ID != "deepseek/deepseek-chat" {
+		t.Errorf("expected deepseek for a short call, got %v (%v)", m, err)
+	}
+	// A long call: deepseek costs $0.0137, so the flat-priced model wins.
+	m, _ = client.CheapestFor(ctx, 10_000, 10_000)
+	if m.ID != "flat/model" {
+		t.Errorf("expected the flat-priced model for a long call, got %s", m.ID)
+	}
+
+	m, err = client.CheapestByCapability(ctx, "vision", 1000, 100)
+	if err != nil || m.ID != "google/gemini-2.5-flash" {
+		t.Errorf("expected gemini among vision models, got %v (%v)", m, err)
+	}
+	if _, err := client.CheapestByCapability(ctx, "audio", 1000, 100); err == nil {
+		t.Error("expected an error for an unknown capability")
+	}
+}
@@ -0,0 +1,199 @@
+package blockrun
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// PrioritisedRequest is a call waiting in a PriorityQueue.
+type PrioritisedRequest struct {
+	// Priority orders dispatch; higher values go first.
+	Priority int
+
+	seq   uint64        // arrival order, for FIFO within a priority
+	ready chan struct{} // closed when the request is dispatched
+	index int           // heap index; -1 once dispatched or removed
+}
+
+// requestHeap is a max-heap on Priority, then min on arrival order.
+type requestHeap []*PrioritisedRequest
+
+func (h requestHeap) Len() int { return len(h) }
+func (h requestHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h requestHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *requestHeap) Push(x any) {
+	r := x.(*PrioritisedRequest)
+	r.index = len(*h)
+	*h = append(*h, r)
+}
+func (h *requestHeap) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	r.index = -1
+	return r
+}
+
+// PriorityQueue limits how many calls run at once and, when callers have to
+// wait, dispatches them by priority (FIFO within a priority level). It is
+// safe for concurrent use.
+type PriorityQueue struct {
+	mu       sync.Mutex
+	capacity int
+	inflight int
+	waiting  requestHeap
+	seq      uint64
+	drained  []chan struct{}
+}
+
+// NewPriorityQueue returns a queue that runs at most capacity calls at once
+// (minimum 1).
+func NewPriorityQueue(capacity int) *PriorityQueue {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &PriorityQueue{capacity: capacity}
+}
+
+// WithPriorityQueue admits at most capacity concurrent ChatCompletion /
+// ChatCompletionStream calls; further callers block (respecting their
+// context) and are dispatched highest WithPriority first. A stream holds its
+// slot until it is closed.
+func WithPriorityQueue(capacity int) ClientOption {
+	return func(c *LLMClient) {
+		c.queue = NewPriorityQueue(capacity)
+	}
+}
+
+// WithPriority sets this call's priority for WithPriorityQueue; higher runs
+// first. The default is 0.
+func WithPriority(priority int) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.priority = priority
+	}
+}
+
+// priorityFrom returns the call's WithPriority value.
+func priorityFrom(ctx context.Context) int {
+	if cfg := requestConfigFrom(ctx); cfg != nil {
+		return cfg.priority
+	}
+	return 0
+}
+
+// Len returns the number of calls waiting for a slot.
+func (q *PriorityQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting)
+}
+
+// acquire waits for a slot and returns the func that frees it. A nil queue
+// admits every call immediately.
+func (q *PriorityQueue) acquire(ctx context.Context, priority int) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+	var once sync.Once
+	release := func() { once.Do(q.release) }
+
+	q.mu.Lock()
+	if q.inflight < q.capacity && len(q.waiting) == 0 {
+		q.inflight++
+		q.mu.Unlock()
+		return release, nil
+	}
+	q.seq++
+	req := &PrioritisedRequest{Priority: priority, seq: q.seq, ready: make(chan struct{})}
+	heap.Push(&q.waiting, req)
+	q.mu.Unlock()
+
+	select {
+	case <-req.ready:
+		return release, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		if req.index >= 0 {
+			heap.Remove(&q.waiting, req.index)
+			q.notifyDrainedLocked()
+			q.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		q.mu.Unlock()
+		// Dispatched while giving up: hand the slot on.
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// release frees a slot and dispatches the next waiting call.
+func (q *PriorityQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inflight--
+	for q.inflight < q.capacity && len(q.waiting) > 0 {
+		req := heap.Pop(&q.waiting).(*PrioritisedRequest)
+		q.inflight++
+		close(req.ready)
+	}
+	q.notifyDrainedLocked()
+}
+
+// notifyDrainedLocked wakes Drain callers once the queue is idle.
+func (q *PriorityQueue) notifyDrainedLocked() {
+	if q.inflight > 0 || len(q.waiting) > 0 {
+		return
+	}
+	for _, ch := range q.drained {
+		close(ch)
+	}
+	q.drained = nil
+}
+
+// Drain blocks until no calls are running or waiting, or ctx is done.
+func (q *PriorityQueue) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	if q.inflight == 0 && len(q.waiting) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	q.drained = append(q.drained, ch)
+	q.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// QueueDepth returns the number of calls waiting in the client's priority
+// queue (0 without WithPriorityQueue).
+func (c *LLMClient) QueueDepth() int {
+	if c.queue == nil {
+		return 0
+	}
+	return c.queue.Len()
+}
+
+// DrainQueue blocks until every queued and running call has finished, or
+// ctx is done. It returns immediately without WithPriorityQueue.
+func (c *LLMClient) DrainQueue(ctx context.Context) error {
+	if c.queue == nil {
+		return nil
+	}
+	return c.queue.Drain(ctx)
+}
@@ -0,0 +1,115 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueueOrdering(t *testing.T) {
+	started, unblock := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	var served []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		name := body.Messages[0].Content
+		if name == "blocker" {
+			close(started)
+			<-unblock
+		}
+		mu.Lock()
+		served = append(served, name)
+		mu.Unlock()
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithPriorityQueue(1))
+	ctx := context.Background()
+	call := func(name string, priority int) {
+		if _, err := client.ChatCompletion(ctx, "gpt-4o", []ChatMessage{{Role: "user", Content: name}}, nil, WithPriority(priority)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { defer wg.Done(); call("blocker", 0) }()
+	<-started
+
+	// Interleave submissions; each waits until the previous one is queued so
+	// arrival order within a priority is known.
+	var want, high, low []string
+	for i := 0; i < 15; i++ {
+		name, priority := fmt.Sprintf("low-%d", len(low)), 0
+		if i%3 == 1 {
+			name, priority = fmt.Sprintf("high-%d", len(high)), 10
+			high = append(high, name)
+		} else {
+			low = append(low, name)
+		}
+		wg.Add(1)
+		go func() { defer wg.Done(); call(name, priority) }()
+		depth := i + 1
+		waitFor(t, func() bool { return client.QueueDepth() == depth })
+	}
+	want = append(append(append(want, "blocker"), high...), low...)
+
+	close(unblock)
+	wg.Wait()
+	if err := client.DrainQueue(ctx); err != nil {
+		t.Fatalf("DrainQueue: %v", err)
+	}
+
+	if fmt.Sprint(served) != fmt.Sprint(want) {
+		t.Errorf("served in order\n%v\nwant\n%v", served, want)
+	}
+}
+
+func TestPriorityQueueRespectsContext(t *testing.T) {
+	q := NewPriorityQueue(1)
+	release, err := q.acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.acquire(ctx, 5); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded while at capacity, got %v", err)
+	}
+	if q.Len() != 0 {
+		t.Errorf("expected the timed-out request to leave the queue, got depth %d", q.Len())
+	}
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer drainCancel()
+	if err := q.Drain(drainCtx); err != context.DeadlineExceeded {
+		t.Errorf("expected Drain to wait for the running call, got %v", err)
+	}
+	release()
+	release() // idempotent
+	if err := q.Drain(context.Background()); err != nil {
+		t.Errorf("Drain after release: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
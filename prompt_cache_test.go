@@ -0,0 +1,90 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCachedMessageChat(t *testing.T) {
+	var sent struct {
+		Messages []map[string]any `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"anthropic/claude-sonnet-4.6",
+			"choices":[{"index":0,"message":{"role":"assistant","content":[{"type":"text","text":"Done."}]},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":2100,"completion_tokens":3,"total_tokens":2103,"cache_creation_input_tokens":0,"cache_read_input_tokens":2048}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	resp, err := client.ChatCompletion(context.Background(), "anthropic/claude-sonnet-4.6", []ChatMessage{
+		NewCachedMessage("system", strings.Repeat("policy ", 1000)),
+		{Role: "user", Content: "Summarise."},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	if len(sent.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %v", sent.Messages)
+	}
+	if cc, _ := sent.Messages[0]["cache_control"].(map[string]any); cc["type"] != "ephemeral" {
+		t.Errorf("expected cache_control on the system message, got %v", sent.Messages[0])
+	}
+	if _, ok := sent.Messages[1]["cache_control"]; ok {
+		t.Errorf("expected no cache_control on the user message, got %v", sent.Messages[1])
+	}
+	if resp.Usage.CacheReadInputTokens != 2048 || resp.Usage.CacheCreationInputTokens != 0 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+	if resp.Choices[0].Message.Content != "Done." {
+		t.Errorf("unexpected content %q", resp.Choices[0].Message.Content)
+	}
+
+	// cache_control is not in ChatRequest, so such requests stay JSON.
+	body := map[string]any{"model": "anthropic/claude-sonnet-4.6", "messages": []ChatMessage{NewCachedMessage("user", "hi")}}
+	if _, ok := marshalChatRequestProto(body); ok {
+		t.Error("expected a cache_control request to fall back to JSON")
+	}
+}
+
+func TestAnthropicPromptCaching(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(data), `"cache_control":{"type":"ephemeral"}`) {
+			t.Errorf("expected cache_control in request, got %s", data)
+		}
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-6",
+			"content":[{"type":"text","text":"Done."}],"stop_reason":"end_turn",
+			"usage":{"input_tokens":12,"output_tokens":3,"cache_creation_input_tokens":2048,"cache_read_input_tokens":0}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAnthropicClient(testPrivateKey, WithAnthropicAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewAnthropicClient: %v", err)
+	}
+	resp, err := client.Messages.Create(context.Background(), AnthropicCreateParams{
+		Model:     "claude-sonnet-4-6",
+		MaxTokens: 64,
+		Messages: []AnthropicMessage{{Role: "user", Content: []AnthropicContentBlock{
+			{Type: "text", Text: strings.Repeat("policy ", 1000), CacheControl: &CacheControlBlock{Type: "ephemeral"}},
+			{Type: "text", Text: "Summarise."},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("Messages.Create: %v", err)
+	}
+	if resp.Usage.CacheCreationInputTokens != 2048 || resp.Usage.CacheReadInputTokens != 0 || resp.Usage.InputTokens != 12 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
@@ -0,0 +1,252 @@
+package blockrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/BlockRunAI/blockrun-llm-go/internal/chatpb"
+)
+
+//go:generate protoc --go_out=. --go_opt=module=github.com/BlockRunAI/blockrun-llm-go chat.proto
+
+// ContentTypeProtobuf is the media type of protobuf chat completion bodies
+// (see WithProtobuf and chat.proto).
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// protobufAccept prefers protobuf responses while still accepting JSON from
+// a gateway that does not speak it.
+const protobufAccept = ContentTypeProtobuf + ", application/json;q=0.9"
+
+// chatCompletionsPath is the chat completions endpoint.
+const chatCompletionsPath = "/v1/chat/completions"
+
+// WithProtobuf negotiates protobuf for non-streaming chat completions:
+// requests are sent as a chat.proto ChatRequest with Content-Type
+// application/x-protobuf, and Accept asks for a ChatResponse in kind.
+// Protobuf bodies are smaller and faster to decode, which helps batch
+// pipelines. Requests using fields ChatRequest does not carry (tools,
+// response_format, thinking, provider options, multi-modal content, ...)
+// are sent as JSON, and a JSON response is still decoded as JSON, so the
+// option is safe against any gateway. Streaming and the x402 payment
+// headers are unaffected.
+func WithProtobuf() ClientOption {
+	return func(c *LLMClient) {
+		c.protobuf = true
+	}
+}
+
+// encodeRequestBody encodes body for endpoint, returning the bytes and their
+// Content-Type: protobuf for chat completions when WithProtobuf is set and
+// the body fits ChatRequest, JSON otherwise.
+func (bc *baseClient) encodeRequestBody(endpoint string, body map[string]any) ([]byte, string, error) {
+	if bc.protobuf && endpoint == chatCompletionsPath {
+		if data, ok := marshalChatRequestProto(body); ok {
+			return data, ContentTypeProtobuf, nil
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode request body: %w", err)
+	}
+	return data, "application/json", nil
+}
+
+// setBodyHeaders sets req's Content-Type and, for chat completions with
+// WithProtobuf, an Accept header preferring protobuf.
+func (bc *baseClient) setBodyHeaders(req *http.Request, contentType string) {
+	req.Header.Set("Content-Type", contentType)
+	if bc.protobuf && bc.endpointPath(req.URL.String()) == chatCompletionsPath {
+		req.Header.Set("Accept", protobufAccept)
+	}
+}
+
+// decodeChatResponse decodes a chat completion response, as protobuf when
+// header's Content-Type says so and as JSON otherwise. Bodies replayed from
+// the response cache or a deduplicated call come without headers; those are
+// told apart by their first byte, since a JSON object starts with '{' and
+// no ChatResponse field tag is that byte.
+func decodeChatResponse(data []byte, header http.Header) (*ChatResponse, error) {
+	var resp ChatResponse
+	if isProtobufBody(data, header) {
+		var pb chatpb.ChatResponse
+		if err := proto.Unmarshal(data, &pb); err != nil {
+			return nil, fmt.Errorf("failed to decode protobuf response: %w", err)
+		}
+		return chatResponseFromProto(&pb), nil
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+// isProtobufBody reports whether data is a protobuf body, by header when
+// there is one and by its first byte otherwise.
+func isProtobufBody(data []byte, header http.Header) bool {
+	if header != nil {
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		return mediaType == ContentTypeProtobuf
+	}
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] != '{'
+}
+
+// marshalChatRequestProto encodes a chat completion request body as a
+// ChatRequest. It reports false if body has a field ChatRequest does not
+// carry, in which case the request must be sent as JSON.
+func marshalChatRequestProto(body map[string]any) ([]byte, bool) {
+	for key := range body {
+		switch key {
+		case "model", "messages", "max_tokens", "temperature", "top_p", "stop":
+		default:
+			return nil, false
+		}
+	}
+	messages, ok := body["messages"].([]ChatMessage)
+	if !ok {
+		return nil, false
+	}
+
+	req := &chatpb.ChatRequest{}
+	req.Model, _ = body["model"].(string)
+	for i := range messages {
+		if len(messages[i].ContentParts) > 0 || messages[i].CacheControl != nil {
+			return nil, false
+		}
+		req.Messages = append(req.Messages, chatMessageToProto(&messages[i]))
+	}
+	if v, ok := body["max_tokens"]; ok {
+		n, ok := v.(int)
+		if !ok {
+			return nil, false
+		}
+		req.MaxTokens = int32(n)
+	}
+	for key, dst := range map[string]*float64{"temperature": &req.Temperature, "top_p": &req.TopP} {
+		if v, ok := body[key]; ok {
+			f, ok := v.(float64)
+			if !ok {
+				return nil, false
+			}
+			*dst = f
+		}
+	}
+	switch stop := body["stop"].(type) {
+	case nil:
+	case string:
+		req.Stop = []string{stop}
+	case []string:
+		req.Stop = stop
+	default:
+		return nil, false
+	}
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// modelFromRequest returns the model of a request body of the given
+// Content-Type: a JSON body's "model" field or a ChatRequest's model.
+func modelFromRequest(body []byte, contentType string) string {
+	switch contentType {
+	case "application/json":
+		return modelFromBody(body)
+	case ContentTypeProtobuf:
+		return chatRequestModelProto(body)
+	}
+	return ""
+}
+
+// chatRequestModelProto returns the model of an encoded ChatRequest, or ""
+// if body is not one.
+func chatRequestModelProto(body []byte) string {
+	var req chatpb.ChatRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.Model
+}
+
+// chatMessageToProto converts m to a chatpb.ChatMessage.
+func chatMessageToProto(m *ChatMessage) *chatpb.ChatMessage {
+	pb := &chatpb.ChatMessage{
+		Role:             m.Role,
+		Content:          m.Content,
+		ToolCallId:       m.ToolCallID,
+		Name:             m.Name,
+		ReasoningContent: m.ReasoningContent,
+		Thinking:         m.Thinking,
+	}
+	for _, tc := range m.ToolCalls {
+		pb.ToolCalls = append(pb.ToolCalls, &chatpb.ToolCall{
+			Id:       tc.ID,
+			Type:     tc.Type,
+			Function: &chatpb.ToolCallFunction{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+		})
+	}
+	if a := m.Audio; a != nil {
+		pb.Audio = &chatpb.AudioMessageContent{Id: a.ID, ExpiresAt: a.ExpiresAt, Data: a.Data, Transcript: a.Transcript}
+	}
+	return pb
+}
+
+// chatMessageFromProto converts a chatpb.ChatMessage. As with JSON,
+// ThinkingContent is taken from Thinking.
+func chatMessageFromProto(pb *chatpb.ChatMessage) ChatMessage {
+	m := ChatMessage{
+		Role:             pb.GetRole(),
+		Content:          pb.GetContent(),
+		ToolCallID:       pb.GetToolCallId(),
+		Name:             pb.GetName(),
+		ReasoningContent: pb.GetReasoningContent(),
+		Thinking:         pb.GetThinking(),
+		ThinkingContent:  pb.GetThinking(),
+	}
+	for _, tc := range pb.GetToolCalls() {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{
+			ID:       tc.GetId(),
+			Type:     tc.GetType(),
+			Function: ToolCallFunction{Name: tc.GetFunction().GetName(), Arguments: tc.GetFunction().GetArguments()},
+		})
+	}
+	if a := pb.GetAudio(); a != nil {
+		m.Audio = &AudioMessageContent{ID: a.GetId(), ExpiresAt: a.GetExpiresAt(), Data: a.GetData(), Transcript: a.GetTranscript()}
+	}
+	return m
+}
+
+// chatResponseFromProto converts a chatpb.ChatResponse.
+func chatResponseFromProto(pb *chatpb.ChatResponse) *ChatResponse {
+	resp := &ChatResponse{
+		ID:        pb.GetId(),
+		Object:    pb.GetObject(),
+		Created:   pb.GetCreated(),
+		Model:     pb.GetModel(),
+		Citations: pb.GetCitations(),
+	}
+	for _, c := range pb.GetChoices() {
+		resp.Choices = append(resp.Choices, Choice{
+			Index:        int(c.GetIndex()),
+			Message:      chatMessageFromProto(c.GetMessage()),
+			FinishReason: c.GetFinishReason(),
+		})
+	}
+	if u := pb.GetUsage(); u != nil {
+		resp.Usage = Usage{
+			PromptTokens:             int(u.GetPromptTokens()),
+			CompletionTokens:         int(u.GetCompletionTokens()),
+			TotalTokens:              int(u.GetTotalTokens()),
+			NumSourcesUsed:           int(u.GetNumSourcesUsed()),
+			CacheReadInputTokens:     int(u.GetCacheReadInputTokens()),
+			CacheCreationInputTokens: int(u.GetCacheCreationInputTokens()),
+		}
+	}
+	return resp
+}
@@ -0,0 +1,241 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/BlockRunAI/blockrun-llm-go/internal/chatpb"
+)
+
+// marshalChatResponseProto encodes r as a chat.proto ChatResponse, as a
+// protobuf-speaking gateway would.
+func marshalChatResponseProto(r *ChatResponse) []byte {
+	pb := &chatpb.ChatResponse{
+		Id:        r.ID,
+		Object:    r.Object,
+		Created:   r.Created,
+		Model:     r.Model,
+		Citations: r.Citations,
+		Usage: &chatpb.Usage{
+			PromptTokens:             int32(r.Usage.PromptTokens),
+			CompletionTokens:         int32(r.Usage.CompletionTokens),
+			TotalTokens:              int32(r.Usage.TotalTokens),
+			NumSourcesUsed:           int32(r.Usage.NumSourcesUsed),
+			CacheReadInputTokens:     int32(r.Usage.CacheReadInputTokens),
+			CacheCreationInputTokens: int32(r.Usage.CacheCreationInputTokens),
+		},
+	}
+	for i := range r.Choices {
+		c := &r.Choices[i]
+		pb.Choices = append(pb.Choices, &chatpb.Choice{
+			Index:        int32(c.Index),
+			Message:      chatMessageToProto(&c.Message),
+			FinishReason: c.FinishReason,
+		})
+	}
+	data, _ := proto.Marshal(pb)
+	return data
+}
+
+// testChatResponse returns a response whose content is about tokens tokens.
+func testChatResponse(tokens int) *ChatResponse {
+	return &ChatResponse{
+		ID:      "chatcmpl-1",
+		Object:  "chat.completion",
+		Created: 1760000000,
+		Model:   "openai/gpt-4o",
+		Choices: []Choice{{
+			Message: ChatMessage{
+				Role:    "assistant",
+				Content: strings.Repeat("word ", tokens),
+				ToolCalls: []ToolCall{{
+					ID: "call_1", Type: "function",
+					Function: ToolCallFunction{Name: "lookup", Arguments: `{"q":"x"}`},
+				}},
+				Thinking:        "hmm",
+				ThinkingContent: "hmm",
+				Audio:           &AudioMessageContent{ID: "audio_1", ExpiresAt: 1760000000, Data: "UklGRg==", Transcript: "hi"},
+			},
+			FinishReason: "stop",
+		}},
+		Usage:     Usage{PromptTokens: 12, CompletionTokens: tokens, TotalTokens: 12 + tokens, CacheReadInputTokens: 3},
+		Citations: []string{"https://example.com"},
+	}
+}
+
+func TestDecodeChatResponseProtobuf(t *testing.T) {
+	want := testChatResponse(20)
+	data := marshalChatResponseProto(want)
+
+	header := http.Header{"Content-Type": {"application/x-protobuf; charset=binary"}}
+	for name, h := range map[string]http.Header{"content type": header, "sniffed": nil} {
+		got, err := decodeChatResponse(data, h)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %+v, got %+v", name, want, got)
+		}
+	}
+
+	jsonData, _ := json.Marshal(want)
+	if got, err := decodeChatResponse(jsonData, http.Header{"Content-Type": {"application/json"}}); err != nil || got.Choices[0].Message.Content != want.Choices[0].Message.Content {
+		t.Errorf("JSON response not decoded: %v", err)
+	}
+	if _, err := decodeChatResponse(data[:len(data)-3], header); err == nil {
+		t.Error("expected an error for a truncated protobuf response")
+	}
+}
+
+func TestMarshalChatRequestProto(t *testing.T) {
+	body := map[string]any{
+		"model":       "openai/gpt-4o",
+		"messages":    []ChatMessage{{Role: "user", Content: "hi"}},
+		"max_tokens":  100,
+		"temperature": 0.5,
+		"stop":        []string{"END"},
+	}
+	data, ok := marshalChatRequestProto(body)
+	if !ok {
+		t.Fatal("expected the body to fit ChatRequest")
+	}
+	if got := chatRequestModelProto(data); got != "openai/gpt-4o" {
+		t.Errorf("expected model openai/gpt-4o, got %q", got)
+	}
+	if got := modelFromRequest(data, ContentTypeProtobuf); got != "openai/gpt-4o" {
+		t.Errorf("modelFromRequest: expected openai/gpt-4o, got %q", got)
+	}
+
+	var req chatpb.ChatRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Content != "hi" || req.MaxTokens != 100 || req.Temperature != 0.5 ||
+		req.TopP != 0 || !reflect.DeepEqual(req.Stop, []string{"END"}) {
+		t.Errorf("unexpected request %v", &req)
+	}
+
+	for name, extra := range map[string]any{
+		"tools":           []Tool{{Type: "function"}},
+		"thinking":        map[string]any{"type": "enabled"},
+		"search_params":   map[string]string{"mode": "on"},
+		"response_format": map[string]string{"type": "json_object"},
+	} {
+		b := map[string]any{"model": "m", "messages": []ChatMessage{{Role: "user", Content: "hi"}}, name: extra}
+		if _, ok := marshalChatRequestProto(b); ok {
+			t.Errorf("%s: expected JSON fallback", name)
+		}
+	}
+	parts := map[string]any{"model": "m", "messages": []ChatMessage{{Role: "user", ContentParts: []ContentPart{TextContentPart{Text: "hi"}}}}}
+	if _, ok := marshalChatRequestProto(parts); ok {
+		t.Error("content parts: expected JSON fallback")
+	}
+}
+
+func TestWithProtobuf(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var contentTypes, accepts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		accepts = append(accepts, r.Header.Get("Accept"))
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		if r.Header.Get("Content-Type") == ContentTypeProtobuf {
+			if chatRequestModelProto(body) != "openai/gpt-4o" {
+				t.Errorf("unexpected protobuf request %q", body)
+			}
+			w.Header().Set("Content-Type", ContentTypeProtobuf)
+			w.Write(marshalChatResponseProto(testChatResponse(5)))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	store := NewMemoryReceiptStore()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithProtobuf(), WithReceiptStore(store))
+	client.costLog = nil
+	ctx := context.Background()
+
+	resp, err := client.ChatCompletion(ctx, "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != strings.Repeat("word ", 5) {
+		t.Errorf("unexpected content %q", resp.Choices[0].Message.Content)
+	}
+	for i := range contentTypes {
+		if contentTypes[i] != ContentTypeProtobuf || accepts[i] != protobufAccept {
+			t.Errorf("request %d: Content-Type %q, Accept %q", i, contentTypes[i], accepts[i])
+		}
+	}
+	receipts, _ := store.List(time.Time{}, time.Time{})
+	if len(receipts) != 1 || receipts[0].Model != "openai/gpt-4o" {
+		t.Errorf("expected a receipt for openai/gpt-4o, got %+v", receipts)
+	}
+
+	// Tools do not fit ChatRequest, so the request goes as JSON and the
+	// JSON response is decoded as such.
+	contentTypes, accepts = nil, nil
+	opts := &ChatCompletionOptions{Tools: []Tool{{Type: "function", Function: ToolFunction{Name: "lookup"}}}}
+	resp, err = client.ChatCompletion(ctx, "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, opts)
+	if err != nil {
+		t.Fatalf("ChatCompletion with tools failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "ok" {
+		t.Errorf("unexpected content %q", resp.Choices[0].Message.Content)
+	}
+	if contentTypes[0] != "application/json" || accepts[0] != protobufAccept {
+		t.Errorf("fallback: Content-Type %q, Accept %q", contentTypes[0], accepts[0])
+	}
+
+	contentTypes, accepts = nil, nil
+	plain, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	plain.costLog = nil
+	if _, err := plain.ChatCompletion(ctx, "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if contentTypes[0] != "application/json" || accepts[0] != "" {
+		t.Errorf("without WithProtobuf: Content-Type %q, Accept %q", contentTypes[0], accepts[0])
+	}
+}
+
+// The decode benchmarks compare a ~500-token response in each encoding.
+
+func BenchmarkDecodeChatResponseJSON(b *testing.B) {
+	data, _ := json.Marshal(testChatResponse(500))
+	header := http.Header{"Content-Type": {"application/json"}}
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeChatResponse(data, header); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeChatResponseProtobuf(b *testing.B) {
+	data := marshalChatResponseProto(testChatResponse(500))
+	header := http.Header{"Content-Type": {ContentTypeProtobuf}}
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeChatResponse(data, header); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package blockrun
+
+import (
+	"context"
+	"sync"
+)
+
+// providerLimiter caps in-flight chat calls per provider with one channel
+// semaphore per limited provider.
+type providerLimiter struct {
+	limits map[string]int
+	// sems maps a provider to its chan struct{}, created on first use.
+	sems sync.Map
+}
+
+// WithProviderConcurrency caps the chat calls and streams in flight at once
+// for each provider, keyed by the model ID prefix before the "/" (e.g.
+// {"anthropic": 3, "xai": 2}), for providers with strict per-account rate
+// limits. Calls over the cap wait for a slot or their context. Providers
+// without a positive limit are not capped.
+func WithProviderConcurrency(limits map[string]int) ClientOption {
+	return func(c *LLMClient) {
+		l := &providerLimiter{limits: make(map[string]int, len(limits))}
+		for provider, n := range limits {
+			if n > 0 {
+				l.limits[provider] = n
+			}
+		}
+		c.providerLimits = l
+	}
+}
+
+// sem returns provider's semaphore, or nil if it is not limited.
+func (l *providerLimiter) sem(provider string) chan struct{} {
+	n, ok := l.limits[provider]
+	if !ok {
+		return nil
+	}
+	if s, ok := l.sems.Load(provider); ok {
+		return s.(chan struct{})
+	}
+	s, _ := l.sems.LoadOrStore(provider, make(chan struct{}, n))
+	return s.(chan struct{})
+}
+
+// acquire waits for a slot for model's provider and returns its release
+// func. A nil limiter or an unlimited provider never waits.
+func (l *providerLimiter) acquire(ctx context.Context, model string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	sem := l.sem(ModelProvider(model))
+	if sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case sem <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-sem }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ProviderConcurrencyStats returns the calls in flight for each provider
+// limited by WithProviderConcurrency, or nil if it was not used.
+func (c *LLMClient) ProviderConcurrencyStats() map[string]int {
+	if c.providerLimits == nil {
+		return nil
+	}
+	stats := make(map[string]int, len(c.providerLimits.limits))
+	for provider := range c.providerLimits.limits {
+		stats[provider] = 0
+	}
+	c.providerLimits.sems.Range(func(provider, sem any) bool {
+		stats[provider.(string)] = len(sem.(chan struct{}))
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,95 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithProviderConcurrency(t *testing.T) {
+	var inflight, peak atomic.Int32
+	entered := make(chan struct{}, 20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		entered <- struct{}{}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithProviderConcurrency(map[string]int{"anthropic": 3, "xai": 0}))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Chat(context.Background(), "anthropic/claude-sonnet-4.6", "hi")
+			errs <- err
+		}()
+	}
+	<-entered
+	if got := client.ProviderConcurrencyStats(); got["anthropic"] < 1 || got["anthropic"] > 3 || len(got) != 1 {
+		t.Errorf("unexpected stats while busy: %v", got)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+	}
+	if p := peak.Load(); p > 3 || p < 2 {
+		t.Errorf("expected at most 3 concurrent anthropic requests, saw %d", p)
+	}
+	if got := client.ProviderConcurrencyStats(); got["anthropic"] != 0 {
+		t.Errorf("expected no calls in flight afterwards, got %v", got)
+	}
+}
+
+func TestProviderConcurrencyContext(t *testing.T) {
+	l := &providerLimiter{limits: map[string]int{"anthropic": 1}}
+	release, err := l.acquire(context.Background(), "anthropic/claude-opus-4.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free, err := l.acquire(context.Background(), "openai/gpt-4o"); err != nil {
+		t.Errorf("expected unlimited providers not to wait, got %v", err)
+	} else {
+		free()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "anthropic/claude-haiku-4.5"); err != context.DeadlineExceeded {
+		t.Errorf("expected the wait to end with the context, got %v", err)
+	}
+	release()
+	release() // a second release is a no-op
+	next, err := l.acquire(context.Background(), "anthropic/claude-haiku-4.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next()
+
+	var nilClient LLMClient
+	if nilClient.ProviderConcurrencyStats() != nil {
+		t.Error("expected nil stats without WithProviderConcurrency")
+	}
+}
@@ -0,0 +1,73 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProviderOptionsMergedIntoBody(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if body["stream"] == true {
+			w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	opts := &ChatCompletionOptions{
+		Temperature: 0.5,
+		ProviderOptions: map[string]any{
+			"thinking": map[string]any{"type": "enabled", "budget_tokens": 1024},
+		},
+	}
+	messages := []ChatMessage{{Role: "user", Content: "think"}}
+	if _, err := client.ChatCompletion(context.Background(), "anthropic/claude-sonnet-4", messages, opts); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	stream, err := client.ChatCompletionStream(context.Background(), "anthropic/claude-sonnet-4", messages, opts)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	stream.Close()
+
+	for i, body := range bodies {
+		thinking, _ := body["thinking"].(map[string]any)
+		if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(1024) {
+			t.Errorf("request %d: thinking not passed through: %v", i, body["thinking"])
+		}
+		if body["model"] != "anthropic/claude-sonnet-4" || body["temperature"] != 0.5 || body["max_tokens"] != float64(DefaultMaxTokens) {
+			t.Errorf("request %d: standard fields missing: %v", i, body)
+		}
+	}
+}
+
+func TestProviderOptionsReservedKeys(t *testing.T) {
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL("http://127.0.0.1:1"))
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	for _, key := range []string{"model", "messages", "max_tokens", "stream"} {
+		opts := &ChatCompletionOptions{ProviderOptions: map[string]any{key: "injected"}}
+		_, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, opts)
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%s: expected a ValidationError, got %v", key, err)
+			continue
+		}
+		if strings.Join(ve.FieldPath, ".") != "Options.ProviderOptions."+key {
+			t.Errorf("%s: unexpected field path %v", key, ve.FieldPath)
+		}
+		if _, err := client.ChatCompletionStream(context.Background(), "gpt-4o", messages, opts); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: stream accepted a reserved key: %v", key, err)
+		}
+	}
+}
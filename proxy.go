@@ -0,0 +1,61 @@
+package blockrun
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WithHTTPProxy routes all gateway traffic through a fixed proxy. Supported
+// schemes are http://, https:// and socks5:// (socks5h:// resolves hostnames
+// on the proxy); credentials go in the URL userinfo. An invalid URL fails
+// each request with a descriptive error rather than silently going direct.
+func WithHTTPProxy(proxyURL string) ClientOption {
+	return func(c *LLMClient) {
+		c.withTransport(func(t *http.Transport) { t.Proxy = fixedProxy(proxyURL) })
+	}
+}
+
+// WithProxyFromEnv takes the proxy from HTTP_PROXY / HTTPS_PROXY / NO_PROXY.
+// This is the default; use it to undo an earlier WithHTTPProxy.
+func WithProxyFromEnv() ClientOption {
+	return func(c *LLMClient) {
+		c.withTransport(func(t *http.Transport) { t.Proxy = http.ProxyFromEnvironment })
+	}
+}
+
+// WithImageHTTPProxy routes image traffic through a fixed proxy (see
+// WithHTTPProxy).
+func WithImageHTTPProxy(proxyURL string) ImageClientOption {
+	return func(c *ImageClient) {
+		c.withTransport(func(t *http.Transport) { t.Proxy = fixedProxy(proxyURL) })
+	}
+}
+
+// WithImageProxyFromEnv takes the proxy from the environment (the default).
+func WithImageProxyFromEnv() ImageClientOption {
+	return func(c *ImageClient) {
+		c.withTransport(func(t *http.Transport) { t.Proxy = http.ProxyFromEnvironment })
+	}
+}
+
+// fixedProxy returns an http.Transport.Proxy func for proxyURL. net/http
+// speaks SOCKS5 natively for socks5:// proxies, so no extra dialer is needed.
+func fixedProxy(proxyURL string) func(*http.Request) (*url.URL, error) {
+	u, err := url.Parse(proxyURL)
+	if err == nil {
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+			if u.Host == "" {
+				err = fmt.Errorf("missing host")
+			}
+		default:
+			err = fmt.Errorf("unsupported scheme %q (want http, https or socks5)", u.Scheme)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(u)
+}
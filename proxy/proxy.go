@@ -0,0 +1,378 @@
+// Package proxy serves an OpenAI-compatible HTTP API backed by BlockRun, so
+// existing OpenAI SDK integrations can switch to BlockRun by changing only
+// their base URL.
+//
+//	client, _ := blockrun.NewLLMClient("")
+//	images, _ := blockrun.NewImageClient("")
+//	http.ListenAndServe(":8080", proxy.NewOpenAICompatServer(client, images))
+//
+// Then point an OpenAI client at http://localhost:8080/v1. Every request is
+// paid by the BlockRun wallet of the client that serves it; with a
+// KeyResolver, each caller's bearer token selects its own wallet.
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// KeyResolver maps the bearer token of an incoming request to the BlockRun
+// wallet private key that should pay for it. Returning "" rejects the request
+// with 401.
+type KeyResolver func(bearerToken string) string
+
+// Option configures the proxy server.
+type Option func(*Server)
+
+// WithKeyResolver makes the server pay for each request from the wallet its
+// bearer token resolves to, instead of the default clients.
+func WithKeyResolver(resolve KeyResolver) Option {
+	return func(s *Server) {
+		s.resolve = resolve
+	}
+}
+
+// WithClientOptions sets options for the per-wallet LLM clients created when
+// a KeyResolver is configured (e.g. WithAPIURL).
+func WithClientOptions(opts ...blockrun.ClientOption) Option {
+	return func(s *Server) {
+		s.clientOpts = opts
+	}
+}
+
+// WithImageClientOptions sets options for the per-wallet image clients
+// created when a KeyResolver is configured.
+func WithImageClientOptions(opts ...blockrun.ImageClientOption) Option {
+	return func(s *Server) {
+		s.imageOpts = opts
+	}
+}
+
+// Server is the OpenAI-compatible handler returned by NewOpenAICompatServer.
+type Server struct {
+	client *blockrun.LLMClient
+	images *blockrun.ImageClient
+
+	resolve    KeyResolver
+	clientOpts []blockrun.ClientOption
+	imageOpts  []blockrun.ImageClientOption
+
+	mu      sync.Mutex
+	wallets map[string]*wallet
+
+	mux *http.ServeMux
+}
+
+// wallet holds the clients for one resolved private key.
+type wallet struct {
+	client *blockrun.LLMClient
+	images *blockrun.ImageClient
+}
+
+// NewOpenAICompatServer returns a handler serving /v1/chat/completions
+// (including SSE streaming), /v1/models, /v1/images/generations and
+// /v1/embeddings. imageClient may be nil, in which case image requests get
+// 404 unless a KeyResolver supplies per-wallet clients.
+func NewOpenAICompatServer(client *blockrun.LLMClient, imageClient *blockrun.ImageClient, opts ...Option) http.Handler {
+	s := &Server{
+		client:  client,
+		images:  imageClient,
+		wallets: make(map[string]*wallet),
+		mux:     http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("/v1/chat/completions", s.handleChat)
+	s.mux.HandleFunc("/v1/models", s.handleModels)
+	s.mux.HandleFunc("/v1/images/generations", s.handleImages)
+	s.mux.HandleFunc("/v1/embeddings", s.handleEmbeddings)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// clientsFor returns the clients that should serve r, honouring the
+// KeyResolver. It writes an error response and returns nil on failure.
+func (s *Server) clientsFor(w http.ResponseWriter, r *http.Request) *wallet {
+	if s.resolve == nil {
+		return &wallet{client: s.client, images: s.images}
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	key := ""
+	if token != "" {
+		key = s.resolve(token)
+	}
+	if key == "" {
+		writeError(w, http.StatusUnauthorized, "invalid_api_key", "Invalid or missing bearer token")
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if wl, ok := s.wallets[key]; ok {
+		return wl
+	}
+	client, err := blockrun.NewLLMClient(key, s.clientOpts...)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid_api_key", "Bearer token did not resolve to a valid wallet key")
+		return nil
+	}
+	images, err := blockrun.NewImageClient(key, s.imageOpts...)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid_api_key", "Bearer token did not resolve to a valid wallet key")
+		return nil
+	}
+	wl := &wallet{client: client, images: images}
+	s.wallets[key] = wl
+	return wl
+}
+
+// chatRequest is the subset of the OpenAI chat request the gateway honours.
+type chatRequest struct {
+	Model          string                 `json:"model"`
+	Messages       []blockrun.ChatMessage `json:"messages"`
+	MaxTokens      int                    `json:"max_tokens"`
+	MaxCompletion  int                    `json:"max_completion_tokens"`
+	Temperature    float64                `json:"temperature"`
+	TopP           float64                `json:"top_p"`
+	Stream         bool                   `json:"stream"`
+	Tools          []blockrun.Tool        `json:"tools"`
+	ToolChoice     any                    `json:"tool_choice"`
+	ResponseFormat any                    `json:"response_format"`
+	Stop           any                    `json:"stop"`
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	wl := s.clientsFor(w, r)
+	if wl == nil {
+		return
+	}
+
+	var req chatRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	opts := &blockrun.ChatCompletionOptions{
+		MaxTokens:      req.MaxTokens,
+		Temperature:    req.Temperature,
+		TopP:           req.TopP,
+		Tools:          req.Tools,
+		ToolChoice:     req.ToolChoice,
+		ResponseFormat: req.ResponseFormat,
+		Stop:           req.Stop,
+	}
+	if opts.MaxTokens == 0 {
+		opts.MaxTokens = req.MaxCompletion
+	}
+
+	if !req.Stream {
+		resp, err := wl.client.ChatCompletion(r.Context(), req.Model, req.Messages, opts)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	stream, err := wl.client.ChatCompletionStream(r.Context(), req.Model, req.Messages, opts)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	defer stream.Close()
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for {
+		chunk, err := stream.Next()
+		if err != nil || chunk == nil {
+			// Headers are already sent, so a mid-stream error can only end
+			// the stream; [DONE] is still written so clients terminate.
+			break
+		}
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	io.WriteString(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "Use GET")
+		return
+	}
+	wl := s.clientsFor(w, r)
+	if wl == nil {
+		return
+	}
+	models, err := wl.client.ListModels(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	for i := range models {
+		if models[i].Object == "" {
+			models[i].Object = "model"
+		}
+		if models[i].Created == 0 {
+			models[i].Created = time.Now().Unix()
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": models})
+}
+
+func (s *Server) handleImages(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	wl := s.clientsFor(w, r)
+	if wl == nil {
+		return
+	}
+	if wl.images == nil {
+		writeError(w, http.StatusNotFound, "not_found", "Image generation is not enabled on this proxy")
+		return
+	}
+
+	var req struct {
+		Prompt  string `json:"prompt"`
+		Model   string `json:"model"`
+		Size    string `json:"size"`
+		N       int    `json:"n"`
+		Quality string `json:"quality"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	resp, err := wl.images.Generate(r.Context(), req.Prompt, &blockrun.ImageGenerateOptions{
+		Model:   req.Model,
+		Size:    req.Size,
+		N:       req.N,
+		Quality: req.Quality,
+	})
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	wl := s.clientsFor(w, r)
+	if wl == nil {
+		return
+	}
+
+	var req struct {
+		Model      string          `json:"model"`
+		Input      json.RawMessage `json:"input"`
+		Dimensions int             `json:"dimensions"`
+		User       string          `json:"user"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	// OpenAI accepts a single string or an array of strings.
+	var inputs []string
+	if err := json.Unmarshal(req.Input, &inputs); err != nil {
+		var single string
+		if err := json.Unmarshal(req.Input, &single); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "input must be a string or an array of strings")
+			return
+		}
+		inputs = []string{single}
+	}
+
+	resp, err := wl.client.CreateEmbedding(r.Context(), req.Model, inputs, &blockrun.EmbeddingOptions{
+		Dimensions: req.Dimensions,
+		User:       req.User,
+	})
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// requirePost rejects non-POST requests.
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+	w.Header().Set("Allow", http.MethodPost)
+	writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "Use POST")
+	return false
+}
+
+// decodeBody decodes the JSON request body into v, writing a 400 on failure.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(io.LimitReader(r.Body, 32<<20)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid JSON body: %v", err))
+		return false
+	}
+	return true
+}
+
+// writeClientError maps a blockrun client error onto an OpenAI error response.
+func writeClientError(w http.ResponseWriter, err error) {
+	var validationErr *blockrun.ValidationError
+	var paymentErr *blockrun.PaymentError
+	var apiErr *blockrun.APIError
+	switch {
+	case errors.As(err, &validationErr):
+		writeError(w, http.StatusBadRequest, "invalid_request_error", validationErr.Error())
+	case errors.As(err, &paymentErr):
+		writeError(w, http.StatusPaymentRequired, "insufficient_quota", paymentErr.Error())
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 400:
+		writeError(w, apiErr.StatusCode, "api_error", apiErr.Error())
+	default:
+		writeError(w, http.StatusBadGateway, "api_error", err.Error())
+	}
+}
+
+// writeError writes an OpenAI-style error body.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    code,
+			"code":    code,
+		},
+	})
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
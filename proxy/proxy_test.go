@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// newGateway mocks the BlockRun endpoints the proxy calls (free, no 402).
+func newGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			if body["stream"] == true {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, piece := range []string{"Hel", "lo"} {
+					fmt.Fprintf(w, "data: {\"id\":\"s1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", piece)
+				}
+				io.WriteString(w, "data: [DONE]\n\n")
+				return
+			}
+			w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+		case "/v1/models":
+			w.Write([]byte(`{"data":[{"id":"openai/gpt-4o","owned_by":"openai"}]}`))
+		case "/v1/images/generations":
+			w.Write([]byte(`{"created":1,"data":[{"url":"https://cdn.example.com/cat.png"}]}`))
+		case "/v1/embeddings":
+			w.Write([]byte(`{"object":"list","model":"text-embedding-3-small","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]},{"object":"embedding","index":1,"embedding":[0.3,0.4]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+// newOpenAIClient starts the proxy and returns an OpenAI SDK client for it.
+func newOpenAIClient(t *testing.T, token string) *openai.Client {
+	t.Helper()
+	gw := newGateway(t)
+	handler := NewOpenAICompatServer(nil, nil,
+		WithKeyResolver(func(bearer string) string {
+			if bearer == "sk-test" {
+				return testPrivateKey
+			}
+			return ""
+		}),
+		WithClientOptions(blockrun.WithAPIURL(gw.URL)),
+		WithImageClientOptions(blockrun.WithImageAPIURL(gw.URL)),
+	)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := openai.DefaultConfig(token)
+	cfg.BaseURL = srv.URL + "/v1"
+	return openai.NewClientWithConfig(cfg)
+}
+
+func TestOpenAICompatRoundTrip(t *testing.T) {
+	client := newOpenAIClient(t, "sk-test")
+	ctx := context.Background()
+
+	chat, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	if chat.Choices[0].Message.Content != "Hello" || chat.Usage.TotalTokens != 4 {
+		t.Errorf("unexpected chat response: %+v", chat)
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if len(models.Models) != 1 || models.Models[0].ID != "openai/gpt-4o" || models.Models[0].OwnedBy != "openai" {
+		t.Errorf("unexpected models: %+v", models.Models)
+	}
+
+	img, err := client.CreateImage(ctx, openai.ImageRequest{Prompt: "a cat", Model: "openai/dall-e-3"})
+	if err != nil {
+		t.Fatalf("CreateImage: %v", err)
+	}
+	if len(img.Data) != 1 || img.Data[0].URL != "https://cdn.example.com/cat.png" {
+		t.Errorf("unexpected image response: %+v", img)
+	}
+
+	emb, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: []string{"a", "b"},
+		Model: openai.SmallEmbedding3,
+	})
+	if err != nil {
+		t.Fatalf("CreateEmbeddings: %v", err)
+	}
+	if len(emb.Data) != 2 || emb.Data[1].Embedding[1] != 0.4 {
+		t.Errorf("unexpected embeddings: %+v", emb.Data)
+	}
+}
+
+func TestOpenAICompatStreaming(t *testing.T) {
+	client := newOpenAIClient(t, "sk-test")
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+		Stream:   true,
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream: %v", err)
+	}
+	defer stream.Close()
+
+	var text string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		text += chunk.Choices[0].Delta.Content
+	}
+	if text != "Hello" {
+		t.Errorf("expected streamed text Hello, got %q", text)
+	}
+}
+
+func TestOpenAICompatRejectsUnknownToken(t *testing.T) {
+	client := newOpenAIClient(t, "sk-wrong")
+
+	_, err := client.ListModels(context.Background())
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 APIError, got %v", err)
+	}
+}
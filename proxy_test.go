@@ -0,0 +1,166 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// startSOCKS5Proxy runs a minimal no-auth SOCKS5 CONNECT proxy and returns its
+// address and a counter of tunnelled connections.
+func startSOCKS5Proxy(t *testing.T) (string, *int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var tunnels int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				target, err := socks5Handshake(conn)
+				if err != nil {
+					return
+				}
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+				atomic.AddInt32(&tunnels, 1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return ln.Addr().String(), &tunnels
+}
+
+// socks5Handshake negotiates no-auth and reads a CONNECT request, returning
+// the requested host:port.
+func socks5Handshake(conn net.Conn) (string, error) {
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(conn, hdr); err != nil || hdr[0] != 5 {
+		return "", errors.New("bad greeting")
+	}
+	if _, err := io.ReadFull(conn, make([]byte, hdr[1])); err != nil {
+		return "", err
+	}
+	conn.Write([]byte{5, 0})
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil || req[1] != 1 {
+		return "", errors.New("bad request")
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return "", err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", errors.New("unsupported address type")
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+func TestWithHTTPProxySOCKS5(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	proxyAddr, tunnels := startSOCKS5Proxy(t)
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithHTTPProxy("socks5://"+proxyAddr))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err != nil {
+		t.Fatalf("ChatCompletion via SOCKS5: %v", err)
+	}
+	if atomic.LoadInt32(tunnels) == 0 {
+		t.Error("expected the request to be tunnelled through the SOCKS5 proxy")
+	}
+}
+
+func TestWithImageHTTPProxy(t *testing.T) {
+	var proxied int32
+	// A plain HTTP proxy sees absolute-form request URIs.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.IsAbs() {
+			atomic.AddInt32(&proxied, 1)
+		}
+		w.Write([]byte(`{"created":1,"data":[{"url":"https://cdn.example.com/a.png"}]}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewImageClient(testPrivateKey, WithImageAPIURL("http://gateway.invalid/api"), WithImageHTTPProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("NewImageClient: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), "a cat", nil); err != nil {
+		t.Fatalf("Generate via HTTP proxy: %v", err)
+	}
+	if proxied == 0 {
+		t.Error("expected the image request to go through the HTTP proxy")
+	}
+}
+
+func TestWithHTTPProxyInvalidURL(t *testing.T) {
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL("http://gateway.invalid"), WithHTTPProxy("ftp://proxy:21"))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	_, err = client.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Fatalf("expected unsupported scheme error, got %v", err)
+	}
+}
+
+func TestWithProxyFromEnvIsDefault(t *testing.T) {
+	client, err := NewLLMClient(testPrivateKey, WithHTTPProxy("http://proxy:3128"), WithProxyFromEnv())
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	transport := client.httpClient.Transport.(*http.Transport)
+	req, _ := http.NewRequest("GET", "http://127.0.0.1/", nil)
+	if u, err := transport.Proxy(req); err != nil || u != nil {
+		t.Errorf("expected environment proxy (none for localhost), got %v, %v", u, err)
+	}
+}
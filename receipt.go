@@ -0,0 +1,276 @@
+package blockrun
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Receipt records one settled x402 payment.
+type Receipt struct {
+	Timestamp time.Time `json:"ts"`
+	Endpoint  string    `json:"endpoint"`
+	Model     string    `json:"model,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	Amount    string    `json:"amount"` // micro-USDC, as quoted by the gateway
+	CostUSD   float64   `json:"cost_usd"`
+	Network   string    `json:"network,omitempty"`
+	PayTo     string    `json:"pay_to,omitempty"`
+	// Nonce is the EIP-3009 authorization nonce of the payment, and
+	// ResourceURL the resource it paid for; Solana payments have no nonce.
+	Nonce       string            `json:"nonce,omitempty"`
+	ResourceURL string            `json:"resource_url,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	// Shadow marks a shadow call's payment (see WithShadowModel).
+	Shadow bool `json:"shadow,omitempty"`
+}
+
+// ReceiptStore persists payment receipts. Implementations must be safe for
+// concurrent use.
+type ReceiptStore interface {
+	// Save appends a receipt.
+	Save(r Receipt) error
+	// List returns receipts with since <= Timestamp < until, oldest first.
+	// A zero until means no upper bound.
+	List(since, until time.Time) ([]Receipt, error)
+}
+
+// WithReceiptStore records a Receipt for every paid call in store.
+func WithReceiptStore(store ReceiptStore) ClientOption {
+	return func(c *LLMClient) {
+		c.receipts = store
+	}
+}
+
+// MemoryReceiptStore keeps receipts in process memory.
+type MemoryReceiptStore struct {
+	mu       sync.Mutex
+	receipts []Receipt
+}
+
+// NewMemoryReceiptStore creates an empty in-memory receipt store.
+func NewMemoryReceiptStore() *MemoryReceiptStore {
+	return &MemoryReceiptStore{}
+}
+
+// Save appends a receipt.
+func (s *MemoryReceiptStore) Save(r Receipt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receipts = append(s.receipts, r)
+	return nil
+}
+
+// List returns receipts in [since, until).
+func (s *MemoryReceiptStore) List(since, until time.Time) ([]Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filterReceipts(s.receipts, since, until), nil
+}
+
+// SpendingByTag totals spending since the given time by the value of tag.
+func (s *MemoryReceiptStore) SpendingByTag(tag string, since time.Time) (map[string]float64, error) {
+	return SpendingByTag(s, tag, since)
+}
+
+// ExportTaggedCSV writes a TaggedSpendingReport for [since, until) as CSV.
+func (s *MemoryReceiptStore) ExportTaggedCSV(w io.Writer, since, until time.Time) error {
+	return ExportTaggedCSV(s, w, since, until)
+}
+
+// FileReceiptStore appends receipts to a JSONL file, one receipt per line.
+type FileReceiptStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileReceiptStore creates a store writing to path. An empty path uses
+// ~/.blockrun/receipts.jsonl.
+func NewFileReceiptStore(path string) *FileReceiptStore {
+	if path == "" {
+		dir := filepath.Join(os.Getenv("HOME"), ".blockrun")
+		os.MkdirAll(dir, 0755)
+		path = filepath.Join(dir, "receipts.jsonl")
+	}
+	return &FileReceiptStore{path: path}
+}
+
+// Save appends a receipt to the file.
+func (s *FileReceiptStore) Save(r Receipt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// List reads receipts in [since, until) from the file. Malformed lines are
+// skipped.
+func (s *FileReceiptStore) List(since, until time.Time) ([]Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var all []Receipt
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r Receipt
+		if err := json.Unmarshal(line, &r); err != nil {
+			continue // skip malformed lines
+		}
+		all = append(all, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return filterReceipts(all, since, until), nil
+}
+
+// SpendingByTag totals spending since the given time by the value of tag.
+func (s *FileReceiptStore) SpendingByTag(tag string, since time.Time) (map[string]float64, error) {
+	return SpendingByTag(s, tag, since)
+}
+
+// ExportTaggedCSV writes a TaggedSpendingReport for [since, until) as CSV.
+func (s *FileReceiptStore) ExportTaggedCSV(w io.Writer, since, until time.Time) error {
+	return ExportTaggedCSV(s, w, since, until)
+}
+
+// filterReceipts returns the receipts in [since, until); a zero until is open.
+func filterReceipts(receipts []Receipt, since, until time.Time) []Receipt {
+	var out []Receipt
+	for _, r := range receipts {
+		if r.Timestamp.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !r.Timestamp.Before(until) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// SpendingByTag returns {tag value → total USD} for receipts in store since
+// the given time. Receipts without the tag are not counted.
+func SpendingByTag(store ReceiptStore, tag string, since time.Time) (map[string]float64, error) {
+	receipts, err := store.List(since, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]float64)
+	for _, r := range receipts {
+		if value, ok := r.Tags[tag]; ok {
+			totals[value] += r.CostUSD
+		}
+	}
+	return totals, nil
+}
+
+// TaggedSpendingRow is one (tag, value) line of a TaggedSpendingReport.
+type TaggedSpendingRow struct {
+	Tag      string
+	Value    string
+	Calls    int
+	TotalUSD float64
+}
+
+// TaggedSpendingReport breaks spending in a time range down by every cost tag
+// seen. A receipt with several tags contributes to several rows, so rows do
+// not sum to the overall total; untagged receipts appear in no row.
+type TaggedSpendingReport struct {
+	Since time.Time
+	Until time.Time
+	Rows  []TaggedSpendingRow // sorted by Tag, then Value
+}
+
+// NewTaggedSpendingReport builds a report from the receipts in [since, until).
+func NewTaggedSpendingReport(store ReceiptStore, since, until time.Time) (*TaggedSpendingReport, error) {
+	receipts, err := store.List(since, until)
+	if err != nil {
+		return nil, err
+	}
+	type tagValue struct{ tag, value string }
+	rows := make(map[tagValue]*TaggedSpendingRow)
+	for _, r := range receipts {
+		for tag, value := range r.Tags {
+			row := rows[tagValue{tag, value}]
+			if row == nil {
+				row = &TaggedSpendingRow{Tag: tag, Value: value}
+				rows[tagValue{tag, value}] = row
+			}
+			row.Calls++
+			row.TotalUSD += r.CostUSD
+		}
+	}
+
+	report := &TaggedSpendingReport{Since: since, Until: until}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Tag != report.Rows[j].Tag {
+			return report.Rows[i].Tag < report.Rows[j].Tag
+		}
+		return report.Rows[i].Value < report.Rows[j].Value
+	})
+	return report, nil
+}
+
+// WriteCSV writes the report as CSV with a tag,value,calls,total_usd header.
+func (r *TaggedSpendingReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"tag", "value", "calls", "total_usd"}); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		record := []string{
+			row.Tag,
+			row.Value,
+			strconv.Itoa(row.Calls),
+			strconv.FormatFloat(row.TotalUSD, 'f', 6, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportTaggedCSV writes the TaggedSpendingReport for [since, until) as CSV.
+func ExportTaggedCSV(store ReceiptStore, w io.Writer, since, until time.Time) error {
+	report, err := NewTaggedSpendingReport(store, since, until)
+	if err != nil {
+		return err
+	}
+	return report.WriteCSV(w)
+}
@@ -0,0 +1,108 @@
+package blockrun
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// receiptCSVHeader is the header row of ExportReceiptsCSV.
+var receiptCSVHeader = []string{
+	"timestamp", "model", "provider", "amount_usdc", "cost_usd",
+	"pay_to", "network", "nonce", "resource_url",
+}
+
+// ExportReceiptsCSV writes the receipts in store with since <= Timestamp <
+// until (a zero until is open) as CSV, oldest first, one row per payment
+// under a timestamp,model,provider,amount_usdc,cost_usd,pay_to,network,
+// nonce,resource_url header. Timestamps are RFC 3339 UTC and amount_usdc is
+// the quoted amount in USDC.
+func ExportReceiptsCSV(store ReceiptStore, w io.Writer, since, until time.Time) error {
+	receipts, err := store.List(since, until)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(receiptCSVHeader); err != nil {
+		return err
+	}
+	for _, r := range receipts {
+		record := []string{
+			r.Timestamp.UTC().Format(time.RFC3339),
+			r.Model,
+			r.Provider,
+			microUSDCString(r.Amount),
+			strconv.FormatFloat(r.CostUSD, 'f', 6, 64),
+			r.PayTo,
+			r.Network,
+			r.Nonce,
+			r.ResourceURL,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportReceiptsJSON writes the receipts in [since, until) as an indented
+// JSON array of Receipt, oldest first; no receipts is an empty array.
+func ExportReceiptsJSON(store ReceiptStore, w io.Writer, since, until time.Time) error {
+	receipts, err := store.List(since, until)
+	if err != nil {
+		return err
+	}
+	if receipts == nil {
+		receipts = []Receipt{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(receipts)
+}
+
+// ExportReceiptsParquet would write the receipts in [since, until) as an
+// Apache Parquet file. It returns ErrNotSupported: Parquet needs the
+// github.com/apache/arrow-go/v18/parquet module, which the SDK does not
+// depend on. Use ExportReceiptsCSV and convert, or add that module and
+// write the Receipt fields as columns.
+func ExportReceiptsParquet(store ReceiptStore, w io.Writer, since, until time.Time) error {
+	return ErrNotSupported
+}
+
+// microUSDCString formats a micro-USDC amount ("1500") as USDC
+// ("0.001500"). Amounts that are not integers are returned unchanged.
+func microUSDCString(amount string) string {
+	micro, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return amount
+	}
+	return new(big.Rat).SetFrac(micro, big.NewInt(1_000_000)).FloatString(6)
+}
+
+// paymentPayloadDetails returns the authorization nonce and resource URL of
+// a base64 x402 payment payload, or empty strings for what it lacks.
+func paymentPayloadDetails(payload string) (nonce, resourceURL string) {
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ""
+	}
+	var p struct {
+		Resource struct {
+			URL string `json:"url"`
+		} `json:"resource"`
+		Payload struct {
+			Authorization struct {
+				Nonce string `json:"nonce"`
+			} `json:"authorization"`
+		} `json:"payload"`
+	}
+	if json.Unmarshal(data, &p) != nil {
+		return "", ""
+	}
+	return p.Payload.Authorization.Nonce, p.Resource.URL
+}
@@ -0,0 +1,118 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exportFixtures returns a store holding five receipts, one a day from
+// 2024-01-01.
+func exportFixtures() *MemoryReceiptStore {
+	store := NewMemoryReceiptStore()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		store.Save(Receipt{
+			Timestamp:   start.AddDate(0, 0, i),
+			Endpoint:    "/v1/chat/completions",
+			Model:       "openai/gpt-4o",
+			Provider:    "openai",
+			Amount:      "1500",
+			CostUSD:     0.0015,
+			Network:     "eip155:8453",
+			PayTo:       "0xpayee",
+			Nonce:       "0xnonce",
+			ResourceURL: "https://blockrun.ai/api/v1/chat/completions",
+		})
+	}
+	return store
+}
+
+func TestExportReceiptsCSV(t *testing.T) {
+	store := exportFixtures()
+	var buf bytes.Buffer
+	if err := ExportReceiptsCSV(store, &buf, time.Time{}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(rows[0], ","); got != "timestamp,model,provider,amount_usdc,cost_usd,pay_to,network,nonce,resource_url" {
+		t.Errorf("unexpected header %q", got)
+	}
+	if len(rows) != 6 {
+		t.Fatalf("expected 5 data rows, got %d", len(rows)-1)
+	}
+	want := []string{"2024-01-01T12:00:00Z", "openai/gpt-4o", "openai", "0.001500", "0.001500", "0xpayee", "eip155:8453", "0xnonce", "https://blockrun.ai/api/v1/chat/completions"}
+	if strings.Join(rows[1], ",") != strings.Join(want, ",") {
+		t.Errorf("expected row %v, got %v", want, rows[1])
+	}
+
+	buf.Reset()
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)
+	if err := ExportReceiptsCSV(store, &buf, since, until); err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ := csv.NewReader(&buf).ReadAll(); len(rows) != 3 {
+		t.Errorf("expected 2 rows in range, got %d", len(rows)-1)
+	}
+}
+
+func TestExportReceiptsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportReceiptsJSON(exportFixtures(), &buf, time.Time{}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	var receipts []Receipt
+	if err := json.Unmarshal(buf.Bytes(), &receipts); err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 5 || receipts[0].Nonce != "0xnonce" {
+		t.Errorf("unexpected receipts %+v", receipts)
+	}
+
+	buf.Reset()
+	if err := ExportReceiptsJSON(NewMemoryReceiptStore(), &buf, time.Time{}, time.Time{}); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("expected an empty array, got %q (%v)", buf.String(), err)
+	}
+
+	if err := ExportReceiptsParquet(exportFixtures(), &buf, time.Time{}, time.Time{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestReceiptRecordsNonceAndResource(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	store := NewMemoryReceiptStore()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithReceiptStore(store))
+	client.costLog = nil
+	if _, err := client.Chat(context.Background(), "openai/gpt-4o", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	receipts, _ := store.List(time.Time{}, time.Time{})
+	if len(receipts) != 1 {
+		t.Fatalf("expected 1 receipt, got %d", len(receipts))
+	}
+	if r := receipts[0]; !strings.HasPrefix(r.Nonce, "0x") || len(r.Nonce) != 66 || r.ResourceURL == "" {
+		t.Errorf("expected a nonce and resource URL, got %q, %q", r.Nonce, r.ResourceURL)
+	}
+}
@@ -0,0 +1,153 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingSpan is a span that keeps the attributes set on it.
+type recordingSpan struct {
+	noop.Span
+	attrs map[attribute.Key]string
+}
+
+func (s *recordingSpan) IsRecording() bool { return true }
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value.AsString()
+	}
+}
+
+func seedTaggedReceipts(t *testing.T, store ReceiptStore, base time.Time) {
+	t.Helper()
+	receipts := []Receipt{
+		{Timestamp: base.Add(-time.Hour), CostUSD: 5.00, Tags: map[string]string{"project": "alpha"}}, // before window
+		{Timestamp: base, CostUSD: 0.10, Tags: map[string]string{"project": "alpha", "team": "ml"}},
+		{Timestamp: base.Add(time.Minute), CostUSD: 0.25, Tags: map[string]string{"project": "alpha"}},
+		{Timestamp: base.Add(2 * time.Minute), CostUSD: 0.05, Tags: map[string]string{"project": "beta", "team": "ml"}},
+		{Timestamp: base.Add(3 * time.Minute), CostUSD: 1.00}, // untagged
+	}
+	for _, r := range receipts {
+		if err := store.Save(r); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+func TestSpendingByTag(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	stores := map[string]interface {
+		ReceiptStore
+		SpendingByTag(string, time.Time) (map[string]float64, error)
+	}{
+		"memory": NewMemoryReceiptStore(),
+		"file":   NewFileReceiptStore(filepath.Join(t.TempDir(), "receipts.jsonl")),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			seedTaggedReceipts(t, store, base)
+
+			byProject, err := store.SpendingByTag("project", base)
+			if err != nil {
+				t.Fatalf("SpendingByTag: %v", err)
+			}
+			if len(byProject) != 2 || math.Abs(byProject["alpha"]-0.35) > 1e-9 || math.Abs(byProject["beta"]-0.05) > 1e-9 {
+				t.Errorf("unexpected project breakdown: %v", byProject)
+			}
+
+			byTeam, err := store.SpendingByTag("team", time.Time{})
+			if err != nil {
+				t.Fatalf("SpendingByTag: %v", err)
+			}
+			if len(byTeam) != 1 || math.Abs(byTeam["ml"]-0.15) > 1e-9 {
+				t.Errorf("unexpected team breakdown: %v", byTeam)
+			}
+		})
+	}
+}
+
+func TestExportTaggedCSV(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryReceiptStore()
+	seedTaggedReceipts(t, store, base)
+
+	var buf bytes.Buffer
+	if err := store.ExportTaggedCSV(&buf, base, base.Add(2*time.Minute)); err != nil {
+		t.Fatalf("ExportTaggedCSV: %v", err)
+	}
+	want := "tag,value,calls,total_usd\n" +
+		"project,alpha,2,0.350000\n" +
+		"team,ml,1,0.100000\n"
+	if buf.String() != want {
+		t.Errorf("CSV mismatch:\n got: %q\nwant: %q", buf.String(), want)
+	}
+}
+
+func TestCostTagsRecordedOnReceipt(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "20000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	store := NewMemoryReceiptStore()
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithReceiptStore(store))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	client.costLog = nil
+	client.SetDefaultTags(map[string]string{"env": "prod", "project": "default"})
+
+	span := &recordingSpan{attrs: map[attribute.Key]string{}}
+	ctx := trace.ContextWithSpan(context.Background(), span)
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatCompletion(ctx, "gpt-4o", messages, nil,
+		WithCostTag("project", "alpha"), WithCostTag("team", "ml")); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	receipts, _ := store.List(time.Time{}, time.Time{})
+	if len(receipts) != 1 {
+		t.Fatalf("expected 1 receipt, got %d", len(receipts))
+	}
+	r := receipts[0]
+	if r.Endpoint != "/v1/chat/completions" || r.Model != "gpt-4o" || r.CostUSD != 0.02 || r.Network != "eip155:8453" {
+		t.Errorf("unexpected receipt: %+v", r)
+	}
+	want := map[string]string{"env": "prod", "project": "alpha", "team": "ml"}
+	if len(r.Tags) != len(want) {
+		t.Fatalf("tags = %v, want %v", r.Tags, want)
+	}
+	for k, v := range want {
+		if r.Tags[k] != v {
+			t.Errorf("tag %s = %q, want %q", k, r.Tags[k], v)
+		}
+		if got := span.attrs[attribute.Key("blockrun.tag."+k)]; got != v {
+			t.Errorf("span attribute blockrun.tag.%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestWithCostTagDoesNotLeakIntoParentContext(t *testing.T) {
+	parent := WithRequestOptions(context.Background(), WithCostTag("project", "alpha"))
+	_ = WithRequestOptions(parent, WithCostTag("team", "ml"))
+	if tags := requestConfigFrom(parent).tags; len(tags) != 1 {
+		t.Errorf("expected parent tags to be unchanged, got %v", tags)
+	}
+}
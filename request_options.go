@@ -0,0 +1,74 @@
+package blockrun
+
+import (
+	"context"
+	"time"
+)
+
+// RequestOption configures a single API call, as opposed to ClientOption
+// which configures the client for every call it makes.
+//
+// Pass RequestOptions directly to ChatCompletion / ChatCompletionStream, or
+// attach them to a context with WithRequestOptions so that any client method
+// called with that context picks them up.
+type RequestOption func(*requestConfig)
+
+// requestConfig is the resolved set of per-call settings.
+type requestConfig struct {
+	// idempotencyKey is sent as the Idempotency-Key header on the probe and
+	// the paid retry, and keys the client's PendingPaymentStore.
+	idempotencyKey string
+	// tags are cost-allocation tags recorded on the call's Receipt.
+	tags map[string]string
+	// externalPayment hands payment to the caller (see WithExternalPayment);
+	// paymentSignature is the caller's PAYMENT-SIGNATURE, if it has one yet.
+	externalPayment  bool
+	paymentSignature string
+	// timeout and deadline replace the client's Timeout for this call (see
+	// WithRequestTimeout, WithRequestDeadline).
+	timeout  time.Duration
+	deadline time.Time
+	// priority orders the call in the client's PriorityQueue.
+	priority int
+	// onCost, if set, is told the USD cost of each payment the call settles.
+	onCost func(usd float64)
+	// shadow marks a shadow call, accounted against the shadow budget.
+	// shadowReserved sums the shadow budget its payments reserved, released
+	// when the call ends (guarded by baseClient.mu).
+	shadow         bool
+	shadowReserved *float64
+	// userMetadata replaces the client's X-User-Metadata when non-nil.
+	userMetadata map[string]string
+}
+
+// requestConfigKey is the context key under which a *requestConfig is stored.
+type requestConfigKey struct{}
+
+// WithRequestOptions returns a copy of ctx carrying opts. Options already on
+// ctx are kept; later options win where they overlap.
+func WithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	cfg := requestConfig{}
+	if existing := requestConfigFrom(ctx); existing != nil {
+		cfg = *existing
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return context.WithValue(ctx, requestConfigKey{}, &cfg)
+}
+
+// requestConfigFrom returns the per-call settings on ctx, or nil if none.
+func requestConfigFrom(ctx context.Context) *requestConfig {
+	cfg, _ := ctx.Value(requestConfigKey{}).(*requestConfig)
+	return cfg
+}
+
+// withCostObserver reports the cost of each payment the call settles to fn.
+func withCostObserver(fn func(usd float64)) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.onCost = fn
+	}
+}
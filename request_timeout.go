@@ -0,0 +1,88 @@
+package blockrun
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithRequestTimeout bounds this call by d in place of the client-wide
+// WithTimeout, so slow reasoning calls can be given more time (or quick
+// lookups less) without changing the client. The deadline is fixed when the
+// call starts and covers all of its HTTP requests, including a paid retry
+// and any polling. A tighter deadline on the caller's context still wins.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.timeout = d
+	}
+}
+
+// WithRequestDeadline makes this call fail once t has passed, in place of
+// the client-wide WithTimeout. A tighter deadline on the caller's context
+// still wins.
+func WithRequestDeadline(t time.Time) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.deadline = t
+	}
+}
+
+// requestDeadline returns the per-call deadline for a request starting now,
+// if WithRequestTimeout or WithRequestDeadline was given.
+func requestDeadline(ctx context.Context, now time.Time) (time.Time, bool) {
+	cfg := requestConfigFrom(ctx)
+	if cfg == nil || (cfg.timeout <= 0 && cfg.deadline.IsZero()) {
+		return time.Time{}, false
+	}
+	deadline := cfg.deadline
+	if cfg.timeout > 0 {
+		if t := now.Add(cfg.timeout); deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+	return deadline, true
+}
+
+// pinRequestDeadline fixes the per-call deadline of a call starting at now,
+// turning a WithRequestTimeout into an absolute deadline on ctx. Every
+// request of the call — probe, paid retry, polls — then shares that one
+// deadline rather than each getting the full timeout. Pinning an already
+// pinned ctx changes nothing.
+func pinRequestDeadline(ctx context.Context, now time.Time) context.Context {
+	cfg := requestConfigFrom(ctx)
+	if cfg == nil || cfg.timeout <= 0 {
+		return ctx
+	}
+	deadline, _ := requestDeadline(ctx, now)
+	pinned := *cfg
+	pinned.timeout = 0
+	pinned.deadline = deadline
+	return context.WithValue(ctx, requestConfigKey{}, &pinned)
+}
+
+// withRequestDeadline applies the per-call deadline to req. It returns the
+// request and HTTP client to use (the client's global Timeout is lifted so
+// the per-call deadline governs) and a cancel func to call once the
+// response body is done with.
+func (bc *baseClient) withRequestDeadline(req *http.Request) (*http.Request, *http.Client, context.CancelFunc) {
+	deadline, ok := requestDeadline(req.Context(), time.Now())
+	if !ok {
+		return req, bc.httpClient, func() {}
+	}
+	ctx, cancel := context.WithDeadline(req.Context(), deadline)
+	client := *bc.httpClient
+	client.Timeout = 0
+	return req.WithContext(ctx), &client, cancel
+}
+
+// cancelOnClose releases a per-call deadline when the response body closes.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
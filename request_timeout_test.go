@@ -0,0 +1,128 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newSlowServer answers chat requests after delay.
+func newSlowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	server := newSlowServer(t, 200*time.Millisecond)
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	_, err = client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithRequestTimeout(100*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded with a 100ms timeout, got %v", err)
+	}
+
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithRequestTimeout(500*time.Millisecond)); err != nil {
+		t.Errorf("expected success with a 500ms timeout, got %v", err)
+	}
+
+	if client.httpClient.Timeout != time.Second {
+		t.Errorf("expected the global timeout to be unchanged, got %v", client.httpClient.Timeout)
+	}
+}
+
+func TestWithRequestTimeoutOverridesGlobalTimeout(t *testing.T) {
+	server := newSlowServer(t, 200*time.Millisecond)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithTimeout(100*time.Millisecond))
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err == nil {
+		t.Error("expected the 100ms global timeout to fail the call")
+	}
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithRequestTimeout(500*time.Millisecond)); err != nil {
+		t.Errorf("expected the per-request timeout to allow a longer call, got %v", err)
+	}
+}
+
+func TestRequestDeadlineTighterWins(t *testing.T) {
+	server := newSlowServer(t, 200*time.Millisecond)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	// Caller's context is tighter than the request option.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.ChatCompletion(ctx, "gpt-4o", messages, nil, WithRequestTimeout(time.Second)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller's tighter deadline to win, got %v", err)
+	}
+
+	// WithRequestDeadline is tighter than WithRequestTimeout.
+	_, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil,
+		WithRequestTimeout(time.Second), WithRequestDeadline(time.Now().Add(50*time.Millisecond)))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the tighter request deadline to win, got %v", err)
+	}
+
+	now := time.Now()
+	ctx = WithRequestOptions(context.Background(), WithRequestDeadline(now.Add(time.Minute)), WithRequestTimeout(time.Second))
+	if got, ok := requestDeadline(ctx, now); !ok || !got.Equal(now.Add(time.Second)) {
+		t.Errorf("requestDeadline = %v, %v; want now+1s", got, ok)
+	}
+	if _, ok := requestDeadline(context.Background(), now); ok {
+		t.Error("expected no deadline without request options")
+	}
+}
+
+func TestRequestTimeoutCoversPaidRetry(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(150 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	// Each request fits in 250ms, but the probe and the paid retry together
+	// do not.
+	_, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil, WithRequestTimeout(250*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call deadline to cover the paid retry, got %v", err)
+	}
+}
+
+func TestPinRequestDeadline(t *testing.T) {
+	now := time.Now()
+	ctx := pinRequestDeadline(WithRequestOptions(context.Background(), WithRequestTimeout(time.Second)), now)
+	if got, ok := requestDeadline(ctx, now.Add(time.Hour)); !ok || !got.Equal(now.Add(time.Second)) {
+		t.Errorf("requestDeadline after pinning = %v, %v; want the deadline fixed at now+1s", got, ok)
+	}
+	if again := pinRequestDeadline(ctx, now.Add(time.Hour)); again != ctx {
+		t.Error("expected pinning a pinned context to change nothing")
+	}
+}
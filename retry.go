@@ -0,0 +1,147 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how the client retries throttled (429) requests.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the
+	// first. 1 disables retries; 0 uses DefaultRetryPolicy.MaxAttempts.
+	MaxAttempts int
+	// MaxWaitDuration caps a single back-off sleep even if Retry-After asks
+	// for longer. 0 uses DefaultRetryPolicy.MaxWaitDuration.
+	MaxWaitDuration time.Duration
+}
+
+// DefaultRetryPolicy is used by clients that do not call WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	MaxWaitDuration: 30 * time.Second,
+}
+
+// defaultThrottleWait is the back-off used when a 429 carries no usable
+// Retry-After header.
+const defaultThrottleWait = time.Second
+
+// WithRetryPolicy sets how 429 Too Many Requests responses are retried.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *LLMClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// ThrottleEvent is emitted each time the client backs off after a 429.
+type ThrottleEvent struct {
+	// URL is the throttled request URL.
+	URL string
+	// Attempt is the attempt that was throttled (1 for the first request).
+	Attempt int
+	// RetryAfter is the delay the gateway asked for (zero if it did not say).
+	RetryAfter time.Duration
+	// Wait is how long the client actually slept before retrying.
+	Wait time.Duration
+	// TotalWait is the cumulative back-off for this request so far.
+	TotalWait time.Duration
+}
+
+// EventName implements Event.
+func (ThrottleEvent) EventName() string { return "throttle" }
+
+// parseRetryAfter parses a Retry-After header value in either delay-seconds
+// or HTTP-date form. It reports false for a missing or malformed value.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// effective fills zero fields from DefaultRetryPolicy.
+func (p RetryPolicy) effective() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.MaxWaitDuration <= 0 {
+		p.MaxWaitDuration = DefaultRetryPolicy.MaxWaitDuration
+	}
+	return p
+}
+
+// sendWithThrottleRetry sends req, and on a 429 sleeps for the advertised
+// Retry-After (capped by the policy) and re-sends it, up to MaxAttempts. If
+// the wait would outlast ctx's deadline it gives up immediately with
+// context.DeadlineExceeded. When attempts run out, or the body cannot be
+// replayed, the last 429 response is returned for the caller to surface.
+func (bc *baseClient) sendWithThrottleRetry(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	policy := bc.retryPolicy.effective()
+	clk := currentClock()
+	var totalWait time.Duration
+
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		if attempt >= policy.MaxAttempts || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), clk.Now())
+		wait := retryAfter
+		if !ok {
+			wait = defaultThrottleWait
+		}
+		if wait > policy.MaxWaitDuration {
+			wait = policy.MaxWaitDuration
+		}
+		if deadline, has := ctx.Deadline(); has && time.Until(deadline) < wait {
+			resp.Body.Close()
+			return nil, context.DeadlineExceeded
+		}
+		resp.Body.Close()
+
+		totalWait += wait
+		bc.emit(ctx, ThrottleEvent{
+			URL:        req.URL.String(),
+			Attempt:    attempt,
+			RetryAfter: retryAfter,
+			Wait:       wait,
+			TotalWait:  totalWait,
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-clk.After(wait):
+		}
+
+		next := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+		req = next
+	}
+}
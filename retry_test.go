@@ -0,0 +1,141 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"1", time.Second, true},
+		{" 120 ", 2 * time.Minute, true},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// throttleOnceServer returns 429 with the given Retry-After on its first
+// request and a chat response afterwards.
+func throttleOnceServer(retryAfter string, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(hits, 1) == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+}
+
+func TestThrottleRetryAfterSeconds(t *testing.T) {
+	var hits int32
+	server := throttleOnceServer("1", &hits)
+	defer server.Close()
+
+	var events []ThrottleEvent
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithMiddleware(MiddlewareFunc(func(ctx context.Context, ev Event) {
+			if te, ok := ev.(ThrottleEvent); ok {
+				events = append(events, te)
+			}
+		})))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	start := time.Now()
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait ~1s before retrying, took %v", elapsed)
+	}
+	if hits != 2 {
+		t.Errorf("expected 2 requests, got %d", hits)
+	}
+	if len(events) != 1 || events[0].Wait != time.Second || events[0].TotalWait != time.Second || events[0].Attempt != 1 {
+		t.Errorf("unexpected throttle events: %+v", events)
+	}
+}
+
+func TestThrottleRespectsMaxWaitDuration(t *testing.T) {
+	var hits int32
+	server := throttleOnceServer("3600", &hits)
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithRetryPolicy(RetryPolicy{MaxWaitDuration: 10 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	start := time.Now()
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected MaxWaitDuration to cap the sleep, took %v", elapsed)
+	}
+}
+
+func TestThrottleWaitBeyondDeadline(t *testing.T) {
+	var hits int32
+	server := throttleOnceServer("30", &hits)
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	_, err = client.ChatCompletion(ctx, "gpt-4o", messages, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("expected to give up immediately, took %v", elapsed)
+	}
+}
+
+func TestThrottleRetriesDisabled(t *testing.T) {
+	var hits int32
+	server := throttleOnceServer("0", &hits)
+	defer server.Close()
+
+	client, err := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	_, err = client.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 APIError, got %v", err)
+	}
+}
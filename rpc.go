@@ -316,6 +316,7 @@ func (c *RPCClient) Batch(ctx context.Context, network string, requests []RPCBat
 // final response headers. Mirrors baseClient.doRequestHeaders, which only
 // accepts map bodies.
 func (c *RPCClient) doRawRequestHeaders(ctx context.Context, endpoint string, body any) ([]byte, http.Header, error) {
+	ctx = c.callContext(ctx)
 	url := c.apiURL + endpoint
 
 	jsonBody, err := json.Marshal(body)
@@ -329,22 +330,19 @@ func (c *RPCClient) doRawRequestHeaders(ctx context.Context, endpoint string, bo
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusPaymentRequired {
-		return c.handlePaymentAndRetryHeaders(ctx, url, jsonBody, resp)
+		return c.handlePaymentAndRetryHeaders(ctx, url, jsonBody, "application/json", resp)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("API error: %s", string(bodyBytes)),
-		}
+		return nil, nil, newAPIError(resp.StatusCode, "API error", bodyBytes)
 	}
 
 	data, err := io.ReadAll(resp.Body)
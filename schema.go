@@ -0,0 +1,131 @@
+package blockrun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// WithResponseSchema returns options requiring the response content to be
+// JSON matching schema (see ChatCompletionOptions.ResponseSchema). Set other
+// fields on the result as usual.
+func WithResponseSchema(schema json.RawMessage) *ChatCompletionOptions {
+	return &ChatCompletionOptions{ResponseSchema: schema}
+}
+
+// SchemaValidationError is returned by ChatCompletion when the response
+// content is not JSON matching ChatCompletionOptions.ResponseSchema.
+type SchemaValidationError struct {
+	Content string
+	Schema  json.RawMessage
+	// Errors describes each violation, prefixed with its JSON pointer.
+	Errors []string
+}
+
+func (e *SchemaValidationError) Error() string {
+	return "response does not match schema: " + strings.Join(e.Errors, "; ")
+}
+
+// ValidateResponseAgainstSchema checks that content is JSON matching the JSON
+// Schema schema, returning a *SchemaValidationError if it is not. An invalid
+// schema is reported as a plain error.
+func ValidateResponseAgainstSchema(content string, schema json.RawMessage) error {
+	compiled, err := compileResponseSchema(schema)
+	if err != nil {
+		return err
+	}
+	return validateAgainst(compiled, content, schema)
+}
+
+// compileResponseSchema compiles a caller-supplied response schema.
+func compileResponseSchema(schema json.RawMessage) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("response-schema.json", bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	compiled, err := compiler.Compile("response-schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// validateAgainst checks content against an already compiled schema.
+func validateAgainst(compiled *jsonschema.Schema, content string, schema json.RawMessage) error {
+	var value any
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return &SchemaValidationError{Content: content, Schema: schema, Errors: []string{"invalid JSON: " + err.Error()}}
+	}
+	if err := compiled.Validate(value); err != nil {
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return err
+		}
+		return &SchemaValidationError{Content: content, Schema: schema, Errors: schemaViolations(ve, nil)}
+	}
+	return nil
+}
+
+// schemaViolations flattens the leaf causes of ve into "location: message".
+func schemaViolations(ve *jsonschema.ValidationError, out []string) []string {
+	if len(ve.Causes) == 0 {
+		location := ve.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return append(out, location+": "+ve.Message)
+	}
+	for _, cause := range ve.Causes {
+		out = schemaViolations(cause, out)
+	}
+	return out
+}
+
+// withResponseSchema runs attempt, checking the first choice's content
+// against opts.ResponseSchema and retrying up to opts.RetryOnSchemaFailure
+// times when it does not match. Each retry is a separate paid call; if ctx
+// carries an idempotency key, retries use a derived key so the gateway does
+// not replay the rejected response. Without a schema attempt runs once.
+func (c *LLMClient) withResponseSchema(ctx context.Context, opts *ChatCompletionOptions, attempt func(ctx context.Context) (*ChatResponse, error)) (*ChatResponse, error) {
+	if opts == nil || opts.ResponseSchema == nil {
+		return attempt(ctx)
+	}
+	// Compile before paying for anything.
+	compiled, err := compileResponseSchema(opts.ResponseSchema)
+	if err != nil {
+		return nil, &ValidationError{
+			Field:      "ResponseSchema",
+			Message:    err.Error(),
+			Suggestion: "pass a valid JSON Schema document",
+		}
+	}
+
+	for n := 0; ; n++ {
+		attemptCtx := ctx
+		if cfg := requestConfigFrom(ctx); n > 0 && cfg != nil && cfg.idempotencyKey != "" {
+			attemptCtx = WithRequestOptions(ctx, WithIdempotencyKey(fmt.Sprintf("%s-schema-retry-%d", cfg.idempotencyKey, n)))
+		}
+		resp, err := attempt(attemptCtx)
+		if err != nil {
+			return nil, err
+		}
+		content := ""
+		if len(resp.Choices) > 0 {
+			content = resp.Choices[0].Message.Content
+		}
+		err = validateAgainst(compiled, content, opts.ResponseSchema)
+		if err == nil {
+			return resp, nil
+		}
+		if n >= opts.RetryOnSchemaFailure {
+			return nil, err
+		}
+		c.warnf("response failed schema validation, retrying (%d of %d): %v", n+1, opts.RetryOnSchemaFailure, err)
+	}
+}
@@ -0,0 +1,128 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var answerSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {"answer": {"type": "string"}},
+	"required": ["answer"]
+}`)
+
+func TestValidateResponseAgainstSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", `{"answer": "42"}`, ""},
+		{"invalid JSON", `the answer is 42`, "invalid JSON"},
+		{"missing field", `{"result": "42"}`, "answer"},
+		{"wrong type", `{"answer": 42}`, "/answer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResponseAgainstSchema(tt.content, answerSchema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var sve *SchemaValidationError
+			if !errors.As(err, &sve) {
+				t.Fatalf("expected a SchemaValidationError, got %v", err)
+			}
+			if sve.Content != tt.content || len(sve.Errors) == 0 || !strings.Contains(sve.Error(), tt.wantErr) {
+				t.Errorf("unexpected error %+v", sve)
+			}
+		})
+	}
+
+	if err := ValidateResponseAgainstSchema(`{}`, json.RawMessage(`{"type": 7}`)); err == nil {
+		t.Error("expected an invalid schema to be rejected")
+	}
+}
+
+// newSchemaServer replies with contents in turn, repeating the last one.
+func newSchemaServer(t *testing.T, contents ...string) (*httptest.Server, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		content := contents[min(calls, len(contents)-1)]
+		calls++
+		mu.Unlock()
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: ChatMessage{Role: "assistant", Content: content}}}})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func TestChatCompletionResponseSchema(t *testing.T) {
+	messages := []ChatMessage{{Role: "user", Content: "What is six times seven?"}}
+
+	t.Run("valid", func(t *testing.T) {
+		server, calls := newSchemaServer(t, `{"answer": "42"}`)
+		client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+		resp, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, WithResponseSchema(answerSchema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Choices[0].Message.Content != `{"answer": "42"}` || calls() != 1 {
+			t.Errorf("unexpected response %+v after %d calls", resp, calls())
+		}
+	})
+
+	t.Run("violation", func(t *testing.T) {
+		server, calls := newSchemaServer(t, `{"answer": 42}`)
+		client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+		_, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, WithResponseSchema(answerSchema))
+		var sve *SchemaValidationError
+		if !errors.As(err, &sve) {
+			t.Fatalf("expected a SchemaValidationError, got %v", err)
+		}
+		if calls() != 1 {
+			t.Errorf("expected no retries by default, got %d calls", calls())
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		server, calls := newSchemaServer(t, `not json`, `{"answer": 42}`, `{"answer": "42"}`)
+		client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+		opts := WithResponseSchema(answerSchema)
+		opts.RetryOnSchemaFailure = 2
+		resp, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Choices[0].Message.Content != `{"answer": "42"}` || calls() != 3 {
+			t.Errorf("unexpected response %+v after %d calls", resp, calls())
+		}
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		server, calls := newSchemaServer(t, `{"answer": "42"}`)
+		client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+		_, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, WithResponseSchema(json.RawMessage(`{"type": 7}`)))
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+		if calls() != 0 {
+			t.Errorf("expected no request with an invalid schema, got %d", calls())
+		}
+	})
+}
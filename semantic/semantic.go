@@ -0,0 +1,250 @@
+// Package semantic provides an in-memory vector store and a semantic
+// response cache for blockrun chat completions.
+//
+// A SemanticCache embeds each prompt and serves a stored response when a
+// previous prompt for the same model is similar enough, so paraphrased
+// repeats skip the paid chat call (the embedding call is still paid, but is
+// far cheaper):
+//
+//	embedder, _ := blockrun.NewLLMClient("")
+//	cache := semantic.NewSemanticCache(embedder, "openai/text-embedding-3-small", 0.95, 1000)
+//	client, _ := blockrun.NewLLMClient("", semantic.WithSemanticCache(cache))
+package semantic
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// Match is a VectorStore search result.
+type Match struct {
+	Key   string
+	Score float64
+	Value any
+}
+
+// VectorStore is an in-memory, LRU-bounded store of embeddings searched by
+// cosine similarity. It is safe for concurrent use.
+type VectorStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // front = most recently used
+	entries    map[string]*list.Element
+}
+
+type vectorEntry struct {
+	key       string
+	embedding []float32
+	norm      float64
+	value     any
+}
+
+// NewVectorStore returns a store holding at most maxEntries vectors
+// (0 = unbounded); the least recently used entry is evicted first.
+func NewVectorStore(maxEntries int) *VectorStore {
+	return &VectorStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Add stores value under key with its embedding, replacing an existing key.
+func (s *VectorStore) Add(key string, embedding []float32, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &vectorEntry{key: key, embedding: embedding, norm: norm(embedding), value: value}
+	if el, ok := s.entries[key]; ok {
+		el.Value = entry
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	if s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*vectorEntry).key)
+	}
+}
+
+// Nearest returns the most similar stored entry whose score is at least
+// threshold and for which accept (if non-nil) returns true. A hit counts as
+// a use for LRU purposes.
+func (s *VectorStore) Nearest(embedding []float32, threshold float64, accept func(value any) bool) (Match, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	qNorm := norm(embedding)
+	var best *list.Element
+	bestScore := math.Inf(-1)
+	for el := s.order.Front(); el != nil; el = el.Next() {
+		entry := el.Value.(*vectorEntry)
+		if accept != nil && !accept(entry.value) {
+			continue
+		}
+		score := cosine(embedding, qNorm, entry.embedding, entry.norm)
+		if score >= threshold && score > bestScore {
+			best, bestScore = el, score
+		}
+	}
+	if best == nil {
+		return Match{}, false
+	}
+	s.order.MoveToFront(best)
+	entry := best.Value.(*vectorEntry)
+	return Match{Key: entry.key, Score: bestScore, Value: entry.value}, true
+}
+
+// Len returns the number of stored entries.
+func (s *VectorStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// CosineSimilarity returns the cosine similarity of a and b, or 0 if either
+// is a zero vector or their lengths differ.
+func CosineSimilarity(a, b []float32) float64 {
+	return cosine(a, norm(a), b, norm(b))
+}
+
+func cosine(a []float32, aNorm float64, b []float32, bNorm float64) float64 {
+	if len(a) != len(b) || aNorm == 0 || bNorm == 0 {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot / (aNorm * bNorm)
+}
+
+func norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// SemanticCache is a blockrun.ChatCache that matches prompts by embedding
+// similarity. Entries are scoped to the chat model; the request body, and so
+// options such as temperature or tools, is not part of the match.
+type SemanticCache struct {
+	// Threshold is the minimum cosine similarity for a hit, e.g. 0.95.
+	Threshold float64
+
+	client *blockrun.LLMClient
+	model  string
+	store  *VectorStore
+
+	mu sync.Mutex
+	// pending holds embeddings computed by a missed Get so Put need not
+	// embed the same prompt again.
+	pending map[string][]float32
+}
+
+var _ blockrun.ChatCache = (*SemanticCache)(nil)
+
+// cached is the value stored per prompt.
+type cached struct {
+	model string
+	resp  *blockrun.ChatResponse
+}
+
+// NewSemanticCache returns a cache that embeds prompts with embeddingModel
+// via embeddingClient, serves hits at or above threshold, and keeps at most
+// maxEntries responses (least recently used evicted first).
+func NewSemanticCache(embeddingClient *blockrun.LLMClient, embeddingModel string, threshold float64, maxEntries int) *SemanticCache {
+	return &SemanticCache{
+		Threshold: threshold,
+		client:    embeddingClient,
+		model:     embeddingModel,
+		store:     NewVectorStore(maxEntries),
+		pending:   make(map[string][]float32),
+	}
+}
+
+// WithSemanticCache installs c in front of the client's ChatCompletion, so
+// a hit is returned before any payment is made.
+func WithSemanticCache(c *SemanticCache) blockrun.ClientOption {
+	return blockrun.WithChatCache(c)
+}
+
+// Get returns a copy of the stored response for the most similar earlier
+// prompt to the same model. Embedding failures count as misses.
+func (c *SemanticCache) Get(ctx context.Context, model string, messages []blockrun.ChatMessage, body []byte) (*blockrun.ChatResponse, bool) {
+	key := model + "\x00" + promptText(messages)
+	embedding, err := c.embed(ctx, messages)
+	if err != nil {
+		return nil, false
+	}
+	match, ok := c.store.Nearest(embedding, c.Threshold, func(v any) bool {
+		return v.(*cached).model == model
+	})
+	if !ok {
+		c.mu.Lock()
+		if len(c.pending) >= 1024 {
+			c.pending = make(map[string][]float32)
+		}
+		c.pending[key] = embedding
+		c.mu.Unlock()
+		return nil, false
+	}
+	return match.Value.(*cached).resp.Clone(), true
+}
+
+// Put stores a copy of resp for the prompt.
+func (c *SemanticCache) Put(ctx context.Context, model string, messages []blockrun.ChatMessage, body []byte, resp *blockrun.ChatResponse) {
+	key := model + "\x00" + promptText(messages)
+	c.mu.Lock()
+	embedding, ok := c.pending[key]
+	delete(c.pending, key)
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		if embedding, err = c.embed(ctx, messages); err != nil {
+			return
+		}
+	}
+	c.store.Add(key, embedding, &cached{model: model, resp: resp.Clone()})
+}
+
+// Len returns the number of cached responses.
+func (c *SemanticCache) Len() int {
+	return c.store.Len()
+}
+
+func (c *SemanticCache) embed(ctx context.Context, messages []blockrun.ChatMessage) ([]float32, error) {
+	resp, err := c.client.CreateEmbedding(ctx, c.model, []string{promptText(messages)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, errors.New("semantic: empty embedding response")
+	}
+	vec := make([]float32, len(resp.Data[0].Embedding))
+	for i, x := range resp.Data[0].Embedding {
+		vec[i] = float32(x)
+	}
+	return vec, nil
+}
+
+// promptText flattens a conversation into the text that is embedded.
+func promptText(messages []blockrun.ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		b.WriteString(m.Content)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
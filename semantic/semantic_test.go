@@ -0,0 +1,162 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// precomputed maps prompt text to a fixed embedding.
+var precomputed = map[string][]float64{
+	"What is the capital of France?": {0.90, 0.10, 0.05},
+	"Tell me France's capital city.": {0.88, 0.14, 0.07},
+	"How do I bake sourdough bread?": {0.05, 0.20, 0.95},
+}
+
+// newGateway serves the precomputed embeddings and counts chat calls.
+func newGateway(t *testing.T, chatCalls *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input    []string `json:"input"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			text := strings.TrimSuffix(strings.TrimPrefix(body.Input[0], "user: "), "\n")
+			vec, ok := precomputed[text]
+			if !ok {
+				t.Errorf("no precomputed embedding for %q", text)
+			}
+			data, _ := json.Marshal(vec)
+			fmt.Fprintf(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":%s}]}`, data)
+		case "/v1/chat/completions":
+			*chatCalls++
+			fmt.Fprintf(w, `{"id":"c%d","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"answer to: %s"},"finish_reason":"stop"}]}`, *chatCalls, body.Messages[0].Content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSemanticCacheParaphraseHit(t *testing.T) {
+	chatCalls := 0
+	gw := newGateway(t, &chatCalls)
+	embedder, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(gw.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	cache := NewSemanticCache(embedder, "openai/text-embedding-3-small", 0.98, 10)
+	client, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(gw.URL), WithSemanticCache(cache))
+	ctx := context.Background()
+
+	first, err := client.Chat(ctx, "gpt-4o", "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	paraphrased, err := client.Chat(ctx, "gpt-4o", "Tell me France's capital city.")
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if chatCalls != 1 || paraphrased != first {
+		t.Errorf("expected the paraphrase to hit the cache, got %d chat calls and %q", chatCalls, paraphrased)
+	}
+
+	if _, err := client.Chat(ctx, "gpt-4o", "How do I bake sourdough bread?"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if chatCalls != 2 {
+		t.Errorf("expected an unrelated prompt to miss, got %d chat calls", chatCalls)
+	}
+
+	if _, err := client.Chat(ctx, "claude-sonnet", "What is the capital of France?"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if chatCalls != 3 {
+		t.Errorf("expected a different model to miss, got %d chat calls", chatCalls)
+	}
+	if cache.Len() != 3 {
+		t.Errorf("expected 3 cached responses, got %d", cache.Len())
+	}
+}
+
+func TestSemanticCacheReturnsCopies(t *testing.T) {
+	chatCalls := 0
+	gw := newGateway(t, &chatCalls)
+	embedder, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(gw.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	cache := NewSemanticCache(embedder, "openai/text-embedding-3-small", 0.98, 10)
+	client, _ := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(gw.URL), WithSemanticCache(cache))
+	ctx := context.Background()
+	messages := []blockrun.ChatMessage{{Role: "user", Content: "What is the capital of France?"}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.ChatCompletion(ctx, "gpt-4o", messages, nil)
+		if err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+		if got := resp.Choices[0].Message.Content; got != "answer to: What is the capital of France?" {
+			t.Fatalf("call %d: expected the cached answer, got %v", i, got)
+		}
+		resp.Choices[0].Message.Content = "edited"
+	}
+	if chatCalls != 1 {
+		t.Errorf("expected 1 chat call, got %d", chatCalls)
+	}
+}
+
+func TestVectorStoreLRUEviction(t *testing.T) {
+	store := NewVectorStore(2)
+	store.Add("a", []float32{1, 0}, "A")
+	store.Add("b", []float32{0, 1}, "B")
+
+	// Touch "a" so "b" becomes least recently used.
+	if m, ok := store.Nearest([]float32{1, 0}, 0.9, nil); !ok || m.Key != "a" {
+		t.Fatalf("expected a hit on a, got %+v %v", m, ok)
+	}
+	store.Add("c", []float32{0.7, 0.7}, "C")
+
+	if store.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", store.Len())
+	}
+	if _, ok := store.Nearest([]float32{0, 1}, 0.99, nil); ok {
+		t.Error("expected b to be evicted")
+	}
+	if m, ok := store.Nearest([]float32{1, 0}, 0.99, nil); !ok || m.Value != "A" {
+		t.Error("expected a to survive eviction")
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{1, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 1}, 0},
+		{[]float32{1, 1}, []float32{-1, -1}, -1},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+		{[]float32{1}, []float32{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("CosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
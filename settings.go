@@ -0,0 +1,126 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Log levels accepted by WithLogLevel, from most to least verbose.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+	LogLevelOff   = "off"
+)
+
+var logLevelRank = map[string]int{
+	LogLevelDebug: 0,
+	LogLevelInfo:  1,
+	LogLevelWarn:  2,
+	LogLevelError: 3,
+	LogLevelOff:   4,
+}
+
+// WithLogLevel sets which of the client's own log messages are written to
+// the standard logger. The default is "warn"; unknown levels are ignored.
+func WithLogLevel(level string) ClientOption {
+	return func(c *LLMClient) {
+		level = strings.ToLower(level)
+		if _, ok := logLevelRank[level]; ok {
+			c.logLevel = level
+		}
+	}
+}
+
+// WithDefaultModel sets the model used when ChatCompletion is called with an
+// empty model.
+func WithDefaultModel(model string) ClientOption {
+	return func(c *LLMClient) {
+		c.defaultModel = model
+	}
+}
+
+// WithDefaultMaxTokens sets max_tokens for calls that don't set
+// ChatCompletionOptions.MaxTokens (DefaultMaxTokens otherwise).
+func WithDefaultMaxTokens(n int) ClientOption {
+	return func(c *LLMClient) {
+		c.defaultMaxTokens = n
+	}
+}
+
+// WithBudget caps the client's session spending at usd: a payment that
+// would take GetSpending().TotalUSD above it is refused with a PaymentError
+// before anything is signed. 0 means no cap.
+func WithBudget(usd float64) ClientOption {
+	return func(c *LLMClient) {
+		c.budgetUSD = usd
+	}
+}
+
+// WithImageBudget is WithBudget for an ImageClient.
+func WithImageBudget(usd float64) ImageClientOption {
+	return func(c *ImageClient) {
+		c.budgetUSD = usd
+	}
+}
+
+// infof logs a message if the client's log level is info or debug.
+func (bc *baseClient) infof(format string, args ...any) {
+	level := bc.logLevel
+	if level == "" {
+		level = LogLevelWarn
+	}
+	if logLevelRank[level] <= logLevelRank[LogLevelInfo] {
+		log.Printf("blockrun: "+format, args...)
+	}
+}
+
+// warnf logs a warning unless the client's log level is above warn.
+func (bc *baseClient) warnf(format string, args ...any) {
+	level := bc.logLevel
+	if level == "" {
+		level = LogLevelWarn
+	}
+	if logLevelRank[level] <= logLevelRank[LogLevelWarn] {
+		log.Printf("blockrun: "+format, args...)
+	}
+}
+
+// checkBudget refuses a payment that would exceed the session budget or,
+// for a shadow call, the shadow budget. A shadow payment that fits reserves
+// its cost under the same lock, so concurrent shadow calls cannot all pass
+// the check; runShadow releases the reservation when the call ends.
+func (bc *baseClient) checkBudget(ctx context.Context, option *PaymentOption) error {
+	cost := optionCostUSD(option)
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if spent := bc.sessionTotalUSD; bc.budgetUSD > 0 && spent+cost > bc.budgetUSD {
+		return &PaymentError{Message: fmt.Sprintf("session budget of $%.2f would be exceeded ($%.4f spent, call costs $%.4f)", bc.budgetUSD, spent, cost)}
+	}
+	cfg := requestConfigFrom(ctx)
+	if cfg == nil || !cfg.shadow || bc.shadowBudgetUSD <= 0 {
+		return nil
+	}
+	if committed := bc.shadowSpentUSD + bc.shadowReservedUSD; committed+cost > bc.shadowBudgetUSD {
+		return &PaymentError{Message: fmt.Sprintf("shadow budget of $%.2f would be exceeded ($%.4f spent or in flight, call costs $%.4f)", bc.shadowBudgetUSD, committed, cost)}
+	}
+	if cfg.shadowReserved != nil {
+		bc.shadowReservedUSD += cost
+		*cfg.shadowReserved += cost
+	}
+	return nil
+}
+
+// optionCostUSD converts a payment option's micro-USDC amount to USD.
+func optionCostUSD(option *PaymentOption) float64 {
+	var amountMicro float64
+	if option.Amount != "" {
+		if _, err := fmt.Sscanf(option.Amount, "%f", &amountMicro); err != nil {
+			return 0
+		}
+	}
+	return amountMicro / 1_000_000
+}
@@ -0,0 +1,84 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+)
+
+// shadowConfig is the model a client mirrors ChatCompletion calls to.
+type shadowConfig struct {
+	model   string
+	handler func(shadow *ChatResponse, err error)
+}
+
+// WithShadowModel mirrors every ChatCompletion call that is not served from
+// the chat cache to model in the background and passes the result to
+// handler. The shadow response is never returned to the caller and its
+// errors never propagate; shadow payments are flagged Shadow in the receipt
+// store and the cost log.
+func WithShadowModel(model string, handler func(shadow *ChatResponse, err error)) ClientOption {
+	return func(c *LLMClient) {
+		c.shadow = &shadowConfig{model: model, handler: handler}
+	}
+}
+
+// WithShadowBudget caps total spending on shadow calls at maxUSD. Once
+// reached, shadow calls fail with a PaymentError (passed to the handler).
+func WithShadowBudget(maxUSD float64) ClientOption {
+	return func(c *LLMClient) {
+		c.shadowBudgetUSD = maxUSD
+	}
+}
+
+// runShadow sends body to the shadow model in the background.
+func (c *LLMClient) runShadow(ctx context.Context, body map[string]any) {
+	cfg := requestConfigFrom(ctx)
+	if c.shadow == nil || (cfg != nil && cfg.externalPayment) {
+		return
+	}
+
+	shadowBody := make(map[string]any, len(body))
+	for k, v := range body {
+		shadowBody[k] = v
+	}
+	shadowBody["model"] = c.shadow.model
+
+	// The shadow call outlives the caller's context and must not share its
+	// idempotency key, priority or cost observer.
+	var reserved float64
+	shadowCfg := requestConfig{shadow: true, shadowReserved: &reserved}
+	if cfg != nil {
+		shadowCfg.tags = cfg.tags
+		shadowCfg.timeout, shadowCfg.deadline = cfg.timeout, cfg.deadline
+	}
+	shadowCtx := context.WithValue(context.WithoutCancel(ctx), requestConfigKey{}, &shadowCfg)
+
+	go func() {
+		resp, err := c.shadowCompletion(shadowCtx, shadowBody)
+		// A settled payment is now in shadowSpentUSD; a failed one never
+		// will be. Either way the reservation has done its job.
+		c.mu.Lock()
+		c.shadowReservedUSD -= reserved
+		c.mu.Unlock()
+		if err != nil {
+			c.warnf("shadow call to %s failed: %v", c.shadow.model, err)
+		}
+		if c.shadow.handler != nil {
+			c.shadow.handler(resp, err)
+		}
+	}()
+}
+
+// shadowCompletion performs the shadow request, bypassing the chat cache,
+// deduplication and the priority queue.
+func (c *LLMClient) shadowCompletion(ctx context.Context, body map[string]any) (*ChatResponse, error) {
+	respBytes, err := c.doRequest(ctx, "/v1/chat/completions", body)
+	if err != nil {
+		return nil, err
+	}
+	chatResp, err := decodeChatResponse(respBytes, nil)
+	if err != nil {
+		return nil, fmt.Errorf("shadow response: %w", err)
+	}
+	return chatResp, nil
+}
@@ -0,0 +1,217 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type shadowResult struct {
+	resp *ChatResponse
+	err  error
+}
+
+func shadowRecorder() (chan shadowResult, func(*ChatResponse, error)) {
+	results := make(chan shadowResult, 4)
+	return results, func(resp *ChatResponse, err error) {
+		results <- shadowResult{resp, err}
+	}
+}
+
+func waitShadow(t *testing.T, results chan shadowResult) shadowResult {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow handler was not called")
+		return shadowResult{}
+	}
+}
+
+func TestShadowModelHandlerReceivesShadowResponse(t *testing.T) {
+	server := newEchoModelServer(t)
+	results, handler := shadowRecorder()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithShadowModel("anthropic/claude-sonnet-4", handler))
+
+	resp, err := client.ChatCompletion(context.Background(), "openai/gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if resp.Model != "openai/gpt-4o" {
+		t.Errorf("primary caller got %q, want the production model", resp.Model)
+	}
+
+	r := waitShadow(t, results)
+	if r.err != nil {
+		t.Fatalf("shadow error: %v", r.err)
+	}
+	if r.resp.Model != "anthropic/claude-sonnet-4" {
+		t.Errorf("shadow handler got %q, want the shadow model", r.resp.Model)
+	}
+}
+
+func TestShadowErrorDoesNotPropagate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if modelFromBody(body) == "broken-model" {
+			http.Error(w, `{"error":{"message":"boom"}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	results, handler := shadowRecorder()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithShadowModel("broken-model", handler), WithLogLevel(LogLevelOff))
+
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatalf("shadow failure leaked to the caller: %v", err)
+	}
+	if r := waitShadow(t, results); r.err == nil {
+		t.Error("expected the shadow handler to receive the error")
+	}
+}
+
+func TestShadowBudgetAndReceipts(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "2500") // $0.0025 per call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	store := NewMemoryReceiptStore()
+	results, handler := shadowRecorder()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithReceiptStore(store),
+		WithShadowModel("shadow-model", handler), WithShadowBudget(0.004), WithLogLevel(LogLevelOff))
+	client.costLog = nil
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil); err != nil {
+			t.Fatalf("ChatCompletion %d: %v", i, err)
+		}
+		r := waitShadow(t, results)
+		var payErr *PaymentError
+		switch {
+		case i == 0 && r.err != nil:
+			t.Fatalf("first shadow call: %v", r.err)
+		case i == 1 && !errors.As(r.err, &payErr):
+			t.Fatalf("second shadow call: expected a budget PaymentError, got %v", r.err)
+		}
+	}
+
+	receipts, _ := store.List(time.Time{}, time.Time{})
+	var primary, shadow int
+	for _, rc := range receipts {
+		if rc.Shadow {
+			shadow++
+			if rc.Model != "shadow-model" {
+				t.Errorf("shadow receipt has model %q", rc.Model)
+			}
+		} else {
+			primary++
+		}
+	}
+	if primary != 2 || shadow != 1 {
+		t.Errorf("expected 2 primary and 1 shadow receipt, got %d and %d", primary, shadow)
+	}
+}
+
+func TestShadowSkippedOnCacheHit(t *testing.T) {
+	var shadowCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if modelFromBody(body) == "shadow-model" {
+			shadowCalls.Add(1)
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	results, handler := shadowRecorder()
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithShadowModel("shadow-model", handler), WithChatCache(NewInMemoryCache(time.Minute)))
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	for i := 0; i < 2; i++ {
+		if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err != nil {
+			t.Fatalf("ChatCompletion %d: %v", i, err)
+		}
+	}
+	waitShadow(t, results)
+	select {
+	case <-results:
+		t.Error("expected no shadow call for the cache hit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if n := shadowCalls.Load(); n != 1 {
+		t.Errorf("expected 1 shadow request, got %d", n)
+	}
+}
+
+func TestShadowBudgetReservedAcrossConcurrentCalls(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "2500") // $0.0025 per call
+	var paidShadows atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if modelFromBody(body) == "shadow-model" {
+			paidShadows.Add(1)
+			time.Sleep(100 * time.Millisecond) // keep the shadow payments in flight together
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	const calls = 4
+	results := make(chan shadowResult, calls)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithShadowModel("shadow-model", func(resp *ChatResponse, err error) { results <- shadowResult{resp, err} }),
+		WithShadowBudget(0.004), WithLogLevel(LogLevelOff))
+	client.costLog = nil
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil); err != nil {
+				t.Errorf("ChatCompletion: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var ok int
+	for i := 0; i < calls; i++ {
+		if r := waitShadow(t, results); r.err == nil {
+			ok++
+		}
+	}
+	if ok != 1 || paidShadows.Load() != 1 {
+		t.Errorf("expected the budget to admit exactly 1 shadow payment, got %d successes and %d paid requests", ok, paidShadows.Load())
+	}
+	client.mu.Lock()
+	reserved := client.shadowReservedUSD
+	client.mu.Unlock()
+	if reserved != 0 {
+		t.Errorf("expected all reservations released, %.4f still reserved", reserved)
+	}
+}
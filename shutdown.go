@@ -0,0 +1,76 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShutdown is returned by calls started after Shutdown.
+var ErrShutdown = errors.New("blockrun: client is shut down")
+
+// drainState tracks in-flight calls so Shutdown can wait for them.
+type drainState struct {
+	mu       sync.Mutex
+	shutdown bool
+	inFlight int
+	wg       sync.WaitGroup
+}
+
+// begin registers a new call, returning the function that ends it, or
+// ErrShutdown once Shutdown has been called. The paid retry of a call runs
+// inside the call, so a payment already signed is always completed.
+func (d *drainState) begin() (func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.shutdown {
+		return nil, ErrShutdown
+	}
+	d.inFlight++
+	d.wg.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			d.inFlight--
+			d.mu.Unlock()
+			d.wg.Done()
+		})
+	}, nil
+}
+
+// Shutdown stops the client accepting new calls, which fail with
+// ErrShutdown, and waits for in-flight calls to finish or ctx to end. Open
+// streams count as in flight until closed. It returns ctx's error if calls
+// were still running. Calling it again just waits again.
+func (c *LLMClient) Shutdown(ctx context.Context) error {
+	c.drain.mu.Lock()
+	c.drain.shutdown = true
+	c.drain.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.drain.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsShutdown reports whether Shutdown has been called.
+func (c *LLMClient) IsShutdown() bool {
+	c.drain.mu.Lock()
+	defer c.drain.mu.Unlock()
+	return c.drain.shutdown
+}
+
+// InFlightCount returns the number of calls in progress.
+func (c *LLMClient) InFlightCount() int {
+	c.drain.mu.Lock()
+	defer c.drain.mu.Unlock()
+	return c.drain.inFlight
+}
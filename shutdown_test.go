@@ -0,0 +1,82 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrainsInFlight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	const calls = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Chat(context.Background(), "gpt-4o", "hi")
+			errs <- err
+		}()
+	}
+	waitFor(t, func() bool { return client.InFlightCount() == calls })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if n := client.InFlightCount(); n != 0 {
+		t.Errorf("expected no calls in flight after Shutdown, got %d", n)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("in-flight call failed: %v", err)
+		}
+	}
+
+	if !client.IsShutdown() {
+		t.Error("expected IsShutdown to be true")
+	}
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); !errors.Is(err, ErrShutdown) {
+		t.Errorf("expected ErrShutdown for a new call, got %v", err)
+	}
+}
+
+func TestShutdownWaitsForOpenStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	stream, err := client.ChatCompletionStream(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Shutdown to time out with a stream open, got %v", err)
+	}
+
+	stream.Close()
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected Shutdown to finish once the stream closed, got %v", err)
+	}
+}
@@ -15,7 +15,7 @@ func NewLLMClientSolana(privateKey, rpcURL string, opts ...ClientOption) (*LLMCl
 	if err != nil {
 		return nil, err
 	}
-	client := &LLMClient{baseClient: bc}
+	client := &LLMClient{baseClient: bc, models: &modelCache[Model]{ttl: DefaultModelCacheTTL}}
 	for _, opt := range opts {
 		opt(client)
 	}
@@ -44,7 +44,7 @@ func NewImageClientSolana(privateKey, rpcURL string, opts ...ImageClientOption)
 	if err != nil {
 		return nil, err
 	}
-	client := &ImageClient{baseClient: bc, pollInterval: imagePollInterval}
+	client := &ImageClient{baseClient: bc, pollInterval: imagePollInterval, models: &modelCache[ImageModel]{ttl: DefaultModelCacheTTL}}
 	for _, opt := range opts {
 		opt(client)
 	}
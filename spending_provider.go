@@ -0,0 +1,154 @@
+package blockrun
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ModelProvider returns the provider part of a model ID, the text before the
+// first "/" ("openai" for "openai/gpt-4o"). It returns "" for IDs without one.
+func ModelProvider(model string) string {
+	provider, _, ok := strings.Cut(model, "/")
+	if !ok {
+		return ""
+	}
+	return provider
+}
+
+// unknownSpendingKey groups receipts without a model or provider.
+const unknownSpendingKey = "unknown"
+
+// SpendingByProvider totals the receipts in the client's ReceiptStore by
+// provider. Receipts written before Receipt.Provider existed use the
+// provider of their model; those with neither are grouped under "unknown".
+// It returns nil without a receipt store or if the store cannot be read.
+func (c *LLMClient) SpendingByProvider() map[string]Spending {
+	return c.spendingBy(func(r Receipt) string {
+		if r.Provider != "" {
+			return r.Provider
+		}
+		return ModelProvider(r.Model)
+	})
+}
+
+// SpendingByModel totals the receipts in the client's ReceiptStore by
+// model. Receipts without a model are grouped under "unknown". It returns
+// nil without a receipt store or if the store cannot be read.
+func (c *LLMClient) SpendingByModel() map[string]Spending {
+	return c.spendingBy(func(r Receipt) string { return r.Model })
+}
+
+// spendingBy totals every stored receipt by key(receipt).
+func (c *LLMClient) spendingBy(key func(Receipt) string) map[string]Spending {
+	if c.receipts == nil {
+		return nil
+	}
+	receipts, err := c.receipts.List(time.Time{}, time.Time{})
+	if err != nil {
+		return nil
+	}
+	totals := make(map[string]Spending)
+	for _, r := range receipts {
+		k := key(r)
+		if k == "" {
+			k = unknownSpendingKey
+		}
+		s := totals[k]
+		s.TotalUSD += r.CostUSD
+		s.Calls++
+		totals[k] = s
+	}
+	return totals
+}
+
+// PrintSpendingReport writes the client's stored spending as a table, by
+// provider and then by model, each sorted by cost. It returns
+// ErrNoReceiptStore if WithReceiptStore was not used.
+func (c *LLMClient) PrintSpendingReport(w io.Writer) error {
+	if c.receipts == nil {
+		return ErrNoReceiptStore
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	writeSpendingTable(tw, "PROVIDER", c.SpendingByProvider())
+	fmt.Fprintln(tw)
+	writeSpendingTable(tw, "MODEL", c.SpendingByModel())
+	return tw.Flush()
+}
+
+// writeSpendingTable writes one section of a spending report, most
+// expensive first, with a total row.
+func writeSpendingTable(w io.Writer, heading string, totals map[string]Spending) {
+	keys := make([]string, 0, len(totals))
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if totals[keys[i]].TotalUSD != totals[keys[j]].TotalUSD {
+			return totals[keys[i]].TotalUSD > totals[keys[j]].TotalUSD
+		}
+		return keys[i] < keys[j]
+	})
+
+	var sum Spending
+	fmt.Fprintf(w, "%s\tCALLS\tCOST (USD)\t\n", heading)
+	for _, k := range keys {
+		s := totals[k]
+		sum.TotalUSD += s.TotalUSD
+		sum.Calls += s.Calls
+		fmt.Fprintf(w, "%s\t%d\t%.6f\t\n", k, s.Calls, s.TotalUSD)
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%.6f\t\n", sum.Calls, sum.TotalUSD)
+}
+
+// Migrate rewrites the receipt file, filling in Provider for receipts that
+// have a Model but no Provider. Malformed lines are kept as they are. The
+// file is replaced atomically, and left untouched if nothing needs changing.
+func (s *FileReceiptStore) Migrate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var out []byte
+	changed := false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var r Receipt
+		if len(line) > 0 && json.Unmarshal(line, &r) == nil && r.Provider == "" && ModelProvider(r.Model) != "" {
+			r.Provider = ModelProvider(r.Model)
+			if line, err = json.Marshal(r); err != nil {
+				return err
+			}
+			changed = true
+		}
+		out = append(out, line...)
+		out = append(out, '\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,94 @@
+package blockrun
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpendingByProvider(t *testing.T) {
+	store := NewMemoryReceiptStore()
+	now := time.Now()
+	for _, r := range []Receipt{
+		{Timestamp: now, Model: "openai/gpt-4o", Provider: "openai", CostUSD: 0.01},
+		{Timestamp: now, Model: "openai/gpt-4o-mini", Provider: "openai", CostUSD: 0.002},
+		{Timestamp: now, Model: "anthropic/claude-sonnet-4", Provider: "anthropic", CostUSD: 0.03},
+		{Timestamp: now, Model: "google/gemini-2.5-pro", CostUSD: 0.005}, // written before Provider existed
+		{Timestamp: now, Endpoint: "/v1/search", CostUSD: 0.001},
+	} {
+		store.Save(r)
+	}
+	client, _ := NewLLMClient(testPrivateKey, WithReceiptStore(store))
+
+	byProvider := client.SpendingByProvider()
+	want := map[string]Spending{
+		"openai":    {TotalUSD: 0.012, Calls: 2},
+		"anthropic": {TotalUSD: 0.03, Calls: 1},
+		"google":    {TotalUSD: 0.005, Calls: 1},
+		"unknown":   {TotalUSD: 0.001, Calls: 1},
+	}
+	if len(byProvider) != len(want) {
+		t.Fatalf("expected %d providers, got %v", len(want), byProvider)
+	}
+	for provider, w := range want {
+		got := byProvider[provider]
+		if got.Calls != w.Calls || math.Abs(got.TotalUSD-w.TotalUSD) > 1e-9 {
+			t.Errorf("%s: expected %+v, got %+v", provider, w, got)
+		}
+	}
+
+	byModel := client.SpendingByModel()
+	if got := byModel["openai/gpt-4o-mini"]; got.Calls != 1 || got.TotalUSD != 0.002 {
+		t.Errorf("unexpected model total: %+v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := client.PrintSpendingReport(&buf); err != nil {
+		t.Fatalf("PrintSpendingReport: %v", err)
+	}
+	report := buf.String()
+	for _, s := range []string{"PROVIDER", "anthropic", "MODEL", "openai/gpt-4o", "0.048000"} {
+		if !strings.Contains(report, s) {
+			t.Errorf("report missing %q:\n%s", s, report)
+		}
+	}
+
+	noStore, _ := NewLLMClient(testPrivateKey)
+	if noStore.SpendingByProvider() != nil {
+		t.Error("expected nil without a receipt store")
+	}
+}
+
+func TestFileReceiptStoreMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.jsonl")
+	lines := `{"ts":"2026-01-01T00:00:00Z","endpoint":"/v1/chat/completions","model":"openai/gpt-4o","amount":"1000","cost_usd":0.001}
+not json
+{"ts":"2026-01-01T00:00:01Z","endpoint":"/v1/search","amount":"500","cost_usd":0.0005}
+`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewFileReceiptStore(path)
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	receipts, _ := store.List(time.Time{}, time.Time{})
+	if len(receipts) != 2 {
+		t.Fatalf("expected 2 receipts, got %d", len(receipts))
+	}
+	if receipts[0].Provider != "openai" {
+		t.Errorf("expected provider openai, got %q", receipts[0].Provider)
+	}
+	if receipts[1].Provider != "" {
+		t.Errorf("expected no provider without a model, got %q", receipts[1].Provider)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "not json\n") {
+		t.Error("expected malformed lines to be preserved")
+	}
+}
@@ -0,0 +1,134 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+var (
+	// ErrNoReceiptStore is returned by calls that need WithReceiptStore.
+	ErrNoReceiptStore = errors.New("blockrun: no receipt store configured (see WithReceiptStore)")
+	// ErrResetNotConfirmed is returned by ResetAllTimeSpending when confirm is
+	// false.
+	ErrResetNotConfirmed = errors.New("blockrun: ResetAllTimeSpending called without confirm")
+)
+
+// SpendingTotal aggregates every receipt in a client's ReceiptStore, which
+// may include payments made by other clients and earlier processes sharing
+// the store.
+type SpendingTotal struct {
+	AllTimeUSD     float64
+	Last24HoursUSD float64
+	// CurrentSessionUSD is this client's GetSpending total.
+	CurrentSessionUSD float64
+	// TotalCalls is the number of receipts.
+	TotalCalls int
+	// ByModel is keyed by model, or by endpoint for calls without one.
+	ByModel map[string]float64
+	// ByDate is keyed by UTC date, "2006-01-02".
+	ByDate map[string]float64
+}
+
+// RecentReceipts returns up to n of the most recent receipts in the client's
+// ReceiptStore, newest first. It returns ErrNoReceiptStore if
+// WithReceiptStore was not used.
+func (c *LLMClient) RecentReceipts(n int) ([]Receipt, error) {
+	if c.receipts == nil {
+		return nil, ErrNoReceiptStore
+	}
+	receipts, err := c.receipts.List(time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if n >= 0 && len(receipts) > n {
+		receipts = receipts[len(receipts)-n:]
+	}
+	recent := make([]Receipt, len(receipts))
+	for i, r := range receipts {
+		recent[len(receipts)-1-i] = r
+	}
+	return recent, nil
+}
+
+// ReceiptTruncater is implemented by receipt stores that can delete every
+// receipt, as ResetAllTimeSpending requires.
+type ReceiptTruncater interface {
+	Truncate() error
+}
+
+// GetTotalSpending totals the receipts in the client's ReceiptStore. It
+// returns ErrNoReceiptStore if WithReceiptStore was not used.
+func (c *LLMClient) GetTotalSpending(ctx context.Context) (*SpendingTotal, error) {
+	if c.receipts == nil {
+		return nil, ErrNoReceiptStore
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	receipts, err := c.receipts.List(time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	total := &SpendingTotal{
+		CurrentSessionUSD: c.GetSpending().TotalUSD,
+		TotalCalls:        len(receipts),
+		ByModel:           make(map[string]float64),
+		ByDate:            make(map[string]float64),
+	}
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	for _, r := range receipts {
+		total.AllTimeUSD += r.CostUSD
+		if !r.Timestamp.Before(dayAgo) {
+			total.Last24HoursUSD += r.CostUSD
+		}
+		key := r.Model
+		if key == "" {
+			key = r.Endpoint
+		}
+		total.ByModel[key] += r.CostUSD
+		total.ByDate[r.Timestamp.UTC().Format(time.DateOnly)] += r.CostUSD
+	}
+	return total, nil
+}
+
+// ResetAllTimeSpending deletes every receipt in the client's ReceiptStore,
+// including those written by other clients sharing it. confirm must be true;
+// it exists so the call cannot be made by accident. The store must implement
+// ReceiptTruncater. The current session's GetSpending total is not reset.
+func (c *LLMClient) ResetAllTimeSpending(ctx context.Context, confirm bool) error {
+	if !confirm {
+		return ErrResetNotConfirmed
+	}
+	if c.receipts == nil {
+		return ErrNoReceiptStore
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t, ok := c.receipts.(ReceiptTruncater)
+	if !ok {
+		return errors.New("blockrun: receipt store does not support Truncate")
+	}
+	return t.Truncate()
+}
+
+// Truncate deletes every receipt.
+func (s *MemoryReceiptStore) Truncate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receipts = nil
+	return nil
+}
+
+// Truncate empties the receipt file.
+func (s *FileReceiptStore) Truncate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Truncate(s.path, 0); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
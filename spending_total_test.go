@@ -0,0 +1,90 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetTotalSpending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.jsonl")
+	now := time.Now().UTC()
+	fixtures := []Receipt{
+		{Timestamp: now.Add(-72 * time.Hour), Model: "openai/gpt-4o", CostUSD: 1.00},
+		{Timestamp: now.Add(-48 * time.Hour), Model: "anthropic/claude-sonnet-4", CostUSD: 0.50},
+		{Timestamp: now.Add(-2 * time.Hour), Model: "openai/gpt-4o", CostUSD: 0.25},
+		{Timestamp: now.Add(-time.Minute), Endpoint: "/v1/search", CostUSD: 0.05},
+	}
+	// Receipts written by another client sharing the file.
+	other := NewFileReceiptStore(path)
+	for _, r := range fixtures {
+		if err := other.Save(r); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	client, _ := NewLLMClient(testPrivateKey, WithReceiptStore(NewFileReceiptStore(path)))
+	ctx := context.Background()
+	total, err := client.GetTotalSpending(ctx)
+	if err != nil {
+		t.Fatalf("GetTotalSpending failed: %v", err)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !near(total.AllTimeUSD, 1.80) || !near(total.Last24HoursUSD, 0.30) || total.TotalCalls != 4 || total.CurrentSessionUSD != 0 {
+		t.Errorf("unexpected totals: %+v", total)
+	}
+	if !near(total.ByModel["openai/gpt-4o"], 1.25) || !near(total.ByModel["/v1/search"], 0.05) {
+		t.Errorf("unexpected ByModel: %v", total.ByModel)
+	}
+	if day := now.Add(-72 * time.Hour).Format(time.DateOnly); !near(total.ByDate[day], 1.00) {
+		t.Errorf("unexpected ByDate: %v", total.ByDate)
+	}
+
+	if err := client.ResetAllTimeSpending(ctx, false); !errors.Is(err, ErrResetNotConfirmed) {
+		t.Errorf("expected ErrResetNotConfirmed, got %v", err)
+	}
+	if total, _ := client.GetTotalSpending(ctx); total.TotalCalls != 4 {
+		t.Error("unconfirmed reset deleted receipts")
+	}
+	if err := client.ResetAllTimeSpending(ctx, true); err != nil {
+		t.Fatalf("ResetAllTimeSpending failed: %v", err)
+	}
+	if total, _ := client.GetTotalSpending(ctx); total.TotalCalls != 0 || total.AllTimeUSD != 0 {
+		t.Errorf("expected no receipts after reset, got %+v", total)
+	}
+	if err := other.Save(fixtures[0]); err != nil {
+		t.Fatalf("Save after reset: %v", err)
+	}
+
+	client, _ = NewLLMClient(testPrivateKey)
+	if _, err := client.GetTotalSpending(ctx); !errors.Is(err, ErrNoReceiptStore) {
+		t.Errorf("expected ErrNoReceiptStore, got %v", err)
+	}
+}
+
+func TestRecentReceipts(t *testing.T) {
+	client, _ := NewLLMClient(testPrivateKey)
+	if _, err := client.RecentReceipts(10); !errors.Is(err, ErrNoReceiptStore) {
+		t.Fatalf("expected ErrNoReceiptStore, got %v", err)
+	}
+
+	store := NewMemoryReceiptStore()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		store.Save(Receipt{Timestamp: now.Add(time.Duration(i) * time.Second), CostUSD: float64(i)})
+	}
+	client, _ = NewLLMClient(testPrivateKey, WithReceiptStore(store))
+	recent, err := client.RecentReceipts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 3 || recent[0].CostUSD != 4 || recent[2].CostUSD != 2 {
+		t.Errorf("expected the 3 newest receipts newest first, got %+v", recent)
+	}
+	if all, _ := client.RecentReceipts(10); len(all) != 5 {
+		t.Errorf("expected all 5 receipts, got %d", len(all))
+	}
+}
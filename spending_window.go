@@ -0,0 +1,151 @@
+package blockrun
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// realTimeRetention is how far back WithRealTimeAnalytics keeps events;
+// longer windows are read from the receipt store.
+const realTimeRetention = 24 * time.Hour
+
+// realTimePruneInterval is how often the analytics goroutine drops events
+// older than realTimeRetention.
+const realTimePruneInterval = time.Minute
+
+// spendEvent is one settled payment seen by a spendingTracker.
+type spendEvent struct {
+	at      time.Time
+	costUSD float64
+}
+
+// spendingTracker keeps the recent settled payments in memory so rolling
+// windows are answered without scanning the receipt store.
+type spendingTracker struct {
+	mu     sync.Mutex
+	events []spendEvent // oldest first
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// WithRealTimeAnalytics has the client keep its own payments of the last 24
+// hours in memory, pruned by a background goroutine, so SpendingWindow and
+// SpendingRate need no receipt store scan. Windows longer than 24 hours
+// still read the store. Call Close to stop the goroutine.
+func WithRealTimeAnalytics() ClientOption {
+	return func(c *LLMClient) {
+		c.analytics = &spendingTracker{}
+	}
+}
+
+// add records a payment settled at at.
+func (t *spendingTracker) add(at time.Time, costUSD float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, spendEvent{at: at, costUSD: costUSD})
+}
+
+// since returns the events from index i on, and the index after them.
+func (t *spendingTracker) since(i int) ([]spendEvent, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i > len(t.events) {
+		i = len(t.events)
+	}
+	return append([]spendEvent(nil), t.events[i:]...), len(t.events)
+}
+
+// merge adds events recorded elsewhere, such as by a fork, keeping the
+// events in time order.
+func (t *spendingTracker) merge(events []spendEvent) {
+	if len(events) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, events...)
+	sort.SliceStable(t.events, func(i, j int) bool { return t.events[i].at.Before(t.events[j].at) })
+}
+
+// window totals the events at or after since.
+func (t *spendingTracker) window(since time.Time) Spending {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var s Spending
+	for i := len(t.events) - 1; i >= 0 && !t.events[i].at.Before(since); i-- {
+		s.TotalUSD += t.events[i].costUSD
+		s.Calls++
+	}
+	return s
+}
+
+// prune drops the events before cutoff.
+func (t *spendingTracker) prune(cutoff time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := 0
+	for i < len(t.events) && t.events[i].at.Before(cutoff) {
+		i++
+	}
+	t.events = append([]spendEvent(nil), t.events[i:]...)
+}
+
+// start launches the pruning goroutine.
+func (t *spendingTracker) start() {
+	t.stop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(realTimePruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.prune(currentClock().Now().Add(-realTimeRetention))
+			}
+		}
+	}()
+}
+
+// close stops the pruning goroutine.
+func (t *spendingTracker) close() {
+	if t != nil && t.stop != nil {
+		t.once.Do(func() { close(t.stop) })
+	}
+}
+
+// SpendingWindow returns the spending and call count of the last d. It reads
+// the in-memory counter kept by WithRealTimeAnalytics when d fits in it, and
+// otherwise the receipt store, which may include payments by other clients
+// sharing it. Without either it returns zero Spending.
+func (c *LLMClient) SpendingWindow(d time.Duration) Spending {
+	since := currentClock().Now().Add(-d)
+	if c.analytics != nil && d <= realTimeRetention {
+		return c.analytics.window(since)
+	}
+	if c.receipts == nil {
+		return Spending{}
+	}
+	receipts, err := c.receipts.List(since, time.Time{})
+	if err != nil {
+		return Spending{}
+	}
+	var s Spending
+	for _, r := range receipts {
+		s.TotalUSD += r.CostUSD
+		s.Calls++
+	}
+	return s
+}
+
+// SpendingRate returns the spending rate in USD per hour, measured over the
+// last hour.
+func (c *LLMClient) SpendingRate() float64 {
+	return c.SpendingWindow(time.Hour).TotalUSD
+}
+
+// IsWithinBudgetRate reports whether SpendingRate is at most maxUSDPerHour.
+func (c *LLMClient) IsWithinBudgetRate(maxUSDPerHour float64) bool {
+	return c.SpendingRate() <= maxUSDPerHour
+}
@@ -0,0 +1,92 @@
+package blockrun
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSpendingWindowFromReceipts(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(NewMockClock(now))
+	defer SetClock(nil)
+
+	store := NewMemoryReceiptStore()
+	for _, r := range []struct {
+		ago  time.Duration
+		cost float64
+	}{
+		{10 * time.Minute, 0.01},
+		{time.Hour, 0.02},               // exactly on the boundary: inside
+		{time.Hour + time.Second, 0.04}, // just outside the hour
+		{3 * time.Hour, 0.08},
+	} {
+		store.Save(Receipt{Timestamp: now.Add(-r.ago), CostUSD: r.cost})
+	}
+	client, _ := NewLLMClient(testPrivateKey, WithReceiptStore(store))
+
+	if got := client.SpendingWindow(time.Hour); got.Calls != 2 || math.Abs(got.TotalUSD-0.03) > 1e-9 {
+		t.Errorf("expected 2 calls totalling $0.03 in the last hour, got %+v", got)
+	}
+	if got := client.SpendingWindow(4 * time.Hour); got.Calls != 4 || math.Abs(got.TotalUSD-0.15) > 1e-9 {
+		t.Errorf("expected every receipt in the last 4 hours, got %+v", got)
+	}
+	if rate := client.SpendingRate(); math.Abs(rate-0.03) > 1e-9 {
+		t.Errorf("expected a rate of $0.03/h, got %v", rate)
+	}
+	if !client.IsWithinBudgetRate(0.05) || client.IsWithinBudgetRate(0.02) {
+		t.Error("expected $0.03/h to be within $0.05/h but not $0.02/h")
+	}
+
+	bare, _ := NewLLMClient(testPrivateKey)
+	if got := bare.SpendingWindow(time.Hour); got != (Spending{}) {
+		t.Errorf("expected zero spending without a store, got %+v", got)
+	}
+}
+
+func TestRealTimeAnalyticsWindow(t *testing.T) {
+	mock := NewMockClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	SetClock(mock)
+	defer SetClock(nil)
+
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithRealTimeAnalytics())
+	defer client.Close()
+	client.costLog = nil
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Chat(ctx, "gpt-4o", "hi"); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+	mock.Advance(61 * time.Minute)
+	if _, err := client.Chat(ctx, "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if got := client.SpendingWindow(time.Hour); got.Calls != 1 {
+		t.Errorf("expected 1 call in the last hour, got %+v", got)
+	}
+	if got := client.SpendingWindow(2 * time.Hour); got.Calls != 3 {
+		t.Errorf("expected 3 calls in the last 2 hours, got %+v", got)
+	}
+
+	client.analytics.prune(mock.Now().Add(-time.Hour))
+	if got := client.SpendingWindow(2 * time.Hour); got.Calls != 1 {
+		t.Errorf("expected pruned events dropped, got %+v", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // ChatCompletionChunk represents a single SSE chunk from a streaming response.
@@ -18,6 +19,8 @@ type ChatCompletionChunk struct {
 	Created int64         `json:"created"`
 	Model   string        `json:"model"`
 	Choices []ChunkChoice `json:"choices"`
+	// Usage is sent by some providers on the final chunk.
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // ChunkChoice represents a single choice within a streaming chunk.
@@ -38,6 +41,12 @@ type Stream struct {
 	scanner *bufio.Scanner
 	body    io.ReadCloser
 	done    bool
+
+	// ctx is the stream's own context, cancelled by Close or by middleware
+	// through StreamChunkEvent.Cancel; bc delivers those events.
+	ctx    context.Context
+	cancel context.CancelFunc
+	bc     *baseClient
 }
 
 // Next returns the next chunk. Returns nil, nil when the stream is complete.
@@ -45,6 +54,9 @@ func (s *Stream) Next() (*ChatCompletionChunk, error) {
 	if s.done {
 		return nil, nil
 	}
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return nil, s.ctx.Err()
+	}
 
 	for s.scanner.Scan() {
 		line := s.scanner.Text()
@@ -73,9 +85,15 @@ func (s *Stream) Next() (*ChatCompletionChunk, error) {
 			return nil, fmt.Errorf("failed to unmarshal chunk: %w", err)
 		}
 
+		if s.bc != nil {
+			s.bc.emit(s.ctx, StreamChunkEvent{Chunk: &chunk, Cancel: s.cancel})
+		}
 		return &chunk, nil
 	}
 
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return nil, s.ctx.Err()
+	}
 	if err := s.scanner.Err(); err != nil {
 		return nil, fmt.Errorf("scanner error: %w", err)
 	}
@@ -94,14 +112,47 @@ func (s *Stream) Close() error {
 // ChatCompletionStream sends a streaming chat completion request and returns a Stream.
 //
 // The caller must call Stream.Close() when done reading to release the connection.
-func (c *LLMClient) ChatCompletionStream(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions) (*Stream, error) {
+// reqOpts apply to this call only (e.g. WithIdempotencyKey).
+func (c *LLMClient) ChatCompletionStream(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions, reqOpts ...RequestOption) (*Stream, error) {
+	ctx = WithRequestOptions(ctx, reqOpts...)
+	ctx = pinRequestDeadline(ctx, time.Now())
+
 	// Validate inputs
+	if model == "" {
+		model = c.defaultModel
+	}
+	if model == "" && len(messages) > 0 {
+		selected, err := c.taskModel(ctx, messages, c.outputTokens(opts))
+		if err != nil {
+			return nil, err
+		}
+		model = selected
+	}
+	model = c.ResolveModel(model)
 	if model == "" {
 		return nil, &ValidationError{Field: "model", Message: "Model is required"}
 	}
 	if len(messages) == 0 {
 		return nil, &ValidationError{Field: "messages", Message: "At least one message is required"}
 	}
+	if opts != nil && opts.AudioOutput != nil {
+		return nil, &ValidationError{
+			Field:      "AudioOutput",
+			Message:    "audio output is not supported on streams",
+			Suggestion: "Use ChatCompletion without Stream or BufferedStream",
+			FieldPath:  []string{"Options", "AudioOutput"},
+		}
+	}
+	if err := c.checkPromptLength(messages); err != nil {
+		return nil, err
+	}
+	if err := c.validateModel(ctx, model); err != nil {
+		return nil, err
+	}
+	if err := c.checkAnomalies(ctx, model, messages, opts); err != nil {
+		return nil, err
+	}
+	c.warnContextUsage(model, messages)
 
 	// Build request body
 	body := map[string]any{
@@ -112,6 +163,9 @@ func (c *LLMClient) ChatCompletionStream(ctx context.Context, model string, mess
 
 	// Apply options
 	maxTokens := DefaultMaxTokens
+	if c.defaultMaxTokens > 0 {
+		maxTokens = c.defaultMaxTokens
+	}
 	if opts != nil {
 		if opts.MaxTokens > 0 {
 			maxTokens = opts.MaxTokens
@@ -141,15 +195,65 @@ func (c *LLMClient) ChatCompletionStream(ctx context.Context, model string, mess
 			body["stop"] = opts.Stop
 		}
 	}
+	if err := applyThinking(body, model, opts, &maxTokens); err != nil {
+		return nil, err
+	}
 	body["max_tokens"] = maxTokens
+	if err := applyProviderOptions(body, opts); err != nil {
+		return nil, err
+	}
+	if err := c.checkRequestBodySize(body); err != nil {
+		return nil, err
+	}
 
-	url := c.apiURL + "/v1/chat/completions"
+	url := c.baseURL() + "/v1/chat/completions"
 
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode request body: %w", err)
 	}
 
+	done, err := c.drain.begin()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	releaseProvider, err := c.providerLimits.acquire(ctx, model)
+	if err != nil {
+		cancel()
+		done()
+		return nil, err
+	}
+	release, err := c.queue.acquire(ctx, priorityFrom(ctx))
+	if err != nil {
+		releaseProvider()
+		cancel()
+		done()
+		return nil, err
+	}
+	stream, err := c.openStream(ctx, url, jsonBody)
+	if err != nil {
+		release()
+		releaseProvider()
+		cancel()
+		done()
+		return nil, err
+	}
+	// Hold the queue and provider slots, and count as in flight, until the
+	// caller closes the stream.
+	stream.body = &cancelOnClose{ReadCloser: stream.body, cancel: func() {
+		release()
+		releaseProvider()
+		cancel()
+		done()
+	}}
+	stream.ctx, stream.cancel, stream.bc = ctx, cancel, c.baseClient
+	return stream, nil
+}
+
+// openStream sends a streaming request, handling the x402 payment flow.
+func (c *LLMClient) openStream(ctx context.Context, url string, jsonBody []byte) (*Stream, error) {
+	ctx = c.callContext(ctx)
 	// First attempt
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
@@ -158,7 +262,7 @@ func (c *LLMClient) ChatCompletionStream(ctx context.Context, model string, mess
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/event-stream")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -173,10 +277,7 @@ func (c *LLMClient) ChatCompletionStream(ctx context.Context, model string, mess
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("API error: %s", string(bodyBytes)),
-		}
+		return nil, newAPIError(resp.StatusCode, "API error", bodyBytes)
 	}
 
 	return &Stream{
@@ -187,97 +288,128 @@ func (c *LLMClient) ChatCompletionStream(ctx context.Context, model string, mess
 
 // handleStreamPaymentAndRetry handles a 402 response for streaming requests.
 func (c *LLMClient) handleStreamPaymentAndRetry(ctx context.Context, url string, jsonBody []byte, resp *http.Response) (*Stream, error) {
-	// Get payment required header
-	paymentHeader := resp.Header.Get("payment-required")
-	if paymentHeader == "" {
-		// Try to get from response body
-		var respBody map[string]any
-		if err := json.NewDecoder(resp.Body).Decode(&respBody); err == nil {
-			if _, ok := respBody["x402"]; ok {
-				jsonBytes, _ := json.Marshal(respBody)
-				paymentHeader = string(jsonBytes)
+	// Payment requirements come in the payment-required header or the body
+	respBody, _ := io.ReadAll(resp.Body)
+	paymentReq, err := paymentRequirementFromResponse(resp.Header, respBody)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Extract payment details
+		paymentOption, err := ExtractPaymentDetails(paymentReq)
+		if err != nil {
+			return nil, &PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
+		}
+
+		// Determine resource URL
+		resourceURL := paymentReq.Resource.URL
+		if resourceURL == "" {
+			resourceURL = url
+		}
+
+		// Create signed payment payload
+		paymentPayload, err := c.signPayment(ctx, paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
+		if err != nil {
+			return nil, err
+		}
+
+		// Retry with payment signature
+		retryReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create retry request: %w", err)
+		}
+		retryReq.Header.Set("Content-Type", "application/json")
+		retryReq.Header.Set("Accept", "text/event-stream")
+		retryReq.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
+
+		retryResp, err := c.do(retryReq)
+		if err != nil {
+			return nil, fmt.Errorf("retry request failed: %w", err)
+		}
+
+		// A rejected payment is re-signed against the new requirements
+		// until the retry limit
+		if retryResp.StatusCode == http.StatusPaymentRequired {
+			if paymentReq, err = c.rejectedPayment(ctx, retryResp, attempt); err != nil {
+				return nil, err
 			}
+			continue
 		}
-	}
 
-	if paymentHeader == "" {
-		return nil, &PaymentError{Message: "402 response but no payment requirements found"}
-	}
+		// Handle other errors
+		if retryResp.StatusCode != http.StatusOK {
+			defer retryResp.Body.Close()
+			bodyBytes, _ := io.ReadAll(retryResp.Body)
+			return nil, newAPIError(retryResp.StatusCode, "API error after payment", bodyBytes)
+		}
 
-	// Parse payment requirements
-	paymentReq, err := ParsePaymentRequired(paymentHeader)
-	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
-	}
+		c.clearPendingPayment(ctx)
 
-	// Extract payment details
-	paymentOption, err := ExtractPaymentDetails(paymentReq)
-	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to extract payment details: %v", err)}
-	}
+		// Track spending
+		c.recordSettledCost(ctx, paymentOption, c.endpointPath(url), modelFromBody(jsonBody), paymentPayload)
 
-	// Determine resource URL
-	resourceURL := paymentReq.Resource.URL
-	if resourceURL == "" {
-		resourceURL = url
+		return &Stream{
+			scanner: bufio.NewScanner(retryResp.Body),
+			body:    retryResp.Body,
+		}, nil
 	}
+}
 
-	// Create signed payment payload
-	paymentPayload, err := c.createPaymentPayload(paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
+// ChatCompletionWithCallback streams a chat completion, calling cb with each
+// non-empty content delta of the first choice, then cb("", true, nil) when
+// the stream ends. If the request or stream fails, cb("", false, err) is
+// called instead and err returned.
+func (c *LLMClient) ChatCompletionWithCallback(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions, cb func(delta string, done bool, err error)) error {
+	stream, err := c.ChatCompletionStream(ctx, model, messages, opts)
 	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to create payment: %v", err)}
+		cb("", false, err)
+		return err
 	}
+	defer stream.Close()
 
-	// Retry with payment signature
-	retryReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create retry request: %w", err)
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			cb("", false, err)
+			return err
+		}
+		if chunk == nil {
+			cb("", true, nil)
+			return nil
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			cb(chunk.Choices[0].Delta.Content, false, nil)
+		}
 	}
-	retryReq.Header.Set("Content-Type", "application/json")
-	retryReq.Header.Set("Accept", "text/event-stream")
-	retryReq.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
+}
 
-	retryResp, err := c.httpClient.Do(retryReq)
-	if err != nil {
-		return nil, fmt.Errorf("retry request failed: %w", err)
-	}
+// bufferedCompletion streams a chat completion and assembles the chunks into
+// a ChatResponse (see ChatCompletionOptions.BufferedStream).
+func (c *LLMClient) bufferedCompletion(ctx context.Context, model string, messages []ChatMessage, opts *ChatCompletionOptions) (*ChatResponse, error) {
+	streamOpts := *opts
+	streamOpts.Stream, streamOpts.BufferedStream = false, false
 
-	// Check for payment rejection
-	if retryResp.StatusCode == http.StatusPaymentRequired {
-		retryResp.Body.Close()
-		return nil, &PaymentError{Message: "Payment was rejected. Check your wallet balance."}
+	stream, err := c.ChatCompletionStream(ctx, model, messages, &streamOpts)
+	if err != nil {
+		return nil, err
 	}
+	defer stream.Close()
 
-	// Handle other errors
-	if retryResp.StatusCode != http.StatusOK {
-		defer retryResp.Body.Close()
-		bodyBytes, _ := io.ReadAll(retryResp.Body)
-		return nil, &APIError{
-			StatusCode: retryResp.StatusCode,
-			Message:    fmt.Sprintf("API error after payment: %s", string(bodyBytes)),
+	acc := NewStreamAccumulator(0)
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	// Track spending
-	c.mu.Lock()
-	c.sessionCalls++
-	var costUSD float64
-	if amountStr := paymentOption.Amount; amountStr != "" {
-		var amountMicro float64
-		if _, err := fmt.Sscanf(amountStr, "%f", &amountMicro); err == nil {
-			costUSD = amountMicro / 1_000_000
-			c.sessionTotalUSD += costUSD
+		if chunk == nil {
+			break
 		}
+		acc.Add(chunk)
 	}
-	c.mu.Unlock()
-
-	if c.costLog != nil && costUSD > 0 {
-		endpoint := strings.TrimPrefix(url, c.apiURL)
-		c.costLog.Append(endpoint, costUSD)
+	resp := acc.Assemble()
+	if resp.Model == "" {
+		resp.Model = model
 	}
-
-	return &Stream{
-		scanner: bufio.NewScanner(retryResp.Body),
-		body:    retryResp.Body,
-	}, nil
+	return resp, nil
 }
@@ -0,0 +1,90 @@
+package blockrun
+
+import "strings"
+
+// StreamAccumulator assembles the chunks of a streamed chat completion into
+// a ChatResponse, keeping each choice apart however the provider
+// interleaves them:
+//
+//	acc := NewStreamAccumulator(1)
+//	for {
+//		chunk, err := stream.Next()
+//		if err != nil || chunk == nil {
+//			break
+//		}
+//		acc.Add(chunk)
+//	}
+//	resp := acc.Assemble()
+//
+// It is not safe for concurrent use.
+type StreamAccumulator struct {
+	resp    ChatResponse
+	choices []Choice
+	// contents holds pointers because a strings.Builder must not be
+	// copied once written, and growing the slice copies its elements.
+	contents []*strings.Builder
+}
+
+// NewStreamAccumulator returns an accumulator expecting n choices; more are
+// added as chunks for them arrive.
+func NewStreamAccumulator(n int) *StreamAccumulator {
+	a := &StreamAccumulator{}
+	a.grow(n - 1)
+	return a
+}
+
+// grow makes room for the choice with index.
+func (a *StreamAccumulator) grow(index int) {
+	for len(a.choices) <= index {
+		a.choices = append(a.choices, Choice{Index: len(a.choices), Message: ChatMessage{Role: "assistant"}})
+		a.contents = append(a.contents, &strings.Builder{})
+	}
+}
+
+// Add merges chunk's deltas into their choices. The response ID, creation
+// time and model come from the first chunk, and Usage from the last chunk
+// that has it.
+func (a *StreamAccumulator) Add(chunk *ChatCompletionChunk) {
+	if a.resp.ID == "" {
+		a.resp.ID, a.resp.Created, a.resp.Model = chunk.ID, chunk.Created, chunk.Model
+	}
+	if chunk.Usage != nil {
+		a.resp.Usage = *chunk.Usage
+	}
+	for _, cc := range chunk.Choices {
+		if cc.Index < 0 {
+			continue
+		}
+		a.grow(cc.Index)
+		if cc.Delta.Role != "" {
+			a.choices[cc.Index].Message.Role = cc.Delta.Role
+		}
+		a.contents[cc.Index].WriteString(cc.Delta.Content)
+		if cc.FinishReason != "" {
+			a.choices[cc.Index].FinishReason = cc.FinishReason
+		}
+	}
+}
+
+// Assemble returns the response accumulated so far, with one Choice per
+// choice index seen, in index order.
+func (a *StreamAccumulator) Assemble() *ChatResponse {
+	resp := a.resp
+	resp.Object = "chat.completion"
+	resp.Choices = make([]Choice, len(a.choices))
+	for i, c := range a.choices {
+		c.Message.Content = a.contents[i].String()
+		resp.Choices[i] = c
+	}
+	return &resp
+}
+
+// FinishReasons returns each choice's finish reason, in index order; it is
+// "" for choices still streaming.
+func (a *StreamAccumulator) FinishReasons() []string {
+	reasons := make([]string, len(a.choices))
+	for i, c := range a.choices {
+		reasons[i] = c.FinishReason
+	}
+	return reasons
+}
@@ -0,0 +1,101 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestStreamAccumulatorInterleavedChoices(t *testing.T) {
+	// Three choices, their deltas interleaved out of order; choice 1
+	// finishes first and usage arrives on a final choiceless chunk.
+	events := []string{
+		`{"id":"c1","created":1700000000,"model":"gpt-4o","choices":[{"index":2,"delta":{"role":"assistant","content":"Gamma"}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"role":"assistant","content":"Alpha"}},{"index":1,"delta":{"content":"Beta"}}]}`,
+		`{"id":"c1","choices":[{"index":1,"delta":{"content":" two"},"finish_reason":"stop"}]}`,
+		`{"id":"c1","choices":[{"index":2,"delta":{"content":" three"}},{"index":0,"delta":{"content":" one"}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"!"},"finish_reason":"stop"},{"index":2,"finish_reason":"length"}]}`,
+		`{"id":"c1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":9,"total_tokens":14}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	stream, err := client.ChatCompletionStream(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	acc := NewStreamAccumulator(3)
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk == nil {
+			break
+		}
+		acc.Add(chunk)
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Index == 1 && chunk.Choices[0].FinishReason == "stop" {
+			if got := acc.FinishReasons(); !reflect.DeepEqual(got, []string{"", "stop", ""}) {
+				t.Errorf("mid-stream finish reasons %q", got)
+			}
+		}
+	}
+
+	resp := acc.Assemble()
+	for i, want := range []string{"Alpha one!", "Beta two", "Gamma three"} {
+		if got := resp.Choices[i]; got.Index != i || got.Message.Content != want || got.Message.Role != "assistant" {
+			t.Errorf("choice %d: expected %q, got %+v", i, want, got)
+		}
+	}
+	if got := acc.FinishReasons(); !reflect.DeepEqual(got, []string{"stop", "stop", "length"}) {
+		t.Errorf("unexpected finish reasons %q", got)
+	}
+	if resp.ID != "c1" || resp.Model != "gpt-4o" || resp.Created != 1700000000 || resp.Object != "chat.completion" {
+		t.Errorf("unexpected response metadata %+v", resp)
+	}
+	if resp.Usage.TotalTokens != 14 {
+		t.Errorf("expected usage from the final chunk, got %+v", resp.Usage)
+	}
+}
+
+func TestStreamAccumulatorGrows(t *testing.T) {
+	acc := NewStreamAccumulator(1)
+	if got := len(acc.Assemble().Choices); got != 1 {
+		t.Errorf("expected 1 preallocated choice, got %d", got)
+	}
+	acc.Add(&ChatCompletionChunk{Choices: []ChunkChoice{{Index: 3, Delta: ChunkDelta{Content: "late"}}}})
+	resp := acc.Assemble()
+	if len(resp.Choices) != 4 || resp.Choices[3].Message.Content != "late" {
+		t.Errorf("expected 4 choices ending in \"late\", got %+v", resp.Choices)
+	}
+}
+
+func TestStreamAccumulatorGrowsAfterWrites(t *testing.T) {
+	acc := NewStreamAccumulator(1)
+	for i := 1; i <= 3; i++ {
+		acc.Add(&ChatCompletionChunk{Choices: []ChunkChoice{{Index: i, Delta: ChunkDelta{Content: fmt.Sprint("c", i)}}}})
+	}
+	acc.Add(&ChatCompletionChunk{Choices: []ChunkChoice{{Index: 0, Delta: ChunkDelta{Content: "c0"}}}})
+	acc.Add(&ChatCompletionChunk{Choices: []ChunkChoice{{Index: 5, Delta: ChunkDelta{Content: "c5"}}}})
+	acc.Add(&ChatCompletionChunk{Choices: []ChunkChoice{{Index: 1, Delta: ChunkDelta{Content: "+"}}}})
+
+	var got []string
+	for _, c := range acc.Assemble().Choices {
+		got = append(got, c.Message.Content)
+	}
+	if want := []string{"c0", "c1+", "c2", "c3", "", "c5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected contents %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,81 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newDualModeServer answers the same completion as plain JSON or, when the
+// request asks for a stream, as SSE chunks ending with a usage chunk.
+func newDualModeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	parts := []string{"The", " quick", " brown", " fox."}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			fmt.Fprint(w, `{"id":"c1","object":"chat.completion","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"The quick brown fox."},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":4,"total_tokens":9}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, p := range parts {
+			delta := map[string]string{"content": p}
+			if i == 0 {
+				delta["role"] = "assistant"
+			}
+			finish := ""
+			if i == len(parts)-1 {
+				finish = "stop"
+			}
+			chunk, _ := json.Marshal(map[string]any{
+				"id": "c1", "object": "chat.completion.chunk", "created": 1700000000, "model": "gpt-4o",
+				"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":4,\"total_tokens\":9}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBufferedStreamMatchesNonStreaming(t *testing.T) {
+	server := newDualModeServer(t)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	messages := []ChatMessage{{Role: "user", Content: "Describe a fox"}}
+
+	plain, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	buffered, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, &ChatCompletionOptions{Stream: true, BufferedStream: true})
+	if err != nil {
+		t.Fatalf("buffered ChatCompletion: %v", err)
+	}
+
+	if buffered.ID != plain.ID || buffered.Model != plain.Model || buffered.Created != plain.Created {
+		t.Errorf("metadata differs: %+v vs %+v", buffered, plain)
+	}
+	if len(buffered.Choices) != 1 || !reflect.DeepEqual(buffered.Choices, plain.Choices) {
+		t.Errorf("choices differ: %+v vs %+v", buffered.Choices, plain.Choices)
+	}
+	if buffered.Usage != plain.Usage {
+		t.Errorf("usage differs: %+v vs %+v", buffered.Usage, plain.Usage)
+	}
+}
+
+func TestStreamWithoutBufferingIsAnError(t *testing.T) {
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL("http://127.0.0.1:1"))
+	_, err := client.ChatCompletion(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "hi"}}, &ChatCompletionOptions{Stream: true})
+	var streamErr *StreamingError
+	if !errors.As(err, &streamErr) {
+		t.Fatalf("expected a StreamingError, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -289,3 +290,64 @@ func TestChatCompletionStreamCollectContent(t *testing.T) {
 		t.Errorf("Expected collected content '%s', got '%s'", expected, fullContent)
 	}
 }
+
+func TestChatCompletionWithCallback(t *testing.T) {
+	parts := []string{"One", " two", " three", " four", " five."}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, p := range parts {
+			chunk, _ := json.Marshal(ChatCompletionChunk{Choices: []ChunkChoice{{Delta: ChunkDelta{Content: p}}}})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	var deltas []string
+	calls, dones := 0, 0
+	err := client.ChatCompletionWithCallback(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "Count"}}, nil,
+		func(delta string, done bool, err error) {
+			calls++
+			if err != nil {
+				t.Errorf("unexpected callback error: %v", err)
+			}
+			if done {
+				dones++
+				return
+			}
+			deltas = append(deltas, delta)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != len(parts)+1 || dones != 1 {
+		t.Errorf("expected %d delta calls and 1 done call, got %d calls, %d done", len(parts), calls, dones)
+	}
+	if got := strings.Join(deltas, ""); got != "One two three four five." {
+		t.Errorf("unexpected accumulated content %q", got)
+	}
+}
+
+func TestChatCompletionWithCallbackError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"error":"boom"}`)
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	var cbErr error
+	err := client.ChatCompletionWithCallback(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "Hi"}}, nil,
+		func(delta string, done bool, err error) {
+			if done {
+				t.Error("done should not be reported after a failure")
+			}
+			cbErr = err
+		})
+	if err == nil || cbErr != err {
+		t.Errorf("expected the same error from the callback and the return, got %v and %v", cbErr, err)
+	}
+}
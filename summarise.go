@@ -0,0 +1,91 @@
+package blockrun
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultSummaryInstruction is the meta-prompt SummarisingTruncation appends
+// to the transcript it asks the model to summarise.
+const DefaultSummaryInstruction = "Summarise this conversation in 2-3 sentences."
+
+// summaryPrefix starts the system message that replaces summarised turns.
+const summaryPrefix = "Earlier conversation summary: "
+
+// SummarisingTruncation is a TruncationStrategy that asks the session's
+// model to summarise the oldest half of the non-system messages and replaces
+// them with a single system message holding the summary. The summary call is
+// an ordinary ChatCompletion, so it is paid and tracked like any other.
+type SummarisingTruncation struct {
+	instruction string
+}
+
+// SummaryOption configures a SummarisingTruncation.
+type SummaryOption func(*SummarisingTruncation)
+
+// WithSummarySuffix replaces DefaultSummaryInstruction as the text appended
+// after the transcript.
+func WithSummarySuffix(s string) SummaryOption {
+	return func(t *SummarisingTruncation) {
+		t.instruction = s
+	}
+}
+
+// NewSummarisingTruncation returns a summarising strategy.
+func NewSummarisingTruncation(opts ...SummaryOption) *SummarisingTruncation {
+	t := &SummarisingTruncation{instruction: DefaultSummaryInstruction}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Truncate implements TruncationStrategy.
+func (t *SummarisingTruncation) Truncate(ctx context.Context, s *ConversationSession, history []ChatMessage, _ int) ([]ChatMessage, error) {
+	// Never summarise the message about to be sent.
+	var candidates []int
+	for i, m := range history[:len(history)-1] {
+		if m.Role != "system" {
+			candidates = append(candidates, i)
+		}
+	}
+	n := len(candidates) / 2
+	if n == 0 {
+		return history, nil
+	}
+	summarised := make(map[int]bool, n)
+	for _, i := range candidates[:n] {
+		summarised[i] = true
+	}
+
+	var transcript strings.Builder
+	for _, i := range candidates[:n] {
+		transcript.WriteString(history[i].Role + ": " + history[i].Content + "\n")
+	}
+	instruction := t.instruction
+	if instruction == "" {
+		instruction = DefaultSummaryInstruction
+	}
+	resp, err := s.client.ChatCompletion(ctx, s.Model, []ChatMessage{
+		{Role: "user", Content: transcript.String() + "\n" + instruction},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, &APIError{Message: "No choices in summary response"}
+	}
+	summary := ChatMessage{Role: "system", Content: summaryPrefix + strings.TrimSpace(resp.Choices[0].Message.Content)}
+
+	out := make([]ChatMessage, 0, len(history)-n+1)
+	for i, m := range history {
+		switch {
+		case i == candidates[0]:
+			out = append(out, summary)
+		case summarised[i]:
+		default:
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
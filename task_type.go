@@ -0,0 +1,97 @@
+package blockrun
+
+import (
+	"context"
+	"fmt"
+)
+
+// TaskType is a kind of work SelectCheapestModel picks a model for.
+type TaskType string
+
+// Task types and the catalogue category each needs.
+const (
+	TaskTypeChat          TaskType = "chat"
+	TaskTypeCoding        TaskType = "coding"
+	TaskTypeReasoning     TaskType = "reasoning"
+	TaskTypeSummarization TaskType = "summarization"
+	TaskTypeVision        TaskType = "vision"
+)
+
+// taskCategories maps a TaskType to the Model.Categories entry it needs.
+// Summarization needs no more than chat; maxTokens is what sets it apart.
+var taskCategories = map[TaskType]string{
+	TaskTypeChat:          "chat",
+	TaskTypeCoding:        "coding",
+	TaskTypeReasoning:     "reasoning",
+	TaskTypeSummarization: "chat",
+	TaskTypeVision:        "vision",
+}
+
+// selectionInputTokens prices a selection when maxTokens is not positive.
+const selectionInputTokens = 1000
+
+// WithDefaultTaskType has ChatCompletion (and so Chat) calls made without a
+// model, and without WithDefaultModel, use SelectCheapestModel for t, sized
+// for the prompt plus max_tokens.
+func WithDefaultTaskType(t TaskType) ClientOption {
+	return func(c *LLMClient) {
+		c.defaultTaskType = t
+	}
+}
+
+// SelectCheapestModel returns the ID of the cheapest listed model suited to
+// taskType: its Categories include the task's category and its context
+// window (see NominalContextTokens) holds at least maxTokens. Models are
+// ranked by the cost of maxTokens input tokens, so by input price; ties go
+// to the model listed first. Hidden models are skipped.
+func (c *LLMClient) SelectCheapestModel(ctx context.Context, taskType TaskType, maxTokens int) (string, error) {
+	category, ok := taskCategories[taskType]
+	if !ok {
+		return "", &ValidationError{Field: "taskType", Message: fmt.Sprintf("unknown task type %q", taskType), Value: taskType}
+	}
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return "", err
+	}
+	inputTokens := maxTokens
+	if inputTokens <= 0 {
+		inputTokens = selectionInputTokens
+	}
+
+	var best *Model
+	bestCost := 0.0
+	for i := range models {
+		m := &models[i]
+		if m.Hidden || !hasCategory(m, category) || NominalContextTokens(m) < maxTokens {
+			continue
+		}
+		if cost := modelCallCost(m, inputTokens, 0); best == nil || cost < bestCost {
+			best, bestCost = m, cost
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no %s model with a %d-token context", taskType, maxTokens)
+	}
+	return best.ID, nil
+}
+
+// outputTokens returns the max_tokens a call with opts sends.
+func (c *LLMClient) outputTokens(opts *ChatCompletionOptions) int {
+	if opts != nil && opts.MaxTokens > 0 {
+		return opts.MaxTokens
+	}
+	if c.defaultMaxTokens > 0 {
+		return c.defaultMaxTokens
+	}
+	return DefaultMaxTokens
+}
+
+// taskModel returns the model SelectCheapestModel picks for the client's
+// default task type, sized for messages plus maxTokens of output, or "" if
+// the client has none.
+func (c *LLMClient) taskModel(ctx context.Context, messages []ChatMessage, maxTokens int) (string, error) {
+	if c.defaultTaskType == "" {
+		return "", nil
+	}
+	return c.SelectCheapestModel(ctx, c.defaultTaskType, estimateTokens(messages)+maxTokens)
+}
@@ -0,0 +1,73 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// taskCatalogue lists models with different categories, prices and context
+// windows.
+const taskCatalogue = `{"data":[
+	{"id":"openai/gpt-4o","pricing":{"input":2.5,"output":10},"context_window":128000,"categories":["chat","vision","coding"]},
+	{"id":"deepseek/deepseek-chat","pricing":{"input":0.27,"output":1.1},"context_window":64000,"categories":["chat","coding"]},
+	{"id":"deepseek/deepseek-reasoner","pricing":{"input":0.55,"output":2.19},"context_window":64000,"categories":["chat","reasoning"]},
+	{"id":"google/gemini-2.5-flash","pricing":{"input":0.3,"output":2.5},"context_window":1000000,"categories":["chat","vision","reasoning"]},
+	{"id":"tiny/cheap","pricing":{"input":0.01,"output":0.02},"context_window":4000,"categories":["chat"]}
+]}`
+
+func TestSelectCheapestModel(t *testing.T) {
+	var chatModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			w.Write([]byte(taskCatalogue))
+			return
+		}
+		var body struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&body)
+		chatModel = body.Model
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	ctx := context.Background()
+	for _, tc := range []struct {
+		task      TaskType
+		maxTokens int
+		want      string
+	}{
+		{TaskTypeChat, 1000, "tiny/cheap"},
+		{TaskTypeChat, 8000, "deepseek/deepseek-chat"},
+		{TaskTypeCoding, 1000, "deepseek/deepseek-chat"},
+		{TaskTypeReasoning, 1000, "google/gemini-2.5-flash"},
+		{TaskTypeReasoning, 0, "google/gemini-2.5-flash"},
+		{TaskTypeVision, 1000, "google/gemini-2.5-flash"},
+		{TaskTypeSummarization, 200000, "google/gemini-2.5-flash"},
+	} {
+		got, err := client.SelectCheapestModel(ctx, tc.task, tc.maxTokens)
+		if err != nil {
+			t.Errorf("%s/%d: %v", tc.task, tc.maxTokens, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s/%d: expected %s, got %s", tc.task, tc.maxTokens, tc.want, got)
+		}
+	}
+	if _, err := client.SelectCheapestModel(ctx, TaskTypeCoding, 500000); err == nil {
+		t.Error("expected an error when no model's context is large enough")
+	}
+	if _, err := client.SelectCheapestModel(ctx, "poetry", 1000); err == nil {
+		t.Error("expected an unknown task type to be rejected")
+	}
+
+	client, _ = NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithDefaultTaskType(TaskTypeCoding))
+	if _, err := client.Chat(ctx, "", "Write a function."); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if chatModel != "deepseek/deepseek-chat" {
+		t.Errorf("expected Chat to auto-select deepseek/deepseek-chat, got %q", chatModel)
+	}
+}
@@ -0,0 +1,174 @@
+// Package testfacilitator settles x402 payments in process, against an
+// in-memory ledger instead of a blockchain, so a complete payment cycle can
+// be tested offline:
+//
+//	f := testfacilitator.NewInProcessFacilitator()
+//	f.SetBalance(wallet, 1_000_000) // $1.00
+//	server := httptest.NewServer(f.Middleware(apiHandler))
+//	client, _ := blockrun.NewLLMClient(key, blockrun.WithAPIURL(server.URL))
+package testfacilitator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// DefaultPayTo is the recipient of payments to a new facilitator.
+const DefaultPayTo = "0x1234567890123456789012345678901234567890"
+
+// Transaction is a payment the facilitator settled.
+type Transaction struct {
+	From        string
+	To          string
+	AmountMicro int64
+	Nonce       string
+	Timestamp   time.Time
+}
+
+// InProcessFacilitator is an x402 facilitator backed by an in-memory USDC
+// ledger. It is safe for concurrent use.
+type InProcessFacilitator struct {
+	// Requirement is sent with every 402. Its first option's Amount is the
+	// price of a request and its PayTo the recipient payments must name. It
+	// defaults to 1000 micro-USDC on Base, paid to DefaultPayTo. Change it
+	// only while no request is in flight.
+	Requirement blockrun.PaymentRequirement
+
+	mu           sync.Mutex
+	balances     map[string]int64
+	nonces       map[string]bool
+	transactions []Transaction
+}
+
+// NewInProcessFacilitator creates a facilitator in which every balance is
+// zero.
+func NewInProcessFacilitator() *InProcessFacilitator {
+	return &InProcessFacilitator{
+		Requirement: blockrun.PaymentRequirement{
+			X402Version: 2,
+			Accepts: []blockrun.PaymentOption{{
+				Scheme:            "exact",
+				Network:           "eip155:8453",
+				Amount:            "1000",
+				Asset:             blockrun.USDCBase,
+				PayTo:             DefaultPayTo,
+				MaxTimeoutSeconds: 300,
+			}},
+			Resource: blockrun.ResourceInfo{URL: "https://blockrun.ai/api/v1", Description: "In-process facilitator"},
+		},
+		balances: make(map[string]int64),
+		nonces:   make(map[string]bool),
+	}
+}
+
+// SetBalance sets address's balance in micro-USDC.
+func (f *InProcessFacilitator) SetBalance(address string, amountMicro int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[strings.ToLower(address)] = amountMicro
+}
+
+// Balance returns address's balance in micro-USDC.
+func (f *InProcessFacilitator) Balance(address string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.balances[strings.ToLower(address)]
+}
+
+// Transactions returns every settled payment, oldest first.
+func (f *InProcessFacilitator) Transactions() []Transaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Transaction(nil), f.transactions...)
+}
+
+// Middleware answers requests without a PAYMENT-SIGNATURE with a 402
+// carrying Requirement. A signed request is passed to next once its payment
+// settles; a payment that cannot settle is answered with a 402 saying why.
+func (f *InProcessFacilitator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			f.paymentRequired(w, "payment required")
+			return
+		}
+		if err := f.settle(sig); err != nil {
+			f.paymentRequired(w, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// settle verifies the payment in sig and debits the payer.
+func (f *InProcessFacilitator) settle(sig string) error {
+	signer, err := blockrun.VerifyPaymentPayload(sig)
+	if err != nil {
+		return err
+	}
+	data, _ := base64.StdEncoding.DecodeString(sig)
+	var payload blockrun.PaymentPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	auth := payload.Payload.Authorization
+
+	price, err := strconv.ParseInt(f.Requirement.Accepts[0].Amount, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid requirement amount %q", f.Requirement.Accepts[0].Amount)
+	}
+	amount, err := strconv.ParseInt(auth.Value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid authorization value %q", auth.Value)
+	}
+	if amount < price {
+		return fmt.Errorf("authorized %d micro-USDC, %d required", amount, price)
+	}
+	if !strings.EqualFold(auth.To, f.Requirement.Accepts[0].PayTo) {
+		return fmt.Errorf("payment to %s, expected %s", auth.To, f.Requirement.Accepts[0].PayTo)
+	}
+	now := time.Now().Unix()
+	validAfter, _ := strconv.ParseInt(auth.ValidAfter, 10, 64)
+	validBefore, _ := strconv.ParseInt(auth.ValidBefore, 10, 64)
+	if now < validAfter || now >= validBefore {
+		return fmt.Errorf("authorization not valid at %d", now)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.nonces[auth.Nonce] {
+		return fmt.Errorf("nonce %s already used", auth.Nonce)
+	}
+	from := strings.ToLower(signer)
+	if f.balances[from] < amount {
+		return fmt.Errorf("insufficient balance: %d micro-USDC, %d needed", f.balances[from], amount)
+	}
+	f.balances[from] -= amount
+	f.balances[strings.ToLower(auth.To)] += amount
+	f.nonces[auth.Nonce] = true
+	f.transactions = append(f.transactions, Transaction{
+		From:        signer,
+		To:          auth.To,
+		AmountMicro: amount,
+		Nonce:       auth.Nonce,
+		Timestamp:   time.Now().UTC(),
+	})
+	return nil
+}
+
+// paymentRequired writes a 402 carrying Requirement and reason.
+func (f *InProcessFacilitator) paymentRequired(w http.ResponseWriter, reason string) {
+	data, _ := json.Marshal(f.Requirement)
+	w.Header().Set("payment-required", base64.StdEncoding.EncodeToString(data))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	json.NewEncoder(w).Encode(map[string]string{"error": reason})
+}
@@ -0,0 +1,90 @@
+package testfacilitator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+const testPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+func TestPaymentCycle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // keep the cost log out of the real home
+
+	f := NewInProcessFacilitator()
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"paid"},"finish_reason":"stop"}]}`))
+	})
+	server := httptest.NewServer(f.Middleware(api))
+	defer server.Close()
+
+	client, err := blockrun.NewLLMClient(testPrivateKey, blockrun.WithAPIURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	wallet := client.GetWalletAddress()
+	f.SetBalance(wallet, 2500)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		reply, err := client.Chat(ctx, "gpt-4o", "hi")
+		if err != nil {
+			t.Fatalf("Chat %d failed: %v", i, err)
+		}
+		if reply != "paid" {
+			t.Errorf("unexpected reply %q", reply)
+		}
+	}
+
+	// 500 micro-USDC is left, less than the 1000 price.
+	_, err = client.Chat(ctx, "gpt-4o", "hi")
+	var payErr *blockrun.PaymentError
+	if !errors.As(err, &payErr) {
+		t.Fatalf("expected a PaymentError once the balance ran out, got %v", err)
+	}
+
+	txs := f.Transactions()
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txs))
+	}
+	if !strings.EqualFold(txs[0].From, wallet) || txs[0].AmountMicro != 1000 || txs[0].To != DefaultPayTo {
+		t.Errorf("unexpected transaction %+v", txs[0])
+	}
+	if txs[0].Nonce == txs[1].Nonce {
+		t.Error("expected distinct nonces")
+	}
+	if got := f.Balance(wallet); got != 500 {
+		t.Errorf("expected 500 left, got %d", got)
+	}
+	if got := f.Balance(DefaultPayTo); got != 2000 {
+		t.Errorf("expected the recipient to hold 2000, got %d", got)
+	}
+}
+
+func TestRejectsInvalidSignature(t *testing.T) {
+	f := NewInProcessFacilitator()
+	served := false
+	server := httptest.NewServer(f.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		served = true
+	})))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	req.Header.Set("PAYMENT-SIGNATURE", "not-a-payment")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPaymentRequired || served {
+		t.Errorf("expected a 402 without reaching the handler, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("payment-required") == "" {
+		t.Error("expected the payment requirement with the 402")
+	}
+}
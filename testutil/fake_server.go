@@ -0,0 +1,157 @@
+// Package testutil provides a fake BlockRun gateway for testing code built on
+// the SDK without a wallet balance or network access.
+//
+//	fake := testutil.NewFakeBlockRunServer(t)
+//	fake.ChatResponses = append(fake.ChatResponses, blockrun.ChatResponse{...})
+//	client, _ := blockrun.NewLLMClient(key, blockrun.WithAPIURL(fake.URL))
+package testutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	blockrun "github.com/BlockRunAI/blockrun-llm-go"
+)
+
+// FakeBlockRunServer is an httptest server speaking enough of the BlockRun
+// API for SDK clients: chat completions and image generations behind the
+// x402 402→sign→retry flow, and the model catalogues. The exported fields
+// may be changed between requests but not while a request is in flight.
+type FakeBlockRunServer struct {
+	*httptest.Server
+
+	// PaymentRequirement is sent with the 402 answering an unsigned chat or
+	// image request; a request carrying PAYMENT-SIGNATURE is served. Set it
+	// to nil to serve every request free. It defaults to 1000 micro-USDC on
+	// Base.
+	PaymentRequirement *blockrun.PaymentRequirement
+	// ChatResponses are returned by /v1/chat/completions in order; once it
+	// is empty a one-choice "ok" response is returned.
+	ChatResponses []blockrun.ChatResponse
+	// ImageResponses are returned by /v1/images/generations in order; once
+	// it is empty a one-image response is returned.
+	ImageResponses []blockrun.ImageResponse
+	// Models and ImageModels are served by /v1/models and /v1/images/models.
+	Models      []blockrun.Model
+	ImageModels []blockrun.ImageModel
+
+	mu           sync.Mutex
+	payments     []string
+	chatRequests []map[string]any
+}
+
+// NewFakeBlockRunServer starts a FakeBlockRunServer, closed when t ends.
+func NewFakeBlockRunServer(t *testing.T) *FakeBlockRunServer {
+	t.Helper()
+	f := &FakeBlockRunServer{
+		PaymentRequirement: &blockrun.PaymentRequirement{
+			X402Version: 2,
+			Accepts: []blockrun.PaymentOption{{
+				Scheme:            "exact",
+				Network:           "eip155:8453",
+				Amount:            "1000",
+				Asset:             blockrun.USDCBase,
+				PayTo:             "0x1234567890123456789012345678901234567890",
+				MaxTimeoutSeconds: 300,
+			}},
+			Resource: blockrun.ResourceInfo{URL: "https://blockrun.ai/api/v1", Description: "BlockRun test server"},
+		},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// RecordedPayments returns the decoded PAYMENT-SIGNATURE of every paid
+// request, in order. Signatures that do not decode are skipped.
+func (f *FakeBlockRunServer) RecordedPayments() []blockrun.PaymentPayload {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []blockrun.PaymentPayload
+	for _, sig := range f.payments {
+		data, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil {
+			continue
+		}
+		var p blockrun.PaymentPayload
+		if json.Unmarshal(data, &p) == nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ChatRequests returns the body of every served chat completion request, in
+// order. Unpaid probes answered with a 402 are not included.
+func (f *FakeBlockRunServer) ChatRequests() []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]any(nil), f.chatRequests...)
+}
+
+func (f *FakeBlockRunServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/models":
+		writeJSON(w, map[string]any{"data": f.Models})
+	case "/v1/images/models":
+		writeJSON(w, map[string]any{"data": f.ImageModels})
+	case "/v1/chat/completions":
+		body, _ := io.ReadAll(r.Body)
+		if !f.paid(w, r) {
+			return
+		}
+		var req map[string]any
+		json.Unmarshal(body, &req)
+		f.chatRequests = append(f.chatRequests, req)
+		resp := blockrun.ChatResponse{
+			ID:      "chatcmpl-fake",
+			Object:  "chat.completion",
+			Choices: []blockrun.Choice{{Message: blockrun.ChatMessage{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		}
+		if len(f.ChatResponses) > 0 {
+			resp, f.ChatResponses = f.ChatResponses[0], f.ChatResponses[1:]
+		}
+		writeJSON(w, resp)
+	case "/v1/images/generations":
+		if !f.paid(w, r) {
+			return
+		}
+		resp := blockrun.ImageResponse{Created: 1, Data: []blockrun.ImageData{{URL: "https://example.com/fake.png"}}}
+		if len(f.ImageResponses) > 0 {
+			resp, f.ImageResponses = f.ImageResponses[0], f.ImageResponses[1:]
+		}
+		writeJSON(w, resp)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// paid records r's payment and reports whether to serve it, answering with
+// a 402 if it is unsigned and a payment is required. f.mu must be held.
+func (f *FakeBlockRunServer) paid(w http.ResponseWriter, r *http.Request) bool {
+	sig := r.Header.Get("PAYMENT-SIGNATURE")
+	if sig != "" {
+		f.payments = append(f.payments, sig)
+		return true
+	}
+	if f.PaymentRequirement == nil {
+		return true
+	}
+	data, _ := json.Marshal(f.PaymentRequirement)
+	w.Header().Set("payment-required", base64.StdEncoding.EncodeToString(data))
+	w.WriteHeader(http.StatusPaymentRequired)
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
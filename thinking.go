@@ -0,0 +1,65 @@
+package blockrun
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MinThinkingBudget is the smallest thinking budget Anthropic accepts, and
+// the budget used when ThinkingOptions.BudgetTokens is 0.
+const MinThinkingBudget = 1024
+
+// ThinkingOptions enables Anthropic extended thinking (see
+// ChatCompletionOptions.Thinking).
+type ThinkingOptions struct {
+	Enabled bool
+	// BudgetTokens caps the tokens spent thinking; 0 uses MinThinkingBudget.
+	// It counts toward max_tokens, which is raised above it if needed.
+	BudgetTokens int
+}
+
+// isAnthropicModel reports whether model is served by Anthropic.
+func isAnthropicModel(model string) bool {
+	return strings.HasPrefix(model, "anthropic/") || strings.HasPrefix(model, "claude")
+}
+
+// applyThinking adds Anthropic's "thinking" parameter to body when
+// opts.Thinking is enabled for an Anthropic model, raising *maxTokens above
+// the budget. Other models are left alone.
+func applyThinking(body map[string]any, model string, opts *ChatCompletionOptions, maxTokens *int) error {
+	if opts == nil || opts.Thinking == nil || !opts.Thinking.Enabled || !isAnthropicModel(model) {
+		return nil
+	}
+	budget := opts.Thinking.BudgetTokens
+	if budget == 0 {
+		budget = MinThinkingBudget
+	}
+	if budget < MinThinkingBudget {
+		return &ValidationError{
+			Field:      "BudgetTokens",
+			Message:    fmt.Sprintf("thinking budget must be at least %d tokens", MinThinkingBudget),
+			Suggestion: "Leave BudgetTokens at 0 to use the minimum",
+			Value:      budget,
+			FieldPath:  []string{"Options", "Thinking", "BudgetTokens"},
+		}
+	}
+	body["thinking"] = map[string]any{"type": "enabled", "budget_tokens": budget}
+	if *maxTokens <= budget {
+		*maxTokens = budget + DefaultMaxTokens
+	}
+	return nil
+}
+
+// ExtractThinking returns the model's thinking from the first choice of
+// resp: Anthropic thinking blocks, or the reasoning text other providers
+// return. It returns "" if there is none.
+func ExtractThinking(resp *ChatResponse) string {
+	if resp == nil || len(resp.Choices) == 0 {
+		return ""
+	}
+	msg := resp.Choices[0].Message
+	if msg.ThinkingContent != "" {
+		return msg.ThinkingContent
+	}
+	return msg.ReasoningContent
+}
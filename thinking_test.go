@@ -0,0 +1,91 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testThinkingResponseJSON = `{
+	"id": "msg_1",
+	"model": "anthropic/claude-sonnet-4",
+	"choices": [{
+		"index": 0,
+		"message": {
+			"role": "assistant",
+			"content": [
+				{"type": "thinking", "thinking": "The user wants 2+2.", "signature": "sig"},
+				{"type": "text", "text": "4"}
+			]
+		},
+		"finish_reason": "stop"
+	}]
+}`
+
+func TestChatCompletionThinking(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Write([]byte(testThinkingResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	ctx := context.Background()
+	messages := []ChatMessage{{Role: "user", Content: "2+2?"}}
+	opts := &ChatCompletionOptions{Thinking: &ThinkingOptions{Enabled: true, BudgetTokens: 2048}}
+
+	resp, err := client.ChatCompletion(ctx, "anthropic/claude-sonnet-4", messages, opts)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	thinking, ok := bodies[0]["thinking"].(map[string]any)
+	if !ok || thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(2048) {
+		t.Errorf("unexpected thinking parameter: %v", bodies[0]["thinking"])
+	}
+	if maxTokens := bodies[0]["max_tokens"].(float64); maxTokens <= 2048 {
+		t.Errorf("expected max_tokens above the budget, got %v", maxTokens)
+	}
+	if got := resp.Choices[0].Message.Content; got != "4" {
+		t.Errorf("expected content %q, got %q", "4", got)
+	}
+	if got := ExtractThinking(resp); got != "The user wants 2+2." {
+		t.Errorf("unexpected thinking: %q", got)
+	}
+
+	if _, err := client.ChatCompletion(ctx, "openai/gpt-4o", messages, opts); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if _, ok := bodies[1]["thinking"]; ok {
+		t.Error("expected no thinking parameter for a non-Anthropic model")
+	}
+
+	opts.Thinking.BudgetTokens = 100
+	_, err = client.ChatCompletion(ctx, "anthropic/claude-sonnet-4", messages, opts)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "BudgetTokens" {
+		t.Errorf("expected a BudgetTokens ValidationError, got %v", err)
+	}
+}
+
+func TestExtractThinkingFallbacks(t *testing.T) {
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi","thinking":"flat"}}]}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := ExtractThinking(&resp); got != "flat" {
+		t.Errorf("expected flattened thinking, got %q", got)
+	}
+	resp.Choices[0].Message = ChatMessage{ReasoningContent: "reasoned"}
+	if got := ExtractThinking(&resp); got != "reasoned" {
+		t.Errorf("expected reasoning content, got %q", got)
+	}
+	if got := ExtractThinking(&ChatResponse{}); got != "" {
+		t.Errorf("expected no thinking, got %q", got)
+	}
+}
@@ -0,0 +1,70 @@
+package blockrun
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrCertificateNotPinned is returned (wrapped in the request error) when none
+// of the certificates presented by the gateway match a WithTLSPinning hash.
+var ErrCertificateNotPinned = errors.New("blockrun: certificate public key does not match any pinned hash")
+
+// WithTLSPinning pins the gateway's TLS public key. Each pin is the base64
+// SHA-256 hash of a certificate's SubjectPublicKeyInfo, as returned by
+// ExtractPubKeyHash; the handshake fails unless at least one certificate in
+// the presented chain matches. Normal CA verification still runs first, so
+// pinning only ever narrows what is trusted.
+//
+// The pin check is added to a clone of the current transport, so it composes
+// with an earlier WithHTTPClient. With no pins the option is a no-op.
+func WithTLSPinning(pubKeyHashSHA256 ...string) ClientOption {
+	return func(c *LLMClient) {
+		if len(pubKeyHashSHA256) == 0 {
+			return
+		}
+		pins := make(map[string]bool, len(pubKeyHashSHA256))
+		for _, pin := range pubKeyHashSHA256 {
+			pins[pin] = true
+		}
+
+		c.withTransport(func(transport *http.Transport) {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+			transport.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+				for _, cert := range cs.PeerCertificates {
+					if pins[pubKeyHash(cert)] {
+						return nil
+					}
+				}
+				return ErrCertificateNotPinned
+			}
+		})
+	}
+}
+
+// ExtractPubKeyHash returns the pin for the first certificate in a PEM
+// bundle: the base64 SHA-256 hash of its SubjectPublicKeyInfo.
+func ExtractPubKeyHash(pemCert []byte) (string, error) {
+	block, _ := pem.Decode(pemCert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", fmt.Errorf("no PEM certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return pubKeyHash(cert), nil
+}
+
+// pubKeyHash hashes a certificate's SubjectPublicKeyInfo for pinning.
+func pubKeyHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newPinningTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	t.Cleanup(server.Close)
+
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	pin, err := ExtractPubKeyHash(pemCert)
+	if err != nil {
+		t.Fatalf("ExtractPubKeyHash: %v", err)
+	}
+	return server, pin
+}
+
+func TestWithTLSPinningAcceptsPinnedCert(t *testing.T) {
+	server, pin := newPinningTestServer(t)
+
+	client, err := NewLLMClient(testPrivateKey,
+		WithAPIURL(server.URL),
+		WithHTTPClient(server.Client()), // trusts the test CA
+		WithTLSPinning("bm90LXRoZS1waW4=", pin),
+	)
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	client.costLog = nil
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatCompletion(context.Background(), "gpt-4o", messages, nil); err != nil {
+		t.Fatalf("expected pinned connection to succeed, got %v", err)
+	}
+}
+
+func TestWithTLSPinningRejectsUnpinnedCert(t *testing.T) {
+	server, _ := newPinningTestServer(t)
+
+	client, err := NewLLMClient(testPrivateKey,
+		WithAPIURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithTLSPinning("bm90LXRoZS1waW4="),
+	)
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+	_, err = client.ChatCompletion(context.Background(), "gpt-4o", messages, nil)
+	if err == nil {
+		t.Fatal("expected unpinned certificate to be rejected")
+	}
+	if !errors.Is(err, ErrCertificateNotPinned) {
+		t.Errorf("expected ErrCertificateNotPinned, got %v", err)
+	}
+}
+
+func TestWithTLSPinningNoPinsIsNoop(t *testing.T) {
+	custom := &http.Client{}
+	client, err := NewLLMClient(testPrivateKey, WithHTTPClient(custom), WithTLSPinning())
+	if err != nil {
+		t.Fatalf("NewLLMClient: %v", err)
+	}
+	if client.httpClient != custom {
+		t.Error("expected WithTLSPinning() with no pins to leave the HTTP client untouched")
+	}
+}
+
+func TestExtractPubKeyHashRejectsGarbage(t *testing.T) {
+	if _, err := ExtractPubKeyHash([]byte("not a cert")); err == nil {
+		t.Error("expected error for non-PEM input")
+	}
+}
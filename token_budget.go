@@ -0,0 +1,114 @@
+package blockrun
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// StreamChunkEvent is emitted for each chunk a Stream decodes. Cancel ends
+// the stream: its connection is closed and Next returns context.Canceled.
+type StreamChunkEvent struct {
+	Chunk  *ChatCompletionChunk
+	Cancel context.CancelFunc
+}
+
+// EventName implements Event.
+func (StreamChunkEvent) EventName() string { return "stream_chunk" }
+
+// TokenBudget is a Middleware that caps the output tokens of streamed
+// responses, counted approximately as whitespace-separated words. The budget
+// is cumulative: it is shared by every stream of the client it is installed
+// on and does not refill between streams, so once it is spent every later
+// stream is cancelled on its first chunk. Call Reset to start over, e.g. per
+// session or billing period. It is safe for concurrent use.
+type TokenBudget struct {
+	max                int
+	onApproachingLimit func(remaining int)
+
+	mu     sync.Mutex
+	spent  int
+	warned bool
+	cancel context.CancelFunc
+}
+
+// TokenBudgetMiddleware returns a TokenBudget allowing maxOutputTokens
+// streamed tokens. onApproachingLimit, if non-nil, is called once when 90%
+// of the budget is spent; once all of it is, the current stream is
+// cancelled. Install it with WithMiddleware.
+func TokenBudgetMiddleware(maxOutputTokens int, onApproachingLimit func(remaining int)) *TokenBudget {
+	return &TokenBudget{max: maxOutputTokens, onApproachingLimit: onApproachingLimit}
+}
+
+// OnEvent implements Middleware.
+func (b *TokenBudget) OnEvent(_ context.Context, ev Event) {
+	chunk, ok := ev.(StreamChunkEvent)
+	if !ok || chunk.Chunk == nil {
+		return
+	}
+	tokens := 0
+	for _, choice := range chunk.Chunk.Choices {
+		tokens += len(strings.Fields(choice.Delta.Content))
+	}
+
+	b.mu.Lock()
+	b.cancel = chunk.Cancel
+	b.spent += tokens
+	remaining := b.remaining()
+	warn := !b.warned && b.spent*10 >= b.max*9
+	if warn {
+		b.warned = true
+	}
+	exhausted := b.spent >= b.max
+	b.mu.Unlock()
+
+	if warn && b.onApproachingLimit != nil {
+		b.onApproachingLimit(remaining)
+	}
+	if exhausted && chunk.Cancel != nil {
+		chunk.Cancel()
+	}
+}
+
+// Remaining returns the number of tokens left in the budget.
+func (b *TokenBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining()
+}
+
+// remaining is Remaining without locking.
+func (b *TokenBudget) remaining() int {
+	if b.spent >= b.max {
+		return 0
+	}
+	return b.max - b.spent
+}
+
+// Spent returns the number of tokens counted so far.
+func (b *TokenBudget) Spent() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// Cancel cancels the stream the budget last saw a chunk from.
+func (b *TokenBudget) Cancel() {
+	b.mu.Lock()
+	cancel := b.cancel
+	b.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Reset restores the full budget: Spent returns to zero and the
+// approaching-limit callback may fire again. Streams already cancelled stay
+// cancelled.
+func (b *TokenBudget) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent = 0
+	b.warned = false
+	b.cancel = nil
+}
@@ -0,0 +1,150 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTokenStreamServer streams n single-token chunks.
+func newTokenStreamServer(t *testing.T, n int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"tok%d \"}}]}\n\n", i)
+			flusher.Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTokenBudgetCancelsStream(t *testing.T) {
+	server := newTokenStreamServer(t, 50)
+
+	var warnings []int
+	var spentAtWarning int
+	var budget *TokenBudget
+	budget = TokenBudgetMiddleware(20, func(remaining int) {
+		warnings = append(warnings, remaining)
+		spentAtWarning = budget.Spent()
+	})
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMiddleware(budget))
+
+	stream, err := client.ChatCompletionStream(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "count"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	defer stream.Close()
+
+	received := 0
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+			break
+		}
+		if chunk == nil {
+			t.Fatal("stream completed despite the budget")
+		}
+		received++
+	}
+
+	if received != 20 || budget.Spent() != 20 || budget.Remaining() != 0 {
+		t.Errorf("expected the stream cut at 20 tokens, got %d received, %d spent, %d remaining", received, budget.Spent(), budget.Remaining())
+	}
+	if len(warnings) != 1 || warnings[0] != 2 || spentAtWarning != 18 {
+		t.Errorf("expected one warning at 90%% (18 spent, 2 remaining), got %v at %d", warnings, spentAtWarning)
+	}
+}
+
+func TestTokenBudgetUnderLimit(t *testing.T) {
+	server := newTokenStreamServer(t, 5)
+	budget := TokenBudgetMiddleware(50, nil)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMiddleware(budget))
+
+	stream, err := client.ChatCompletionStream(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "count"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	defer stream.Close()
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if chunk == nil {
+			break
+		}
+	}
+	if budget.Spent() != 5 || budget.Remaining() != 45 {
+		t.Errorf("spent %d, remaining %d", budget.Spent(), budget.Remaining())
+	}
+}
+
+func TestTokenBudgetManualCancel(t *testing.T) {
+	server := newTokenStreamServer(t, 10)
+	budget := TokenBudgetMiddleware(100, nil)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMiddleware(budget))
+
+	stream, _ := client.ChatCompletionStream(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "count"}}, nil)
+	defer stream.Close()
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	budget.Cancel()
+	if _, err := stream.Next(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled after Cancel, got %v", err)
+	}
+}
+
+func TestTokenBudgetSharedUntilReset(t *testing.T) {
+	server := newTokenStreamServer(t, 50)
+	var warnings int
+	budget := TokenBudgetMiddleware(20, func(int) { warnings++ })
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithMiddleware(budget))
+
+	// drain reads a stream until it ends, returning the chunks received.
+	drain := func() int {
+		t.Helper()
+		stream, err := client.ChatCompletionStream(context.Background(), "gpt-4o", []ChatMessage{{Role: "user", Content: "count"}}, nil)
+		if err != nil {
+			t.Fatalf("ChatCompletionStream: %v", err)
+		}
+		defer stream.Close()
+		received := 0
+		for {
+			chunk, err := stream.Next()
+			if err != nil || chunk == nil {
+				return received
+			}
+			received++
+		}
+	}
+
+	if got := drain(); got != 20 {
+		t.Fatalf("first stream received %d chunks, want 20", got)
+	}
+	if got := drain(); got != 1 {
+		t.Errorf("expected a spent budget to cancel the next stream on its first chunk, got %d chunks", got)
+	}
+
+	budget.Reset()
+	if budget.Spent() != 0 || budget.Remaining() != 20 {
+		t.Errorf("after Reset: spent %d, remaining %d", budget.Spent(), budget.Remaining())
+	}
+	if got := drain(); got != 20 {
+		t.Errorf("after Reset the stream received %d chunks, want 20", got)
+	}
+	if warnings != 2 {
+		t.Errorf("expected the warning to fire again after Reset, got %d warnings", warnings)
+	}
+}
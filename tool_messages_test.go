@@ -0,0 +1,97 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestToolResultMessageRoundTrip(t *testing.T) {
+	msg := NewToolResultMessage("call_1", "get_weather", `{"temp":21}`)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	if fields["role"] != "tool" || fields["tool_call_id"] != "call_1" || fields["name"] != "get_weather" {
+		t.Errorf("unexpected wire form: %s", data)
+	}
+
+	var decoded ChatMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, msg) {
+		t.Errorf("round trip changed the message: %+v != %+v", decoded, msg)
+	}
+}
+
+func TestAddToolResult(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]ChatMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		mu.Lock()
+		requests = append(requests, body.Messages)
+		first := len(requests) == 1
+		mu.Unlock()
+
+		reply := ChatMessage{Role: "assistant", Content: "Sunny in both."}
+		if first {
+			reply = ChatMessage{Role: "assistant", ToolCalls: []ToolCall{
+				{ID: "call_1", Type: "function", Function: ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				{ID: "call_2", Type: "function", Function: ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Rome"}`}},
+			}}
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: reply}}})
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	session := NewConversationSession(client, "gpt-4o")
+	ctx := context.Background()
+
+	if _, err := session.Send(ctx, "Weather in Paris and Rome?"); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := session.AddToolResult(ctx, "call_1", "get_weather", "sunny")
+	if err != nil || reply != "" {
+		t.Fatalf("expected no request until every tool call has a result, got %q, %v", reply, err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request so far, got %d", len(requests))
+	}
+
+	reply, err = session.AddToolResult(ctx, "call_2", "get_weather", "sunny")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "Sunny in both." {
+		t.Errorf("unexpected reply %q", reply)
+	}
+
+	sent := requests[1]
+	if len(sent) != 4 {
+		t.Fatalf("expected user, assistant and two tool messages, got %+v", sent)
+	}
+	for i, id := range []string{"call_1", "call_2"} {
+		m := sent[2+i]
+		if m.Role != "tool" || m.ToolCallID != id || m.Name != "get_weather" || m.Content != "sunny" {
+			t.Errorf("tool message %d sent as %+v", i, m)
+		}
+	}
+	if got := len(session.History()); got != 5 {
+		t.Errorf("expected 5 messages in history, got %d", got)
+	}
+}
@@ -0,0 +1,131 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// maxToolRounds caps the tool-call rounds a ConversationSession with a
+// ToolRegistry runs for one message.
+const maxToolRounds = 10
+
+// ToolHandler runs a registered tool with the model's JSON arguments and
+// returns the result given back to the model.
+type ToolHandler func(args json.RawMessage) (string, error)
+
+// ToolRegistry is a set of tools and their handlers, kept in one place
+// rather than rebuilt for every call. It is safe for concurrent use.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	order []string
+	tools map[string]registeredTool
+}
+
+// registeredTool is a ToolRegistry entry.
+type registeredTool struct {
+	def     Tool
+	handler ToolHandler
+}
+
+// NewToolRegistry returns an empty registry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]registeredTool)}
+}
+
+// Register adds the tool name, described to the model by description and
+// the JSON Schema schema of its arguments (nil for none), run by handler.
+// It fails if name is empty or already registered, or schema is not a JSON
+// object.
+func (r *ToolRegistry) Register(name, description string, schema json.RawMessage, handler func(args json.RawMessage) (string, error)) error {
+	if name == "" {
+		return &ValidationError{Field: "name", Message: "tool name is required"}
+	}
+	if handler == nil {
+		return &ValidationError{Field: "handler", Message: "tool " + name + " has no handler"}
+	}
+	var params map[string]any
+	if len(schema) > 0 {
+		if err := json.Unmarshal(schema, &params); err != nil {
+			return &ValidationError{Field: "schema", Message: fmt.Sprintf("tool %s schema must be a JSON object: %v", name, err)}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[name]; ok {
+		return &ValidationError{Field: "name", Message: "tool " + name + " is already registered"}
+	}
+	r.tools[name] = registeredTool{
+		def: Tool{
+			Type:     "function",
+			Function: ToolFunction{Name: name, Description: description, Parameters: params},
+		},
+		handler: handler,
+	}
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Definitions returns the registered tools, in registration order, for
+// ChatCompletionOptions.Tools.
+func (r *ToolRegistry) Definitions() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := make([]Tool, len(r.order))
+	for i, name := range r.order {
+		defs[i] = r.tools[name].def
+	}
+	return defs
+}
+
+// Execute runs the tool name with argsJSON. Empty arguments are passed to
+// the handler as {}.
+func (r *ToolRegistry) Execute(name string, argsJSON json.RawMessage) (string, error) {
+	r.mu.RLock()
+	tool, ok := r.tools[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+	if len(argsJSON) == 0 {
+		argsJSON = json.RawMessage("{}")
+	}
+	return tool.handler(argsJSON)
+}
+
+// executeCall runs call through the registry, for ExecuteToolsParallel.
+func (r *ToolRegistry) executeCall(_ context.Context, call ToolCall) (string, error) {
+	return r.Execute(call.Function.Name, json.RawMessage(call.Function.Arguments))
+}
+
+// ErrToolRounds is returned when a model keeps calling registry tools past
+// the round limit.
+var ErrToolRounds = errors.New("blockrun: model kept calling tools")
+
+// WithToolRegistry has the session offer registry's tools to the model
+// (unless the session's options already set Tools), run the tools the
+// model calls and send their results back, until the model replies without
+// tool calls. Errors from a handler are reported to the model. It returns s.
+func (s *ConversationSession) WithToolRegistry(registry *ToolRegistry) *ConversationSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools = registry
+	return s
+}
+
+// sendOptions returns the options for a turn, with the registry's tools
+// added when the session has one.
+func (s *ConversationSession) sendOptions() *ChatCompletionOptions {
+	if s.tools == nil || (s.options != nil && len(s.options.Tools) > 0) {
+		return s.options
+	}
+	var opts ChatCompletionOptions
+	if s.options != nil {
+		opts = *s.options
+	}
+	opts.Tools = s.tools.Definitions()
+	return &opts
+}
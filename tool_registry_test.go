@@ -0,0 +1,101 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConversationWithToolRegistry(t *testing.T) {
+	registry := NewToolRegistry()
+	err := registry.Register("get_weather", "Current weather for a city.",
+		json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		func(args json.RawMessage) (string, error) {
+			var a struct{ City string }
+			if err := json.Unmarshal(args, &a); err != nil {
+				return "", err
+			}
+			return "sunny in " + a.City, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("get_time", "Current time.", nil, func(json.RawMessage) (string, error) {
+		return "12:00", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []struct {
+		Messages []ChatMessage `json:"messages"`
+		Tools    []Tool        `json:"tools"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []ChatMessage `json:"messages"`
+			Tools    []Tool        `json:"tools"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+
+		msg := ChatMessage{Role: "assistant", Content: "It is sunny and noon."}
+		if len(requests) == 1 {
+			msg = ChatMessage{Role: "assistant", ToolCalls: []ToolCall{
+				{ID: "call_1", Type: "function", Function: ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				{ID: "call_2", Type: "function", Function: ToolCallFunction{Name: "get_time"}},
+			}}
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: msg, FinishReason: "stop"}}})
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	session := NewConversationSession(client, "gpt-4o").WithToolRegistry(registry)
+	reply, err := session.Send(context.Background(), "Weather and time in Paris?")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if reply != "It is sunny and noon." {
+		t.Errorf("unexpected reply %q", reply)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if tools := requests[0].Tools; len(tools) != 2 || tools[0].Function.Name != "get_weather" || tools[1].Function.Name != "get_time" {
+		t.Errorf("expected both registry tools offered, got %+v", tools)
+	}
+	results := map[string]string{}
+	for _, m := range requests[1].Messages {
+		if m.Role == "tool" {
+			results[m.ToolCallID] = m.Content
+		}
+	}
+	if results["call_1"] != "sunny in Paris" || results["call_2"] != "12:00" {
+		t.Errorf("expected both tool results sent back, got %v", results)
+	}
+	if n := len(session.History()); n != 5 {
+		t.Errorf("expected user, assistant, 2 tool and assistant messages, got %d", n)
+	}
+}
+
+func TestToolRegistryErrors(t *testing.T) {
+	registry := NewToolRegistry()
+	noop := func(json.RawMessage) (string, error) { return "", nil }
+	if err := registry.Register("a", "", nil, noop); err != nil {
+		t.Fatal(err)
+	}
+	var vErr *ValidationError
+	if err := registry.Register("a", "", nil, noop); !errors.As(err, &vErr) {
+		t.Errorf("expected a duplicate name to be rejected, got %v", err)
+	}
+	if err := registry.Register("b", "", json.RawMessage(`[1]`), noop); !errors.As(err, &vErr) {
+		t.Errorf("expected a non-object schema to be rejected, got %v", err)
+	}
+	if _, err := registry.Execute("missing", nil); err == nil {
+		t.Error("expected an unknown tool to fail")
+	}
+}
@@ -0,0 +1,65 @@
+package blockrun
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultToolConcurrency is the number of tool calls ExecuteToolsParallel
+// runs at once when concurrency is not positive.
+const DefaultToolConcurrency = 4
+
+// ToolResult is the outcome of executing one ToolCall.
+type ToolResult struct {
+	ToolCallID string
+	Name       string
+	Content    string
+	Err        error
+}
+
+// ExecuteToolsParallel runs executor for each tool call with at most
+// concurrency in flight and returns the results in toolCalls order. A failed
+// call sets its result's Err without stopping the others; calls not started
+// before ctx ends get ctx's error. The returned error is non-nil only if ctx
+// ends before every call completes.
+func ExecuteToolsParallel(ctx context.Context, toolCalls []ToolCall, executor func(ctx context.Context, call ToolCall) (string, error), concurrency int) ([]ToolResult, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultToolConcurrency
+	}
+	results := make([]ToolResult, len(toolCalls))
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, call := range toolCalls {
+		i, call := i, call
+		g.Go(func() error {
+			result := ToolResult{ToolCallID: call.ID, Name: call.Function.Name}
+			if err := ctx.Err(); err != nil {
+				result.Err = err
+			} else {
+				result.Content, result.Err = executor(ctx, call)
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	g.Wait()
+
+	return results, ctx.Err()
+}
+
+// ToolResultsToMessages converts results to the "tool" messages that follow
+// the assistant message in the next ChatCompletion call. A failed result is
+// reported to the model as "Error: " and the error text.
+func ToolResultsToMessages(results []ToolResult) []ChatMessage {
+	messages := make([]ChatMessage, len(results))
+	for i, r := range results {
+		content := r.Content
+		if r.Err != nil {
+			content = "Error: " + r.Err.Error()
+		}
+		messages[i] = NewToolResultMessage(r.ToolCallID, r.Name, content)
+	}
+	return messages
+}
@@ -0,0 +1,90 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecuteToolsParallel(t *testing.T) {
+	var calls []ToolCall
+	for i := 0; i < 8; i++ {
+		calls = append(calls, ToolCall{ID: fmt.Sprintf("call_%d", i), Type: "function", Function: ToolCallFunction{Name: "lookup"}})
+	}
+
+	var inFlight, peak int32
+	executor := func(ctx context.Context, call ToolCall) (string, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		// Early calls are slowest so they finish last.
+		var i int
+		fmt.Sscanf(call.ID, "call_%d", &i)
+		time.Sleep(time.Duration(8-i) * 5 * time.Millisecond)
+		if i == 3 {
+			return "", errors.New("lookup failed")
+		}
+		return "result " + call.ID, nil
+	}
+
+	results, err := ExecuteToolsParallel(context.Background(), calls, executor, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent calls, saw %d", peak)
+	}
+	for i, r := range results {
+		if r.ToolCallID != calls[i].ID || r.Name != "lookup" {
+			t.Errorf("result %d out of order: %+v", i, r)
+		}
+		if i == 3 {
+			if r.Err == nil {
+				t.Errorf("expected result 3 to carry the error")
+			}
+		} else if r.Err != nil || r.Content != "result "+calls[i].ID {
+			t.Errorf("unexpected result %d: %+v", i, r)
+		}
+	}
+
+	messages := ToolResultsToMessages(results)
+	if len(messages) != len(calls) {
+		t.Fatalf("expected %d messages, got %d", len(calls), len(messages))
+	}
+	if m := messages[0]; m.Role != "tool" || m.ToolCallID != "call_0" || m.Name != "lookup" || m.Content != "result call_0" {
+		t.Errorf("unexpected message %+v", m)
+	}
+	if m := messages[3]; m.Content != "Error: lookup failed" {
+		t.Errorf("expected the error to be reported, got %q", m.Content)
+	}
+}
+
+func TestExecuteToolsParallelCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var ran int32
+	results, err := ExecuteToolsParallel(ctx, []ToolCall{{ID: "a"}, {ID: "b"}}, func(ctx context.Context, call ToolCall) (string, error) {
+		atomic.AddInt32(&ran, 1)
+		return "", nil
+	}, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if ran != 0 {
+		t.Errorf("expected no tool calls to start, %d did", ran)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("expected each result to carry the cancellation, got %+v", r)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package blockrun
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// W3C Trace Context headers.
+const (
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+)
+
+// traceParentRegex matches a version-00-shaped traceparent.
+var traceParentRegex = regexp.MustCompile(`^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// traceContext is the trace a call belongs to.
+type traceContext struct {
+	parent string
+	state  string
+}
+
+// traceContextKey is the context key under which a traceContext is stored.
+type traceContextKey struct{}
+
+// WithW3CTracing sends W3C Trace Context headers with every request. The
+// traceparent and tracestate come from ContextWithTraceParent, or else from
+// the OpenTelemetry span active on the call's context; only a call with
+// neither gets a fresh random traceparent. Either way the unsigned request
+// and its paid retry carry the same traceparent, so the gateway can link
+// them. Fields of the global OpenTelemetry propagator, such as baggage, are
+// injected too.
+func WithW3CTracing() ClientOption {
+	return func(c *LLMClient) {
+		c.w3cTracing = true
+	}
+}
+
+// ContextWithTraceParent returns a copy of ctx whose calls carry traceparent
+// and tracestate when the client uses WithW3CTracing. An invalid traceparent
+// is ignored, and a fresh one is generated instead.
+func ContextWithTraceParent(ctx context.Context, traceparent, tracestate string) context.Context {
+	if !validTraceParent(traceparent) {
+		return ctx
+	}
+	return context.WithValue(ctx, traceContextKey{}, &traceContext{parent: traceparent, state: tracestate})
+}
+
+// TraceParentFromContext returns the traceparent and tracestate on ctx, or
+// empty strings if there are none.
+func TraceParentFromContext(ctx context.Context) (traceparent, tracestate string) {
+	if tc, ok := ctx.Value(traceContextKey{}).(*traceContext); ok {
+		return tc.parent, tc.state
+	}
+	return "", ""
+}
+
+// validTraceParent reports whether s is a traceparent this client forwards:
+// well formed, not version ff and without all-zero IDs.
+func validTraceParent(s string) bool {
+	if !traceParentRegex.MatchString(s) || strings.HasPrefix(s, "ff-") {
+		return false
+	}
+	return s[3:35] != strings.Repeat("0", 32) && s[36:52] != strings.Repeat("0", 16)
+}
+
+// newTraceParent returns a version 00 traceparent with a random trace ID
+// and parent ID, flagged sampled and random-trace-ID (Trace Context Level 2).
+func newTraceParent() string {
+	var ids [24]byte
+	rand.Read(ids[:])
+	return "00-" + hex.EncodeToString(ids[:16]) + "-" + hex.EncodeToString(ids[16:]) + "-03"
+}
+
+// withTrace makes sure ctx carries a trace when W3C tracing is on, so that
+// every request of the call — probe, paid retry and polls — shares it.
+func (bc *baseClient) withTrace(ctx context.Context) context.Context {
+	if !bc.w3cTracing {
+		return ctx
+	}
+	if parent, _ := TraceParentFromContext(ctx); parent != "" {
+		return ctx
+	}
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		carrier := propagation.MapCarrier{}
+		propagation.TraceContext{}.Inject(ctx, carrier)
+		if parent := carrier.Get(traceParentHeader); validTraceParent(parent) {
+			return context.WithValue(ctx, traceContextKey{}, &traceContext{parent: parent, state: carrier.Get(traceStateHeader)})
+		}
+	}
+	return context.WithValue(ctx, traceContextKey{}, &traceContext{parent: newTraceParent()})
+}
+
+// setTraceHeaders stamps req with its call's trace when W3C tracing is on.
+func (bc *baseClient) setTraceHeaders(req *http.Request) {
+	if !bc.w3cTracing {
+		return
+	}
+	ctx := bc.withTrace(req.Context())
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	parent, state := TraceParentFromContext(ctx)
+	req.Header.Del(traceStateHeader)
+	req.Header.Set(traceParentHeader, parent)
+	if state != "" {
+		req.Header.Set(traceStateHeader, state)
+	}
+}
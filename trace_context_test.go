@@ -0,0 +1,131 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithW3CTracing(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var mu sync.Mutex
+	var seen []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Clone())
+		mu.Unlock()
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithW3CTracing())
+	client.costLog = nil
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := ContextWithTraceParent(context.Background(), parent, "vendor=abc")
+	if _, err := client.Chat(ctx, "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected probe and paid retry, got %d requests", len(seen))
+	}
+	for i, h := range seen {
+		if got := h.Get("traceparent"); got != parent {
+			t.Errorf("request %d: expected traceparent %q, got %q", i, parent, got)
+		}
+		if got := h.Get("tracestate"); got != "vendor=abc" {
+			t.Errorf("request %d: expected tracestate vendor=abc, got %q", i, got)
+		}
+	}
+
+	// Without a trace on the context, the call gets a generated one shared
+	// by the probe and the paid retry.
+	seen = nil
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	generated := seen[0].Get("traceparent")
+	if !validTraceParent(generated) {
+		t.Fatalf("expected a valid generated traceparent, got %q", generated)
+	}
+	if got := seen[1].Get("traceparent"); got != generated {
+		t.Errorf("expected paid retry traceparent %q, got %q", generated, got)
+	}
+	if seen[0].Get("tracestate") != "" {
+		t.Errorf("expected no tracestate, got %q", seen[0].Get("tracestate"))
+	}
+}
+
+func TestTraceHeadersOffByDefault(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	ctx := ContextWithTraceParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "")
+	if _, err := client.Chat(ctx, "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got != "" {
+		t.Errorf("expected no traceparent without WithW3CTracing, got %q", got)
+	}
+}
+
+func TestContextWithTraceParentRejectsInvalid(t *testing.T) {
+	for _, tp := range []string{
+		"",
+		"garbage",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	} {
+		ctx := ContextWithTraceParent(context.Background(), tp, "")
+		if got, _ := TraceParentFromContext(ctx); got != "" {
+			t.Errorf("expected %q to be rejected, got %q", tp, got)
+		}
+	}
+}
+
+func TestW3CTracingUsesActiveSpan(t *testing.T) {
+	var got, gotState string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+		gotState = r.Header.Get("tracestate")
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithW3CTracing())
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	state, _ := trace.ParseTraceState("vendor=abc")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		TraceState: state,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	if _, err := client.Chat(ctx, "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; got != want {
+		t.Errorf("expected the active span's traceparent %q, got %q", want, got)
+	}
+	if gotState != "vendor=abc" {
+		t.Errorf("expected tracestate vendor=abc, got %q", gotState)
+	}
+}
@@ -0,0 +1,95 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonObjectFormat is the response_format that asks for JSON mode.
+var jsonObjectFormat = map[string]string{"type": "json_object"}
+
+// ChatCompletionTyped calls client.ChatCompletion in JSON mode and decodes
+// the first choice's content into T. opts.ResponseFormat is only set if the
+// caller left it empty, so a json_schema format can be passed instead. The
+// response is returned alongside T, including when decoding fails.
+func ChatCompletionTyped[T any](ctx context.Context, client *LLMClient, model string, messages []ChatMessage, opts *ChatCompletionOptions) (T, *ChatResponse, error) {
+	var zero T
+	o := ChatCompletionOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ResponseFormat == nil {
+		o.ResponseFormat = jsonObjectFormat
+	}
+	resp, err := client.ChatCompletion(ctx, model, messages, &o)
+	if err != nil {
+		return zero, nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return zero, resp, &APIError{Message: "No choices in response"}
+	}
+	v, err := TryParse[T](resp.Choices[0].Message.Content)
+	return v, resp, err
+}
+
+// TryParse decodes content into T. A Markdown code fence around the JSON,
+// which some models add even in JSON mode, is ignored.
+func TryParse[T any](content string) (T, error) {
+	var v T
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") && strings.HasSuffix(content, "```") {
+		content = strings.TrimSuffix(content, "```")
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			content = content[i+1:]
+		} else {
+			content = strings.TrimPrefix(content, "```")
+		}
+	}
+	if err := json.Unmarshal([]byte(content), &v); err != nil {
+		return v, fmt.Errorf("failed to parse response as %T: %w", v, err)
+	}
+	return v, nil
+}
+
+// MustParse decodes the first choice of resp into T, panicking if it cannot.
+// Use it where a malformed response is a programming error; otherwise use
+// TryParse.
+func MustParse[T any](resp *ChatResponse) T {
+	if resp == nil || len(resp.Choices) == 0 {
+		panic("blockrun: MustParse: no choices in response")
+	}
+	v, err := TryParse[T](resp.Choices[0].Message.Content)
+	if err != nil {
+		panic("blockrun: MustParse: " + err.Error())
+	}
+	return v
+}
+
+// TypedConversationSession is a ConversationSession in JSON mode whose
+// replies are decoded into T.
+type TypedConversationSession[T any] struct {
+	*ConversationSession
+}
+
+// NewTypedConversationSession starts a JSON-mode conversation with model.
+// system should describe the JSON shape of T; it may be empty.
+func NewTypedConversationSession[T any](client *LLMClient, model, system string) *TypedConversationSession[T] {
+	s := NewConversationSession(client, model,
+		WithSystemPrompt(system),
+		WithConversationOptions(&ChatCompletionOptions{ResponseFormat: jsonObjectFormat}),
+	)
+	return &TypedConversationSession[T]{ConversationSession: s}
+}
+
+// Send adds a user message and returns the assistant's reply decoded into
+// T. A reply that fails to decode is still kept in the history.
+func (s *TypedConversationSession[T]) Send(ctx context.Context, content string) (T, error) {
+	reply, err := s.ConversationSession.Send(ctx, content)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return TryParse[T](reply)
+}
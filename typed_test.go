@@ -0,0 +1,97 @@
+package blockrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testWeather struct {
+	City  string  `json:"city"`
+	TempC float64 `json:"temp_c"`
+}
+
+// newJSONChatServer replies to every chat completion with content and
+// records the response_format of each request.
+func newJSONChatServer(t *testing.T, content string, formats *[]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		*formats = append(*formats, body["response_format"])
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: ChatMessage{Role: "assistant", Content: content}}}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChatCompletionTyped(t *testing.T) {
+	var formats []any
+	server := newJSONChatServer(t, `{"city":"Lisbon","temp_c":21.5}`, &formats)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	got, resp, err := ChatCompletionTyped[testWeather](context.Background(), client, "openai/gpt-4o",
+		[]ChatMessage{{Role: "user", Content: "Weather in Lisbon?"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionTyped failed: %v", err)
+	}
+	if got != (testWeather{City: "Lisbon", TempC: 21.5}) {
+		t.Errorf("unexpected value: %+v", got)
+	}
+	if resp == nil {
+		t.Error("expected the response to be returned")
+	}
+	if f, _ := formats[0].(map[string]any); f["type"] != "json_object" {
+		t.Errorf("expected JSON mode, got %v", formats[0])
+	}
+	if MustParse[testWeather](resp) != got {
+		t.Error("MustParse disagrees with ChatCompletionTyped")
+	}
+
+	if _, _, err := ChatCompletionTyped[[]int](context.Background(), client, "openai/gpt-4o",
+		[]ChatMessage{{Role: "user", Content: "hi"}}, nil); err == nil {
+		t.Error("expected a decode error for the wrong type")
+	}
+}
+
+func TestTryParse(t *testing.T) {
+	fenced := "```json\n{\"city\":\"Oslo\",\"temp_c\":-3}\n```"
+	got, err := TryParse[testWeather](fenced)
+	if err != nil || got != (testWeather{City: "Oslo", TempC: -3}) {
+		t.Errorf("TryParse(fenced) = %+v, %v", got, err)
+	}
+	if _, err := TryParse[testWeather]("not json"); err == nil || !strings.Contains(err.Error(), "testWeather") {
+		t.Errorf("expected a decode error naming the type, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustParse to panic")
+		}
+	}()
+	MustParse[testWeather](&ChatResponse{})
+}
+
+func TestTypedConversationSession(t *testing.T) {
+	var formats []any
+	server := newJSONChatServer(t, `{"city":"Paris","temp_c":18}`, &formats)
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+
+	s := NewTypedConversationSession[testWeather](client, "openai/gpt-4o", "Reply with {city, temp_c}.")
+	got, err := s.Send(context.Background(), "Paris?")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got != (testWeather{City: "Paris", TempC: 18}) {
+		t.Errorf("unexpected value: %+v", got)
+	}
+	if len(s.History()) != 2 {
+		t.Errorf("expected 2 history messages, got %d", len(s.History()))
+	}
+	if f, _ := formats[0].(map[string]any); f["type"] != "json_object" {
+		t.Errorf("expected JSON mode, got %v", formats[0])
+	}
+}
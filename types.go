@@ -10,7 +10,9 @@ package blockrun
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // jsonUnmarshal is aliased so the Model UnmarshalJSON can call encoding/json
@@ -23,12 +25,115 @@ type ChatMessage struct {
 	Content    string     `json:"content"`                // Message content
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // Tool calls from assistant
 	ToolCallID string     `json:"tool_call_id,omitempty"` // ID of the tool call this message responds to
+	Name       string     `json:"name,omitempty"`         // Name of the tool that produced a "tool" message
+	// ContentParts are sent as the message's content, for multi-modal
+	// input (see NewAudioMessage). Content, if also set, is sent first as a
+	// text part.
+	ContentParts []ContentPart `json:"-"`
+	// Audio is an assistant message's spoken form, when audio output was
+	// requested (see ChatCompletionOptions.AudioOutput).
+	Audio *AudioMessageContent `json:"audio,omitempty"`
+	// CacheControl marks the prompt up to and including this message for
+	// Anthropic prompt caching (see NewCachedMessage).
+	CacheControl *CacheControlBlock `json:"cache_control,omitempty"`
 	// Extended fields returned by reasoning-capable upstream providers
 	// (DeepSeek Reasoner, Grok 4 / 4.20 reasoning, xAI multi-agent, etc.).
 	// Backend strips these from inbound requests but may forward them on
 	// the response side, so we accept them as optional.
 	ReasoningContent string `json:"reasoning_content,omitempty"`
 	Thinking         string `json:"thinking,omitempty"`
+	// ThinkingContent is the text of Anthropic "thinking" content blocks in
+	// a response (or Thinking, if the gateway flattened them). It is never
+	// sent.
+	ThinkingContent string `json:"-"`
+}
+
+// MarshalJSON sends ContentParts, when set, as the content array.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	type plain ChatMessage
+	return json.Marshal(struct {
+		plain
+		Content any `json:"content"`
+	}{plain(m), m.contentJSON()})
+}
+
+// UnmarshalJSON accepts content as a string or as content blocks. Text-only
+// content joins its "text" blocks into Content. Content with image, audio or
+// file parts is the reverse of MarshalJSON: a leading "text" block becomes
+// Content and the remaining parts, in order, ContentParts. "thinking"
+// blocks are joined into ThinkingContent.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	type plain ChatMessage
+	var raw struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = ChatMessage(raw.plain)
+
+	var thinking []string
+	if len(raw.Content) > 0 && raw.Content[0] == '[' {
+		var blocks []contentBlock
+		if err := json.Unmarshal(raw.Content, &blocks); err != nil {
+			return err
+		}
+		var text []string
+		var parts []ContentPart
+		for _, b := range blocks {
+			switch b.Type {
+			case "text":
+				text = append(text, b.Text)
+				parts = append(parts, TextContentPart{Text: b.Text})
+			case "thinking":
+				thinking = append(thinking, b.Thinking)
+			default:
+				if p := b.part(); p != nil {
+					parts = append(parts, p)
+				}
+			}
+		}
+		if len(parts) == len(text) {
+			m.Content = strings.Join(text, "")
+		} else {
+			if t, ok := parts[0].(TextContentPart); ok {
+				m.Content = t.Text
+				parts = parts[1:]
+			}
+			m.ContentParts = parts
+		}
+	} else if len(raw.Content) > 0 && string(raw.Content) != "null" {
+		if err := json.Unmarshal(raw.Content, &m.Content); err != nil {
+			return err
+		}
+	}
+
+	m.ThinkingContent = strings.Join(thinking, "\n\n")
+	if m.ThinkingContent == "" {
+		m.ThinkingContent = m.Thinking
+	}
+	return nil
+}
+
+// NewToolResultMessage returns the "tool" message that feeds the result of
+// the tool call toolCallID (from a model's ToolCalls) back to the model.
+func NewToolResultMessage(toolCallID, name, content string) ChatMessage {
+	return ChatMessage{Role: "tool", ToolCallID: toolCallID, Name: name, Content: content}
+}
+
+// CacheControlBlock is an Anthropic cache_control marker. Type is
+// "ephemeral", the only type Anthropic supports.
+type CacheControlBlock struct {
+	Type string `json:"type"`
+}
+
+// NewCachedMessage returns a message whose prompt prefix, up to and
+// including it, is cached by anthropic/* models. Later calls that repeat the
+// prefix bill it as Usage.CacheReadInputTokens; the first pays the cache
+// write (Usage.CacheCreationInputTokens).
+func NewCachedMessage(role, content string) ChatMessage {
+	return ChatMessage{Role: role, Content: content, CacheControl: &CacheControlBlock{Type: "ephemeral"}}
 }
 
 // ChatCompletionOptions contains optional parameters for chat completion.
@@ -42,6 +147,29 @@ type ChatCompletionOptions struct {
 	ToolChoice       any               `json:"tool_choice,omitempty"`     // string ("none","auto","required") or object
 	ResponseFormat   any               `json:"response_format,omitempty"` // e.g. map[string]string{"type": "json_object"} for JSON mode
 	Stop             any               `json:"stop,omitempty"`            // string or []string — up to 4 stop sequences
+	// Stream asks ChatCompletion for a streamed response. On its own it
+	// returns a StreamingError (use ChatCompletionStream); with
+	// BufferedStream the response is streamed and assembled.
+	Stream bool `json:"-"`
+	// BufferedStream makes ChatCompletion stream the response and return
+	// the assembled ChatResponse, including Usage if the final chunk has it.
+	BufferedStream bool `json:"-"`
+	// ProviderOptions are merged into the top level of the request body, for
+	// provider-specific parameters such as Anthropic's "thinking". The keys
+	// model, messages, max_tokens and stream are reserved.
+	ProviderOptions map[string]any `json:"-"`
+	// ResponseSchema is a JSON Schema the response content must match;
+	// ChatCompletion returns a SchemaValidationError if it does not.
+	ResponseSchema json.RawMessage `json:"-"`
+	// RetryOnSchemaFailure is how many times ChatCompletion repeats the
+	// request, paying again, when the content does not match ResponseSchema.
+	RetryOnSchemaFailure int `json:"-"`
+	// Thinking enables extended thinking on Anthropic models; it is ignored
+	// for other models. The thinking text is returned in ThinkingContent.
+	Thinking *ThinkingOptions `json:"-"`
+	// AudioOutput asks the model to answer with audio too, returned in the
+	// message's Audio. Streams do not support it.
+	AudioOutput *AudioOutputOptions `json:"-"`
 }
 
 // SearchParameters contains xAI Live Search configuration.
@@ -158,10 +286,16 @@ type Model struct {
 	// Legacy flat fields — populated from Pricing / ContextWindow for
 	// backward compatibility with callers written against the old struct.
 	// They carry `json:"-"` so Marshal doesn't emit duplicate keys.
-	InputPrice   float64 `json:"-"`
-	OutputPrice  float64 `json:"-"`
-	FlatPrice    float64 `json:"-"`
-	ContextLimit int     `json:"-"`
+	InputPrice  float64 `json:"-"`
+	OutputPrice float64 `json:"-"`
+	FlatPrice   float64 `json:"-"`
+	// ContextLimit is the context window, in ContextLimitUnit; 0 if the
+	// catalogue does not report one (see NominalContextTokens).
+	ContextLimit int `json:"-"`
+	// ContextLimitUnit is ContextUnitTokens, or ContextUnitCharacters for
+	// the few models measured in characters. It is empty when ContextLimit
+	// is 0.
+	ContextLimitUnit string `json:"context_limit_unit,omitempty"`
 	// Type is set by ListAllModels to mark whether an entry came from the
 	// LLM or image catalogue; not emitted by /v1/models itself.
 	Type string `json:"type,omitempty"`
@@ -186,6 +320,7 @@ func (m *Model) UnmarshalJSON(data []byte) error {
 		Provider      string        `json:"provider,omitempty"` // legacy
 		ContextWindow int           `json:"context_window,omitempty"`
 		ContextLimit  int           `json:"contextLimit,omitempty"` // legacy
+		ContextUnit   string        `json:"context_limit_unit,omitempty"`
 		MaxOutput     int           `json:"max_output,omitempty"`
 		Categories    []string      `json:"categories,omitempty"`
 		BillingMode   string        `json:"billing_mode,omitempty"`
@@ -223,6 +358,13 @@ func (m *Model) UnmarshalJSON(data []byte) error {
 	}
 	m.MaxOutput = r.MaxOutput
 	m.ContextLimit = m.ContextWindow
+	m.ContextLimitUnit = ""
+	if m.ContextLimit > 0 {
+		m.ContextLimitUnit = r.ContextUnit
+		if m.ContextLimitUnit == "" {
+			m.ContextLimitUnit = ContextUnitTokens
+		}
+	}
 
 	if r.Pricing != nil {
 		m.Pricing = *r.Pricing
@@ -307,6 +449,12 @@ type TransferAuthorization struct {
 	ValidAfter  string `json:"validAfter"`
 	ValidBefore string `json:"validBefore"`
 	Nonce       string `json:"nonce"`
+
+	// Extras holds non-standard authorization fields, such as a memo, that
+	// some ERC-20 implementations sign alongside the standard ones. They are
+	// serialised inline and signed as strings. They are only set when the
+	// server opts in; see AuthorizationExtrasKey.
+	Extras map[string]string `json:"-"`
 }
 
 // APIError represents an error from the BlockRun API.
@@ -314,6 +462,9 @@ type APIError struct {
 	StatusCode int
 	Message    string
 	Body       map[string]any
+	// ErrorCode is the machine-readable code from the error body
+	// ({"error": {"code": ...}}), e.g. ErrCodeRateLimit; "" if none.
+	ErrorCode string
 }
 
 func (e *APIError) Error() string {
@@ -323,18 +474,55 @@ func (e *APIError) Error() string {
 // PaymentError represents an error during payment processing.
 type PaymentError struct {
 	Message string
+	// Attempts is the number of signed payments the call made, when it
+	// failed because they were rejected (see WithMaxPaymentRetries).
+	Attempts int
 }
 
 func (e *PaymentError) Error() string {
 	return fmt.Sprintf("Payment error: %s", e.Message)
 }
 
-// ValidationError represents an input validation error.
+// ValidationError represents an input validation error. errors.Is(err,
+// ErrValidation) matches any ValidationError.
 type ValidationError struct {
 	Field   string
 	Message string
+	// Suggestion is a concrete fix the caller can apply, if one is known.
+	Suggestion string
+	// Value is the offending value, if there is one.
+	Value any
+	// FieldPath locates Field within nested options, e.g.
+	// ["Options", "SearchParameters", "Mode"]. Empty for top-level fields.
+	FieldPath []string
 }
 
+// ErrValidation is the sentinel matched by every ValidationError.
+var ErrValidation = errors.New("validation error")
+
 func (e *ValidationError) Error() string {
-	return fmt.Sprintf("Validation error for %s: %s", e.Field, e.Message)
+	field := e.Field
+	if len(e.FieldPath) > 0 {
+		field = strings.Join(e.FieldPath, ".")
+	}
+	msg := fmt.Sprintf("Validation error for %s: %s", field, e.Message)
+	if e.Suggestion != "" {
+		msg += " (" + e.Suggestion + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// StreamingError is returned by ChatCompletion when Stream is set without
+// BufferedStream.
+type StreamingError struct {
+	Message string
+}
+
+func (e *StreamingError) Error() string {
+	return fmt.Sprintf("Streaming error: %s", e.Message)
 }
@@ -0,0 +1,13 @@
+package blockrun
+
+// DefaultUserAgent is the User-Agent sent with every gateway request unless
+// WithUserAgent replaces it.
+const DefaultUserAgent = "blockrun-llm-go/" + Version + " (+https://github.com/BlockRunAI/blockrun-llm-go)"
+
+// WithUserAgent replaces DefaultUserAgent with ua on every request. An
+// empty ua keeps the default.
+func WithUserAgent(ua string) ClientOption {
+	return func(c *LLMClient) {
+		c.userAgent = ua
+	}
+}
@@ -0,0 +1,34 @@
+package blockrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if !strings.HasPrefix(got, "blockrun-llm-go/"+Version) {
+		t.Errorf("expected the SDK User-Agent, got %q", got)
+	}
+
+	client, _ = NewLLMClient(testPrivateKey, WithAPIURL(server.URL), WithUserAgent("custom"))
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got != "custom" {
+		t.Errorf("expected User-Agent custom, got %q", got)
+	}
+}
@@ -0,0 +1,49 @@
+package blockrun
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// userMetadataHeader attributes a request to a sub-user or agent on
+// multi-tenant gateway deployments.
+const userMetadataHeader = "X-User-Metadata"
+
+// WithUserMetadata sends metadata, JSON-encoded, as the X-User-Metadata
+// header of every request, probe and paid retry alike. Metadata that fails
+// ValidateUserMetadata is logged and ignored.
+func WithUserMetadata(metadata map[string]string) ClientOption {
+	return func(c *LLMClient) {
+		if err := ValidateUserMetadata(metadata); err != nil {
+			c.warnf("WithUserMetadata: %v; ignoring", err)
+			return
+		}
+		encoded, _ := json.Marshal(metadata)
+		c.userMetadata = string(encoded)
+	}
+}
+
+// WithRequestUserMetadata replaces the client's X-User-Metadata for one
+// call. Invalid metadata fails the call with a ValidationError before
+// anything is sent.
+func WithRequestUserMetadata(metadata map[string]string) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.userMetadata = metadata
+	}
+}
+
+// setUserMetadata stamps req with the call's or the client's user metadata.
+func (bc *baseClient) setUserMetadata(req *http.Request) error {
+	value := bc.userMetadata
+	if cfg := requestConfigFrom(req.Context()); cfg != nil && cfg.userMetadata != nil {
+		if err := ValidateUserMetadata(cfg.userMetadata); err != nil {
+			return err
+		}
+		encoded, _ := json.Marshal(cfg.userMetadata)
+		value = string(encoded)
+	}
+	if value != "" {
+		req.Header.Set(userMetadataHeader, value)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package blockrun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUserMetadataHeader(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var mu sync.Mutex
+	var probes, retries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("PAYMENT-SIGNATURE") == "" {
+			probes = append(probes, r.Header.Get("X-User-Metadata"))
+			w.Header().Set("payment-required", prHeader)
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		retries = append(retries, r.Header.Get("X-User-Metadata"))
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL),
+		WithUserMetadata(map[string]string{"tenant": "acme", "agent_id": "a-1"}))
+	client.costLog = nil
+	ctx := context.Background()
+	messages := []ChatMessage{{Role: "user", Content: "hi"}}
+
+	if _, err := client.ChatCompletion(ctx, "gpt-4o", messages, nil); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	want := `{"agent_id":"a-1","tenant":"acme"}`
+	if probes[0] != want || retries[0] != want {
+		t.Errorf("expected %s on probe and retry, got %q and %q", want, probes[0], retries[0])
+	}
+
+	if _, err := client.ChatCompletion(ctx, "gpt-4o", messages, nil,
+		WithRequestUserMetadata(map[string]string{"tenant": "globex"})); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if want := `{"tenant":"globex"}`; probes[1] != want || retries[1] != want {
+		t.Errorf("expected the per-call override %s, got %q and %q", want, probes[1], retries[1])
+	}
+
+	huge := map[string]string{"note": strings.Repeat("x", MaxUserMetadataSize)}
+	_, err := client.ChatCompletion(ctx, "gpt-4o", messages, nil, WithRequestUserMetadata(huge))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "userMetadata" {
+		t.Errorf("expected a userMetadata ValidationError, got %v", err)
+	}
+	if len(probes) != 2 {
+		t.Errorf("expected oversized metadata not to be sent, got %d probes", len(probes))
+	}
+}
+
+func TestValidateUserMetadata(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxUserMetadataKeys; i++ {
+		tooMany[string(rune('a'+i))] = "v"
+	}
+	for _, m := range []map[string]string{
+		tooMany,
+		{"bad key": "v"},
+		{"k": strings.Repeat("x", 505)},
+	} {
+		if err := ValidateUserMetadata(m); !errors.Is(err, ErrValidation) {
+			t.Errorf("ValidateUserMetadata(%d keys) = %v, want a ValidationError", len(m), err)
+		}
+	}
+	// {"k":"..."} is 8 bytes of framing, so 503 characters encode to 511.
+	if err := ValidateUserMetadata(map[string]string{"k": strings.Repeat("x", 503)}); err != nil {
+		t.Errorf("metadata under the limit rejected: %v", err)
+	}
+
+	client, _ := NewLLMClient(testPrivateKey, WithUserMetadata(map[string]string{"bad key": "v"}))
+	if client.userMetadata != "" {
+		t.Error("expected invalid client metadata to be ignored")
+	}
+}
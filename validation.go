@@ -1,9 +1,13 @@
 package blockrun
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 var (
@@ -12,21 +16,38 @@ var (
 
 	// modelRegex validates model IDs (provider/model format)
 	modelRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(/[a-zA-Z0-9._-]+)?$`)
+
+	// userMetadataKeyRegex validates X-User-Metadata keys
+	userMetadataKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+	// addressRegex validates an Ethereum address (0x + 40 hex digits)
+	addressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+const (
+	// MaxUserMetadataKeys is the most keys X-User-Metadata may carry.
+	MaxUserMetadataKeys = 10
+	// MaxUserMetadataSize is the limit, in bytes, on the JSON-encoded
+	// X-User-Metadata header; encodings must be shorter.
+	MaxUserMetadataSize = 512
 )
 
 // ValidatePrivateKey validates the format of a private key.
 func ValidatePrivateKey(key string) error {
 	if key == "" {
 		return &ValidationError{
-			Field:   "privateKey",
-			Message: "Private key is required",
+			Field:      "privateKey",
+			Message:    "Private key is required",
+			Suggestion: "Pass a key or set BLOCKRUN_WALLET_KEY",
 		}
 	}
 
 	if !privateKeyRegex.MatchString(key) {
+		// The key itself is never echoed back in Value.
 		return &ValidationError{
-			Field:   "privateKey",
-			Message: "Private key must be a 64-character hex string (with optional 0x prefix)",
+			Field:      "privateKey",
+			Message:    "Private key must be a 64-character hex string (with optional 0x prefix)",
+			Suggestion: "Check the key for missing characters or stray whitespace",
 		}
 	}
 
@@ -37,26 +58,137 @@ func ValidatePrivateKey(key string) error {
 func ValidateAPIURL(apiURL string) error {
 	if apiURL == "" {
 		return &ValidationError{
-			Field:   "apiURL",
-			Message: "API URL is required",
+			Field:      "apiURL",
+			Message:    "API URL is required",
+			Suggestion: "Use " + DefaultAPIURL,
 		}
 	}
 
 	parsed, err := url.Parse(apiURL)
 	if err != nil {
 		return &ValidationError{
-			Field:   "apiURL",
-			Message: "Invalid URL format",
+			Field:      "apiURL",
+			Message:    "Invalid URL format",
+			Suggestion: "Use an absolute URL such as " + DefaultAPIURL,
+			Value:      apiURL,
 		}
 	}
 
 	if parsed.Scheme != "http" && parsed.Scheme != "https" {
 		return &ValidationError{
-			Field:   "apiURL",
-			Message: "URL must use http or https scheme",
+			Field:      "apiURL",
+			Message:    "URL must use http or https scheme",
+			Suggestion: "Prefix the URL with https://",
+			Value:      apiURL,
+		}
+	}
+
+	return nil
+}
+
+// ValidateAddress validates an Ethereum address: "0x" followed by 40 hex
+// digits. A mixed-case address must match its EIP-55 checksum; all-lowercase
+// and all-uppercase addresses carry no checksum and are accepted.
+func ValidateAddress(addr string) error {
+	if !strings.HasPrefix(addr, "0x") {
+		return &ValidationError{
+			Field:      "address",
+			Message:    "Address must start with 0x",
+			Suggestion: "Prefix the address with 0x",
+			Value:      addr,
+		}
+	}
+	if len(addr) != 42 {
+		return &ValidationError{
+			Field:      "address",
+			Message:    fmt.Sprintf("Address must be 42 characters, got %d", len(addr)),
+			Suggestion: "Check the address for missing or extra characters",
+			Value:      addr,
+		}
+	}
+	if !addressRegex.MatchString(addr) {
+		return &ValidationError{
+			Field:      "address",
+			Message:    "Address must contain only hex characters after 0x",
+			Suggestion: "Check the address for stray characters",
+			Value:      addr,
+		}
+	}
+	digits := addr[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && common.HexToAddress(addr).Hex() != addr {
+		return &ValidationError{
+			Field:      "address",
+			Message:    "Address has an invalid EIP-55 checksum",
+			Suggestion: "Copy the address again; a bad checksum usually means a typo",
+			Value:      addr,
+		}
+	}
+	return nil
+}
+
+// ValidateBasePath validates a WithBasePath prefix: empty, or a path that
+// starts with '/', has no trailing slash, empty segments, query or fragment.
+func ValidateBasePath(path string) error {
+	if path == "" {
+		return nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return &ValidationError{
+			Field:      "basePath",
+			Message:    "Base path must start with '/'",
+			Suggestion: "Use a path such as /internal",
+			Value:      path,
+		}
+	}
+	if strings.HasSuffix(path, "/") || strings.Contains(path, "//") {
+		return &ValidationError{
+			Field:      "basePath",
+			Message:    "Base path must not have a trailing slash or empty segments",
+			Suggestion: "Use a path such as /internal",
+			Value:      path,
+		}
+	}
+	if strings.ContainsAny(path, "?# \t\n") {
+		return &ValidationError{
+			Field:   "basePath",
+			Message: "Base path must not contain a query, fragment or whitespace",
+			Value:   path,
 		}
 	}
+	return nil
+}
 
+// ValidateUserMetadata validates metadata for the X-User-Metadata header
+// (see WithUserMetadata): at most MaxUserMetadataKeys keys of letters,
+// digits, '_' and '-', encoding to under MaxUserMetadataSize bytes of JSON.
+func ValidateUserMetadata(m map[string]string) error {
+	if len(m) > MaxUserMetadataKeys {
+		return &ValidationError{
+			Field:      "userMetadata",
+			Message:    fmt.Sprintf("User metadata has %d keys, limit is %d", len(m), MaxUserMetadataKeys),
+			Suggestion: "Drop or combine keys",
+			Value:      len(m),
+		}
+	}
+	for k := range m {
+		if !userMetadataKeyRegex.MatchString(k) {
+			return &ValidationError{
+				Field:      "userMetadata",
+				Message:    "User metadata keys may only contain letters, digits, '_' and '-'",
+				Suggestion: "Rename the key, e.g. \"agent_id\"",
+				Value:      k,
+			}
+		}
+	}
+	encoded, _ := json.Marshal(m)
+	if len(encoded) >= MaxUserMetadataSize {
+		return &ValidationError{
+			Field:      "userMetadata",
+			Message:    fmt.Sprintf("User metadata encodes to %d bytes, limit is %d", len(encoded), MaxUserMetadataSize),
+			Suggestion: "Shorten the values or send fewer keys",
+			Value:      len(encoded),
+		}
+	}
 	return nil
 }
 
@@ -64,15 +196,18 @@ func ValidateAPIURL(apiURL string) error {
 func ValidateModel(model string) error {
 	if model == "" {
 		return &ValidationError{
-			Field:   "model",
-			Message: "Model is required",
+			Field:      "model",
+			Message:    "Model is required",
+			Suggestion: "Pass a model ID such as \"openai/gpt-4o\" or set WithDefaultModel",
 		}
 	}
 
 	if !modelRegex.MatchString(model) {
 		return &ValidationError{
-			Field:   "model",
-			Message: "Invalid model format. Expected format: 'provider/model' or 'model-name'",
+			Field:      "model",
+			Message:    "Invalid model format. Expected format: 'provider/model' or 'model-name'",
+			Suggestion: "Use an ID from ListModels, e.g. \"openai/gpt-4o\"",
+			Value:      model,
 		}
 	}
 
@@ -83,15 +218,19 @@ func ValidateModel(model string) error {
 func ValidateMaxTokens(maxTokens int) error {
 	if maxTokens < 0 {
 		return &ValidationError{
-			Field:   "maxTokens",
-			Message: "max_tokens must be non-negative",
+			Field:      "maxTokens",
+			Message:    "max_tokens must be non-negative",
+			Suggestion: "Use 0 for the default or a positive token count",
+			Value:      maxTokens,
 		}
 	}
 
 	if maxTokens > 1000000 {
 		return &ValidationError{
-			Field:   "maxTokens",
-			Message: "max_tokens exceeds maximum allowed value",
+			Field:      "maxTokens",
+			Message:    "max_tokens exceeds maximum allowed value",
+			Suggestion: "Use a value of at most 1000000",
+			Value:      maxTokens,
 		}
 	}
 
@@ -102,15 +241,19 @@ func ValidateMaxTokens(maxTokens int) error {
 func ValidateTemperature(temperature float64) error {
 	if temperature < 0 {
 		return &ValidationError{
-			Field:   "temperature",
-			Message: "temperature must be non-negative",
+			Field:      "temperature",
+			Message:    "temperature must be non-negative",
+			Suggestion: "Use a value between 0.0 and 2.0",
+			Value:      temperature,
 		}
 	}
 
 	if temperature > 2.0 {
 		return &ValidationError{
-			Field:   "temperature",
-			Message: "temperature must be at most 2.0",
+			Field:      "temperature",
+			Message:    "temperature must be at most 2.0",
+			Suggestion: "Use a value between 0.0 and 2.0",
+			Value:      temperature,
 		}
 	}
 
@@ -121,18 +264,97 @@ func ValidateTemperature(temperature float64) error {
 func ValidateTopP(topP float64) error {
 	if topP < 0 {
 		return &ValidationError{
-			Field:   "topP",
-			Message: "top_p must be non-negative",
+			Field:      "topP",
+			Message:    "top_p must be non-negative",
+			Suggestion: "Use a value between 0.0 and 1.0",
+			Value:      topP,
 		}
 	}
 
 	if topP > 1.0 {
 		return &ValidationError{
-			Field:   "topP",
-			Message: "top_p must be at most 1.0",
+			Field:      "topP",
+			Message:    "top_p must be at most 1.0",
+			Suggestion: "Use a value between 0.0 and 1.0",
+			Value:      topP,
+		}
+	}
+
+	return nil
+}
+
+// ValidateChatCompletionOptions validates opts, reporting nested fields with
+// a FieldPath rooted at "Options". A nil opts is valid.
+func ValidateChatCompletionOptions(opts *ChatCompletionOptions) error {
+	if opts == nil {
+		return nil
+	}
+	checks := []struct {
+		field string
+		err   error
+	}{
+		{"MaxTokens", ValidateMaxTokens(opts.MaxTokens)},
+		{"Temperature", ValidateTemperature(opts.Temperature)},
+		{"TopP", ValidateTopP(opts.TopP)},
+	}
+	for _, c := range checks {
+		if ve, ok := c.err.(*ValidationError); ok {
+			ve.FieldPath = []string{"Options", c.field}
+			return ve
 		}
 	}
 
+	if err := validateProviderOptions(opts.ProviderOptions); err != nil {
+		return err
+	}
+
+	if sp := opts.SearchParameters; sp != nil {
+		switch sp.Mode {
+		case "", "off", "auto", "on":
+		default:
+			return &ValidationError{
+				Field:      "mode",
+				Message:    "search mode must be \"off\", \"auto\" or \"on\"",
+				Suggestion: "Use \"auto\" to let the model decide when to search",
+				Value:      sp.Mode,
+				FieldPath:  []string{"Options", "SearchParameters", "Mode"},
+			}
+		}
+	}
+	return nil
+}
+
+// reservedBodyKeys are request body keys ProviderOptions may not set.
+var reservedBodyKeys = []string{"model", "messages", "max_tokens", "stream"}
+
+// validateProviderOptions rejects ProviderOptions that would override a
+// reserved request body key.
+func validateProviderOptions(providerOpts map[string]any) error {
+	for _, key := range reservedBodyKeys {
+		if _, ok := providerOpts[key]; ok {
+			return &ValidationError{
+				Field:      key,
+				Message:    fmt.Sprintf("%q is reserved and cannot be set through ProviderOptions", key),
+				Suggestion: "Set it through the ChatCompletion arguments or options instead",
+				Value:      providerOpts[key],
+				FieldPath:  []string{"Options", "ProviderOptions", key},
+			}
+		}
+	}
+	return nil
+}
+
+// applyProviderOptions merges opts.ProviderOptions into body.
+func applyProviderOptions(body map[string]any, opts *ChatCompletionOptions) error {
+	if opts == nil || len(opts.ProviderOptions) == 0 {
+		return nil
+	}
+	if err := validateProviderOptions(opts.ProviderOptions); err != nil {
+		return err
+	}
+	for k, v := range opts.ProviderOptions {
+		body[k] = v
+	}
 	return nil
 }
 
@@ -145,16 +367,20 @@ func ValidateResourceURL(resourceURL, expectedBase string) (string, error) {
 	parsed, err := url.Parse(resourceURL)
 	if err != nil {
 		return "", &ValidationError{
-			Field:   "resourceURL",
-			Message: "Invalid resource URL format",
+			Field:      "resourceURL",
+			Message:    "Invalid resource URL format",
+			Suggestion: "Use an absolute http(s) URL",
+			Value:      resourceURL,
 		}
 	}
 
 	// Ensure the URL uses a safe scheme
 	if parsed.Scheme != "http" && parsed.Scheme != "https" {
 		return "", &ValidationError{
-			Field:   "resourceURL",
-			Message: "Resource URL must use http or https scheme",
+			Field:      "resourceURL",
+			Message:    "Resource URL must use http or https scheme",
+			Suggestion: "Use an absolute http(s) URL",
+			Value:      resourceURL,
 		}
 	}
 
@@ -0,0 +1,133 @@
+package blockrun
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrorIs(t *testing.T) {
+	err := ValidateTemperature(-0.5)
+	if !errors.Is(err, ErrValidation) {
+		t.Error("expected errors.Is(err, ErrValidation)")
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatal("expected a *ValidationError")
+	}
+	if ve.Suggestion != "Use a value between 0.0 and 2.0" || ve.Value != -0.5 {
+		t.Errorf("unexpected fields %+v", ve)
+	}
+	if !strings.Contains(err.Error(), "(Use a value between 0.0 and 2.0)") {
+		t.Errorf("expected the suggestion in the message, got %q", err.Error())
+	}
+	if errors.Is(errors.New("other"), ErrValidation) || errors.Is(&PaymentError{}, ErrValidation) {
+		t.Error("ErrValidation matched a non-validation error")
+	}
+}
+
+func TestValidatorsPopulateSuggestion(t *testing.T) {
+	_, resourceErr := ValidateResourceURL("ftp://example.com", DefaultAPIURL)
+	for name, err := range map[string]error{
+		"empty key":        ValidatePrivateKey(""),
+		"bad key":          ValidatePrivateKey("0x1234"),
+		"empty url":        ValidateAPIURL(""),
+		"bad url":          ValidateAPIURL("%zz"),
+		"url scheme":       ValidateAPIURL("ftp://example.com"),
+		"empty model":      ValidateModel(""),
+		"bad model":        ValidateModel("not a model"),
+		"negative tokens":  ValidateMaxTokens(-1),
+		"too many tokens":  ValidateMaxTokens(2000000),
+		"low temperature":  ValidateTemperature(-1),
+		"high temperature": ValidateTemperature(3),
+		"low top_p":        ValidateTopP(-1),
+		"high top_p":       ValidateTopP(2),
+		"resource scheme":  resourceErr,
+	} {
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%s: expected a ValidationError, got %v", name, err)
+			continue
+		}
+		if ve.Suggestion == "" {
+			t.Errorf("%s: missing Suggestion", name)
+		}
+	}
+
+	var ve *ValidationError
+	errors.As(ValidatePrivateKey("0xnotakey"), &ve)
+	if ve.Value != nil {
+		t.Error("a rejected private key must not be echoed in Value")
+	}
+}
+
+func TestValidateChatCompletionOptionsFieldPath(t *testing.T) {
+	if err := ValidateChatCompletionOptions(&ChatCompletionOptions{Temperature: 0.7, SearchParameters: &SearchParameters{Mode: "auto"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := ValidateChatCompletionOptions(&ChatCompletionOptions{SearchParameters: &SearchParameters{Mode: "always"}})
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if strings.Join(ve.FieldPath, ".") != "Options.SearchParameters.Mode" || ve.Value != "always" {
+		t.Errorf("unexpected error %+v", ve)
+	}
+	if !strings.HasPrefix(err.Error(), "Validation error for Options.SearchParameters.Mode:") {
+		t.Errorf("expected the field path in the message, got %q", err.Error())
+	}
+
+	errors.As(ValidateChatCompletionOptions(&ChatCompletionOptions{TopP: 1.5}), &ve)
+	if strings.Join(ve.FieldPath, ".") != "Options.TopP" {
+		t.Errorf("unexpected path %v", ve.FieldPath)
+	}
+}
+
+func TestValidateBasePath(t *testing.T) {
+	for _, path := range []string{"", "/internal", "/api/v2/blockrun"} {
+		if err := ValidateBasePath(path); err != nil {
+			t.Errorf("ValidateBasePath(%q): %v", path, err)
+		}
+	}
+	for _, path := range []string{"internal", "/internal/", "/a//b", "/a?b=1", "/a#b", "/a b"} {
+		if err := ValidateBasePath(path); err == nil {
+			t.Errorf("expected ValidateBasePath(%q) to fail", path)
+		}
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	// Test vectors from EIP-55.
+	valid := []string{
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+		"0xde709f2102306220921060314715629080e2fb77",
+		"0x27b1fdb04752bbc536007a920d24acb045561c26",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+	for _, addr := range valid {
+		if err := ValidateAddress(addr); err != nil {
+			t.Errorf("ValidateAddress(%q): %v", addr, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed":     "start with 0x",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA":     "42 characters",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAedAA": "42 characters",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg":   "hex characters",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD":   "checksum",
+		"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed":   "checksum",
+	}
+	for addr, want := range invalid {
+		err := ValidateAddress(addr)
+		var ve *ValidationError
+		if !errors.As(err, &ve) || ve.Field != "address" || !strings.Contains(ve.Message, want) {
+			t.Errorf("ValidateAddress(%q) = %v, want an error about %q", addr, err, want)
+		}
+	}
+}
@@ -340,6 +340,7 @@ func (c *VideoClient) GenerateFromContent(ctx context.Context, content []map[str
 // reaches "completed". The gateway settles only on the first completed poll, so
 // upstream failure or a caller giving up costs nothing.
 func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string, body map[string]any) (*VideoResponse, error) {
+	ctx = c.callContext(ctx)
 	submitURL := c.apiURL + submitPath
 
 	jsonBody, err := json.Marshal(body)
@@ -353,11 +354,10 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req1.Header.Set("Content-Type", "application/json")
-	resp1, err := c.httpClient.Do(req1)
+	resp1, err := c.do(req1)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	paymentHeader := resp1.Header.Get("payment-required")
 	body1, _ := io.ReadAll(resp1.Body)
 	resp1.Body.Close()
 
@@ -367,14 +367,11 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 			Message:    fmt.Sprintf("expected 402 on video submit, got %d: %s", resp1.StatusCode, string(body1)),
 		}
 	}
-	if paymentHeader == "" {
-		return nil, &PaymentError{Message: "402 response but no payment requirements found"}
-	}
 
 	// Step 2: sign the payment authorization.
-	paymentReq, err := ParsePaymentRequired(paymentHeader)
+	paymentReq, err := paymentRequirementFromResponse(resp1.Header, body1)
 	if err != nil {
-		return nil, &PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
+		return nil, err
 	}
 	paymentOption, err := ExtractPaymentDetails(paymentReq)
 	if err != nil {
@@ -389,7 +386,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 		// (Base/EIP-712 only; the Solana path re-derives validity from the blockhash).
 		paymentOption.MaxTimeoutSeconds = videoMaxTimeoutSeconds
 	}
-	paymentPayload, err := c.createPaymentPayload(paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
+	paymentPayload, err := c.createPaymentPayload(ctx, paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
 	if err != nil {
 		return nil, &PaymentError{Message: fmt.Sprintf("Failed to create payment: %v", err)}
 	}
@@ -401,7 +398,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 	}
 	req2.Header.Set("Content-Type", "application/json")
 	req2.Header.Set("PAYMENT-SIGNATURE", paymentPayload)
-	resp2, err := c.httpClient.Do(req2)
+	resp2, err := c.do(req2)
 	if err != nil {
 		return nil, fmt.Errorf("submit request failed: %w", err)
 	}
@@ -412,10 +409,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 		return nil, &PaymentError{Message: "Payment was rejected. Check your wallet balance."}
 	}
 	if resp2.StatusCode != http.StatusOK && resp2.StatusCode != http.StatusAccepted {
-		return nil, &APIError{
-			StatusCode: resp2.StatusCode,
-			Message:    fmt.Sprintf("Submit failed: %s", string(body2)),
-		}
+		return nil, newAPIError(resp2.StatusCode, "Submit failed", body2)
 	}
 
 	var submitData struct {
@@ -427,10 +421,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 		return nil, fmt.Errorf("failed to decode submit response: %w", err)
 	}
 	if submitData.ID == "" || submitData.PollURL == "" {
-		return nil, &APIError{
-			StatusCode: resp2.StatusCode,
-			Message:    fmt.Sprintf("submit response missing id/poll_url: %s", string(body2)),
-		}
+		return nil, newAPIError(resp2.StatusCode, "submit response missing id/poll_url", body2)
 	}
 
 	pollURL := c.absoluteURL(submitData.PollURL)
@@ -458,7 +449,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 		}
 		pollSig, lastSigned = c.pollPaymentPayload(pollSig, lastSigned, paymentOption, resourceURL, paymentReq.Resource.Description, paymentReq.Extensions)
 		pollReq.Header.Set("PAYMENT-SIGNATURE", pollSig)
-		pollResp, err := c.httpClient.Do(pollReq)
+		pollResp, err := c.do(pollReq)
 		if err != nil {
 			return nil, fmt.Errorf("poll request failed: %w", err)
 		}
@@ -475,10 +466,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 			continue
 		}
 		if lastStatus == "failed" {
-			return nil, &APIError{
-				StatusCode: pollResp.StatusCode,
-				Message:    fmt.Sprintf("Upstream generation failed: %s", string(pollBytes)),
-			}
+			return nil, newAPIError(pollResp.StatusCode, "Upstream generation failed", pollBytes)
 		}
 		// Terminal success is keyed on status, NOT the HTTP code — the gateway
 		// settles on-chain the moment a poll reports "completed", so coupling
@@ -487,7 +475,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 		// caller was already charged. Record the cost as soon as completion is
 		// observed (the charge is irreversible at that point), then decode.
 		if lastStatus == "completed" {
-			c.recordVideoCost(paymentOption.Amount, submitPath)
+			c.recordVideoCost(ctx, paymentOption, submitPath, modelFromBody(jsonBody), pollSig)
 			var videoResp VideoResponse
 			if err := json.Unmarshal(pollBytes, &videoResp); err != nil {
 				return nil, fmt.Errorf("failed to decode response: %w", err)
@@ -502,10 +490,7 @@ func (c *VideoClient) submitVideoAndPoll(ctx context.Context, submitPath string,
 		if pollResp.StatusCode != http.StatusOK &&
 			pollResp.StatusCode != http.StatusAccepted &&
 			pollResp.StatusCode != http.StatusGatewayTimeout {
-			return nil, &APIError{
-				StatusCode: pollResp.StatusCode,
-				Message:    fmt.Sprintf("Poll failed: %s", string(pollBytes)),
-			}
+			return nil, newAPIError(pollResp.StatusCode, "Poll failed", pollBytes)
 		}
 	}
 
@@ -525,6 +510,6 @@ func (c *VideoClient) absoluteURL(u string) string {
 
 // recordVideoCost tracks spending for a completed video job, mirroring the
 // accounting baseClient does for synchronous paid calls.
-func (c *VideoClient) recordVideoCost(amount, submitPath string) {
-	c.recordSettledCost(amount, submitPath)
+func (c *VideoClient) recordVideoCost(ctx context.Context, option *PaymentOption, submitPath, model, payload string) {
+	c.recordSettledCost(ctx, option, submitPath, model, payload)
 }
@@ -2,13 +2,17 @@ package blockrun
 
 import (
 	"crypto/ecdsa"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	qrcode "github.com/skip2/go-qrcode"
 )
 
 const (
@@ -36,10 +40,32 @@ type WalletInfo struct {
 
 // PaymentLinksInfo contains various payment links for a wallet.
 type PaymentLinksInfo struct {
-	Basescan   string
-	WalletLink string
+	Basescan   string // block explorer page for the address (basescan on Base)
+	WalletLink string // EIP-681 token transfer URI, suitable for a QR code
 	Ethereum   string
 	Blockrun   string
+	ENSName    string // primary ENS name, set by GetPaymentLinksWithENS
+}
+
+// ChainConfig describes an EVM chain and the stablecoin used to fund a wallet
+// on it, for building payment links.
+type ChainConfig struct {
+	ChainID       int64
+	Name          string
+	TokenContract string
+	TokenSymbol   string
+	TokenDecimals int
+	ExplorerURL   string // e.g. "https://basescan.org"
+}
+
+// BaseChainConfig is USDC on Base mainnet, the default funding chain.
+var BaseChainConfig = ChainConfig{
+	ChainID:       8453,
+	Name:          "Base",
+	TokenContract: USDCBaseContract,
+	TokenSymbol:   "USDC",
+	TokenDecimals: 6,
+	ExplorerURL:   "https://basescan.org",
 }
 
 // CreateWallet creates a new Ethereum wallet.
@@ -93,19 +119,44 @@ func LoadWallet() (string, error) {
 	return "", nil
 }
 
+// WalletOption configures GetOrCreateWallet.
+type WalletOption func(*walletConfig)
+
+type walletConfig struct {
+	passphrase string
+	envPrefix  string
+}
+
+// WithWalletPassphrase makes GetOrCreateWallet read the encrypted wallet
+// (see SaveWalletEncrypted) and encrypt any newly created one.
+func WithWalletPassphrase(passphrase string) WalletOption {
+	return func(cfg *walletConfig) {
+		cfg.passphrase = passphrase
+	}
+}
+
 // GetOrCreateWallet gets an existing wallet or creates a new one.
 //
 // Priority:
-// 1. BLOCKRUN_WALLET_KEY environment variable
+// 1. BLOCKRUN_WALLET_KEY environment variable (see WithWalletEnvPrefix)
 // 2. BASE_CHAIN_WALLET_KEY environment variable
-// 3. ~/.blockrun/.session file
-// 4. ~/.blockrun/wallet.key file (legacy)
-// 5. Create new wallet
-func GetOrCreateWallet() (*WalletInfo, error) {
+// 3. ~/.blockrun/.session.enc encrypted file (with WithWalletPassphrase)
+// 4. ~/.blockrun/.session file
+// 5. ~/.blockrun/wallet.key file (legacy)
+// 6. Create new wallet (encrypted with WithWalletPassphrase)
+//
+// If only an encrypted wallet exists and no passphrase is given, an error is
+// returned rather than creating a second wallet.
+func GetOrCreateWallet(opts ...WalletOption) (*WalletInfo, error) {
+	var cfg walletConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	// Check environment variables first
-	envKey := os.Getenv("BLOCKRUN_WALLET_KEY")
+	envKey := os.Getenv(cfg.envPrefix + "BLOCKRUN_WALLET_KEY")
 	if envKey == "" {
-		envKey = os.Getenv("BASE_CHAIN_WALLET_KEY")
+		envKey = os.Getenv(cfg.envPrefix + "BASE_CHAIN_WALLET_KEY")
 	}
 
 	if envKey != "" {
@@ -120,6 +171,25 @@ func GetOrCreateWallet() (*WalletInfo, error) {
 		}, nil
 	}
 
+	// Prefer the encrypted file
+	_, encErr := os.Stat(encryptedWalletFile())
+	hasEncrypted := encErr == nil
+	if hasEncrypted && cfg.passphrase != "" {
+		key, err := LoadWalletDecrypted(cfg.passphrase)
+		if err != nil {
+			return nil, err
+		}
+		address, err := GetAddressFromKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return &WalletInfo{
+			PrivateKey: key,
+			Address:    address,
+			IsNew:      false,
+		}, nil
+	}
+
 	// Check file
 	fileKey, _ := LoadWallet()
 	if fileKey != "" {
@@ -134,13 +204,22 @@ func GetOrCreateWallet() (*WalletInfo, error) {
 		}, nil
 	}
 
+	if hasEncrypted {
+		return nil, &ValidationError{Field: "passphrase", Message: "Wallet is encrypted; a passphrase is required (WithWalletPassphrase)"}
+	}
+
 	// Create new wallet
 	address, privateKey, err := CreateWallet()
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := SaveWallet(privateKey); err != nil {
+	if cfg.passphrase != "" {
+		_, err = SaveWalletEncrypted(privateKey, cfg.passphrase)
+	} else {
+		_, err = SaveWallet(privateKey)
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -170,7 +249,17 @@ func GetWalletAddressFromEnvOrFile() (string, error) {
 	return "", nil
 }
 
-// GetAddressFromKey derives the Ethereum address from a private key.
+// ToChecksumAddress returns addr in its EIP-55 checksum form. addr must pass
+// ValidateAddress.
+func ToChecksumAddress(addr string) (string, error) {
+	if err := ValidateAddress(addr); err != nil {
+		return "", err
+	}
+	return common.HexToAddress(addr).Hex(), nil
+}
+
+// GetAddressFromKey derives the EIP-55 checksummed Ethereum address from a
+// private key.
 func GetAddressFromKey(privateKey string) (string, error) {
 	key := strings.TrimPrefix(privateKey, "0x")
 	ecdsaKey, err := crypto.HexToECDSA(key)
@@ -187,6 +276,8 @@ func GetPrivateKeyFromHex(privateKey string) (*ecdsa.PrivateKey, error) {
 }
 
 // GetEIP681URI generates an EIP-681 URI for USDC transfer on Base.
+// The amount is always written out in base units (1 USDC → "1000000"); use
+// GeneratePaymentURI for the compact form.
 func GetEIP681URI(address string, amountUSDC float64) string {
 	// USDC has 6 decimals
 	amountWei := int64(amountUSDC * 1_000_000)
@@ -194,16 +285,114 @@ func GetEIP681URI(address string, amountUSDC float64) string {
 		USDCBaseContract, BaseChainIDStr, address, amountWei)
 }
 
-// GetPaymentLinks generates payment links for the wallet address.
-func GetPaymentLinks(address string) *PaymentLinksInfo {
+// GeneratePaymentURI builds an EIP-681 ERC-20 transfer URI asking to send
+// amountUnits base units (amount × 10^decimals) of tokenContract to recipient
+// on chainID. Whole-token amounts use the compact scientific notation EIP-681
+// recommends (5 USDC → "5e6"); anything else is written out in base units.
+// An amountUnits of zero or less omits the amount, letting the wallet ask.
+func GeneratePaymentURI(chainID int64, tokenContract, recipient string, amountUnits int64, decimals int) string {
+	uri := fmt.Sprintf("ethereum:%s@%d/transfer?address=%s", tokenContract, chainID, recipient)
+	if amountUnits <= 0 {
+		return uri
+	}
+	return uri + "&uint256=" + formatEIP681Amount(amountUnits, decimals)
+}
+
+// formatEIP681Amount renders units as "<whole>e<decimals>" when it is an
+// exact number of whole tokens, and as a plain integer otherwise.
+func formatEIP681Amount(units int64, decimals int) string {
+	if decimals > 0 && decimals <= 18 {
+		scale := int64(1)
+		for i := 0; i < decimals; i++ {
+			scale *= 10
+		}
+		if units%scale == 0 {
+			return fmt.Sprintf("%de%d", units/scale, decimals)
+		}
+	}
+	return strconv.FormatInt(units, 10)
+}
+
+// GetPaymentLinks generates payment links for the wallet address. It uses
+// BaseChainConfig unless a ChainConfig is given.
+func GetPaymentLinks(address string, chain ...ChainConfig) *PaymentLinksInfo {
+	cfg := BaseChainConfig
+	if len(chain) > 0 {
+		cfg = chain[0]
+	}
 	return &PaymentLinksInfo{
-		Basescan:   fmt.Sprintf("https://basescan.org/address/%s", address),
-		WalletLink: fmt.Sprintf("ethereum:%s@%s/transfer?address=%s", USDCBaseContract, BaseChainIDStr, address),
-		Ethereum:   fmt.Sprintf("ethereum:%s@%s", address, BaseChainIDStr),
+		Basescan:   fmt.Sprintf("%s/address/%s", strings.TrimSuffix(cfg.ExplorerURL, "/"), address),
+		WalletLink: GeneratePaymentURI(cfg.ChainID, cfg.TokenContract, address, 0, cfg.TokenDecimals),
+		Ethereum:   fmt.Sprintf("ethereum:%s@%d", address, cfg.ChainID),
 		Blockrun:   fmt.Sprintf("https://blockrun.ai/fund?address=%s", address),
 	}
 }
 
+// QRCodePNG encodes the WalletLink as a sizePx × sizePx QR code PNG.
+func (info *PaymentLinksInfo) QRCodePNG(sizePx int) ([]byte, error) {
+	return qrcode.Encode(info.WalletLink, qrcode.Medium, sizePx)
+}
+
+// QRCodeSVG encodes the WalletLink as a QR code SVG, one unit per module.
+func (info *PaymentLinksInfo) QRCodeSVG() string {
+	qr, err := qrcode.New(info.WalletLink, qrcode.Medium)
+	if err != nil {
+		return ""
+	}
+	bitmap := qr.Bitmap()
+	n := len(bitmap)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
+
+// QRCodeTerminal renders the WalletLink QR code for a terminal using
+// Unicode half blocks, two modules a character. Light modules are drawn as
+// blocks, so the code scans on a dark terminal background.
+func (info *PaymentLinksInfo) QRCodeTerminal() string {
+	qr, err := qrcode.New(info.WalletLink, qrcode.Medium)
+	if err != nil {
+		return ""
+	}
+	bitmap := qr.Bitmap()
+	light := func(y, x int) bool { return y >= len(bitmap) || !bitmap[y][x] }
+
+	var b strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		for x := range bitmap[y] {
+			switch top, bottom := light(y, x), light(y+1, x); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal. It is a variable so
+// tests can force either branch of FormatWalletCreatedMessage.
+var stdoutIsTerminal = func() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // FormatWalletCreatedMessage formats the message shown when a new wallet is created.
 func FormatWalletCreatedMessage(address string) string {
 	links := GetPaymentLinks(address)
@@ -226,12 +415,25 @@ What $1 USDC gets you:
 Quick links:
 - Check my balance: %s
 - Get USDC: https://www.coinbase.com or https://bridge.base.org
-
+%s
 Questions? care@blockrun.ai | Issues? github.com/BlockRunAI/blockrun-llm-go/issues
 
 Key stored securely in ~/.blockrun/
 Your private key never leaves your machine - only signatures are sent.
-`, address, links.Basescan)
+`, address, links.Basescan, walletQRSection(links))
+}
+
+// walletQRSection returns a "scan to pay" line embedding the WalletLink QR
+// code as a PNG data URI when stdout is a terminal, and "" otherwise.
+func walletQRSection(links *PaymentLinksInfo) string {
+	if !stdoutIsTerminal() {
+		return ""
+	}
+	png, err := links.QRCodePNG(256)
+	if err != nil {
+		return ""
+	}
+	return "- Scan to pay: data:image/png;base64," + base64.StdEncoding.EncodeToString(png) + "\n"
 }
 
 // FormatNeedsFundingMessage formats the message shown when wallet needs more funds.
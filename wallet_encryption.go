@@ -0,0 +1,153 @@
+package blockrun
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// walletEncryptionAlgo identifies the envelope format: Argon2id key
+// derivation (3 passes, 64 MiB, 4 lanes) and AES-256-GCM.
+const walletEncryptionAlgo = "argon2id-aes-256-gcm"
+
+// Argon2id parameters for wallet encryption.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+// ErrWalletAuthFailed is returned when an encrypted wallet cannot be
+// decrypted: the passphrase is wrong or the file has been tampered with.
+var ErrWalletAuthFailed = errors.New("blockrun: wallet authentication failed (wrong passphrase or corrupted file)")
+
+// encryptedWallet is the on-disk JSON envelope; byte fields are hex.
+type encryptedWallet struct {
+	Algo       string `json:"algo"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// encryptedWalletFile returns the path of the encrypted wallet,
+// ~/.blockrun/.session.enc (inside WalletDir).
+func encryptedWalletFile() string {
+	return filepath.Join(WalletDir, ".session.enc")
+}
+
+// SaveWalletEncrypted encrypts privateKey with a key derived from
+// passphrase (Argon2id) using AES-256-GCM, and writes it to
+// ~/.blockrun/.session.enc. It returns the file path.
+func SaveWalletEncrypted(privateKey, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", &ValidationError{Field: "passphrase", Message: "Passphrase is required"}
+	}
+	envelope, err := encryptWalletKey(privateKey, passphrase)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode wallet: %w", err)
+	}
+	if err := os.MkdirAll(WalletDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create wallet directory: %w", err)
+	}
+	path := encryptedWalletFile()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write wallet file: %w", err)
+	}
+	return path, nil
+}
+
+// LoadWalletDecrypted reads and decrypts ~/.blockrun/.session.enc. A wrong
+// passphrase or modified file returns ErrWalletAuthFailed.
+func LoadWalletDecrypted(passphrase string) (string, error) {
+	data, err := os.ReadFile(encryptedWalletFile())
+	if err != nil {
+		return "", fmt.Errorf("failed to read encrypted wallet: %w", err)
+	}
+	var envelope encryptedWallet
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return "", fmt.Errorf("failed to decode encrypted wallet: %w", err)
+	}
+	return decryptWalletKey(&envelope, passphrase)
+}
+
+// RotateWalletPassphrase re-encrypts the encrypted wallet under newPass.
+func RotateWalletPassphrase(oldPass, newPass string) error {
+	privateKey, err := LoadWalletDecrypted(oldPass)
+	if err != nil {
+		return err
+	}
+	_, err = SaveWalletEncrypted(privateKey, newPass)
+	return err
+}
+
+// encryptWalletKey seals privateKey under passphrase with a fresh salt and
+// nonce.
+func encryptWalletKey(privateKey, passphrase string) (*encryptedWallet, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := walletCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return &encryptedWallet{
+		Algo:       walletEncryptionAlgo,
+		Salt:       hex.EncodeToString(salt),
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(gcm.Seal(nil, nonce, []byte(privateKey), []byte(walletEncryptionAlgo))),
+	}, nil
+}
+
+// decryptWalletKey opens an envelope produced by encryptWalletKey.
+func decryptWalletKey(envelope *encryptedWallet, passphrase string) (string, error) {
+	if envelope.Algo != walletEncryptionAlgo {
+		return "", fmt.Errorf("unsupported wallet encryption %q", envelope.Algo)
+	}
+	salt, err1 := hex.DecodeString(envelope.Salt)
+	nonce, err2 := hex.DecodeString(envelope.Nonce)
+	ciphertext, err3 := hex.DecodeString(envelope.Ciphertext)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return "", fmt.Errorf("failed to decode encrypted wallet: %w", err)
+	}
+	gcm, err := walletCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return "", ErrWalletAuthFailed
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(walletEncryptionAlgo))
+	if err != nil {
+		return "", ErrWalletAuthFailed
+	}
+	return string(plaintext), nil
+}
+
+// walletCipher derives the AES-256-GCM cipher for passphrase and salt.
+func walletCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
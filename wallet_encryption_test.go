@@ -0,0 +1,106 @@
+package blockrun
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func useTempWalletDir(t *testing.T) {
+	t.Helper()
+	tempDir := t.TempDir()
+	WalletDir = tempDir
+	WalletFile = filepath.Join(tempDir, ".session")
+	t.Setenv("BLOCKRUN_WALLET_KEY", "")
+	t.Setenv("BASE_CHAIN_WALLET_KEY", "")
+}
+
+func TestSaveWalletEncryptedRoundTrip(t *testing.T) {
+	useTempWalletDir(t)
+
+	path, err := SaveWalletEncrypted(testPrivateKey, "correct horse")
+	if err != nil {
+		t.Fatalf("SaveWalletEncrypted: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), strings.TrimPrefix(testPrivateKey, "0x")) {
+		t.Fatal("private key stored in plaintext")
+	}
+	var envelope encryptedWallet
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Algo != walletEncryptionAlgo ||
+		envelope.Salt == "" || envelope.Nonce == "" || envelope.Ciphertext == "" {
+		t.Fatalf("unexpected envelope %s: %v", data, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected 0600 permissions, got %v", info.Mode().Perm())
+	}
+
+	key, err := LoadWalletDecrypted("correct horse")
+	if err != nil || key != testPrivateKey {
+		t.Fatalf("LoadWalletDecrypted = %q, %v", key, err)
+	}
+	if _, err := LoadWalletDecrypted("wrong"); !errors.Is(err, ErrWalletAuthFailed) {
+		t.Errorf("expected ErrWalletAuthFailed for a wrong passphrase, got %v", err)
+	}
+}
+
+func TestLoadWalletDecryptedTampered(t *testing.T) {
+	useTempWalletDir(t)
+	path, _ := SaveWalletEncrypted(testPrivateKey, "pass")
+
+	data, _ := os.ReadFile(path)
+	var envelope encryptedWallet
+	json.Unmarshal(data, &envelope)
+	ct, _ := hex.DecodeString(envelope.Ciphertext)
+	ct[0] ^= 0x01
+	envelope.Ciphertext = hex.EncodeToString(ct)
+	data, _ = json.Marshal(envelope)
+	os.WriteFile(path, data, 0600)
+
+	if _, err := LoadWalletDecrypted("pass"); !errors.Is(err, ErrWalletAuthFailed) {
+		t.Errorf("expected ErrWalletAuthFailed for tampered ciphertext, got %v", err)
+	}
+}
+
+func TestRotateWalletPassphrase(t *testing.T) {
+	useTempWalletDir(t)
+	SaveWalletEncrypted(testPrivateKey, "old")
+
+	if err := RotateWalletPassphrase("wrong", "new"); !errors.Is(err, ErrWalletAuthFailed) {
+		t.Errorf("expected rotation with a wrong passphrase to fail, got %v", err)
+	}
+	if err := RotateWalletPassphrase("old", "new"); err != nil {
+		t.Fatalf("RotateWalletPassphrase: %v", err)
+	}
+	if _, err := LoadWalletDecrypted("old"); !errors.Is(err, ErrWalletAuthFailed) {
+		t.Error("expected the old passphrase to stop working")
+	}
+	if key, err := LoadWalletDecrypted("new"); err != nil || key != testPrivateKey {
+		t.Errorf("LoadWalletDecrypted(new) = %q, %v", key, err)
+	}
+}
+
+func TestGetOrCreateWalletEncrypted(t *testing.T) {
+	useTempWalletDir(t)
+
+	created, err := GetOrCreateWallet(WithWalletPassphrase("pass"))
+	if err != nil || !created.IsNew {
+		t.Fatalf("GetOrCreateWallet: %+v, %v", created, err)
+	}
+	if _, err := os.Stat(WalletFile); !os.IsNotExist(err) {
+		t.Error("expected no plaintext wallet file")
+	}
+
+	loaded, err := GetOrCreateWallet(WithWalletPassphrase("pass"))
+	if err != nil || loaded.IsNew || loaded.Address != created.Address {
+		t.Errorf("expected the encrypted wallet to be loaded, got %+v, %v", loaded, err)
+	}
+
+	if _, err := GetOrCreateWallet(); err == nil {
+		t.Error("expected an error instead of a new wallet when only an encrypted one exists")
+	}
+}
@@ -0,0 +1,99 @@
+package blockrun
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrNotSupported is returned by features that are defined but not yet
+// available on this platform or build.
+var ErrNotSupported = errors.New("blockrun: not supported")
+
+// minInteractiveEntropy is the number of characters CreateWalletInteractive
+// asks the user to type.
+const minInteractiveEntropy = 32
+
+// CreateWalletFromEntropy creates a new wallet whose key mixes crypto/rand
+// output with extra entropy (dice rolls, a hardware RNG dump, ...). The extra
+// bytes are hashed and XORed into the random seed, so the key is never
+// weaker than CreateWallet's, and the same extra bytes never reproduce a key.
+func CreateWalletFromEntropy(extra []byte) (address string, privateKey string, err error) {
+	key, err := generateKeyWithEntropy(extra)
+	if err != nil {
+		return "", "", err
+	}
+	privateKey = "0x" + fmt.Sprintf("%x", crypto.FromECDSA(key))
+	address = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	return address, privateKey, nil
+}
+
+// CreateWalletInteractive creates a new wallet after asking the user to type
+// random characters on stdin, which are mixed (with their keystroke timing)
+// into the key as in CreateWalletFromEntropy. The wallet is not saved; call
+// SaveWallet to persist it.
+func CreateWalletInteractive() (*WalletInfo, error) {
+	return createWalletInteractive(os.Stdin, os.Stderr)
+}
+
+// createWalletInteractive reads entropy lines from in until enough
+// characters are collected, prompting on out.
+func createWalletInteractive(in io.Reader, out io.Writer) (*WalletInfo, error) {
+	var entropy []byte
+	typed := 0
+	scanner := bufio.NewScanner(in)
+	for typed < minInteractiveEntropy {
+		fmt.Fprintf(out, "Type at least %d random characters, then press Enter: ", minInteractiveEntropy-typed)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, fmt.Errorf("failed to read entropy: %w", err)
+			}
+			return nil, fmt.Errorf("failed to read entropy: input ended after %d characters", typed)
+		}
+		line := scanner.Bytes()
+		typed += len(line)
+		entropy = append(entropy, line...)
+		entropy = binary.BigEndian.AppendUint64(entropy, uint64(time.Now().UnixNano()))
+	}
+
+	address, privateKey, err := CreateWalletFromEntropy(entropy)
+	if err != nil {
+		return nil, err
+	}
+	return &WalletInfo{PrivateKey: privateKey, Address: address, IsNew: true}, nil
+}
+
+// CreateWalletFromHardwareKey is the extension point for deriving or
+// seeding a wallet from a FIDO2 / USB security device at usbPath. It is not
+// implemented yet and always returns ErrNotSupported.
+func CreateWalletFromHardwareKey(usbPath string) (*WalletInfo, error) {
+	return nil, fmt.Errorf("hardware key %s: %w", usbPath, ErrNotSupported)
+}
+
+// generateKeyWithEntropy derives a secp256k1 key from 32 random bytes XORed
+// with SHA-256(extra), redrawing in the negligible case that the seed is not
+// a valid private key.
+func generateKeyWithEntropy(extra []byte) (*ecdsa.PrivateKey, error) {
+	mix := sha256.Sum256(extra)
+	for {
+		var seed [32]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		for i := range seed {
+			seed[i] ^= mix[i]
+		}
+		if key, err := crypto.ToECDSA(seed[:]); err == nil {
+			return key, nil
+		}
+	}
+}
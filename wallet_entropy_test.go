@@ -0,0 +1,54 @@
+package blockrun
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCreateWalletFromEntropy(t *testing.T) {
+	extra := []byte("the same dice rolls: 3 1 4 1 5 9 2 6")
+	addr1, key1, err := CreateWalletFromEntropy(extra)
+	if err != nil {
+		t.Fatalf("CreateWalletFromEntropy: %v", err)
+	}
+	addr2, key2, err := CreateWalletFromEntropy(extra)
+	if err != nil {
+		t.Fatalf("CreateWalletFromEntropy: %v", err)
+	}
+	if addr1 == addr2 || key1 == key2 {
+		t.Error("expected crypto/rand to make wallets from the same extra entropy differ")
+	}
+	if got, err := GetAddressFromKey(key1); err != nil || got != addr1 {
+		t.Errorf("key does not match address: %v, %v", got, err)
+	}
+	if _, _, err := CreateWalletFromEntropy(nil); err != nil {
+		t.Errorf("expected nil extra entropy to work, got %v", err)
+	}
+}
+
+func TestCreateWalletInteractive(t *testing.T) {
+	in := strings.NewReader("asdkjh2398\nqwoeiu!@#zmxn,cvbq\npoiuytrewq\n")
+	var prompts bytes.Buffer
+	info, err := createWalletInteractive(in, &prompts)
+	if err != nil {
+		t.Fatalf("createWalletInteractive: %v", err)
+	}
+	if !info.IsNew || info.Address == "" || !strings.HasPrefix(info.PrivateKey, "0x") {
+		t.Errorf("unexpected wallet: %+v", info)
+	}
+	if n := strings.Count(prompts.String(), "random characters"); n != 3 {
+		t.Errorf("expected 3 prompts until 32 characters were typed, got %d", n)
+	}
+
+	if _, err := createWalletInteractive(strings.NewReader("too short\n"), &prompts); err == nil {
+		t.Error("expected an error when input ends before enough entropy")
+	}
+}
+
+func TestCreateWalletFromHardwareKey(t *testing.T) {
+	if _, err := CreateWalletFromHardwareKey("/dev/hidraw0"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
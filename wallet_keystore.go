@@ -0,0 +1,147 @@
+package blockrun
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Scrypt parameters for ExportKeystore, geth's "standard" strength. The
+// cost is a variable so tests can lower it.
+var keystoreScryptN = 1 << 18
+
+const (
+	keystoreScryptR = 8
+	keystoreScryptP = 1
+)
+
+// keystoreV3 is a Web3 Secret Storage (version 3) keystore file.
+type keystoreV3 struct {
+	Address string         `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+	ID      string         `json:"id"`
+	Version int            `json:"version"`
+}
+
+type keystoreCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	KDF       string `json:"kdf"`
+	KDFParams struct {
+		DKLen int    `json:"dklen"`
+		N     int    `json:"n"`
+		P     int    `json:"p"`
+		R     int    `json:"r"`
+		Salt  string `json:"salt"`
+	} `json:"kdfparams"`
+	MAC string `json:"mac"`
+}
+
+// ExportKeystore encrypts privateKey under password as a Web3 Secret Storage
+// (version 3) keystore, the JSON format geth, MetaMask and most wallets
+// import. It uses scrypt and AES-128-CTR.
+func ExportKeystore(privateKey, password string) ([]byte, error) {
+	if password == "" {
+		return nil, &ValidationError{Field: "password", Message: "Password is required"}
+	}
+	key, err := GetPrivateKeyFromHex(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	id := make([]byte, 16)
+	for _, b := range [][]byte{salt, iv, id} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate randomness: %w", err)
+		}
+	}
+	id[6] = id[6]&0x0f | 0x40 // UUID version 4
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+
+	derived, err := scrypt.Key([]byte(password), salt, keystoreScryptN, keystoreScryptR, keystoreScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := aesCTR(derived[:16], iv, crypto.FromECDSA(key))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := keystoreV3{
+		Address: strings.ToLower(strings.TrimPrefix(crypto.PubkeyToAddress(key.PublicKey).Hex(), "0x")),
+		ID:      fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		Version: 3,
+	}
+	ks.Crypto.Cipher = "aes-128-ctr"
+	ks.Crypto.CipherText = hex.EncodeToString(ciphertext)
+	ks.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	ks.Crypto.KDF = "scrypt"
+	ks.Crypto.KDFParams.DKLen = 32
+	ks.Crypto.KDFParams.N = keystoreScryptN
+	ks.Crypto.KDFParams.R = keystoreScryptR
+	ks.Crypto.KDFParams.P = keystoreScryptP
+	ks.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	ks.Crypto.MAC = hex.EncodeToString(crypto.Keccak256(derived[16:32], ciphertext))
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// ImportKeystore decrypts a version 3 scrypt keystore, such as one written
+// by ExportKeystore, and returns the 0x-prefixed private key. A wrong
+// password returns ErrWalletAuthFailed.
+func ImportKeystore(data []byte, password string) (string, error) {
+	var ks keystoreV3
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return "", fmt.Errorf("failed to decode keystore: %w", err)
+	}
+	c := ks.Crypto
+	if ks.Version != 3 || c.KDF != "scrypt" || c.Cipher != "aes-128-ctr" {
+		return "", fmt.Errorf("unsupported keystore (version %d, kdf %q, cipher %q)", ks.Version, c.KDF, c.Cipher)
+	}
+	salt, err1 := hex.DecodeString(c.KDFParams.Salt)
+	iv, err2 := hex.DecodeString(c.CipherParams.IV)
+	ciphertext, err3 := hex.DecodeString(c.CipherText)
+	mac, err4 := hex.DecodeString(c.MAC)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return "", fmt.Errorf("failed to decode keystore: malformed hex")
+	}
+
+	derived, err := scrypt.Key([]byte(password), salt, c.KDFParams.N, c.KDFParams.R, c.KDFParams.P, c.KDFParams.DKLen)
+	if err != nil {
+		return "", err
+	}
+	if len(derived) < 32 || !bytes.Equal(crypto.Keccak256(derived[16:32], ciphertext), mac) {
+		return "", ErrWalletAuthFailed
+	}
+	plaintext, err := aesCTR(derived[:16], iv, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(plaintext), nil
+}
+
+// aesCTR encrypts or decrypts data with AES-128-CTR.
+func aesCTR(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV length %d", len(iv))
+	}
+	out := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(out, data)
+	return out, nil
+}
@@ -0,0 +1,65 @@
+package blockrun
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExportKeystoreRoundTrip(t *testing.T) {
+	prev := keystoreScryptN
+	keystoreScryptN = 1 << 12 // keep the test fast
+	defer func() { keystoreScryptN = prev }()
+
+	data, err := ExportKeystore(testPrivateKey, "hunter2")
+	if err != nil {
+		t.Fatalf("ExportKeystore: %v", err)
+	}
+	var ks map[string]any
+	json.Unmarshal(data, &ks)
+	if ks["version"] != float64(3) || ks["address"] != strings.ToLower(strings.TrimPrefix(testWalletAddress, "0x")) {
+		t.Errorf("unexpected keystore header: %v", ks)
+	}
+	if strings.Contains(string(data), strings.TrimPrefix(testPrivateKey, "0x")) {
+		t.Fatal("keystore contains the plaintext key")
+	}
+
+	key, err := ImportKeystore(data, "hunter2")
+	if err != nil {
+		t.Fatalf("ImportKeystore: %v", err)
+	}
+	if key != testPrivateKey {
+		t.Errorf("expected %s, got %s", testPrivateKey, key)
+	}
+	if _, err := ImportKeystore(data, "wrong"); !errors.Is(err, ErrWalletAuthFailed) {
+		t.Errorf("expected ErrWalletAuthFailed, got %v", err)
+	}
+	if _, err := ExportKeystore(testPrivateKey, ""); err == nil {
+		t.Error("expected an empty password to be rejected")
+	}
+}
+
+// TestImportKeystoreVector decrypts the scrypt test vector from the Web3
+// Secret Storage definition.
+func TestImportKeystoreVector(t *testing.T) {
+	const vector = `{
+		"crypto": {
+			"cipher": "aes-128-ctr",
+			"cipherparams": {"iv": "83dbcc02d8ccb40e466191a123791e0e"},
+			"ciphertext": "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+			"kdf": "scrypt",
+			"kdfparams": {"dklen": 32, "n": 262144, "p": 8, "r": 1, "salt": "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},
+			"mac": "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"
+		},
+		"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+		"version": 3
+	}`
+	key, err := ImportKeystore([]byte(vector), "testpassword")
+	if err != nil {
+		t.Fatalf("ImportKeystore: %v", err)
+	}
+	if key != "0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+		t.Errorf("unexpected key %s", key)
+	}
+}
@@ -1,11 +1,18 @@
 package blockrun
 
 import (
+	"bytes"
 	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	qrreader "github.com/makiuchi-d/gozxing/qrcode"
 )
 
 func TestCreateWallet(t *testing.T) {
@@ -54,6 +61,30 @@ func TestGetAddressFromKeyWithoutPrefix(t *testing.T) {
 	}
 }
 
+func TestToChecksumAddress(t *testing.T) {
+	for _, want := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		for _, in := range []string{want, strings.ToLower(want), "0x" + strings.ToUpper(want[2:])} {
+			got, err := ToChecksumAddress(in)
+			if err != nil || got != want {
+				t.Errorf("ToChecksumAddress(%q) = %q, %v; want %q", in, got, err, want)
+			}
+		}
+	}
+	if _, err := ToChecksumAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"); err == nil {
+		t.Error("expected a bad checksum to be rejected")
+	}
+
+	address, _ := GetAddressFromKey(testPrivateKey)
+	if checksummed, err := ToChecksumAddress(address); err != nil || checksummed != address {
+		t.Errorf("expected GetAddressFromKey to return a checksum address, got %q", address)
+	}
+}
+
 func TestGetAddressFromKeyInvalid(t *testing.T) {
 	_, err := GetAddressFromKey("invalid-key")
 	if err == nil {
@@ -160,6 +191,11 @@ func TestGetEIP681URI(t *testing.T) {
 	if !strings.Contains(uri, BaseChainIDStr) {
 		t.Errorf("Expected URI to contain chain ID %s, got %s", BaseChainIDStr, uri)
 	}
+
+	// The amount stays in plain base units
+	if !strings.HasSuffix(uri, "&uint256=1000000") {
+		t.Errorf("Expected amount in base units, got %s", uri)
+	}
 }
 
 func TestGetPaymentLinks(t *testing.T) {
@@ -362,3 +398,131 @@ func TestScanWalletsUsesHomeEnv(t *testing.T) {
 		t.Errorf("Expected address %s, got %s", testWalletAddress, wallets[0].Address)
 	}
 }
+
+func TestGeneratePaymentURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		units    int64
+		decimals int
+		want     string
+	}{
+		{"whole tokens", 5_000_000, 6, "ethereum:0xToken@10/transfer?address=0xRecipient&uint256=5e6"},
+		{"fractional", 1_500_000, 6, "ethereum:0xToken@10/transfer?address=0xRecipient&uint256=1500000"},
+		{"18 decimals", 2_000_000_000_000_000_000, 18, "ethereum:0xToken@10/transfer?address=0xRecipient&uint256=2e18"},
+		{"no amount", 0, 6, "ethereum:0xToken@10/transfer?address=0xRecipient"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GeneratePaymentURI(10, "0xToken", "0xRecipient", tt.units, tt.decimals); got != tt.want {
+				t.Errorf("GeneratePaymentURI = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPaymentLinksWithChainConfig(t *testing.T) {
+	optimism := ChainConfig{
+		ChainID:       10,
+		Name:          "Optimism",
+		TokenContract: "0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85",
+		TokenSymbol:   "USDC",
+		TokenDecimals: 6,
+		ExplorerURL:   "https://optimistic.etherscan.io/",
+	}
+	links := GetPaymentLinks(testWalletAddress, optimism)
+
+	if links.Basescan != "https://optimistic.etherscan.io/address/"+testWalletAddress {
+		t.Errorf("unexpected explorer link %s", links.Basescan)
+	}
+	want := "ethereum:" + optimism.TokenContract + "@10/transfer?address=" + testWalletAddress
+	if links.WalletLink != want {
+		t.Errorf("WalletLink = %s, want %s", links.WalletLink, want)
+	}
+}
+
+func TestQRCodePNGRoundTrip(t *testing.T) {
+	links := GetPaymentLinks(testWalletAddress)
+	data, err := links.QRCodePNG(256)
+	if err != nil {
+		t.Fatalf("QRCodePNG: %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 256 || b.Dy() != 256 {
+		t.Errorf("expected 256x256 image, got %v", b)
+	}
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		t.Fatalf("NewBinaryBitmapFromImage: %v", err)
+	}
+	result, err := qrreader.NewQRCodeReader().Decode(bmp, nil)
+	if err != nil {
+		t.Fatalf("decode QR: %v", err)
+	}
+	if result.GetText() != links.WalletLink {
+		t.Errorf("decoded %q, want %q", result.GetText(), links.WalletLink)
+	}
+}
+
+func TestQRCodeTerminalRoundTrip(t *testing.T) {
+	links := GetPaymentLinks(testWalletAddress)
+	rows := strings.Split(strings.TrimSuffix(links.QRCodeTerminal(), "\n"), "\n")
+
+	// Each character is two modules; redraw them 8px square, light modules
+	// white, and read the code back.
+	const scale = 8
+	width := len([]rune(rows[0]))
+	img := image.NewGray(image.Rect(0, 0, width*scale, len(rows)*2*scale))
+	for y, row := range rows {
+		for x, r := range []rune(row) {
+			top := r == '█' || r == '▀'
+			bottom := r == '█' || r == '▄'
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					if top {
+						img.SetGray(x*scale+dx, 2*y*scale+dy, color.Gray{Y: 255})
+					}
+					if bottom {
+						img.SetGray(x*scale+dx, (2*y+1)*scale+dy, color.Gray{Y: 255})
+					}
+				}
+			}
+		}
+	}
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		t.Fatalf("NewBinaryBitmapFromImage: %v", err)
+	}
+	result, err := qrreader.NewQRCodeReader().Decode(bmp, nil)
+	if err != nil {
+		t.Fatalf("decode QR: %v", err)
+	}
+	if result.GetText() != links.WalletLink {
+		t.Errorf("decoded %q, want %q", result.GetText(), links.WalletLink)
+	}
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	svg := GetPaymentLinks(testWalletAddress).QRCodeSVG()
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") || !strings.Contains(svg, "h1v1h-1z") {
+		t.Errorf("unexpected SVG output: %.80s", svg)
+	}
+}
+
+func TestFormatWalletCreatedMessageQRCode(t *testing.T) {
+	orig := stdoutIsTerminal
+	defer func() { stdoutIsTerminal = orig }()
+
+	stdoutIsTerminal = func() bool { return true }
+	if msg := FormatWalletCreatedMessage(testWalletAddress); !strings.Contains(msg, "data:image/png;base64,") {
+		t.Error("expected QR data URI when stdout is a terminal")
+	}
+
+	stdoutIsTerminal = func() bool { return false }
+	if msg := FormatWalletCreatedMessage(testWalletAddress); strings.Contains(msg, "data:image/png") {
+		t.Error("expected no QR data URI when stdout is not a terminal")
+	}
+}
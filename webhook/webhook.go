@@ -0,0 +1,130 @@
+// Package webhook receives payment confirmation callbacks that x402 servers
+// POST to the payer once a payment settles on-chain.
+//
+//	srv, err := webhook.StartWebhookServer(":8090", secret,
+//		func(ctx context.Context, c webhook.PaymentConfirmation) error {
+//			log.Printf("payment %s confirmed in block %d", c.TxHash, c.BlockNumber)
+//			return nil
+//		})
+//
+// Each callback must carry a hex HMAC-SHA256 of its body, keyed with the
+// shared secret, in the X-BlockRun-Signature header ("sha256=" prefix
+// optional). Unsigned or mis-signed callbacks are rejected with 401.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of the callback body.
+const SignatureHeader = "X-BlockRun-Signature"
+
+// maxBodyBytes bounds the size of a callback body.
+const maxBodyBytes = 1 << 20
+
+// PaymentConfirmation is the payload of a payment confirmation callback.
+type PaymentConfirmation struct {
+	TxHash      string    `json:"tx_hash"`
+	BlockNumber uint64    `json:"block_number"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Amount      string    `json:"amount"`
+	Network     string    `json:"network"`
+	Nonce       string    `json:"nonce"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// PaymentConfirmationHandler is called for each verified callback. A
+// non-nil error answers the callback with 500 so the sender retries.
+type PaymentConfirmationHandler func(ctx context.Context, confirmation PaymentConfirmation) error
+
+// NewWebhookServer returns a handler that verifies each POSTed callback
+// against secret and passes the confirmation to handler.
+func NewWebhookServer(secret string, handler PaymentConfirmationHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxBodyBytes {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if !VerifySignature(secret, body, r.Header.Get(SignatureHeader)) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		var confirmation PaymentConfirmation
+		if err := json.Unmarshal(body, &confirmation); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		if confirmation.TxHash == "" {
+			http.Error(w, "missing tx_hash", http.StatusBadRequest)
+			return
+		}
+
+		if err := handler(r.Context(), confirmation); err != nil {
+			http.Error(w, "handler failed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, as expected in
+// the X-BlockRun-Signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the HMAC-SHA256 of body keyed
+// with secret. The comparison is constant-time.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil || len(got) == 0 {
+		return false
+	}
+	want, _ := hex.DecodeString(Sign(secret, body))
+	return hmac.Equal(got, want)
+}
+
+// StartWebhookServer listens on addr and serves NewWebhookServer(secret,
+// handler) in the background. Stop it with the returned server's Shutdown.
+func StartWebhookServer(addr, secret string, handler PaymentConfirmationHandler) (*http.Server, error) {
+	if secret == "" {
+		return nil, errors.New("webhook: secret is required")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Addr:              ln.Addr().String(),
+		Handler:           NewWebhookServer(secret, handler),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go srv.Serve(ln)
+	return srv, nil
+}
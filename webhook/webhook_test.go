@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+const testPayload = `{"tx_hash":"0xabc","block_number":123,"from":"0x1","to":"0x2","amount":"5000","network":"eip155:8453","nonce":"0xn","timestamp":"2026-01-02T03:04:05Z"}`
+
+func post(t *testing.T, h http.Handler, body, signature string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+	if signature != "" {
+		req.Header.Set(SignatureHeader, signature)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookValidSignature(t *testing.T) {
+	var got []PaymentConfirmation
+	h := NewWebhookServer(testSecret, func(_ context.Context, c PaymentConfirmation) error {
+		got = append(got, c)
+		return nil
+	})
+
+	sig := Sign(testSecret, []byte(testPayload))
+	for _, s := range []string{sig, "sha256=" + sig} {
+		if code := post(t, h, testPayload, s); code != http.StatusNoContent {
+			t.Fatalf("signature %q: status %d", s, code)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 handler calls, got %d", len(got))
+	}
+	want := PaymentConfirmation{
+		TxHash: "0xabc", BlockNumber: 123, From: "0x1", To: "0x2", Amount: "5000",
+		Network: "eip155:8453", Nonce: "0xn", Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if got[0] != want {
+		t.Errorf("confirmation = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestWebhookRejectsBadSignature(t *testing.T) {
+	called := false
+	h := NewWebhookServer(testSecret, func(context.Context, PaymentConfirmation) error {
+		called = true
+		return nil
+	})
+
+	for name, sig := range map[string]string{
+		"missing":      "",
+		"wrong secret": Sign("other", []byte(testPayload)),
+		"not hex":      "zz",
+		"other body":   Sign(testSecret, []byte(`{"tx_hash":"0xdef"}`)),
+	} {
+		if code := post(t, h, testPayload, sig); code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, want 401", name, code)
+		}
+	}
+	if called {
+		t.Error("handler was called for an unverified callback")
+	}
+}
+
+func TestWebhookHandlerErrorAndMethod(t *testing.T) {
+	h := NewWebhookServer(testSecret, func(context.Context, PaymentConfirmation) error {
+		return errors.New("db down")
+	})
+	if code := post(t, h, testPayload, Sign(testSecret, []byte(testPayload))); code != http.StatusInternalServerError {
+		t.Errorf("handler error: status %d, want 500", code)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d, want 405", rec.Code)
+	}
+}
+
+func TestStartWebhookServer(t *testing.T) {
+	done := make(chan PaymentConfirmation, 1)
+	srv, err := StartWebhookServer("127.0.0.1:0", testSecret, func(_ context.Context, c PaymentConfirmation) error {
+		done <- c
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StartWebhookServer: %v", err)
+	}
+	defer srv.Shutdown(context.Background())
+
+	req, _ := http.NewRequest(http.MethodPost, "http://"+srv.Addr, bytes.NewBufferString(testPayload))
+	req.Header.Set(SignatureHeader, Sign(testSecret, []byte(testPayload)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if c := <-done; c.TxHash != "0xabc" {
+		t.Errorf("unexpected confirmation %+v", c)
+	}
+
+	if _, err := StartWebhookServer("127.0.0.1:0", "", nil); err == nil {
+		t.Error("expected an error without a secret")
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -70,7 +73,9 @@ func withBuilderCodeServiceCode(extensions map[string]any) map[string]any {
 // CreatePaymentPayload creates a signed x402 v2 payment payload.
 //
 // This uses EIP-712 typed data signing to create a payment authorization
-// that the CDP facilitator can verify and settle.
+// that the CDP facilitator can verify and settle. Fields the server lists
+// under extra[AuthorizationExtrasKey], such as a memo, are signed as part of
+// the authorization; see AuthorizationExtras.
 //
 // SECURITY: The private key is used ONLY for local signing.
 // Only the signature is sent to the server - the key NEVER leaves your machine.
@@ -85,11 +90,35 @@ func CreatePaymentPayload(
 	extra map[string]any,
 	extensions map[string]any,
 ) (string, error) {
-	// Get wallet address from private key
-	walletAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	return createPaymentPayloadAt(currentClock().Now(), localSigner{key: privateKey}, recipient, amount, network,
+		resourceURL, resourceDescription, maxTimeoutSeconds, extra, extensions)
+}
+
+// createPaymentPayloadAt is CreatePaymentPayload with the validity window
+// anchored at at rather than the local clock (see WithMaxClockDrift), signed
+// by signer.
+func createPaymentPayloadAt(
+	at time.Time,
+	signer MPCSigner,
+	recipient string,
+	amount string,
+	network string,
+	resourceURL string,
+	resourceDescription string,
+	maxTimeoutSeconds int,
+	extra map[string]any,
+	extensions map[string]any,
+) (string, error) {
+	// Get wallet address from the signer; both it and the recipient are
+	// embedded in EIP-55 checksum form
+	from := common.HexToAddress(signer.Address()).Hex()
+	recipient, err := ToChecksumAddress(recipient)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient: %w", err)
+	}
 
 	// Current timestamp
-	now := time.Now().Unix()
+	now := at.Unix()
 	validAfter := now - 600 // 10 minutes before (allows for clock skew)
 	validBefore := now + int64(maxTimeoutSeconds)
 
@@ -117,66 +146,18 @@ func CreatePaymentPayload(
 		}
 	}
 
-	// EIP-712 typed data for TransferWithAuthorization
-	typedData := apitypes.TypedData{
-		Types: apitypes.Types{
-			"EIP712Domain": {
-				{Name: "name", Type: "string"},
-				{Name: "version", Type: "string"},
-				{Name: "chainId", Type: "uint256"},
-				{Name: "verifyingContract", Type: "address"},
-			},
-			"TransferWithAuthorization": {
-				{Name: "from", Type: "address"},
-				{Name: "to", Type: "address"},
-				{Name: "value", Type: "uint256"},
-				{Name: "validAfter", Type: "uint256"},
-				{Name: "validBefore", Type: "uint256"},
-				{Name: "nonce", Type: "bytes32"},
-			},
-		},
-		PrimaryType: "TransferWithAuthorization",
-		Domain: apitypes.TypedDataDomain{
-			Name:              usdcName,
-			Version:           usdcVersion,
-			ChainId:           math.NewHexOrDecimal256(BaseChainID),
-			VerifyingContract: USDCBase,
-		},
-		Message: apitypes.TypedDataMessage{
-			"from":        walletAddress.Hex(),
-			"to":          recipient,
-			"value":       amountBig.String(),
-			"validAfter":  big.NewInt(validAfter).String(),
-			"validBefore": big.NewInt(validBefore).String(),
-			"nonce":       nonce,
-		},
-	}
-
-	// Hash the typed data
-	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
-	if err != nil {
-		return "", fmt.Errorf("failed to hash domain: %w", err)
-	}
-
-	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash message: %w", err)
+	auth := TransferAuthorization{
+		From:        from,
+		To:          recipient,
+		Value:       amountBig.String(),
+		ValidAfter:  strconv.FormatInt(validAfter, 10),
+		ValidBefore: strconv.FormatInt(validBefore, 10),
+		Nonce:       nonce,
+		Extras:      AuthorizationExtras(extra),
 	}
-
-	// Create final hash: keccak256("\x19\x01" + domainSeparator + messageHash)
-	rawData := append([]byte{0x19, 0x01}, domainSeparator...)
-	rawData = append(rawData, messageHash...)
-	hash := crypto.Keccak256Hash(rawData)
-
-	// Sign the hash
-	signature, err := crypto.Sign(hash.Bytes(), privateKey)
+	signature, err := signTransferAuthorizationWith(signer, auth, usdcName, usdcVersion)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign: %w", err)
-	}
-
-	// Fix signature v value (Ethereum uses 27/28, go-ethereum uses 0/1)
-	if signature[64] < 27 {
-		signature[64] += 27
+		return "", err
 	}
 
 	// Build extra for response
@@ -184,6 +165,9 @@ func CreatePaymentPayload(
 		"name":    usdcName,
 		"version": usdcVersion,
 	}
+	for k, v := range auth.Extras {
+		responseExtra[k] = v
+	}
 
 	// Create payment payload
 	payload := PaymentPayload{
@@ -205,12 +189,13 @@ func CreatePaymentPayload(
 		Payload: PaymentData{
 			Signature: "0x" + common.Bytes2Hex(signature),
 			Authorization: TransferAuthorization{
-				From:        walletAddress.Hex(),
+				From:        auth.From,
 				To:          recipient,
 				Value:       amount,
-				ValidAfter:  strconv.FormatInt(validAfter, 10),
-				ValidBefore: strconv.FormatInt(validBefore, 10),
+				ValidAfter:  auth.ValidAfter,
+				ValidBefore: auth.ValidBefore,
 				Nonce:       nonce,
+				Extras:      auth.Extras,
 			},
 		},
 		Extensions: withBuilderCodeServiceCode(extensions),
@@ -225,6 +210,156 @@ func CreatePaymentPayload(
 	return base64.StdEncoding.EncodeToString(jsonData), nil
 }
 
+// signTransferAuthorization returns the 65-byte EIP-712 signature (v = 27/28)
+// over a USDC-on-Base TransferWithAuthorization.
+//
+// Signing is deterministic (RFC 6979), so a given key and authorization yield
+// the same bytes whichever secp256k1 backend go-ethereum was built with —
+// libsecp256k1 under cgo, or the pure-Go btcec fallback used for
+// GOOS=js GOARCH=wasm and CGO_ENABLED=0 builds.
+func signTransferAuthorization(privateKey *ecdsa.PrivateKey, auth TransferAuthorization, usdcName, usdcVersion string) ([]byte, error) {
+	return signTransferAuthorizationWith(localSigner{key: privateKey}, auth, usdcName, usdcVersion)
+}
+
+// signTransferAuthorizationWith is signTransferAuthorization for any
+// MPCSigner.
+func signTransferAuthorizationWith(signer MPCSigner, auth TransferAuthorization, usdcName, usdcVersion string) ([]byte, error) {
+	hash, err := transferAuthorizationHash(auth, usdcName, usdcVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign the hash
+	signature, err := signer.Sign(hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("failed to sign: expected a 65-byte signature, got %d bytes", len(signature))
+	}
+	signature = append([]byte(nil), signature...)
+
+	// Fix signature v value (Ethereum uses 27/28, go-ethereum uses 0/1)
+	if signature[64] < 27 {
+		signature[64] += 27
+	}
+	return signature, nil
+}
+
+// transferAuthorizationHash returns the EIP-712 digest signed for a
+// USDC-on-Base TransferWithAuthorization. An authorization without extras
+// is encoded directly against the precomputed type hash and cached domain
+// separator; one with extras goes through apitypes.
+func transferAuthorizationHash(auth TransferAuthorization, usdcName, usdcVersion string) (common.Hash, error) {
+	if len(auth.Extras) == 0 {
+		if messageHash, ok := transferAuthorizationMessageHash(auth); ok {
+			domainSeparator, err := usdcDomainSeparator(usdcName, usdcVersion)
+			if err != nil {
+				return common.Hash{}, err
+			}
+			return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator[:], messageHash[:]), nil
+		}
+	}
+	typedData := transferAuthorizationTypedData(auth, usdcName, usdcVersion)
+
+	// Hash the typed data
+	domainSeparator, err := cachedDomainSeparator(typedData)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to hash message: %w", err)
+	}
+
+	// Create final hash: keccak256("\x19\x01" + domainSeparator + messageHash)
+	rawData := append([]byte{0x19, 0x01}, domainSeparator[:]...)
+	rawData = append(rawData, messageHash...)
+	return crypto.Keccak256Hash(rawData), nil
+}
+
+// transferAuthorizationTypedData returns the EIP-712 typed data of a
+// USDC-on-Base TransferWithAuthorization.
+func transferAuthorizationTypedData(auth TransferAuthorization, usdcName, usdcVersion string) apitypes.TypedData {
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"TransferWithAuthorization": transferAuthorizationTypes(auth),
+		},
+		PrimaryType: "TransferWithAuthorization",
+		Domain: apitypes.TypedDataDomain{
+			Name:              usdcName,
+			Version:           usdcVersion,
+			ChainId:           math.NewHexOrDecimal256(BaseChainID),
+			VerifyingContract: USDCBase,
+		},
+		Message: apitypes.TypedDataMessage{
+			"from":        auth.From,
+			"to":          auth.To,
+			"value":       auth.Value,
+			"validAfter":  auth.ValidAfter,
+			"validBefore": auth.ValidBefore,
+			"nonce":       auth.Nonce,
+		},
+	}
+	for k, v := range auth.Extras {
+		typedData.Message[k] = v
+	}
+	return typedData
+}
+
+// VerifyPaymentPayload decodes a base64 PAYMENT-SIGNATURE value produced by
+// CreatePaymentPayload and returns the address that signed its
+// authorization. It fails if the signature does not recover to the
+// authorization's from address. It does not check balances, validity
+// windows or whether the nonce has been used.
+func VerifyPaymentPayload(payload string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode payment payload: %w", err)
+	}
+	var p PaymentPayload
+	if err := json.Unmarshal(decoded, &p); err != nil {
+		return "", fmt.Errorf("failed to parse payment payload: %w", err)
+	}
+
+	usdcName, usdcVersion := "USD Coin", "2"
+	if name, ok := p.Accepted.Extra["name"].(string); ok {
+		usdcName = name
+	}
+	if version, ok := p.Accepted.Extra["version"].(string); ok {
+		usdcVersion = version
+	}
+	hash, err := transferAuthorizationHash(p.Payload.Authorization, usdcName, usdcVersion)
+	if err != nil {
+		return "", err
+	}
+
+	signature := common.FromHex(p.Payload.Signature)
+	if len(signature) != 65 {
+		return "", fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	signature = append([]byte(nil), signature...)
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), signature)
+	if err != nil {
+		return "", fmt.Errorf("failed to recover signer: %w", err)
+	}
+	signer := crypto.PubkeyToAddress(*pub)
+	if !common.IsHexAddress(p.Payload.Authorization.From) || common.HexToAddress(p.Payload.Authorization.From) != signer {
+		return "", fmt.Errorf("signature is from %s, not %s", signer.Hex(), p.Payload.Authorization.From)
+	}
+	return signer.Hex(), nil
+}
+
 // ParsePaymentRequired parses the payment-required header from a 402 response.
 func ParsePaymentRequired(headerValue string) (*PaymentRequirement, error) {
 	decoded, err := base64.StdEncoding.DecodeString(headerValue)
@@ -240,14 +375,121 @@ func ParsePaymentRequired(headerValue string) (*PaymentRequirement, error) {
 	return &req, nil
 }
 
+// errNoPaymentRequirements is returned by ParsePaymentRequiredFromBody when
+// the body carries no payment requirements.
+var errNoPaymentRequirements = errors.New("no payment requirements in body")
+
+// ParsePaymentRequiredFromBody parses payment requirements embedded in a 402
+// response body, for servers that send them there instead of in the
+// payment-required header. The body is either the requirement itself (with
+// an "accepts" list) or an object whose "x402" key holds it, as a JSON
+// object or a base64 header-style string.
+func ParsePaymentRequiredFromBody(body []byte) (*PaymentRequirement, error) {
+	var envelope struct {
+		X402    json.RawMessage `json:"x402"`
+		Accepts json.RawMessage `json:"accepts"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse payment required body: %w", err)
+	}
+
+	raw := envelope.X402
+	switch {
+	case len(raw) > 0 && raw[0] == '"':
+		var headerValue string
+		if err := json.Unmarshal(raw, &headerValue); err != nil {
+			return nil, fmt.Errorf("failed to parse payment required: %w", err)
+		}
+		return ParsePaymentRequired(headerValue)
+	case len(raw) > 0 && string(raw) != "null":
+	case len(envelope.Accepts) > 0:
+		raw = body
+	default:
+		return nil, errNoPaymentRequirements
+	}
+
+	var req PaymentRequirement
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse payment required: %w", err)
+	}
+	return &req, nil
+}
+
+// paymentAmountHeader carries the amount due, in micro-USDC, when the
+// gateway has a fresher quote than the payment requirements.
+const paymentAmountHeader = "X-Payment-Amount"
+
+// ExtractPaymentAmount returns the amount to pay for opt in answer to the
+// 402 resp: the X-Payment-Amount header if it holds a positive integer,
+// otherwise opt.Amount.
+func ExtractPaymentAmount(resp *http.Response, opt *PaymentOption) string {
+	return paymentAmount(resp.Header, opt.Amount)
+}
+
+// paymentAmount returns the X-Payment-Amount in header, or amount.
+func paymentAmount(header http.Header, amount string) string {
+	v := strings.TrimSpace(header.Get(paymentAmountHeader))
+	if n, ok := new(big.Int).SetString(v, 10); ok && n.Sign() > 0 {
+		return n.String()
+	}
+	return amount
+}
+
+// paymentRequirementFromResponse returns a 402's payment requirements from
+// the payment-required header or, failing that, the response body, with
+// amounts overridden by X-Payment-Amount. Errors are PaymentErrors.
+func paymentRequirementFromResponse(header http.Header, body []byte) (*PaymentRequirement, error) {
+	var req *PaymentRequirement
+	if headerValue := header.Get("payment-required"); headerValue != "" {
+		parsed, err := ParsePaymentRequired(headerValue)
+		if err != nil {
+			return nil, &PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
+		}
+		req = parsed
+	} else {
+		parsed, err := ParsePaymentRequiredFromBody(body)
+		if errors.Is(err, errNoPaymentRequirements) || (err != nil && !json.Valid(body)) {
+			return nil, &PaymentError{Message: "402 response but no payment requirements found"}
+		}
+		if err != nil {
+			return nil, &PaymentError{Message: fmt.Sprintf("Failed to parse payment requirements: %v", err)}
+		}
+		req = parsed
+	}
+
+	for i := range req.Accepts {
+		req.Accepts[i].Amount = paymentAmount(header, req.Accepts[i].Amount)
+	}
+	return req, nil
+}
+
 // ExtractPaymentDetails extracts payment details from a PaymentRequirement.
-// Returns the first payment option if multiple are available.
+// It returns the first option whose scheme was added with
+// RegisterPaymentScheme, so a scheme the caller registered wins over the
+// built-in "exact" wherever the server lists it; failing that the first
+// option whose scheme is registered, and failing that the first option.
 func ExtractPaymentDetails(req *PaymentRequirement) (*PaymentOption, error) {
 	if len(req.Accepts) == 0 {
 		return nil, fmt.Errorf("no payment options in payment required response")
 	}
 
 	option := req.Accepts[0]
+	best := 0 // 1: registered scheme, 2: user-registered scheme
+	for _, o := range req.Accepts {
+		if lookupPaymentScheme(o.Scheme) == nil {
+			continue
+		}
+		rank := 1
+		if o.Scheme != "exact" {
+			rank = 2
+		}
+		if rank > best {
+			option, best = o, rank
+		}
+		if best == 2 {
+			break
+		}
+	}
 
 	// Support both v1 (maxAmountRequired) and v2 (amount) formats
 	if option.Amount == "" {
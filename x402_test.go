@@ -1,9 +1,15 @@
 package blockrun
 
 import (
+	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 func decodeServiceCodeInfo(t *testing.T, payload string) map[string]any {
@@ -59,6 +65,36 @@ func TestCreatePaymentPayloadAttachesServiceCode(t *testing.T) {
 	assertServiceCode(t, decodeServiceCodeInfo(t, payload))
 }
 
+func TestCreatePaymentPayloadChecksumsAddresses(t *testing.T) {
+	client, err := NewLLMClient(testPrivateKey)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	payload, err := CreatePaymentPayload(client.privateKey, "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", "1000",
+		"eip155:8453", "https://blockrun.ai/api/v1/chat/completions", "Test payment", 300, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create payment payload: %v", err)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(payload)
+	var p PaymentPayload
+	if err := json.Unmarshal(decoded, &p); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+	const recipient = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+	if p.Accepted.PayTo != recipient || p.Payload.Authorization.To != recipient {
+		t.Errorf("expected the recipient in checksum form, got %q and %q", p.Accepted.PayTo, p.Payload.Authorization.To)
+	}
+	if p.Payload.Authorization.From != testWalletAddress {
+		t.Errorf("expected from %s, got %s", testWalletAddress, p.Payload.Authorization.From)
+	}
+
+	if _, err := CreatePaymentPayload(client.privateKey, "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d35", "1000",
+		"eip155:8453", "https://blockrun.ai/api/v1/chat/completions", "Test payment", 300, nil, nil); err == nil {
+		t.Error("expected an invalid recipient to be rejected")
+	}
+}
+
 func TestCreatePaymentPayloadPreservesEchoedAppCode(t *testing.T) {
 	client, err := NewLLMClient(testPrivateKey)
 	if err != nil {
@@ -92,3 +128,175 @@ func TestCreatePaymentPayloadPreservesEchoedAppCode(t *testing.T) {
 		t.Errorf("expected echoed app code blockrun, got %v", info["a"])
 	}
 }
+
+// TestSignTransferAuthorizationGolden pins the EIP-712 signature for a fixed
+// key and authorization. CI runs it both with cgo (libsecp256k1) and with
+// CGO_ENABLED=0 (the pure-Go btcec backend go-ethereum also uses for
+// GOOS=js GOARCH=wasm), so the two signing paths must agree byte for byte.
+func TestSignTransferAuthorizationGolden(t *testing.T) {
+	key, err := GetPrivateKeyFromHex(testPrivateKey)
+	if err != nil {
+		t.Fatalf("GetPrivateKeyFromHex: %v", err)
+	}
+	auth := TransferAuthorization{
+		From:        "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		To:          "0x1234567890123456789012345678901234567890",
+		Value:       "1000",
+		ValidAfter:  "1700000000",
+		ValidBefore: "1700000300",
+		Nonce:       "0x000000000000000000000000000000000000000000000000000000000000002a",
+	}
+
+	sig, err := signTransferAuthorization(key, auth, "USD Coin", "2")
+	if err != nil {
+		t.Fatalf("signTransferAuthorization: %v", err)
+	}
+	const want = "d9c63a2bdeca85ecb38d2aa63ea5d11a7409cf055690ea6898ebb05d98db8bc031dbfd21eb7fc4b8f216f84075cb5f7d61f2c1d281196d30d18dee0f322c89541c"
+	if got := hex.EncodeToString(sig); got != want {
+		t.Errorf("signature mismatch:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestVerifyPaymentPayload(t *testing.T) {
+	client, err := NewLLMClient(testPrivateKey)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	payload, err := CreatePaymentPayload(client.privateKey, "0x1234567890123456789012345678901234567890", "1000",
+		"eip155:8453", "https://blockrun.ai/api/v1/chat/completions", "Test payment", 300, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create payment payload: %v", err)
+	}
+
+	signer, err := VerifyPaymentPayload(payload)
+	if err != nil {
+		t.Fatalf("VerifyPaymentPayload: %v", err)
+	}
+	if signer != client.GetWalletAddress() {
+		t.Errorf("expected signer %s, got %s", client.GetWalletAddress(), signer)
+	}
+
+	// Changing the signed amount invalidates the signature.
+	decoded, _ := base64.StdEncoding.DecodeString(payload)
+	var pp PaymentPayload
+	json.Unmarshal(decoded, &pp)
+	pp.Payload.Authorization.Value = "2000"
+	tampered, _ := json.Marshal(pp)
+	if _, err := VerifyPaymentPayload(base64.StdEncoding.EncodeToString(tampered)); err == nil {
+		t.Error("expected a tampered payload to fail verification")
+	}
+}
+
+func TestPaymentAmountHeaderOverridesRequirement(t *testing.T) {
+	prHeader := testPaymentRequiredHeader(t, "1000")
+	var signed PaymentPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := r.Header.Get("PAYMENT-SIGNATURE")
+		if sig == "" {
+			w.Header().Set("payment-required", prHeader)
+			w.Header().Set("X-Payment-Amount", "500")
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(sig)
+		json.Unmarshal(decoded, &signed)
+		w.Write([]byte(testChatResponseJSON))
+	}))
+	defer server.Close()
+
+	client, _ := NewLLMClient(testPrivateKey, WithAPIURL(server.URL))
+	client.costLog = nil
+	if _, err := client.Chat(context.Background(), "gpt-4o", "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if signed.Payload.Authorization.Value != "500" {
+		t.Errorf("expected the signed value to be 500, got %q", signed.Payload.Authorization.Value)
+	}
+	if got := client.GetSpending().TotalUSD; got != 0.0005 {
+		t.Errorf("expected $0.0005 recorded, got %v", got)
+	}
+
+	resp := &http.Response{Header: http.Header{}}
+	opt := &PaymentOption{Amount: "1000"}
+	if got := ExtractPaymentAmount(resp, opt); got != "1000" {
+		t.Errorf("expected the option amount without the header, got %q", got)
+	}
+	resp.Header.Set("X-Payment-Amount", "not-a-number")
+	if got := ExtractPaymentAmount(resp, opt); got != "1000" {
+		t.Errorf("expected a malformed header to be ignored, got %q", got)
+	}
+	resp.Header.Set("X-Payment-Amount", "750")
+	if got := ExtractPaymentAmount(resp, opt); got != "750" {
+		t.Errorf("expected the header amount, got %q", got)
+	}
+}
+
+func TestCreatePaymentPayloadAuthorizationExtras(t *testing.T) {
+	client, err := NewLLMClient(testPrivateKey)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	extra := map[string]any{"name": "USD Coin", "version": "2", AuthorizationExtrasKey: map[string]any{"memo": "invoice-42"}}
+	payload, err := CreatePaymentPayload(client.privateKey, "0x1234567890123456789012345678901234567890", "1000",
+		"eip155:8453", "https://blockrun.ai/api/v1/chat/completions", "Test payment", 300, extra, nil)
+	if err != nil {
+		t.Fatalf("failed to create payment payload: %v", err)
+	}
+
+	// The memo is serialised inline in the authorization object.
+	decoded, _ := base64.StdEncoding.DecodeString(payload)
+	var raw struct {
+		Payload struct {
+			Authorization map[string]any `json:"authorization"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(decoded, &raw); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+	if raw.Payload.Authorization["memo"] != "invoice-42" {
+		t.Errorf("expected memo in the authorization, got %v", raw.Payload.Authorization)
+	}
+	for _, k := range []string{"name", "version", AuthorizationExtrasKey} {
+		if _, ok := raw.Payload.Authorization[k]; ok {
+			t.Errorf("standard extra %q should not be an authorization field", k)
+		}
+	}
+
+	// The memo is part of the signed typed data.
+	if _, err := VerifyPaymentPayload(payload); err != nil {
+		t.Fatalf("VerifyPaymentPayload: %v", err)
+	}
+	var pp PaymentPayload
+	json.Unmarshal(decoded, &pp)
+	types := transferAuthorizationTypes(pp.Payload.Authorization)
+	if last := types[len(types)-1]; last.Name != "memo" || last.Type != "string" {
+		t.Errorf("expected memo as the last typed field, got %+v", last)
+	}
+	withMemo, _ := transferAuthorizationHash(pp.Payload.Authorization, "USD Coin", "2")
+	pp.Payload.Authorization.Extras = nil
+	withoutMemo, _ := transferAuthorizationHash(pp.Payload.Authorization, "USD Coin", "2")
+	if withMemo == withoutMemo {
+		t.Error("expected the memo to change the signed hash")
+	}
+	pp.Payload.Authorization.Extras = map[string]string{"memo": "invoice-43"}
+	tampered, _ := json.Marshal(pp)
+	if _, err := VerifyPaymentPayload(base64.StdEncoding.EncodeToString(tampered)); err == nil {
+		t.Error("expected a changed memo to fail verification")
+	}
+}
+
+func TestTransferAuthorizationDefaultTypeHash(t *testing.T) {
+	// TRANSFER_WITH_AUTHORIZATION_TYPEHASH from EIP-3009 and the USDC
+	// contract: keccak256("TransferWithAuthorization(address from,address
+	// to,uint256 value,uint256 validAfter,uint256 validBefore,bytes32 nonce)").
+	const canonical = "0x7c7c6cdb67a18743f49ec6fa9b35f50d52ed05cbed4cc592e13b44501c1a2267"
+
+	// Unknown Extra keys outside AuthorizationExtrasKey must not change the
+	// signed type.
+	extra := map[string]any{"name": "USD Coin", "version": "2", "memo": "invoice-42", "routing": "x"}
+	auth := TransferAuthorization{Extras: AuthorizationExtras(extra)}
+	typedData := apitypes.TypedData{Types: apitypes.Types{"TransferWithAuthorization": transferAuthorizationTypes(auth)}}
+	if got := typedData.TypeHash("TransferWithAuthorization").String(); got != canonical {
+		t.Errorf("TransferWithAuthorization typehash = %s, want %s", got, canonical)
+	}
+}